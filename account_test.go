@@ -0,0 +1,240 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"toloko-backend/controllers"
+	"toloko-backend/models"
+	"toloko-backend/routes"
+	"toloko-backend/services"
+	"toloko-backend/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// setupAccountTestApp создает тестовое приложение с маршрутами аккаунта
+func setupAccountTestApp() (*fiber.App, *gorm.DB, uint, uint) {
+	db := setupTestDB()
+	db.AutoMigrate(&models.Event{}, &models.EventParticipant{}, &models.ParticipantInventory{}, &models.Rating{}, &models.Complaint{}, &models.Subscription{}, &models.Community{}, &models.CommunityRole{}, &models.News{}, &models.Comment{}, &models.PinnedPost{}, &models.UserRole{}, &models.AccountDeletionRequest{}, &models.NewsMedia{}, &models.Mention{}, &models.CommunityModerationLog{}, &models.Certificate{})
+
+	hash, _ := utils.HashPassword("password123")
+	user := models.User{Name: "Volunteer", Email: "volunteer@example.com", PasswordHash: hash, IsActive: true, Bio: "Люблю субботники"}
+	other := models.User{Name: "Organizer", Email: "organizer@example.com", PasswordHash: hash, IsActive: true}
+	db.Create(&user)
+	db.Create(&other)
+
+	app := fiber.New()
+	authController := controllers.NewAuthController(db)
+	app.Post("/auth/login", authController.Login)
+	routes.SetupAccountRoutes(app, db)
+
+	return app, db, user.ID, other.ID
+}
+
+// readExportArchive разбирает ZIP архив экспорта в карту имя файла -> содержимое
+func readExportArchive(t *testing.T, data []byte) map[string][]byte {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	assert.NoError(t, err)
+
+	files := make(map[string][]byte)
+	for _, file := range archive.File {
+		r, err := file.Open()
+		assert.NoError(t, err)
+		content, _ := io.ReadAll(r)
+		r.Close()
+		files[file.Name] = content
+	}
+	return files
+}
+
+func TestAccountExport(t *testing.T) {
+	app, db, userID, otherID := setupAccountTestApp()
+
+	event := models.Event{CreatorID: otherID, Title: "Уборка парка", Latitude: 1, Longitude: 1, StartTime: time.Now(), EndTime: time.Now().Add(time.Hour)}
+	db.Create(&event)
+	participant := models.EventParticipant{EventID: event.ID, UserID: userID, Status: models.ParticipantStatusJoined}
+	db.Create(&participant)
+	db.Create(&models.ParticipantInventory{ParticipantID: participant.ID, CustomName: "Термос", Quantity: 1})
+	db.Create(&models.Rating{EventID: event.ID, FromUserID: otherID, ToUserID: userID, Score: 9, Comment: "Отлично"})
+	db.Create(&models.Message{ConversationID: 1, FromUserID: userID, ToUserID: otherID, Text: "Привет"})
+
+	req := httptest.NewRequest("GET", "/api/account/export", nil)
+	req.Header.Set("Authorization", "Bearer "+roleTestToken(userID))
+	resp, err := app.Test(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Equal(t, "application/zip", resp.Header.Get("Content-Type"))
+
+	body, _ := io.ReadAll(resp.Body)
+	files := readExportArchive(t, body)
+
+	for _, name := range []string{"profile.json", "events_created.json", "events_joined.json", "participant_inventory.json", "ratings_given.json", "ratings_received.json", "complaints_filed.json", "messages.json", "attachments.json", "news.json", "comments.json"} {
+		assert.Contains(t, files, name)
+	}
+
+	var profile map[string]map[string]interface{}
+	json.Unmarshal(files["profile.json"], &profile)
+	assert.Equal(t, "volunteer@example.com", profile["user"]["email"])
+	assert.NotContains(t, string(files["profile.json"]), "password")
+
+	var joined []map[string]interface{}
+	json.Unmarshal(files["events_joined.json"], &joined)
+	assert.Len(t, joined, 1)
+	assert.Equal(t, "Уборка парка", joined[0]["event_title"])
+
+	var inventory []map[string]interface{}
+	json.Unmarshal(files["participant_inventory.json"], &inventory)
+	assert.Len(t, inventory, 1)
+
+	// Автор полученной оценки не раскрывается
+	var received []map[string]interface{}
+	json.Unmarshal(files["ratings_received.json"], &received)
+	assert.Len(t, received, 1)
+	assert.NotContains(t, received[0], "from_user_id")
+
+	var messages []map[string]interface{}
+	json.Unmarshal(files["messages.json"], &messages)
+	assert.Len(t, messages, 1)
+	assert.Equal(t, "Привет", messages[0]["text"])
+}
+
+func TestAccountDeletionFlow(t *testing.T) {
+	app, db, userID, _ := setupAccountTestApp()
+	token := roleTestToken(userID)
+
+	// Неверный пароль
+	status, _ := postJSON(app, "/api/account/deletion", controllers.AccountDeletionRequest{Password: "wrong"}, token)
+	assert.Equal(t, 401, status)
+
+	status, result := postJSON(app, "/api/account/deletion", controllers.AccountDeletionRequest{Password: "password123"}, token)
+	assert.Equal(t, 202, status)
+	assert.NotNil(t, result["deletion"])
+
+	// Повторный запрос
+	status, _ = postJSON(app, "/api/account/deletion", controllers.AccountDeletionRequest{Password: "password123"}, token)
+	assert.Equal(t, 409, status)
+
+	// До окончания льготного периода аккаунт не удаляется
+	processed, err := services.NewAccountService(db).ProcessDueDeletions()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, processed)
+
+	// Отмена удаления
+	req := httptest.NewRequest("DELETE", "/api/account/deletion", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, _ := app.Test(req)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	req = httptest.NewRequest("DELETE", "/api/account/deletion", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, _ = app.Test(req)
+	assert.Equal(t, 404, resp.StatusCode)
+}
+
+func TestAccountAnonymization(t *testing.T) {
+	t.Setenv("ACCOUNT_DELETION_GRACE_DAYS", "0")
+
+	app, db, userID, otherID := setupAccountTestApp()
+
+	community := models.Community{CreatorID: otherID, Name: "Чистый город"}
+	db.Create(&community)
+	news := models.News{CommunityID: community.ID, AuthorID: userID, Content: "Моя новость"}
+	db.Create(&news)
+	comment := models.Comment{NewsID: news.ID, AuthorID: userID, Content: "Мой комментарий"}
+	db.Create(&comment)
+	message := models.Message{ConversationID: 1, FromUserID: userID, ToUserID: otherID, Text: "Личное"}
+	db.Create(&message)
+	db.Create(&models.Subscription{SubscriberID: userID, SubscribedToID: otherID})
+	certificate := models.Certificate{Code: "CERT-ANON", UserID: userID, UserName: "Volunteer", TotalHours: 4, EventsCount: 2}
+	db.Create(&certificate)
+
+	status, _ := postJSON(app, "/api/account/deletion", controllers.AccountDeletionRequest{Password: "password123"}, roleTestToken(userID))
+	assert.Equal(t, 202, status)
+
+	processed, err := services.NewAccountService(db).ProcessDueDeletions()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, processed)
+
+	// Строка пользователя сохраняется, но обезличивается
+	var user models.User
+	assert.NoError(t, db.First(&user, userID).Error)
+	assert.Equal(t, services.DeletedUserEmail(userID), user.Email)
+	assert.NotEqual(t, "Volunteer", user.Name)
+	assert.Empty(t, user.Bio)
+	assert.False(t, user.IsActive)
+
+	// Контент остается на месте без исходного текста
+	db.First(&news, news.ID)
+	db.First(&comment, comment.ID)
+	db.First(&message, message.ID)
+	assert.Equal(t, userID, news.AuthorID)
+	assert.NotEqual(t, "Моя новость", news.Content)
+	assert.NotEqual(t, "Мой комментарий", comment.Content)
+	assert.NotEqual(t, "Личное", message.Text)
+
+	// Сертификат по-прежнему проверяется, но имя владельца скрыто
+	db.First(&certificate, certificate.ID)
+	assert.NotEqual(t, "Volunteer", certificate.UserName)
+	assert.Equal(t, float64(4), certificate.TotalHours)
+
+	var subscriptions int64
+	db.Model(&models.Subscription{}).Where("subscriber_id = ?", userID).Count(&subscriptions)
+	assert.Equal(t, int64(0), subscriptions)
+
+	var request models.AccountDeletionRequest
+	db.Where("user_id = ?", userID).First(&request)
+	assert.Equal(t, models.AccountDeletionStatusCompleted, request.Status)
+
+	// Войти со старым паролем нельзя
+	status, _ = postJSON(app, "/auth/login", controllers.LoginRequest{Email: "volunteer@example.com", Password: "password123"}, "")
+	assert.Equal(t, 401, status)
+}
+
+func TestAccountDeletionKeepsCommunitiesManaged(t *testing.T) {
+	t.Setenv("ACCOUNT_DELETION_GRACE_DAYS", "0")
+
+	app, db, userID, otherID := setupAccountTestApp()
+	token := roleTestToken(userID)
+
+	// Владелец сообщества сначала передает владение
+	community := models.Community{CreatorID: userID, Name: "Чистый город"}
+	db.Create(&community)
+	db.Create(&models.CommunityRole{CommunityID: community.ID, UserID: userID, Role: models.CommunityRoleAdmin})
+	status, _ := postJSON(app, "/api/account/deletion", controllers.AccountDeletionRequest{Password: "password123"}, token)
+	assert.Equal(t, 409, status)
+
+	// Единственный администратор сообщества тоже не может удалить аккаунт
+	db.Model(&community).Update("creator_id", otherID)
+	db.Create(&models.CommunityRole{CommunityID: community.ID, UserID: otherID, Role: models.CommunityRoleMember})
+	status, _ = postJSON(app, "/api/account/deletion", controllers.AccountDeletionRequest{Password: "password123"}, token)
+	assert.Equal(t, 409, status)
+
+	db.Model(&models.CommunityRole{}).Where("user_id = ?", otherID).Update("role", models.CommunityRoleAdmin)
+	status, _ = postJSON(app, "/api/account/deletion", controllers.AccountDeletionRequest{Password: "password123"}, token)
+	assert.Equal(t, 202, status)
+
+	// Если за льготный период пользователь остался последним администратором, удаление откладывается
+	db.Where("user_id = ?", otherID).Delete(&models.CommunityRole{})
+	processed, err := services.NewAccountService(db).ProcessDueDeletions()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, processed)
+
+	var membership models.CommunityRole
+	assert.NoError(t, db.Where("community_id = ? AND user_id = ?", community.ID, userID).First(&membership).Error)
+}
+
+func TestAccountDeletionLastPlatformAdmin(t *testing.T) {
+	app, db, userID, _ := setupAccountTestApp()
+	db.Create(&models.UserRole{UserID: userID, Role: models.RoleAdmin})
+
+	status, _ := postJSON(app, "/api/account/deletion", controllers.AccountDeletionRequest{Password: "password123"}, roleTestToken(userID))
+	assert.Equal(t, 409, status)
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"toloko-backend/controllers"
+	"toloko-backend/models"
+	"toloko-backend/routes"
+	"toloko-backend/services"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// setupAchievementEngineTestApp создает тестовое приложение с маршрутами комментариев и подписок
+func setupAchievementEngineTestApp() (*fiber.App, *gorm.DB) {
+	db := setupCommunitiesTestDB()
+	db.AutoMigrate(&models.UserRole{}, &models.EventParticipant{})
+
+	app := fiber.New()
+	routes.SetupCommentRoutes(app, controllers.NewCommentController(db))
+	routes.SetupSubscriptionRoutes(app, controllers.NewSubscriptionController(db))
+	return app, db
+}
+
+// countUserAchievements возвращает, сколько раз пользователь получил достижение
+func countUserAchievements(db *gorm.DB, userID, achievementID uint) int64 {
+	var count int64
+	db.Model(&models.UserAchievement{}).Where("user_id = ? AND achievement_id = ?", userID, achievementID).Count(&count)
+	return count
+}
+
+func TestAchievementEngineDomainEvents(t *testing.T) {
+	app, db := setupAchievementEngineTestApp()
+	authorID, authorToken := createCommunityMemberTestUser(db, "author@example.com")
+	followerID, followerToken := createCommunityMemberTestUser(db, "follower@example.com")
+	community := createCommunitiesTestCommunity(db, authorID)
+	news := models.News{CommunityID: community.ID, AuthorID: authorID, Content: "Субботник в парке"}
+	db.Create(&news)
+
+	commentator := models.Achievement{Name: "Комментатор", Description: "Оставьте 2 комментария", IconPath: "/icons/commentator.png", Points: 120,
+		CriterionEvent: models.AchievementEventCommentCreated, CriterionThreshold: 2}
+	popular := models.Achievement{Name: "Популярный пользователь", Description: "Получите подписчика", IconPath: "/icons/popular-user.png", Points: 40,
+		CriterionEvent: models.AchievementEventSubscriberGained, CriterionThreshold: 1}
+	manual := models.Achievement{Name: "Почетный волонтер", Description: "Выдается вручную", IconPath: "/icons/honor.png", Points: 500}
+	db.Create(&commentator)
+	db.Create(&popular)
+	db.Create(&manual)
+
+	// Достижение выдается при достижении порога и только один раз
+	path := fmt.Sprintf("/news/%d/comments", news.ID)
+	for i := 0; i < 3; i++ {
+		status, _ := communityRequest(app, "POST", path, authorToken, map[string]interface{}{"content": "Комментарий"})
+		assert.Equal(t, 201, status)
+		if i == 0 {
+			assert.Equal(t, int64(0), countUserAchievements(db, authorID, commentator.ID))
+		}
+	}
+	assert.Equal(t, int64(1), countUserAchievements(db, authorID, commentator.ID))
+
+	var level models.UserLevel
+	assert.NoError(t, db.Where("user_id = ?", authorID).First(&level).Error)
+	assert.Equal(t, 120, level.Points)
+	assert.Equal(t, 2, level.Level)
+
+	var notification models.Notification
+	assert.NoError(t, db.Where("user_id = ? AND type = ?", authorID, models.NotificationTypeAchievement).First(&notification).Error)
+	assert.Contains(t, notification.Body, "Комментатор")
+
+	// Подписка засчитывается тому, на кого подписались
+	status, _ := communityRequest(app, "POST", fmt.Sprintf("/subscriptions/%d", authorID), followerToken, nil)
+	assert.Equal(t, 201, status)
+	assert.Equal(t, int64(1), countUserAchievements(db, authorID, popular.ID))
+	assert.Equal(t, int64(0), countUserAchievements(db, followerID, popular.ID))
+
+	// Достижения без критерия автоматически не выдаются, повторная ручная выдача запрещена
+	engine := services.NewAchievementService(db)
+	_, err := engine.Emit(authorID, models.AchievementEventCommentCreated)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), countUserAchievements(db, authorID, manual.ID))
+	assert.Equal(t, services.ErrAchievementAlreadyEarned, engine.Award(authorID, &commentator))
+}
+
+func TestAchievementEngineBackfill(t *testing.T) {
+	_, db := setupAchievementEngineTestApp()
+	volunteerID, _ := createCommunityMemberTestUser(db, "volunteer@example.com")
+	newcomerID, _ := createCommunityMemberTestUser(db, "newcomer@example.com")
+
+	eco := models.Achievement{Name: "Экологический активист", Description: "Участвуйте в 2 экологических мероприятиях", IconPath: "/icons/eco-activist.png", Points: 60,
+		CriterionEvent: models.AchievementEventParticipated, CriterionThreshold: 2, CriterionEventType: models.EventTypeEnvironmental}
+	cleanup := models.Achievement{Name: "Эксперт по уборке", Description: "Участвуйте в 2 уборках", IconPath: "/icons/cleaning-expert.png", Points: 75,
+		CriterionEvent: models.AchievementEventParticipated, CriterionThreshold: 2, CriterionKeyword: "уборк"}
+	organizer := models.Achievement{Name: "Организатор", Description: "Создайте первое мероприятие", IconPath: "/icons/organizer.png", Points: 75,
+		CriterionEvent: models.AchievementEventEventCreated, CriterionThreshold: 1}
+	db.Create(&eco)
+	db.Create(&cleanup)
+	db.Create(&organizer)
+
+	// История, накопленная до появления автоматической выдачи
+	events := []models.Event{
+		{CreatorID: newcomerID, Title: "Большая уборка парка", EventType: models.EventTypeEnvironmental},
+		{CreatorID: newcomerID, Title: "Посадка деревьев", EventType: models.EventTypeEnvironmental},
+		{CreatorID: newcomerID, Title: "Весенняя уборка двора", EventType: models.EventTypeSocial},
+	}
+	for i := range events {
+		events[i].StartTime = time.Now().Add(-48 * time.Hour)
+		events[i].EndTime = time.Now().Add(-46 * time.Hour)
+		db.Create(&events[i])
+	}
+	checkedIn := time.Now().Add(-47 * time.Hour)
+	db.Create(&models.EventParticipant{EventID: events[0].ID, UserID: volunteerID, Status: models.ParticipantStatusJoined, CheckedInAt: &checkedIn})
+	db.Create(&models.EventParticipant{EventID: events[1].ID, UserID: volunteerID, Status: models.ParticipantStatusAccepted, CheckedInAt: &checkedIn})
+	db.Create(&models.EventParticipant{EventID: events[2].ID, UserID: volunteerID, Status: models.ParticipantStatusJoined})
+
+	engine := services.NewAchievementService(db)
+	awarded, err := engine.Backfill()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, awarded)
+	assert.Equal(t, int64(1), countUserAchievements(db, volunteerID, eco.ID))
+	assert.Equal(t, int64(0), countUserAchievements(db, volunteerID, cleanup.ID))
+	assert.Equal(t, int64(1), countUserAchievements(db, newcomerID, organizer.ID))
+
+	// Повторный запуск ничего не выдает
+	awarded, err = engine.Backfill()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, awarded)
+
+	// Фильтр по названию учитывает только ивенты, где организатор отметил присутствие
+	db.Model(&models.EventParticipant{}).Where("event_id = ?", events[2].ID).Update("checked_in_at", checkedIn)
+	earned, err := engine.Emit(volunteerID, models.AchievementEventParticipated)
+	assert.NoError(t, err)
+	if assert.Len(t, earned, 1) {
+		assert.Equal(t, cleanup.ID, earned[0].ID)
+	}
+}
+
+func TestAchievementProgress(t *testing.T) {
+	db := setupCommunitiesTestDB()
+	db.AutoMigrate(&models.UserRole{}, &models.EventParticipant{})
+	app := fiber.New()
+	routes.SetupAchievementRoutes(app, controllers.NewAchievementController(db))
+
+	userID, token := createCommunityMemberTestUser(db, "volunteer@example.com")
+	_, strangerToken := createCommunityMemberTestUser(db, "stranger@example.com")
+
+	active := models.Achievement{Name: "Активный участник", Description: "Участвуйте в 5 ивентах", IconPath: "/icons/active.png", Category: "participation",
+		CriterionEvent: models.AchievementEventParticipated, CriterionThreshold: 5}
+	eco := models.Achievement{Name: "Эко-воин", Description: "Участвуйте в 2 экологических ивентах", IconPath: "/icons/eco.png", Category: "participation",
+		CriterionEvent: models.AchievementEventParticipated, CriterionThreshold: 2, CriterionEventType: models.EventTypeEnvironmental}
+	organizer := models.Achievement{Name: "Организатор", Description: "Создайте ивент", IconPath: "/icons/organizer.png", Category: "organization",
+		CriterionEvent: models.AchievementEventEventCreated, CriterionThreshold: 1}
+	manual := models.Achievement{Name: "Почетный волонтер", Description: "Выдается вручную", IconPath: "/icons/honor.png", Category: "special"}
+	for _, achievement := range []*models.Achievement{&active, &eco, &organizer, &manual} {
+		db.Create(achievement)
+	}
+
+	// Три ивента, один из них экологический
+	for i, eventType := range []string{models.EventTypeEnvironmental, models.EventTypeSports, models.EventTypeSocial} {
+		event := models.Event{CreatorID: userID + 100, Title: fmt.Sprintf("Ивент %d", i), EventType: eventType, StartTime: time.Now(), EndTime: time.Now()}
+		db.Create(&event)
+		checkedIn := time.Now()
+		db.Create(&models.EventParticipant{EventID: event.ID, UserID: userID, Status: models.ParticipantStatusJoined, CheckedInAt: &checkedIn})
+	}
+	db.Create(&models.UserAchievement{UserID: userID, AchievementID: organizer.ID})
+
+	status, result := levelRequest(app, "GET", fmt.Sprintf("/api/achievements/user/%d", userID), token, nil)
+	assert.Equal(t, 200, status)
+	progress := make(map[string]map[string]interface{})
+	for _, item := range result["achievements"].([]interface{}) {
+		entry := item.(map[string]interface{})
+		progress[entry["achievement"].(map[string]interface{})["name"].(string)] = entry
+	}
+	assert.Equal(t, []interface{}{float64(3), float64(5), float64(60)},
+		[]interface{}{progress["Активный участник"]["current"], progress["Активный участник"]["target"], progress["Активный участник"]["percent"]})
+	assert.Equal(t, float64(50), progress["Эко-воин"]["percent"])
+	assert.Equal(t, true, progress["Организатор"]["earned"])
+	assert.Equal(t, float64(100), progress["Организатор"]["percent"])
+	assert.Equal(t, true, progress["Почетный волонтер"]["manual"])
+
+	// Достижения сгруппированы по категориям
+	categories := result["categories"].([]interface{})
+	if assert.Len(t, categories, 3) {
+		organization := categories[0].(map[string]interface{})
+		assert.Equal(t, "organization", organization["category"])
+		assert.Equal(t, float64(1), organization["earned"])
+		assert.Len(t, categories[1].(map[string]interface{})["achievements"], 2)
+	}
+
+	// Ближайшие достижения: полученные и ручные не показываются
+	status, result = levelRequest(app, "GET", fmt.Sprintf("/api/achievements/user/%d/closest?limit=1", userID), token, nil)
+	assert.Equal(t, 200, status)
+	if closest := result["achievements"].([]interface{}); assert.Len(t, closest, 1) {
+		assert.Equal(t, "Активный участник", closest[0].(map[string]interface{})["achievement"].(map[string]interface{})["name"])
+	}
+	status, result = levelRequest(app, "GET", fmt.Sprintf("/api/achievements/user/%d/closest", userID), token, nil)
+	assert.Equal(t, 200, status)
+	assert.Len(t, result["achievements"], 2)
+
+	status, _ = levelRequest(app, "GET", fmt.Sprintf("/api/achievements/user/%d/closest", userID), strangerToken, nil)
+	assert.Equal(t, 403, status)
+}
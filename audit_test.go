@@ -0,0 +1,133 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+
+	"toloko-backend/models"
+	"toloko-backend/routes"
+	"toloko-backend/services"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// setupAuditTestApp создает тестовое приложение с маршрутами журнала аудита
+func setupAuditTestApp() (*fiber.App, *gorm.DB, uint, uint) {
+	db := setupTestDB()
+	db.AutoMigrate(&models.UserRole{}, &models.CommunityRole{}, &models.AuditLog{})
+
+	admin := models.User{Name: "Admin", Email: "admin@example.com", PasswordHash: "hash", IsActive: true}
+	organizer := models.User{Name: "Organizer", Email: "organizer@example.com", PasswordHash: "hash", IsActive: true}
+	db.Create(&admin)
+	db.Create(&organizer)
+	db.Create(&models.UserRole{UserID: admin.ID, Role: models.RoleAdmin})
+
+	app := fiber.New()
+	routes.SetupAuditRoutes(app, db)
+
+	return app, db, admin.ID, organizer.ID
+}
+
+// getAuditLogs выполняет запрос к журналу аудита
+func getAuditLogs(app *fiber.App, path string, userID uint) (int, map[string]interface{}) {
+	req := httptest.NewRequest("GET", path, nil)
+	req.Header.Set("Authorization", "Bearer "+roleTestToken(userID))
+	resp, err := app.Test(req)
+	if err != nil {
+		return 0, nil
+	}
+
+	var result map[string]interface{}
+	json.NewDecoder(resp.Body).Decode(&result)
+	return resp.StatusCode, result
+}
+
+func TestAuditDiff(t *testing.T) {
+	before := models.Event{ID: 1, Title: "Уборка", MaxParticipants: 10}
+	after := before
+	after.Title = "Уборка парка"
+
+	changes, err := services.AuditDiff(before, after)
+	assert.NoError(t, err)
+	assert.Len(t, changes, 1)
+	assert.Equal(t, "Уборка", changes["title"].From)
+	assert.Equal(t, "Уборка парка", changes["title"].To)
+
+	// При удалении сохраняется снимок объекта без связей
+	changes, err = services.AuditDiff(before, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "Уборка", changes["title"].From)
+	assert.Nil(t, changes["title"].To)
+	assert.NotContains(t, changes, "creator")
+}
+
+func TestAuditLogAppendOnly(t *testing.T) {
+	_, db, adminID, _ := setupAuditTestApp()
+
+	err := services.RecordAudit(db, adminID, models.AuditActionEventDelete, models.AuditTargetEvent, 1, models.Event{ID: 1}, nil, services.AuditMetadata{IPAddress: "127.0.0.1"})
+	assert.NoError(t, err)
+
+	var entry models.AuditLog
+	db.First(&entry)
+	assert.Error(t, db.Model(&entry).Update("action", "event.update").Error)
+	assert.Error(t, db.Delete(&entry).Error)
+
+	var count int64
+	db.Model(&models.AuditLog{}).Count(&count)
+	assert.Equal(t, int64(1), count)
+}
+
+func TestAuditRecordedInTransaction(t *testing.T) {
+	_, db, adminID, _ := setupAuditTestApp()
+
+	// Откат изменения откатывает и запись журнала
+	db.Transaction(func(tx *gorm.DB) error {
+		services.RecordAudit(tx, adminID, models.AuditActionCommunityDelete, models.AuditTargetCommunity, 1, models.Community{ID: 1}, nil, services.AuditMetadata{})
+		return fmt.Errorf("change failed")
+	})
+
+	var count int64
+	db.Model(&models.AuditLog{}).Count(&count)
+	assert.Equal(t, int64(0), count)
+}
+
+func TestAuditAdminAPI(t *testing.T) {
+	app, db, adminID, organizerID := setupAuditTestApp()
+
+	for i := 1; i <= 3; i++ {
+		services.RecordAudit(db, organizerID, models.AuditActionEventUpdate, models.AuditTargetEvent, uint(i), models.Event{ID: uint(i)}, models.Event{ID: uint(i), Title: "Новое"}, services.AuditMetadata{})
+	}
+	services.RecordAudit(db, adminID, models.AuditActionCommunityDelete, models.AuditTargetCommunity, 7, models.Community{ID: 7}, nil, services.AuditMetadata{})
+
+	// Обычный пользователь не видит журнал
+	status, _ := getAuditLogs(app, "/api/admin/audit", organizerID)
+	assert.Equal(t, 403, status)
+
+	status, result := getAuditLogs(app, "/api/admin/audit?limit=2", adminID)
+	assert.Equal(t, 200, status)
+	assert.Len(t, result["logs"], 2)
+	pagination := result["pagination"].(map[string]interface{})
+	assert.Equal(t, float64(4), pagination["total_count"])
+	assert.Equal(t, float64(2), pagination["total_pages"])
+
+	status, result = getAuditLogs(app, fmt.Sprintf("/api/admin/audit?actor_id=%d&action=%s", organizerID, models.AuditActionEventUpdate), adminID)
+	assert.Equal(t, 200, status)
+	assert.Len(t, result["logs"], 3)
+
+	status, result = getAuditLogs(app, "/api/admin/audit?target_type=community&target_id=7", adminID)
+	assert.Equal(t, 200, status)
+	logs := result["logs"].([]interface{})
+	assert.Len(t, logs, 1)
+	entryID := uint(logs[0].(map[string]interface{})["id"].(float64))
+
+	status, result = getAuditLogs(app, fmt.Sprintf("/api/admin/audit/%d", entryID), adminID)
+	assert.Equal(t, 200, status)
+	assert.Equal(t, models.AuditActionCommunityDelete, result["log"].(map[string]interface{})["action"])
+
+	status, _ = getAuditLogs(app, "/api/admin/audit?from=yesterday", adminID)
+	assert.Equal(t, 400, status)
+}
@@ -0,0 +1,333 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"toloko-backend/controllers"
+	"toloko-backend/models"
+	"toloko-backend/routes"
+	"toloko-backend/services"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// topicRecorder запоминает сообщения, опубликованные в темы WebSocket
+type topicRecorder struct {
+	mutex    sync.Mutex
+	messages map[string][]services.WSMessage
+}
+
+func (r *topicRecorder) Publish(topic string, message services.WSMessage) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.messages[topic] = append(r.messages[topic], message)
+}
+
+// setupChallengeTestApp создает тестовое приложение с маршрутами челленджей и участников ивентов
+func setupChallengeTestApp(t *testing.T) (*fiber.App, *gorm.DB, *topicRecorder) {
+	db := setupCommunitiesTestDB()
+	db.AutoMigrate(&models.UserRole{}, &models.EventParticipant{})
+
+	recorder := &topicRecorder{messages: make(map[string][]services.WSMessage)}
+	services.SetTopicPublisher(recorder)
+	t.Cleanup(func() { services.SetTopicPublisher(nil) })
+
+	app := fiber.New()
+	routes.SetupChallengeRoutes(app, db)
+	routes.SetupParticipantRoutes(app, controllers.NewParticipantController(db))
+	return app, db, recorder
+}
+
+// attendTestEvent создает участие пользователя в ивенте с отметкой о присутствии
+func attendTestEvent(db *gorm.DB, event models.Event, userID uint) {
+	now := time.Now()
+	db.Create(&models.EventParticipant{EventID: event.ID, UserID: userID, Status: models.ParticipantStatusJoined, CheckedInAt: &now})
+}
+
+func TestImpactChallengeWithTeams(t *testing.T) {
+	app, db, recorder := setupChallengeTestApp(t)
+	adminID, adminToken := createCommunityMemberTestUser(db, "admin@example.com")
+	aliceID, aliceToken := createCommunityMemberTestUser(db, "alice@example.com")
+	bobID, bobToken := createCommunityMemberTestUser(db, "bob@example.com")
+	carolID, carolToken := createCommunityMemberTestUser(db, "carol@example.com")
+	_, strangerToken := createCommunityMemberTestUser(db, "stranger@example.com")
+
+	community := createCommunitiesTestCommunity(db, adminID)
+	db.Model(community).Update("visibility", models.CommunityVisibilityInvite)
+	for _, userID := range []uint{aliceID, bobID, carolID} {
+		db.Create(&models.CommunityRole{CommunityID: community.ID, UserID: userID, Role: models.CommunityRoleMember})
+	}
+	badge := models.Achievement{Name: "Апрельский чистильщик", Description: "Выполните челлендж", IconPath: "/icons/april.png"}
+	db.Create(&badge)
+
+	request := map[string]interface{}{
+		"community_id": community.ID, "title": "1000 мешков в апреле", "metric": models.ChallengeMetricImpact,
+		"impact_unit": "мешки", "goal": 10, "starts_at": time.Now().Add(-time.Hour), "ends_at": time.Now().Add(30 * 24 * time.Hour),
+		"reward_points": 30,
+	}
+
+	// Челленджи сообщества создают только его администраторы и модераторы, челленджи платформы - администраторы платформы
+	status, _ := communityRequest(app, "POST", "/api/challenges", aliceToken, request)
+	assert.Equal(t, 403, status)
+	platform := map[string]interface{}{"title": "Платформа", "metric": models.ChallengeMetricEvents, "goal": 1,
+		"starts_at": time.Now(), "ends_at": time.Now().Add(time.Hour)}
+	status, _ = communityRequest(app, "POST", "/api/challenges", adminToken, platform)
+	assert.Equal(t, 403, status)
+	status, _ = communityRequest(app, "POST", "/api/challenges", adminToken, map[string]interface{}{"title": "Без единиц", "metric": models.ChallengeMetricImpact,
+		"goal": 5, "starts_at": time.Now(), "ends_at": time.Now().Add(time.Hour)})
+	assert.Equal(t, 400, status)
+
+	// Награда сообщества ограничена, достижения платформы за нее не выдаются
+	request["reward_points"] = models.MaxCommunityChallengeRewardPoints + 1
+	status, _ = communityRequest(app, "POST", "/api/challenges", adminToken, request)
+	assert.Equal(t, 400, status)
+	request["reward_points"] = 30
+	request["reward_achievement_id"] = badge.ID
+	status, _ = communityRequest(app, "POST", "/api/challenges", adminToken, request)
+	assert.Equal(t, 400, status)
+	delete(request, "reward_achievement_id")
+
+	status, result := communityRequest(app, "POST", "/api/challenges", adminToken, request)
+	assert.Equal(t, 201, status)
+	challengeID := uint(result["challenge"].(map[string]interface{})["id"].(float64))
+	path := fmt.Sprintf("/api/challenges/%d", challengeID)
+
+	// Челлендж закрытого сообщества не виден посторонним
+	status, _ = communityRequest(app, "GET", path, strangerToken, nil)
+	assert.Equal(t, 403, status)
+	status, result = communityRequest(app, "GET", "/api/challenges", strangerToken, nil)
+	assert.Equal(t, 200, status)
+	assert.Empty(t, result["challenges"])
+
+	// Алиса участвует лично, Боб создает команду, Кэрол вступает в нее
+	status, _ = communityRequest(app, "POST", path+"/join", aliceToken, nil)
+	assert.Equal(t, 201, status)
+	status, _ = communityRequest(app, "POST", path+"/join", aliceToken, nil)
+	assert.Equal(t, 409, status)
+	status, result = communityRequest(app, "POST", path+"/teams", bobToken, map[string]interface{}{"name": "Зеленые"})
+	assert.Equal(t, 201, status)
+	teamID := result["team"].(map[string]interface{})["id"]
+	status, _ = communityRequest(app, "POST", path+"/join", carolToken, map[string]interface{}{"team_id": teamID})
+	assert.Equal(t, 201, status)
+
+	first := models.Event{CreatorID: adminID, CommunityID: &community.ID, Title: "Уборка берега", StartTime: time.Now(), EndTime: time.Now().Add(time.Hour)}
+	second := models.Event{CreatorID: adminID, CommunityID: &community.ID, Title: "Уборка парка", StartTime: time.Now(), EndTime: time.Now().Add(time.Hour)}
+	outside := models.Event{CreatorID: adminID, Title: "Чужой ивент", StartTime: time.Now(), EndTime: time.Now().Add(time.Hour)}
+	db.Create(&first)
+	db.Create(&second)
+	db.Create(&outside)
+	for _, userID := range []uint{aliceID, bobID, carolID} {
+		attendTestEvent(db, first, userID)
+	}
+	attendTestEvent(db, second, aliceID)
+	attendTestEvent(db, outside, aliceID)
+
+	// Результат засчитывается только за посещенные ивенты и один раз за ивент, после подтверждения организатором
+	contribute := func(token string, event models.Event, quantity float64) int {
+		status, result := communityRequest(app, "POST", path+"/contributions", token, map[string]interface{}{"event_id": event.ID, "quantity": quantity})
+		if status == 201 {
+			contributionID := uint(result["contribution"].(map[string]interface{})["id"].(float64))
+			communityRequest(app, "POST", fmt.Sprintf("%s/contributions/%d/confirm", path, contributionID), adminToken, nil)
+		}
+		return status
+	}
+	assert.Equal(t, 201, contribute(aliceToken, first, 6))
+	assert.Equal(t, 409, contribute(aliceToken, first, 6))
+	assert.Equal(t, 400, contribute(bobToken, second, 6))
+	assert.Equal(t, 400, contribute(aliceToken, outside, 6))
+	assert.Equal(t, 403, contribute(strangerToken, first, 6))
+
+	// Команда достигает цели вместе: награду получают оба участника
+	assert.Equal(t, 201, contribute(bobToken, first, 5))
+	assert.Equal(t, 0, userPoints(db, bobID))
+	assert.Equal(t, 201, contribute(carolToken, first, 5))
+	assert.Equal(t, 30, userPoints(db, bobID))
+	assert.Equal(t, 30, userPoints(db, carolID))
+	assert.Equal(t, 0, userPoints(db, aliceID))
+
+	assert.Equal(t, 201, contribute(aliceToken, second, 4))
+	assert.Equal(t, 30, userPoints(db, aliceID))
+
+	// Таблицы участников и команд
+	status, result = communityRequest(app, "GET", path+"/leaderboard?type=team", bobToken, nil)
+	assert.Equal(t, 200, status)
+	if teams := result["standings"].([]interface{}); assert.Len(t, teams, 1) {
+		team := teams[0].(map[string]interface{})
+		assert.Equal(t, []interface{}{"Зеленые", float64(10), float64(100), true, float64(2)},
+			[]interface{}{team["name"], team["progress"], team["percent"], team["completed"], team["members"]})
+	}
+	status, result = communityRequest(app, "GET", path+"/leaderboard", bobToken, nil)
+	assert.Equal(t, 200, status)
+	if individuals := result["standings"].([]interface{}); assert.Len(t, individuals, 3) {
+		leader := individuals[0].(map[string]interface{})
+		assert.Equal(t, []interface{}{float64(aliceID), float64(10), float64(1)}, []interface{}{leader["user_id"], leader["progress"], leader["rank"]})
+		assert.Equal(t, float64(2), individuals[2].(map[string]interface{})["rank"])
+	}
+
+	status, result = communityRequest(app, "GET", path, carolToken, nil)
+	assert.Equal(t, 200, status)
+	progress := result["progress"].(map[string]interface{})
+	assert.Equal(t, float64(20), progress["total_progress"])
+	assert.Equal(t, float64(3), progress["completed"])
+	assert.Equal(t, true, result["me"].(map[string]interface{})["completed"])
+
+	// Обновления прогресса публикуются в тему сообщества
+	messages := recorder.messages[services.CommunityTopic(community.ID)]
+	if assert.NotEmpty(t, messages) {
+		last := messages[len(messages)-1]
+		assert.Equal(t, "challenge.progress", last.Type)
+		assert.Equal(t, float64(20), last.Payload.(*services.ChallengeProgress).TotalProgress)
+	}
+}
+
+func TestEventsChallengeCompletesOnCheckIn(t *testing.T) {
+	app, db, recorder := setupChallengeTestApp(t)
+	adminID, adminToken := createCommunityMemberTestUser(db, "admin@example.com")
+	db.Create(&models.UserRole{UserID: adminID, Role: models.RoleAdmin})
+	volunteerID, volunteerToken := createCommunityMemberTestUser(db, "volunteer@example.com")
+	badge := models.Achievement{Name: "Первые шаги", Description: "Посетите первый ивент", IconPath: "/icons/first.png"}
+	db.Create(&badge)
+
+	status, result := communityRequest(app, "POST", "/api/challenges", adminToken, map[string]interface{}{
+		"title": "Первый ивент", "metric": models.ChallengeMetricEvents, "goal": 1, "reward_points": 15,
+		"reward_achievement_id": badge.ID, "starts_at": time.Now().Add(-time.Hour), "ends_at": time.Now().Add(24 * time.Hour),
+	})
+	assert.Equal(t, 201, status)
+	challengeID := uint(result["challenge"].(map[string]interface{})["id"].(float64))
+	status, _ = communityRequest(app, "POST", fmt.Sprintf("/api/challenges/%d/join", challengeID), volunteerToken, nil)
+	assert.Equal(t, 201, status)
+
+	// Отметка организатора засчитывает посещение и завершает челлендж
+	event, participants := createPointsTestEvent(db, adminID, volunteerID)
+	status, _ = communityRequest(app, "POST", fmt.Sprintf("/events/%d/participants/%d/check-in", event.ID, participants[0].ID), generateTestJWT(adminID), nil)
+	assert.Equal(t, 200, status)
+
+	var participant models.ChallengeParticipant
+	assert.NoError(t, db.Where("challenge_id = ? AND user_id = ?", challengeID, volunteerID).First(&participant).Error)
+	assert.NotNil(t, participant.CompletedAt)
+	assert.Equal(t, services.PointsForParticipation+15, userPoints(db, volunteerID))
+	assert.Equal(t, int64(1), countUserAchievements(db, volunteerID, badge.ID))
+	assert.NotEmpty(t, recorder.messages[services.PlatformTopic])
+
+	// Создатель может выполнить свой челлендж, но награду не получает
+	status, _ = communityRequest(app, "POST", fmt.Sprintf("/api/challenges/%d/join", challengeID), adminToken, nil)
+	assert.Equal(t, 201, status)
+	other, _ := createPointsTestEvent(db, volunteerID)
+	attendTestEvent(db, other, adminID)
+	services.NewChallengeService(db).TrackUser(adminID)
+	var creator models.ChallengeParticipant
+	assert.NoError(t, db.Where("challenge_id = ? AND user_id = ?", challengeID, adminID).First(&creator).Error)
+	assert.NotNil(t, creator.CompletedAt)
+	assert.Equal(t, 0, userPoints(db, adminID))
+	assert.Equal(t, int64(0), countUserAchievements(db, adminID, badge.ID))
+}
+
+func TestCollectiveChallenge(t *testing.T) {
+	app, db, _ := setupChallengeTestApp(t)
+	adminID, adminToken := createCommunityMemberTestUser(db, "admin@example.com")
+	aliceID, aliceToken := createCommunityMemberTestUser(db, "alice@example.com")
+	bobID, bobToken := createCommunityMemberTestUser(db, "bob@example.com")
+	idleID, idleToken := createCommunityMemberTestUser(db, "idle@example.com")
+	community := createCommunitiesTestCommunity(db, adminID)
+
+	status, _ := communityRequest(app, "POST", "/api/challenges", adminToken, map[string]interface{}{
+		"community_id": community.ID, "title": "1000 мешков в апреле", "metric": models.ChallengeMetricImpact, "impact_unit": "мешки",
+		"goal": 10, "goal_scope": "everyone", "starts_at": time.Now().Add(-time.Hour), "ends_at": time.Now().Add(24 * time.Hour),
+	})
+	assert.Equal(t, 400, status)
+
+	status, result := communityRequest(app, "POST", "/api/challenges", adminToken, map[string]interface{}{
+		"community_id": community.ID, "title": "1000 мешков в апреле", "metric": models.ChallengeMetricImpact, "impact_unit": "мешки",
+		"goal": 10, "goal_scope": models.ChallengeGoalCollective, "reward_points": 20,
+		"starts_at": time.Now().Add(-time.Hour), "ends_at": time.Now().Add(24 * time.Hour),
+	})
+	assert.Equal(t, 201, status)
+	path := fmt.Sprintf("/api/challenges/%d", uint(result["challenge"].(map[string]interface{})["id"].(float64)))
+
+	event := models.Event{CreatorID: adminID, CommunityID: &community.ID, Title: "Уборка берега", StartTime: time.Now(), EndTime: time.Now().Add(time.Hour)}
+	db.Create(&event)
+	for _, token := range []string{aliceToken, bobToken, idleToken} {
+		status, _ = communityRequest(app, "POST", path+"/join", token, nil)
+		assert.Equal(t, 201, status)
+	}
+	for _, userID := range []uint{aliceID, bobID, idleID} {
+		attendTestEvent(db, event, userID)
+	}
+
+	contribute := func(token string, quantity float64) {
+		status, result := communityRequest(app, "POST", path+"/contributions", token, map[string]interface{}{"event_id": event.ID, "quantity": quantity})
+		assert.Equal(t, 201, status)
+		contributionID := uint(result["contribution"].(map[string]interface{})["id"].(float64))
+		status, _ = communityRequest(app, "POST", fmt.Sprintf("%s/contributions/%d/confirm", path, contributionID), adminToken, nil)
+		assert.Equal(t, 200, status)
+	}
+
+	// Общая цель достигается суммой вкладов, награду получают внесшие вклад
+	contribute(aliceToken, 6)
+	assert.Equal(t, 0, userPoints(db, aliceID))
+	contribute(bobToken, 4)
+	assert.Equal(t, 20, userPoints(db, aliceID))
+	assert.Equal(t, 20, userPoints(db, bobID))
+	assert.Equal(t, 0, userPoints(db, idleID))
+
+	status, result = communityRequest(app, "GET", path, aliceToken, nil)
+	assert.Equal(t, 200, status)
+	progress := result["progress"].(map[string]interface{})
+	assert.Equal(t, true, progress["goal_reached"])
+	assert.Equal(t, float64(2), progress["completed"])
+	assert.NotNil(t, result["challenge"].(map[string]interface{})["goal_reached_at"])
+}
+
+func TestChallengeContributionConfirmation(t *testing.T) {
+	app, db, _ := setupChallengeTestApp(t)
+	adminID, adminToken := createCommunityMemberTestUser(db, "admin@example.com")
+	aliceID, aliceToken := createCommunityMemberTestUser(db, "alice@example.com")
+	community := createCommunitiesTestCommunity(db, adminID)
+
+	status, result := communityRequest(app, "POST", "/api/challenges", adminToken, map[string]interface{}{
+		"community_id": community.ID, "title": "Мешки", "metric": models.ChallengeMetricImpact, "impact_unit": "мешки",
+		"goal": 10, "reward_points": 25, "starts_at": time.Now().Add(-time.Hour), "ends_at": time.Now().Add(24 * time.Hour),
+	})
+	assert.Equal(t, 201, status)
+	path := fmt.Sprintf("/api/challenges/%d", uint(result["challenge"].(map[string]interface{})["id"].(float64)))
+	status, _ = communityRequest(app, "POST", path+"/join", aliceToken, nil)
+	assert.Equal(t, 201, status)
+
+	event := models.Event{CreatorID: adminID, CommunityID: &community.ID, Title: "Уборка берега", StartTime: time.Now(), EndTime: time.Now().Add(time.Hour)}
+	db.Create(&event)
+	attendTestEvent(db, event, aliceID)
+
+	// Заявленный результат не засчитывается до подтверждения
+	status, result = communityRequest(app, "POST", path+"/contributions", aliceToken, map[string]interface{}{"event_id": event.ID, "quantity": 1e9})
+	assert.Equal(t, 201, status)
+	contributionPath := fmt.Sprintf("%s/contributions/%d", path, uint(result["contribution"].(map[string]interface{})["id"].(float64)))
+	_, result = communityRequest(app, "GET", path, aliceToken, nil)
+	assert.Equal(t, float64(0), result["progress"].(map[string]interface{})["total_progress"])
+	assert.Equal(t, 0, userPoints(db, aliceID))
+
+	// Рассматривает результат только организатор ивента
+	status, _ = communityRequest(app, "POST", contributionPath+"/confirm", aliceToken, nil)
+	assert.Equal(t, 403, status)
+	status, result = communityRequest(app, "GET", path+"/contributions/pending", adminToken, nil)
+	assert.Equal(t, 200, status)
+	assert.Len(t, result["contributions"], 1)
+
+	// Отклоненный результат можно отправить заново, организатор исправляет количество
+	status, _ = communityRequest(app, "POST", contributionPath+"/reject", adminToken, nil)
+	assert.Equal(t, 200, status)
+	status, result = communityRequest(app, "POST", path+"/contributions", aliceToken, map[string]interface{}{"event_id": event.ID, "quantity": 1e9})
+	assert.Equal(t, 201, status)
+	contributionPath = fmt.Sprintf("%s/contributions/%d", path, uint(result["contribution"].(map[string]interface{})["id"].(float64)))
+	status, result = communityRequest(app, "POST", contributionPath+"/confirm", adminToken, map[string]interface{}{"quantity": 12})
+	assert.Equal(t, 200, status)
+	assert.Equal(t, float64(12), result["contribution"].(map[string]interface{})["quantity"])
+	assert.Equal(t, 25, userPoints(db, aliceID))
+
+	status, _ = communityRequest(app, "POST", contributionPath+"/confirm", adminToken, nil)
+	assert.Equal(t, 409, status)
+}
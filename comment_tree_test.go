@@ -0,0 +1,214 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"toloko-backend/models"
+	"toloko-backend/services"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommentTree(t *testing.T) {
+	db := setupCommunitiesTestDB()
+	app := setupCommunitiesTestApp(db)
+
+	authorID, token := createCommunityMemberTestUser(db, "author@example.com")
+	_, readerToken := createCommunityMemberTestUser(db, "reader@example.com")
+	community := createCommunitiesTestCommunity(db, authorID)
+	news := models.News{CommunityID: community.ID, AuthorID: authorID, Content: "Новость для обсуждения"}
+	db.Create(&news)
+
+	comment := func(parentID *uint, content string) (int, uint) {
+		body := map[string]interface{}{"content": content}
+		if parentID != nil {
+			body["parent_id"] = *parentID
+		}
+		status, result := communityRequest(app, "POST", fmt.Sprintf("/news/%d/comments", news.ID), token, body)
+		if status != 201 {
+			return status, 0
+		}
+		return status, uint(result["data"].(map[string]interface{})["id"].(float64))
+	}
+
+	_, rootID := comment(nil, "Первый комментарий")
+	comment(nil, "Второй комментарий")
+	var replyIDs []uint
+	for i := 0; i < 5; i++ {
+		_, id := comment(&rootID, fmt.Sprintf("Ответ %d", i+1))
+		replyIDs = append(replyIDs, id)
+	}
+
+	// Глубина ответов ограничена
+	parent := replyIDs[0]
+	for depth := 2; depth <= services.MaxCommentDepth; depth++ {
+		status, id := comment(&parent, fmt.Sprintf("Глубина %d", depth))
+		assert.Equal(t, 201, status)
+		parent = id
+	}
+	status, _ := comment(&parent, "Слишком глубоко")
+	assert.Equal(t, 400, status)
+
+	status, result := communityRequest(app, "GET", fmt.Sprintf("/news/%d/comments/tree?replies=2", news.ID), "", nil)
+	assert.Equal(t, 200, status)
+	assert.Equal(t, float64(2), result["total"])
+	threads := result["data"].([]interface{})
+	root := threads[0].(map[string]interface{})
+	assert.Equal(t, float64(5), root["replies_count"])
+	assert.Len(t, root["replies"], 2)
+	assert.Equal(t, float64(replyIDs[1]), root["next_cursor"])
+	assert.Empty(t, threads[1].(map[string]interface{})["replies"])
+	assert.Nil(t, threads[1].(map[string]interface{})["next_cursor"])
+
+	// Дозагрузка ответов по курсору
+	status, result = communityRequest(app, "GET", fmt.Sprintf("/comments/%d/replies?cursor=%d&limit=2", rootID, replyIDs[1]), "", nil)
+	assert.Equal(t, 200, status)
+	assert.Len(t, result["data"], 2)
+	assert.Equal(t, float64(replyIDs[3]), result["next_cursor"])
+	status, result = communityRequest(app, "GET", fmt.Sprintf("/comments/%d/replies?cursor=%d&limit=2", rootID, replyIDs[3]), "", nil)
+	assert.Equal(t, 200, status)
+	assert.Len(t, result["data"], 1)
+	assert.Nil(t, result["next_cursor"])
+
+	// Лайки комментариев
+	status, result = communityRequest(app, "POST", fmt.Sprintf("/comments/%d/like", rootID), readerToken, nil)
+	assert.Equal(t, 200, status)
+	assert.Equal(t, true, result["liked"])
+	assert.Equal(t, float64(1), result["likes_count"])
+	_, result = communityRequest(app, "GET", fmt.Sprintf("/news/%d/comments/tree", news.ID), readerToken, nil)
+	assert.Equal(t, true, result["data"].([]interface{})[0].(map[string]interface{})["liked_by_me"])
+	_, result = communityRequest(app, "POST", fmt.Sprintf("/comments/%d/like", rootID), readerToken, nil)
+	assert.Equal(t, false, result["liked"])
+	assert.Equal(t, float64(0), result["likes_count"])
+}
+
+func TestMembersOnlyEventComments(t *testing.T) {
+	db := setupCommunitiesTestDB()
+	db.AutoMigrate(&models.EventParticipant{})
+	app := setupCommunitiesTestApp(db)
+
+	authorID, authorToken := createCommunityMemberTestUser(db, "author@example.com")
+	_, outsiderToken := createCommunityMemberTestUser(db, "outsider@example.com")
+	memberID, memberToken := createCommunityMemberTestUser(db, "member@example.com")
+	community := createCommunitiesTestCommunity(db, authorID)
+	db.Create(&models.CommunityRole{CommunityID: community.ID, UserID: memberID, Role: models.CommunityRoleMember})
+
+	event := createCommunityTestEvent(db, authorID, community.ID, time.Now().Add(24*time.Hour), true)
+	news := models.News{CommunityID: community.ID, AuthorID: authorID, EventID: &event.ID, Content: "Анонс закрытого ивента"}
+	db.Create(&news)
+	status, result := communityRequest(app, "POST", fmt.Sprintf("/news/%d/comments", news.ID), authorToken, map[string]interface{}{"content": "Берем перчатки"})
+	assert.Equal(t, 201, status)
+	commentID := uint(result["data"].(map[string]interface{})["id"].(float64))
+
+	// Посторонний не может комментировать и лайкать то, что ему не видно
+	status, _ = communityRequest(app, "POST", fmt.Sprintf("/news/%d/comments", news.ID), outsiderToken, map[string]interface{}{"content": "А я?"})
+	assert.Equal(t, 404, status)
+	status, _ = communityRequest(app, "POST", fmt.Sprintf("/comments/%d/like", commentID), outsiderToken, nil)
+	assert.Equal(t, 404, status)
+	status, _ = communityRequest(app, "GET", fmt.Sprintf("/news/%d/like", news.ID), outsiderToken, nil)
+	assert.Equal(t, 404, status)
+
+	// Участник сообщества обсуждает анонс как обычно
+	status, _ = communityRequest(app, "POST", fmt.Sprintf("/news/%d/comments", news.ID), memberToken, map[string]interface{}{"content": "Буду"})
+	assert.Equal(t, 201, status)
+	status, _ = communityRequest(app, "POST", fmt.Sprintf("/comments/%d/like", commentID), memberToken, nil)
+	assert.Equal(t, 200, status)
+	status, _ = communityRequest(app, "GET", fmt.Sprintf("/news/%d/like", news.ID), memberToken, nil)
+	assert.Equal(t, 200, status)
+
+	var likes int64
+	db.Model(&models.CommentLike{}).Count(&likes)
+	assert.Equal(t, int64(1), likes)
+}
+
+func TestDeleteCommentWithReplies(t *testing.T) {
+	db := setupCommunitiesTestDB()
+	app := setupCommunitiesTestApp(db)
+
+	authorID, token := createCommunityMemberTestUser(db, "author@example.com")
+	community := createCommunitiesTestCommunity(db, authorID)
+	news := models.News{CommunityID: community.ID, AuthorID: authorID, Content: "Новость для обсуждения"}
+	db.Create(&news)
+
+	comments := services.NewCommentService(db)
+	root := models.Comment{NewsID: news.ID, AuthorID: authorID, Content: "Родитель"}
+	assert.NoError(t, comments.Create(&root))
+	reply := models.Comment{NewsID: news.ID, AuthorID: authorID, ParentID: &root.ID, Content: "Ответ"}
+	assert.NoError(t, comments.Create(&reply))
+
+	// Родитель с ответами превращается в заглушку, ответ остается
+	status, _ := communityRequest(app, "DELETE", fmt.Sprintf("/comments/%d", root.ID), token, nil)
+	assert.Equal(t, 200, status)
+	status, result := communityRequest(app, "GET", fmt.Sprintf("/news/%d/comments/tree", news.ID), "", nil)
+	assert.Equal(t, 200, status)
+	placeholder := result["data"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, models.DeletedCommentContent, placeholder["content"])
+	assert.Equal(t, true, placeholder["is_deleted"])
+	assert.Len(t, placeholder["replies"], 1)
+
+	// Старые эндпоинты тоже не раскрывают автора заглушки
+	_, result = communityRequest(app, "GET", fmt.Sprintf("/news/%d/comments", news.ID), "", nil)
+	placeholder = result["data"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, float64(0), placeholder["author_id"])
+	assert.Equal(t, float64(0), placeholder["author"].(map[string]interface{})["id"])
+	_, result = communityRequest(app, "GET", fmt.Sprintf("/comments/%d", root.ID), "", nil)
+	assert.Equal(t, float64(0), result["data"].(map[string]interface{})["author_id"])
+	_, result = communityRequest(app, "GET", fmt.Sprintf("/comments/%d", reply.ID), "", nil)
+	assert.Equal(t, float64(authorID), result["data"].(map[string]interface{})["author_id"])
+	assert.Equal(t, float64(0), result["data"].(map[string]interface{})["parent"].(map[string]interface{})["author_id"])
+	_, result = communityRequest(app, "GET", fmt.Sprintf("/news/%d", news.ID), "", nil)
+	embedded := result["data"].(map[string]interface{})["comments"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, float64(0), embedded["author_id"])
+	assert.Equal(t, float64(authorID), embedded["children"].([]interface{})[0].(map[string]interface{})["author_id"])
+	_, result = communityRequest(app, "GET", fmt.Sprintf("/communities/%d/news", community.ID), "", nil)
+	embedded = result["data"].([]interface{})[0].(map[string]interface{})["comments"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, float64(0), embedded["author_id"])
+
+	status, _ = communityRequest(app, "POST", fmt.Sprintf("/news/%d/comments", news.ID), token, map[string]interface{}{"content": "Ответ заглушке", "parent_id": root.ID})
+	assert.Equal(t, 400, status)
+
+	// После удаления последнего ответа заглушка тоже исчезает
+	status, _ = communityRequest(app, "DELETE", fmt.Sprintf("/comments/%d", reply.ID), token, nil)
+	assert.Equal(t, 200, status)
+	var count int64
+	db.Model(&models.Comment{}).Where("news_id = ?", news.ID).Count(&count)
+	assert.Equal(t, int64(0), count)
+}
+
+func TestBackfillCommentTree(t *testing.T) {
+	db := setupCommunitiesTestDB()
+	app := setupCommunitiesTestApp(db)
+
+	authorID, token := createCommunityMemberTestUser(db, "author@example.com")
+	community := createCommunitiesTestCommunity(db, authorID)
+	news := models.News{CommunityID: community.ID, AuthorID: authorID, Content: "Новость для обсуждения"}
+	db.Create(&news)
+
+	// Комментарии, оставленные до появления дерева: без счетчиков и глубины
+	root := models.Comment{NewsID: news.ID, AuthorID: authorID, Content: "Родитель"}
+	db.Create(&root)
+	reply := models.Comment{NewsID: news.ID, AuthorID: authorID, ParentID: &root.ID, Content: "Ответ"}
+	db.Create(&reply)
+	nested := models.Comment{NewsID: news.ID, AuthorID: authorID, ParentID: &reply.ID, Content: "Ответ на ответ"}
+	db.Create(&nested)
+
+	// Удаление не доверяет устаревшему счетчику: ответы не теряются
+	status, _ := communityRequest(app, "DELETE", fmt.Sprintf("/comments/%d", root.ID), token, nil)
+	assert.Equal(t, 200, status)
+	var count int64
+	db.Model(&models.Comment{}).Where("news_id = ?", news.ID).Count(&count)
+	assert.Equal(t, int64(3), count)
+
+	assert.NoError(t, models.BackfillCommentTree(db))
+	db.First(&root, root.ID)
+	db.First(&reply, reply.ID)
+	db.First(&nested, nested.ID)
+	assert.Equal(t, 1, root.RepliesCount)
+	assert.Equal(t, 1, reply.RepliesCount)
+	assert.Equal(t, 0, nested.RepliesCount)
+	assert.Equal(t, 0, root.Depth)
+	assert.Equal(t, 1, reply.Depth)
+	assert.Equal(t, 2, nested.Depth)
+}
@@ -25,7 +25,7 @@ func setupCommunitiesTestDB() *gorm.DB {
 	}
 
 	// Автомиграция
-	db.AutoMigrate(&models.User{}, &models.Community{}, &models.CommunityRole{}, &models.News{}, &models.Comment{}, &models.NewsLike{})
+	db.AutoMigrate(&models.User{}, &models.Community{}, &models.CommunityRole{}, &models.News{}, &models.Comment{}, &models.NewsLike{}, &models.AuditLog{}, &models.CommunityRoleChange{}, &models.Event{}, &models.Subscription{}, &models.Notification{}, &models.NewsMedia{}, &models.CommentLike{}, &models.Mention{}, &models.Block{}, &models.CommunitySanction{}, &models.CommunityModerationLog{}, &models.Achievement{}, &models.UserAchievement{}, &models.UserLevel{}, &models.PointsEntry{}, &models.LevelDefinition{}, &models.FeedActivity{}, &models.LeaderboardEntry{}, &models.Challenge{}, &models.ChallengeTeam{}, &models.ChallengeParticipant{}, &models.ChallengeContribution{})
 
 	return db
 }
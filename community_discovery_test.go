@@ -0,0 +1,123 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"toloko-backend/models"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// createDiscoveryTestCommunity создает сообщество с координатами и участниками
+func createDiscoveryTestCommunity(db *gorm.DB, name, city string, lat, lng float64, memberIDs ...uint) models.Community {
+	community := models.Community{
+		CreatorID:   memberIDs[0],
+		Name:        name,
+		Description: "Волонтеры города " + city,
+		City:        city,
+		Latitude:    &lat,
+		Longitude:   &lng,
+		Visibility:  models.CommunityVisibilityOpen,
+	}
+	db.Create(&community)
+	for _, userID := range memberIDs {
+		db.Create(&models.CommunityRole{CommunityID: community.ID, UserID: userID, Role: models.CommunityRoleMember})
+	}
+	return community
+}
+
+func TestCommunityDiscovery(t *testing.T) {
+	db := setupCommunitiesTestDB()
+	app := setupCommunitiesTestApp(db)
+
+	var userIDs []uint
+	for _, email := range []string{"a@example.com", "b@example.com", "c@example.com"} {
+		id, _ := createCommunityMemberTestUser(db, email)
+		userIDs = append(userIDs, id)
+	}
+
+	// Москва: центр и окраина, Казань - далеко
+	center := createDiscoveryTestCommunity(db, "Чистый центр", "Москва", 55.7558, 37.6176, userIDs[0])
+	outskirts := createDiscoveryTestCommunity(db, "Зеленое Бутово", "Москва", 55.5450, 37.5600, userIDs...)
+	kazan := createDiscoveryTestCommunity(db, "Kazan Cleanup", "Казань", 55.7963, 49.1088, userIDs[0], userIDs[1])
+	db.Create(&models.News{CommunityID: kazan.ID, AuthorID: userIDs[0], Content: "Свежая новость"})
+	db.Create(&models.News{CommunityID: kazan.ID, AuthorID: userIDs[0], Content: "Еще одна новость"})
+	old := models.News{CommunityID: outskirts.ID, AuthorID: userIDs[0], Content: "Старая новость"}
+	db.Create(&old)
+	db.Model(&old).UpdateColumns(map[string]interface{}{"created_at": time.Now().AddDate(0, 0, -60), "published_at": time.Now().AddDate(0, 0, -60)})
+
+	ids := func(result map[string]interface{}) []uint {
+		var list []uint
+		data, _ := result["data"].([]interface{})
+		for _, item := range data {
+			list = append(list, uint(item.(map[string]interface{})["id"].(float64)))
+		}
+		return list
+	}
+
+	status, result := communityRequest(app, "GET", "/communities?sort=members", "", nil)
+	assert.Equal(t, 200, status)
+	assert.Equal(t, []uint{outskirts.ID, kazan.ID, center.ID}, ids(result))
+	assert.Equal(t, float64(3), result["data"].([]interface{})[0].(map[string]interface{})["members_count"])
+
+	status, result = communityRequest(app, "GET", "/communities?sort=activity", "", nil)
+	assert.Equal(t, 200, status)
+	assert.Equal(t, kazan.ID, ids(result)[0])
+	assert.Equal(t, float64(2), result["data"].([]interface{})[0].(map[string]interface{})["recent_activity"])
+
+	status, result = communityRequest(app, "GET", "/communities?city=%D0%9C%D0%BE%D1%81%D0%BA%D0%B2%D0%B0", "", nil) // Москва
+	assert.Equal(t, 200, status)
+	assert.Equal(t, float64(2), result["total"])
+
+	status, result = communityRequest(app, "GET", "/communities?search=kazan", "", nil)
+	assert.Equal(t, 200, status)
+	assert.Equal(t, []uint{kazan.ID}, ids(result))
+
+	// Поиск поблизости: окраина в ~24 км от центра
+	status, result = communityRequest(app, "GET", "/communities?lat=55.7558&lng=37.6176&radius=10&sort=distance", "", nil)
+	assert.Equal(t, 200, status)
+	assert.Equal(t, []uint{center.ID}, ids(result))
+
+	status, result = communityRequest(app, "GET", "/communities?lat=55.7558&lng=37.6176&radius=50&sort=distance", "", nil)
+	assert.Equal(t, 200, status)
+	assert.Equal(t, []uint{center.ID, outskirts.ID}, ids(result))
+	distance := result["data"].([]interface{})[1].(map[string]interface{})["distance_km"].(float64)
+	assert.InDelta(t, 23.8, distance, 1)
+
+	status, _ = communityRequest(app, "GET", "/communities?sort=distance", "", nil)
+	assert.Equal(t, 400, status)
+	status, _ = communityRequest(app, "GET", "/communities?sort=popular", "", nil)
+	assert.Equal(t, 400, status)
+}
+
+func TestSuggestedCommunities(t *testing.T) {
+	db := setupCommunitiesTestDB()
+	app := setupCommunitiesTestApp(db)
+
+	userID, token := createCommunityMemberTestUser(db, "me@example.com")
+	friendID, _ := createCommunityMemberTestUser(db, "friend@example.com")
+	otherFriendID, _ := createCommunityMemberTestUser(db, "other-friend@example.com")
+	strangerID, _ := createCommunityMemberTestUser(db, "stranger@example.com")
+	db.Create(&models.Subscription{SubscriberID: userID, SubscribedToID: friendID})
+	db.Create(&models.Subscription{SubscriberID: userID, SubscribedToID: otherFriendID})
+
+	popular := createDiscoveryTestCommunity(db, "Друзья природы", "Москва", 55.75, 37.61, friendID, otherFriendID)
+	single := createDiscoveryTestCommunity(db, "Помощь приютам", "Москва", 55.75, 37.61, otherFriendID)
+	createDiscoveryTestCommunity(db, "Чужое сообщество", "Москва", 55.75, 37.61, strangerID)
+	createDiscoveryTestCommunity(db, "Мое сообщество", "Москва", 55.75, 37.61, userID, friendID)
+	hidden := createDiscoveryTestCommunity(db, "Закрытый клуб", "Москва", 55.75, 37.61, friendID)
+	db.Model(&hidden).Update("visibility", models.CommunityVisibilityInvite)
+
+	status, _ := communityRequest(app, "GET", "/communities/suggestions", "", nil)
+	assert.Equal(t, 401, status)
+
+	status, result := communityRequest(app, "GET", "/communities/suggestions", token, nil)
+	assert.Equal(t, 200, status)
+	data := result["data"].([]interface{})
+	assert.Len(t, data, 2)
+	assert.Equal(t, float64(popular.ID), data[0].(map[string]interface{})["id"])
+	assert.Equal(t, float64(2), data[0].(map[string]interface{})["subscriptions_count"])
+	assert.Equal(t, float64(single.ID), data[1].(map[string]interface{})["id"])
+}
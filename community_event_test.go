@@ -0,0 +1,157 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"toloko-backend/controllers"
+	"toloko-backend/models"
+	"toloko-backend/routes"
+	"toloko-backend/services"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// setupCommunityEventTestApp создает тестовое приложение с ивентами, сообществами и новостями
+func setupCommunityEventTestApp() (*fiber.App, *gorm.DB) {
+	app, db := setupCommunityMemberTestApp()
+	db.AutoMigrate(&models.Event{}, &models.Inventory{}, &models.EventInventory{}, &models.EventPhoto{}, &models.EventParticipant{})
+
+	routes.SetupEventRoutes(app, controllers.NewEventController(db))
+	routes.SetupNewsRoutes(app, controllers.NewNewsController(db))
+
+	return app, db
+}
+
+// createCommunityTestEvent создает ивент сообщества напрямую в базе
+func createCommunityTestEvent(db *gorm.DB, creatorID, communityID uint, start time.Time, membersOnly bool) models.Event {
+	event := models.Event{
+		CreatorID:   creatorID,
+		CommunityID: &communityID,
+		Title:       "Community Event",
+		Latitude:    55.75,
+		Longitude:   37.61,
+		StartTime:   start,
+		EndTime:     start.Add(2 * time.Hour),
+		JoinMode:    "free",
+		IsActive:    true,
+		IsPublic:    true,
+		MembersOnly: membersOnly,
+	}
+	db.Create(&event)
+	return event
+}
+
+func TestCreateCommunityEvent(t *testing.T) {
+	app, db := setupCommunityEventTestApp()
+	adminID, adminToken := createCommunityMemberTestUser(db, "admin@example.com")
+	_, memberToken := createCommunityMemberTestUser(db, "member@example.com")
+	community := createCommunitiesTestCommunity(db, adminID)
+	communityRequest(app, "POST", fmt.Sprintf("/communities/%d/join", community.ID), memberToken, nil)
+
+	eventData := map[string]interface{}{
+		"title":            "Субботник в парке",
+		"latitude":         55.7558,
+		"longitude":        37.6176,
+		"start_time":       time.Now().Add(48 * time.Hour).Format(time.RFC3339),
+		"end_time":         time.Now().Add(50 * time.Hour).Format(time.RFC3339),
+		"join_mode":        "free",
+		"min_participants": 1,
+		"max_participants": 10,
+		"community_id":     community.ID,
+		"members_only":     true,
+	}
+
+	// Рядовой участник не может создавать ивенты от имени сообщества
+	status, _ := communityRequest(app, "POST", "/events", memberToken, eventData)
+	assert.Equal(t, 403, status)
+
+	status, result := communityRequest(app, "POST", "/events", adminToken, eventData)
+	assert.Equal(t, 201, status)
+	event := result["event"].(map[string]interface{})
+	assert.Equal(t, float64(community.ID), event["community_id"])
+	assert.Equal(t, true, event["members_only"])
+
+	// Анонс ивента появился в новостях сообщества
+	var news models.News
+	assert.NoError(t, db.Where("community_id = ? AND event_id = ?", community.ID, uint(event["id"].(float64))).First(&news).Error)
+
+	// Ивент «только для участников» без сообщества создать нельзя
+	delete(eventData, "community_id")
+	status, _ = communityRequest(app, "POST", "/events", adminToken, eventData)
+	assert.Equal(t, 400, status)
+}
+
+func TestMembersOnlyCommunityEvents(t *testing.T) {
+	app, db := setupCommunityEventTestApp()
+	adminID, _ := createCommunityMemberTestUser(db, "admin@example.com")
+	_, memberToken := createCommunityMemberTestUser(db, "member@example.com")
+	_, outsiderToken := createCommunityMemberTestUser(db, "outsider@example.com")
+	community := createCommunitiesTestCommunity(db, adminID)
+	communityRequest(app, "POST", fmt.Sprintf("/communities/%d/join", community.ID), memberToken, nil)
+
+	start := time.Date(2031, 3, 12, 10, 0, 0, 0, time.UTC) // среда
+	public := createCommunityTestEvent(db, adminID, community.ID, start, false)
+	private := createCommunityTestEvent(db, adminID, community.ID, start.AddDate(0, 0, 10), true)
+	db.Create(&models.News{CommunityID: community.ID, AuthorID: adminID, EventID: &private.ID, Content: "Анонс закрытого ивента"})
+
+	// Общий список ивентов
+	status, result := communityRequest(app, "GET", "/events", outsiderToken, nil)
+	assert.Equal(t, 200, status)
+	assert.Len(t, result["events"], 1)
+	status, result = communityRequest(app, "GET", "/events", memberToken, nil)
+	assert.Equal(t, 200, status)
+	assert.Len(t, result["events"], 2)
+
+	status, _ = communityRequest(app, "GET", fmt.Sprintf("/events/%d", private.ID), outsiderToken, nil)
+	assert.Equal(t, 404, status)
+	status, _ = communityRequest(app, "GET", fmt.Sprintf("/events/%d", private.ID), memberToken, nil)
+	assert.Equal(t, 200, status)
+
+	// Календарь на месяц
+	calendarPath := fmt.Sprintf("/communities/%d/events?view=month&date=2031-03-01", community.ID)
+	status, result = communityRequest(app, "GET", calendarPath, "", nil)
+	assert.Equal(t, 200, status)
+	assert.Equal(t, float64(1), result["total"])
+	status, result = communityRequest(app, "GET", calendarPath, memberToken, nil)
+	assert.Equal(t, 200, status)
+	assert.Equal(t, float64(2), result["total"])
+
+	// Календарь на неделю содержит только ивент этой недели; ивент, закончившийся к ее началу, не попадает
+	createCommunityTestEvent(db, adminID, community.ID, time.Date(2031, 3, 9, 22, 0, 0, 0, time.UTC), false)
+	status, result = communityRequest(app, "GET", fmt.Sprintf("/communities/%d/events?view=week&date=2031-03-15", community.ID), memberToken, nil)
+	assert.Equal(t, 200, status)
+	assert.Equal(t, float64(1), result["total"])
+	assert.Equal(t, float64(public.ID), result["events"].([]interface{})[0].(map[string]interface{})["id"])
+
+	status, _ = communityRequest(app, "GET", fmt.Sprintf("/communities/%d/events?view=year", community.ID), memberToken, nil)
+	assert.Equal(t, 400, status)
+
+	// Анонс закрытого ивента скрыт от посторонних
+	status, result = communityRequest(app, "GET", fmt.Sprintf("/communities/%d/news", community.ID), outsiderToken, nil)
+	assert.Equal(t, 200, status)
+	assert.Nil(t, result["data"])
+	status, result = communityRequest(app, "GET", fmt.Sprintf("/communities/%d/news", community.ID), memberToken, nil)
+	assert.Equal(t, 200, status)
+	assert.Len(t, result["data"], 1)
+}
+
+func TestCalendarRange(t *testing.T) {
+	date := time.Date(2026, 10, 18, 15, 30, 0, 0, time.UTC) // воскресенье
+
+	from, to, err := services.CalendarRange(services.CalendarViewWeek, date)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC), from)
+	assert.Equal(t, time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC), to)
+
+	from, to, err = services.CalendarRange(services.CalendarViewMonth, date)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC), from)
+	assert.Equal(t, time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC), to)
+
+	_, _, err = services.CalendarRange("year", date)
+	assert.Equal(t, services.ErrInvalidCalendarView, err)
+}
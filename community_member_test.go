@@ -0,0 +1,284 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+
+	"toloko-backend/controllers"
+	"toloko-backend/models"
+	"toloko-backend/routes"
+	"toloko-backend/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// setupCommunityMemberTestApp создает тестовое приложение с маршрутами участия в сообществах
+func setupCommunityMemberTestApp() (*fiber.App, *gorm.DB) {
+	db := setupCommunitiesTestDB()
+	db.AutoMigrate(&models.UserRole{}, &models.CommunityJoinRequest{}, &models.CommunityInvitation{}, &models.CommunityRoleChange{})
+
+	app := fiber.New()
+	routes.SetupCommunityRoutes(app, controllers.NewCommunityController(db))
+	routes.SetupCommunityMemberRoutes(app, controllers.NewCommunityMemberController(db))
+	app.Get("/users/:id/communities", controllers.NewUserController(db).GetUserCommunities)
+
+	return app, db
+}
+
+// createCommunityMemberTestUser создает пользователя и возвращает его ID и токен
+func createCommunityMemberTestUser(db *gorm.DB, email string) (uint, string) {
+	user := models.User{Name: email, Email: email, PasswordHash: "hash", IsActive: true}
+	db.Create(&user)
+	token, _ := utils.GenerateJWT(user.ID, user.Email)
+	return user.ID, token
+}
+
+// communityRequest выполняет запрос к маршрутам сообществ
+func communityRequest(app *fiber.App, method, path, token string, body interface{}) (int, map[string]interface{}) {
+	var reader *bytes.Buffer
+	if body != nil {
+		data, _ := json.Marshal(body)
+		reader = bytes.NewBuffer(data)
+	} else {
+		reader = bytes.NewBuffer(nil)
+	}
+
+	req := httptest.NewRequest(method, path, reader)
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := app.Test(req)
+	if err != nil {
+		return 0, nil
+	}
+
+	var result map[string]interface{}
+	json.NewDecoder(resp.Body).Decode(&result)
+	return resp.StatusCode, result
+}
+
+func TestJoinOpenCommunity(t *testing.T) {
+	app, db := setupCommunityMemberTestApp()
+	adminID, _ := createCommunityMemberTestUser(db, "admin@example.com")
+	memberID, memberToken := createCommunityMemberTestUser(db, "member@example.com")
+	community := createCommunitiesTestCommunity(db, adminID)
+
+	status, result := communityRequest(app, "POST", fmt.Sprintf("/communities/%d/join", community.ID), memberToken, nil)
+	assert.Equal(t, 200, status)
+	assert.Equal(t, "joined", result["status"])
+
+	// Повторное вступление
+	status, _ = communityRequest(app, "POST", fmt.Sprintf("/communities/%d/join", community.ID), memberToken, nil)
+	assert.Equal(t, 409, status)
+
+	status, result = communityRequest(app, "GET", fmt.Sprintf("/communities/%d/members/count", community.ID), "", nil)
+	assert.Equal(t, 200, status)
+	assert.Equal(t, float64(2), result["count"])
+
+	status, result = communityRequest(app, "GET", fmt.Sprintf("/communities/%d/members?role=member", community.ID), "", nil)
+	assert.Equal(t, 200, status)
+	assert.Len(t, result["data"], 1)
+
+	// Сообщество отображается в профиле участника
+	status, result = communityRequest(app, "GET", fmt.Sprintf("/users/%d/communities", memberID), "", nil)
+	assert.Equal(t, 200, status)
+	assert.Len(t, result["communities"], 1)
+
+	status, _ = communityRequest(app, "POST", fmt.Sprintf("/communities/%d/leave", community.ID), memberToken, nil)
+	assert.Equal(t, 200, status)
+
+	var count int64
+	db.Model(&models.CommunityRole{}).Where("community_id = ? AND user_id = ?", community.ID, memberID).Count(&count)
+	assert.Equal(t, int64(0), count)
+}
+
+func TestLastAdminCannotLeave(t *testing.T) {
+	app, db := setupCommunityMemberTestApp()
+	adminID, adminToken := createCommunityMemberTestUser(db, "admin@example.com")
+	community := createCommunitiesTestCommunity(db, adminID)
+
+	status, _ := communityRequest(app, "POST", fmt.Sprintf("/communities/%d/leave", community.ID), adminToken, nil)
+	assert.Equal(t, 409, status)
+}
+
+func TestCommunityJoinRequests(t *testing.T) {
+	app, db := setupCommunityMemberTestApp()
+	adminID, adminToken := createCommunityMemberTestUser(db, "admin@example.com")
+	memberID, memberToken := createCommunityMemberTestUser(db, "member@example.com")
+	_, otherToken := createCommunityMemberTestUser(db, "other@example.com")
+	community := createCommunitiesTestCommunity(db, adminID)
+	db.Model(community).Update("visibility", models.CommunityVisibilityRequest)
+
+	status, result := communityRequest(app, "POST", fmt.Sprintf("/communities/%d/join", community.ID), memberToken, controllers.JoinCommunityRequest{Message: "Хочу помогать"})
+	assert.Equal(t, 202, status)
+	assert.Equal(t, "requested", result["status"])
+	requestID := uint(result["data"].(map[string]interface{})["id"].(float64))
+
+	// Заявки видят только администраторы и модераторы
+	status, _ = communityRequest(app, "GET", fmt.Sprintf("/communities/%d/requests", community.ID), otherToken, nil)
+	assert.Equal(t, 403, status)
+
+	status, result = communityRequest(app, "GET", fmt.Sprintf("/communities/%d/requests", community.ID), adminToken, nil)
+	assert.Equal(t, 200, status)
+	assert.Len(t, result["data"], 1)
+
+	status, _ = communityRequest(app, "POST", fmt.Sprintf("/communities/%d/requests/%d/approve", community.ID, requestID), adminToken, nil)
+	assert.Equal(t, 200, status)
+
+	var role models.CommunityRole
+	assert.NoError(t, db.Where("community_id = ? AND user_id = ?", community.ID, memberID).First(&role).Error)
+	assert.Equal(t, models.CommunityRoleMember, role.Role)
+
+	// Рассмотренную заявку нельзя рассмотреть повторно
+	status, _ = communityRequest(app, "POST", fmt.Sprintf("/communities/%d/requests/%d/reject", community.ID, requestID), adminToken, nil)
+	assert.Equal(t, 404, status)
+
+	// Отзыв собственной заявки
+	status, _ = communityRequest(app, "POST", fmt.Sprintf("/communities/%d/join", community.ID), otherToken, nil)
+	assert.Equal(t, 202, status)
+	status, _ = communityRequest(app, "POST", fmt.Sprintf("/communities/%d/leave", community.ID), otherToken, nil)
+	assert.Equal(t, 200, status)
+}
+
+func TestInviteOnlyCommunity(t *testing.T) {
+	app, db := setupCommunityMemberTestApp()
+	adminID, adminToken := createCommunityMemberTestUser(db, "admin@example.com")
+	memberID, memberToken := createCommunityMemberTestUser(db, "member@example.com")
+	community := createCommunitiesTestCommunity(db, adminID)
+	db.Model(community).Update("visibility", models.CommunityVisibilityInvite)
+
+	// Без приглашения вступить нельзя
+	status, _ := communityRequest(app, "POST", fmt.Sprintf("/communities/%d/join", community.ID), memberToken, nil)
+	assert.Equal(t, 403, status)
+
+	// Закрытое сообщество не попадает в общий список, а его участники скрыты
+	status, result := communityRequest(app, "GET", "/communities", "", nil)
+	assert.Equal(t, 200, status)
+	assert.Nil(t, result["data"])
+	status, _ = communityRequest(app, "GET", fmt.Sprintf("/communities/%d/members", community.ID), memberToken, nil)
+	assert.Equal(t, 403, status)
+
+	status, result = communityRequest(app, "POST", fmt.Sprintf("/communities/%d/invitations", community.ID), adminToken, controllers.InviteMemberRequest{UserID: memberID})
+	assert.Equal(t, 201, status)
+	invitationID := uint(result["data"].(map[string]interface{})["id"].(float64))
+
+	status, result = communityRequest(app, "GET", "/community-invitations", memberToken, nil)
+	assert.Equal(t, 200, status)
+	assert.Len(t, result["data"], 1)
+
+	status, _ = communityRequest(app, "POST", fmt.Sprintf("/community-invitations/%d/accept", invitationID), memberToken, nil)
+	assert.Equal(t, 200, status)
+
+	status, result = communityRequest(app, "GET", fmt.Sprintf("/communities/%d/members", community.ID), memberToken, nil)
+	assert.Equal(t, 200, status)
+	assert.Equal(t, float64(2), result["total"])
+
+	// Закрытое сообщество в профиле видно только самому пользователю
+	status, result = communityRequest(app, "GET", fmt.Sprintf("/users/%d/communities", memberID), "", nil)
+	assert.Equal(t, 200, status)
+	assert.Len(t, result["communities"], 0)
+}
+
+func TestCommunityRoleManagement(t *testing.T) {
+	app, db := setupCommunityMemberTestApp()
+	adminID, adminToken := createCommunityMemberTestUser(db, "admin@example.com")
+	moderatorID, moderatorToken := createCommunityMemberTestUser(db, "moderator@example.com")
+	memberID, memberToken := createCommunityMemberTestUser(db, "member@example.com")
+	community := createCommunitiesTestCommunity(db, adminID)
+
+	for _, token := range []string{moderatorToken, memberToken} {
+		status, _ := communityRequest(app, "POST", fmt.Sprintf("/communities/%d/join", community.ID), token, nil)
+		assert.Equal(t, 200, status)
+	}
+
+	rolePath := func(userID uint) string {
+		return fmt.Sprintf("/communities/%d/members/%d/role", community.ID, userID)
+	}
+
+	// Рядовой участник не может менять роли
+	status, _ := communityRequest(app, "PUT", rolePath(moderatorID), memberToken, controllers.ChangeMemberRoleRequest{Role: models.CommunityRoleModerator})
+	assert.Equal(t, 403, status)
+
+	status, _ = communityRequest(app, "PUT", rolePath(moderatorID), adminToken, controllers.ChangeMemberRoleRequest{Role: "owner"})
+	assert.Equal(t, 400, status)
+
+	status, result := communityRequest(app, "PUT", rolePath(moderatorID), adminToken, controllers.ChangeMemberRoleRequest{Role: models.CommunityRoleModerator})
+	assert.Equal(t, 200, status)
+	assert.Equal(t, models.CommunityRoleModerator, result["data"].(map[string]interface{})["role"])
+
+	// Модератор не может назначать и понижать администраторов
+	status, _ = communityRequest(app, "PUT", rolePath(memberID), moderatorToken, controllers.ChangeMemberRoleRequest{Role: models.CommunityRoleAdmin})
+	assert.Equal(t, 403, status)
+	status, _ = communityRequest(app, "PUT", rolePath(adminID), moderatorToken, controllers.ChangeMemberRoleRequest{Role: models.CommunityRoleMember})
+	assert.Equal(t, 403, status)
+	status, _ = communityRequest(app, "DELETE", fmt.Sprintf("/communities/%d/members/%d", community.ID, adminID), moderatorToken, nil)
+	assert.Equal(t, 403, status)
+
+	// Владельца нельзя понизить
+	status, _ = communityRequest(app, "PUT", rolePath(adminID), adminToken, controllers.ChangeMemberRoleRequest{Role: models.CommunityRoleMember})
+	assert.Equal(t, 409, status)
+
+	// Модератор может исключить рядового участника
+	status, _ = communityRequest(app, "DELETE", fmt.Sprintf("/communities/%d/members/%d", community.ID, memberID), moderatorToken, nil)
+	assert.Equal(t, 200, status)
+	status, _ = communityRequest(app, "DELETE", fmt.Sprintf("/communities/%d/members/%d", community.ID, memberID), moderatorToken, nil)
+	assert.Equal(t, 404, status)
+
+	status, result = communityRequest(app, "GET", fmt.Sprintf("/communities/%d/roles/history?user_id=%d", community.ID, memberID), adminToken, nil)
+	assert.Equal(t, 200, status)
+	history := result["data"].([]interface{})
+	assert.Len(t, history, 2)
+	assert.Equal(t, models.RoleChangeRemoved, history[0].(map[string]interface{})["action"])
+	assert.Equal(t, float64(moderatorID), history[0].(map[string]interface{})["actor_id"])
+	assert.Equal(t, models.RoleChangeJoined, history[1].(map[string]interface{})["action"])
+}
+
+func TestTransferCommunityOwnership(t *testing.T) {
+	app, db := setupCommunityMemberTestApp()
+	ownerID, ownerToken := createCommunityMemberTestUser(db, "owner@example.com")
+	newOwnerID, newOwnerToken := createCommunityMemberTestUser(db, "new-owner@example.com")
+	_, outsiderToken := createCommunityMemberTestUser(db, "outsider@example.com")
+	community := createCommunitiesTestCommunity(db, ownerID)
+	transferPath := fmt.Sprintf("/communities/%d/transfer", community.ID)
+
+	// Передать владение можно только участнику
+	status, _ := communityRequest(app, "POST", transferPath, ownerToken, controllers.TransferOwnershipRequest{UserID: newOwnerID})
+	assert.Equal(t, 404, status)
+
+	status, _ = communityRequest(app, "POST", fmt.Sprintf("/communities/%d/join", community.ID), newOwnerToken, nil)
+	assert.Equal(t, 200, status)
+
+	status, _ = communityRequest(app, "POST", transferPath, outsiderToken, controllers.TransferOwnershipRequest{UserID: newOwnerID})
+	assert.Equal(t, 403, status)
+
+	status, _ = communityRequest(app, "POST", transferPath, ownerToken, controllers.TransferOwnershipRequest{UserID: newOwnerID})
+	assert.Equal(t, 200, status)
+
+	var updated models.Community
+	db.First(&updated, community.ID)
+	assert.Equal(t, newOwnerID, updated.CreatorID)
+
+	var role models.CommunityRole
+	db.Where("community_id = ? AND user_id = ?", community.ID, newOwnerID).First(&role)
+	assert.Equal(t, models.CommunityRoleAdmin, role.Role)
+
+	var change models.CommunityRoleChange
+	assert.NoError(t, db.Where("community_id = ? AND action = ?", community.ID, models.RoleChangeTransferred).First(&change).Error)
+	assert.Equal(t, ownerID, change.ActorID)
+	assert.Equal(t, models.CommunityRoleMember, change.FromRole)
+
+	// Прежний владелец остается администратором и теперь может быть понижен или выйти
+	status, _ = communityRequest(app, "PUT", fmt.Sprintf("/communities/%d/members/%d/role", community.ID, ownerID), newOwnerToken, controllers.ChangeMemberRoleRequest{Role: models.CommunityRoleMember})
+	assert.Equal(t, 200, status)
+
+	// Единственного администратора-владельца понизить нельзя
+	status, _ = communityRequest(app, "POST", fmt.Sprintf("/communities/%d/leave", community.ID), newOwnerToken, nil)
+	assert.Equal(t, 409, status)
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"toloko-backend/controllers"
+	"toloko-backend/models"
+	"toloko-backend/routes"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// setupCommunityModerationTestApp создает тестовое приложение с маршрутами участия, новостей и комментариев
+func setupCommunityModerationTestApp() (*fiber.App, *gorm.DB) {
+	app, db := setupCommunityMemberTestApp()
+	routes.SetupNewsRoutes(app, controllers.NewNewsController(db))
+	routes.SetupCommentRoutes(app, controllers.NewCommentController(db))
+	return app, db
+}
+
+func TestCommunityBan(t *testing.T) {
+	app, db := setupCommunityModerationTestApp()
+	adminID, adminToken := createCommunityMemberTestUser(db, "admin@example.com")
+	trollID, trollToken := createCommunityMemberTestUser(db, "troll@example.com")
+	_, otherToken := createCommunityMemberTestUser(db, "other@example.com")
+	community := createCommunitiesTestCommunity(db, adminID)
+	news := models.News{CommunityID: community.ID, AuthorID: adminID, Content: "Субботник в парке"}
+	db.Create(&news)
+
+	status, _ := communityRequest(app, "POST", fmt.Sprintf("/communities/%d/join", community.ID), trollToken, nil)
+	assert.Equal(t, 200, status)
+	status, result := communityRequest(app, "POST", fmt.Sprintf("/news/%d/comments", news.ID), trollToken, map[string]interface{}{"content": "Спам спам спам"})
+	assert.Equal(t, 201, status)
+	commentID := uint(result["data"].(map[string]interface{})["id"].(float64))
+
+	// Удаление модератором с причиной записывается в журнал, автор получает уведомление
+	status, _ = communityRequest(app, "DELETE", fmt.Sprintf("/comments/%d", commentID), adminToken, map[string]interface{}{"reason": "Реклама"})
+	assert.Equal(t, 200, status)
+	var notification models.Notification
+	assert.NoError(t, db.Where("user_id = ? AND type = ?", trollID, models.NotificationTypeRemoved).First(&notification).Error)
+	assert.Contains(t, notification.Body, "Реклама")
+
+	// Бан исключает из сообщества
+	status, result = communityRequest(app, "POST", fmt.Sprintf("/communities/%d/bans", community.ID), adminToken, map[string]interface{}{
+		"user_id": trollID,
+		"reason":  "Спам",
+	})
+	assert.Equal(t, 201, status)
+	assert.Nil(t, result["data"].(map[string]interface{})["expires_at"])
+	var members int64
+	db.Model(&models.CommunityRole{}).Where("community_id = ? AND user_id = ?", community.ID, trollID).Count(&members)
+	assert.Equal(t, int64(0), members)
+
+	// Забаненный не может вернуться, комментировать и лайкать
+	status, _ = communityRequest(app, "POST", fmt.Sprintf("/communities/%d/join", community.ID), trollToken, nil)
+	assert.Equal(t, 403, status)
+	status, _ = communityRequest(app, "POST", fmt.Sprintf("/news/%d/comments", news.ID), trollToken, map[string]interface{}{"content": "Я вернулся"})
+	assert.Equal(t, 403, status)
+	status, _ = communityRequest(app, "GET", fmt.Sprintf("/news/%d/like", news.ID), trollToken, nil)
+	assert.Equal(t, 403, status)
+	status, _ = communityRequest(app, "POST", fmt.Sprintf("/communities/%d/invitations", community.ID), adminToken, map[string]interface{}{"user_id": trollID})
+	assert.Equal(t, 409, status)
+
+	// Санкции видны самому пользователю вместе с причинами удаления контента
+	path := fmt.Sprintf("/communities/%d/members/%d/sanctions", community.ID, trollID)
+	status, result = communityRequest(app, "GET", path, trollToken, nil)
+	assert.Equal(t, 200, status)
+	assert.Len(t, result["data"], 1)
+	if removals := result["removals"].([]interface{}); assert.Len(t, removals, 1) {
+		assert.Equal(t, "Реклама", removals[0].(map[string]interface{})["reason"])
+	}
+	status, _ = communityRequest(app, "GET", path, otherToken, nil)
+	assert.Equal(t, 403, status)
+
+	// Журнал модерации доступен только управляющим
+	status, result = communityRequest(app, "GET", fmt.Sprintf("/communities/%d/moderation/log", community.ID), adminToken, nil)
+	assert.Equal(t, 200, status)
+	assert.Equal(t, float64(2), result["total"])
+	status, _ = communityRequest(app, "GET", fmt.Sprintf("/communities/%d/moderation/log", community.ID), otherToken, nil)
+	assert.Equal(t, 403, status)
+
+	// После снятия бана можно вступить снова
+	status, _ = communityRequest(app, "DELETE", fmt.Sprintf("/communities/%d/bans/%d", community.ID, trollID), adminToken, nil)
+	assert.Equal(t, 200, status)
+	status, _ = communityRequest(app, "DELETE", fmt.Sprintf("/communities/%d/bans/%d", community.ID, trollID), adminToken, nil)
+	assert.Equal(t, 404, status)
+	status, _ = communityRequest(app, "POST", fmt.Sprintf("/communities/%d/join", community.ID), trollToken, nil)
+	assert.Equal(t, 200, status)
+}
+
+func TestCommunityMute(t *testing.T) {
+	app, db := setupCommunityModerationTestApp()
+	adminID, adminToken := createCommunityMemberTestUser(db, "admin@example.com")
+	moderatorID, moderatorToken := createCommunityMemberTestUser(db, "moderator@example.com")
+	memberID, memberToken := createCommunityMemberTestUser(db, "member@example.com")
+	secondAdminID, _ := createCommunityMemberTestUser(db, "second-admin@example.com")
+	community := createCommunitiesTestCommunity(db, adminID)
+	db.Create(&models.CommunityRole{CommunityID: community.ID, UserID: secondAdminID, Role: models.CommunityRoleAdmin})
+	db.Create(&models.CommunityRole{CommunityID: community.ID, UserID: moderatorID, Role: models.CommunityRoleModerator})
+	db.Create(&models.CommunityRole{CommunityID: community.ID, UserID: memberID, Role: models.CommunityRoleMember})
+	news := models.News{CommunityID: community.ID, AuthorID: adminID, Content: "Субботник в парке"}
+	db.Create(&news)
+	memberNews := models.News{CommunityID: community.ID, AuthorID: memberID, Content: "Новость участника"}
+	db.Create(&memberNews)
+	memberComment := models.Comment{NewsID: news.ID, AuthorID: memberID, Content: "Комментарий участника"}
+	db.Create(&memberComment)
+
+	path := fmt.Sprintf("/communities/%d/mutes", community.ID)
+
+	// Мут обязательно ограничен по времени
+	status, _ := communityRequest(app, "POST", path, moderatorToken, map[string]interface{}{"user_id": memberID})
+	assert.Equal(t, 400, status)
+
+	// Модератор не может ограничить администратора, владельца не может ограничить никто
+	status, _ = communityRequest(app, "POST", path, moderatorToken, map[string]interface{}{"user_id": secondAdminID, "duration_minutes": 60})
+	assert.Equal(t, 403, status)
+	status, _ = communityRequest(app, "POST", path, moderatorToken, map[string]interface{}{"user_id": adminID, "duration_minutes": 60})
+	assert.Equal(t, 409, status)
+
+	status, result := communityRequest(app, "POST", path, moderatorToken, map[string]interface{}{
+		"user_id":          memberID,
+		"reason":           "Оскорбления",
+		"duration_minutes": 60,
+	})
+	assert.Equal(t, 201, status)
+	assert.NotNil(t, result["data"].(map[string]interface{})["expires_at"])
+
+	// Участник остается в сообществе, но не может писать и лайкать
+	status, result = communityRequest(app, "POST", fmt.Sprintf("/news/%d/comments", news.ID), memberToken, map[string]interface{}{"content": "Привет"})
+	assert.Equal(t, 403, status)
+	assert.Contains(t, result["message"], "временно")
+	status, _ = communityRequest(app, "GET", fmt.Sprintf("/news/%d/like", news.ID), memberToken, nil)
+	assert.Equal(t, 403, status)
+	status, _ = communityRequest(app, "POST", fmt.Sprintf("/comments/%d/like", memberComment.ID), memberToken, nil)
+	assert.Equal(t, 403, status)
+
+	// Редактировать свои новости и комментарии тоже нельзя
+	status, _ = communityRequest(app, "PUT", fmt.Sprintf("/comments/%d", memberComment.ID), memberToken, map[string]interface{}{"content": "Исправлено"})
+	assert.Equal(t, 403, status)
+	status, _ = communityRequest(app, "PUT", fmt.Sprintf("/news/%d", memberNews.ID), memberToken, map[string]interface{}{"content": "Исправлено"})
+	assert.Equal(t, 403, status)
+	db.First(&memberComment, memberComment.ID)
+	assert.Equal(t, "Комментарий участника", memberComment.Content)
+
+	// Ограничение действует и на редакторов
+	status, _ = communityRequest(app, "POST", path, adminToken, map[string]interface{}{"user_id": moderatorID, "duration_minutes": 30})
+	assert.Equal(t, 201, status)
+	status, _ = communityRequest(app, "POST", fmt.Sprintf("/communities/%d/news", community.ID), moderatorToken, map[string]interface{}{"content": "Новость от модератора"})
+	assert.Equal(t, 403, status)
+
+	// По истечении срока ограничение снимается
+	db.Model(&models.CommunitySanction{}).Where("user_id = ?", memberID).Update("expires_at", time.Now().Add(-time.Minute))
+	status, _ = communityRequest(app, "POST", fmt.Sprintf("/news/%d/comments", news.ID), memberToken, map[string]interface{}{"content": "Привет"})
+	assert.Equal(t, 201, status)
+	status, _ = communityRequest(app, "PUT", fmt.Sprintf("/comments/%d", memberComment.ID), memberToken, map[string]interface{}{"content": "Исправлено"})
+	assert.Equal(t, 200, status)
+
+	// Досрочное снятие записывается в журнал
+	status, _ = communityRequest(app, "DELETE", fmt.Sprintf("/communities/%d/mutes/%d", community.ID, moderatorID), adminToken, nil)
+	assert.Equal(t, 200, status)
+	status, result = communityRequest(app, "GET", fmt.Sprintf("/communities/%d/moderation/log?action=unmute", community.ID), adminToken, nil)
+	assert.Equal(t, 200, status)
+	if entries := result["data"].([]interface{}); assert.Len(t, entries, 1) {
+		assert.Equal(t, float64(moderatorID), entries[0].(map[string]interface{})["target_user_id"])
+	}
+}
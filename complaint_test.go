@@ -17,6 +17,9 @@ import (
 	"gorm.io/gorm"
 )
 
+// complaintTestEventID ID ивента, созданного в setupComplaintTestDB
+var complaintTestEventID uint = 1
+
 // setupComplaintTestDB создает тестовую базу данных в памяти для тестов жалоб
 func setupComplaintTestDB() *gorm.DB {
 	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
@@ -36,7 +39,10 @@ func setupComplaintTestDB() *gorm.DB {
 		&models.EventPhotoPost{},
 		&models.Rating{},
 		&models.UserRatingSummary{},
+		&models.UserRatingBucket{},
 		&models.Complaint{},
+		&models.UserRole{},
+		&models.AuditLog{},
 	)
 
 	// Создаем тестовых пользователей
@@ -64,6 +70,9 @@ func setupComplaintTestDB() *gorm.DB {
 		db.Create(&user)
 	}
 
+	// Назначаем первого пользователя модератором
+	db.Create(&models.UserRole{UserID: 1, Role: models.RoleModerator})
+
 	// Создаем тестовый ивент
 	event := models.Event{
 		CreatorID:       1,
@@ -262,7 +271,7 @@ func TestGetComplaints(t *testing.T) {
 	// Создаем тестовые жалобы
 	complaints := []models.Complaint{
 		{
-			EventID:     1,
+			EventID:     &complaintTestEventID,
 			FromUserID:  2,
 			AboutUserID: 3,
 			ReasonCode:  "inappropriate_behavior",
@@ -270,7 +279,7 @@ func TestGetComplaints(t *testing.T) {
 			Status:      "open",
 		},
 		{
-			EventID:     1,
+			EventID:     &complaintTestEventID,
 			FromUserID:  3,
 			AboutUserID: 2,
 			ReasonCode:  "no_show",
@@ -311,6 +320,28 @@ func TestGetComplaints(t *testing.T) {
 		assert.NoError(t, err)
 		assert.Equal(t, 200, resp.StatusCode)
 	})
+
+	// Тест получения жалоб пользователем без роли
+	t.Run("Get complaints without role", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/complaints", nil)
+		req.Header.Set("Authorization", "Bearer "+generateTestJWT(2))
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, 403, resp.StatusCode)
+	})
+
+	// Тест получения жалоб поддержкой
+	t.Run("Get complaints as support", func(t *testing.T) {
+		db.Create(&models.UserRole{UserID: 3, Role: models.RoleSupport})
+
+		req := httptest.NewRequest("GET", "/complaints", nil)
+		req.Header.Set("Authorization", "Bearer "+generateTestJWT(3))
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, 200, resp.StatusCode)
+	})
 }
 
 func TestUpdateComplaintStatus(t *testing.T) {
@@ -319,7 +350,7 @@ func TestUpdateComplaintStatus(t *testing.T) {
 
 	// Создаем тестовую жалобу
 	complaint := models.Complaint{
-		EventID:     1,
+		EventID:     &complaintTestEventID,
 		FromUserID:  2,
 		AboutUserID: 3,
 		ReasonCode:  "inappropriate_behavior",
@@ -348,6 +379,14 @@ func TestUpdateComplaintStatus(t *testing.T) {
 		err = db.Where("id = ?", 1).First(&updatedComplaint).Error
 		assert.NoError(t, err)
 		assert.Equal(t, "under_review", updatedComplaint.Status)
+
+		// Изменение записано в журнал аудита
+		var entry models.AuditLog
+		err = db.Where("action = ? AND target_id = ?", models.AuditActionComplaintStatusUpdate, 1).First(&entry).Error
+		assert.NoError(t, err)
+		assert.Equal(t, uint(1), entry.ActorID)
+		assert.Equal(t, "open", entry.Changes["status"].From)
+		assert.Equal(t, "under_review", entry.Changes["status"].To)
 	})
 
 	// Тест обновления статуса несуществующей жалобы
@@ -381,6 +420,24 @@ func TestUpdateComplaintStatus(t *testing.T) {
 		assert.NoError(t, err)
 		assert.Equal(t, 400, resp.StatusCode)
 	})
+
+	// Тест обновления статуса пользователем без прав модерации
+	t.Run("Update complaint status without permission", func(t *testing.T) {
+		db.Create(&models.UserRole{UserID: 3, Role: models.RoleSupport})
+
+		statusData := map[string]interface{}{
+			"status": "resolved",
+		}
+
+		jsonData, _ := json.Marshal(statusData)
+		req := httptest.NewRequest("PUT", "/complaints/1/status", bytes.NewBuffer(jsonData))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+generateTestJWT(3))
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, 403, resp.StatusCode)
+	})
 }
 
 func TestGetComplaintReasons(t *testing.T) {
@@ -404,7 +461,7 @@ func TestGetUserComplaints(t *testing.T) {
 	// Создаем тестовые жалобы пользователя
 	complaints := []models.Complaint{
 		{
-			EventID:     1,
+			EventID:     &complaintTestEventID,
 			FromUserID:  2,
 			AboutUserID: 3,
 			ReasonCode:  "inappropriate_behavior",
@@ -412,7 +469,7 @@ func TestGetUserComplaints(t *testing.T) {
 			Status:      "open",
 		},
 		{
-			EventID:     1,
+			EventID:     &complaintTestEventID,
 			FromUserID:  2,
 			AboutUserID: 3,
 			ReasonCode:  "no_show",
@@ -451,7 +508,7 @@ func TestDuplicateComplaint(t *testing.T) {
 
 	// Создаем существующую жалобу
 	existingComplaint := models.Complaint{
-		EventID:     1,
+		EventID:     &complaintTestEventID,
 		FromUserID:  2,
 		AboutUserID: 3,
 		ReasonCode:  "inappropriate_behavior",
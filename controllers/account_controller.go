@@ -0,0 +1,150 @@
+package controllers
+
+import (
+	"fmt"
+	"time"
+
+	"toloko-backend/models"
+	"toloko-backend/services"
+	"toloko-backend/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// AccountController обрабатывает HTTP запросы экспорта данных и удаления аккаунта
+type AccountController struct {
+	db             *gorm.DB
+	accountService *services.AccountService
+}
+
+// NewAccountController создает новый контроллер аккаунта
+func NewAccountController(db *gorm.DB) *AccountController {
+	return &AccountController{
+		db:             db,
+		accountService: services.NewAccountService(db),
+	}
+}
+
+// AccountDeletionRequest структура запроса на удаление аккаунта
+type AccountDeletionRequest struct {
+	Password string `json:"password"`
+}
+
+// ExportData возвращает ZIP архив с персональными данными пользователя
+func (c *AccountController) ExportData(ctx *fiber.Ctx) error {
+	userID := ctx.Locals("user_id").(uint)
+
+	data, err := c.accountService.BuildExport(userID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return ctx.Status(404).JSON(fiber.Map{
+				"error": "User not found",
+			})
+		}
+		return ctx.Status(500).JSON(fiber.Map{
+			"error": "Failed to export data",
+		})
+	}
+
+	fileName := fmt.Sprintf("toloka-export-%d-%s.zip", userID, time.Now().Format("20060102"))
+	ctx.Set(fiber.HeaderContentType, "application/zip")
+	ctx.Set(fiber.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", fileName))
+	return ctx.Send(data)
+}
+
+// GetDeletionStatus возвращает активный запрос на удаление аккаунта
+func (c *AccountController) GetDeletionStatus(ctx *fiber.Ctx) error {
+	userID := ctx.Locals("user_id").(uint)
+
+	request, err := c.accountService.GetDeletionRequest(userID)
+	if err != nil {
+		return ctx.Status(500).JSON(fiber.Map{
+			"error": "Failed to get deletion status",
+		})
+	}
+
+	return ctx.JSON(fiber.Map{
+		"success":  true,
+		"pending":  request != nil,
+		"deletion": request,
+	})
+}
+
+// RequestDeletion планирует удаление аккаунта по окончании льготного периода
+func (c *AccountController) RequestDeletion(ctx *fiber.Ctx) error {
+	userID := ctx.Locals("user_id").(uint)
+
+	var req AccountDeletionRequest
+	if err := ctx.BodyParser(&req); err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	var user models.User
+	if err := c.db.First(&user, userID).Error; err != nil || !user.IsActive {
+		return ctx.Status(404).JSON(fiber.Map{
+			"error": "User not found",
+		})
+	}
+
+	// Аккаунты с паролем подтверждают удаление паролем
+	if user.OAuthProvider == "" && !utils.CheckPasswordHash(req.Password, user.PasswordHash) {
+		return ctx.Status(401).JSON(fiber.Map{
+			"error": "Invalid password",
+		})
+	}
+
+	request, err := c.accountService.RequestDeletion(userID)
+	if err != nil {
+		switch err {
+		case services.ErrAccountDeletionPending:
+			return ctx.Status(409).JSON(fiber.Map{
+				"error": "Account deletion already requested",
+			})
+		case services.ErrAccountOwnsCommunities:
+			return ctx.Status(409).JSON(fiber.Map{
+				"error": "Transfer ownership of your communities before deleting the account",
+			})
+		case services.ErrLastCommunityAdmin:
+			return ctx.Status(409).JSON(fiber.Map{
+				"error": "Appoint another admin in communities where you are the only admin before deleting the account",
+			})
+		case services.ErrLastAdmin:
+			return ctx.Status(409).JSON(fiber.Map{
+				"error": "The last platform admin cannot delete the account",
+			})
+		}
+		return ctx.Status(500).JSON(fiber.Map{
+			"error": "Failed to request account deletion",
+		})
+	}
+
+	return ctx.Status(202).JSON(fiber.Map{
+		"success":  true,
+		"message":  "Account will be deleted after the grace period unless the request is cancelled",
+		"deletion": request,
+	})
+}
+
+// CancelDeletion отменяет запрос на удаление аккаунта
+func (c *AccountController) CancelDeletion(ctx *fiber.Ctx) error {
+	userID := ctx.Locals("user_id").(uint)
+
+	if err := c.accountService.CancelDeletion(userID); err != nil {
+		if err == services.ErrAccountDeletionNotFound {
+			return ctx.Status(404).JSON(fiber.Map{
+				"error": "No pending deletion request",
+			})
+		}
+		return ctx.Status(500).JSON(fiber.Map{
+			"error": "Failed to cancel account deletion",
+		})
+	}
+
+	return ctx.JSON(fiber.Map{
+		"success": true,
+		"message": "Account deletion cancelled",
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"strconv"
 
 	"toloko-backend/models"
+	"toloko-backend/services"
 	"toloko-backend/utils"
 
 	"github.com/gofiber/fiber/v2"
@@ -12,12 +13,18 @@ import (
 
 // AchievementController контроллер для управления достижениями
 type AchievementController struct {
-	db *gorm.DB
+	db           *gorm.DB
+	permissions  *services.PermissionService
+	achievements *services.AchievementService
 }
 
 // NewAchievementController создает новый экземпляр AchievementController
 func NewAchievementController(db *gorm.DB) *AchievementController {
-	return &AchievementController{db: db}
+	return &AchievementController{
+		db:           db,
+		permissions:  services.NewPermissionService(db),
+		achievements: services.NewAchievementService(db),
+	}
 }
 
 // GetUserAchievements получает достижения пользователя
@@ -48,16 +55,16 @@ func (ac *AchievementController) GetUserAchievements(c *fiber.Ctx) error {
 	}
 
 	// Проверяем, что пользователь запрашивает свои достижения или имеет права
-	if claims.UserID != uint(userID) {
+	if claims.UserID != uint(userID) && !ac.hasPermission(claims.UserID, models.PermissionUsersView) {
 		return c.Status(403).JSON(fiber.Map{
 			"error":   true,
 			"message": "Можно просматривать только свои достижения",
 		})
 	}
 
-	// Получаем достижения пользователя
-	var userAchievements []models.UserAchievement
-	if err := ac.db.Preload("Achievement").Where("user_id = ?", userID).Order("earned_at DESC").Find(&userAchievements).Error; err != nil {
+	// Прогресс считается по тем же счетчикам, по которым выдаются достижения
+	progress, err := ac.achievements.Progress(uint(userID))
+	if err != nil {
 		return c.Status(500).JSON(fiber.Map{
 			"error":   true,
 			"message": "Ошибка при получении достижений",
@@ -68,41 +75,67 @@ func (ac *AchievementController) GetUserAchievements(c *fiber.Ctx) error {
 	var userLevel models.UserLevel
 	ac.db.Where("user_id = ?", userID).First(&userLevel)
 
-	// Получаем все доступные достижения для отображения прогресса
-	var allAchievements []models.Achievement
-	ac.db.Where("is_active = ?", true).Find(&allAchievements)
+	return c.JSON(fiber.Map{
+		"achievements": progress,
+		"categories":   services.GroupProgressByCategory(progress),
+		"user_level":   userLevel,
+		"error":        false,
+		"message":      "Достижения получены успешно",
+	})
+}
 
-	// Создаем карту полученных достижений
-	earnedMap := make(map[uint]bool)
-	for _, ua := range userAchievements {
-		earnedMap[ua.AchievementID] = true
+// GetClosestAchievements получает еще не полученные достижения, до которых пользователю осталось меньше всего (?limit=)
+func (ac *AchievementController) GetClosestAchievements(c *fiber.Ctx) error {
+	userID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error":   true,
+			"message": "Неверный ID пользователя",
+		})
 	}
 
-	// Формируем ответ с прогрессом
-	var achievementsWithProgress []fiber.Map
-	for _, achievement := range allAchievements {
-		achievementsWithProgress = append(achievementsWithProgress, fiber.Map{
-			"achievement": achievement,
-			"earned":      earnedMap[achievement.ID],
-			"earned_at":   nil,
+	// Проверяем авторизацию
+	token := c.Get("Authorization")
+	if token == "" {
+		return c.Status(401).JSON(fiber.Map{
+			"error":   true,
+			"message": "Необходима авторизация",
 		})
 	}
 
-	// Добавляем дату получения для заработанных достижений
-	for _, ua := range userAchievements {
-		for i, awp := range achievementsWithProgress {
-			if awp["achievement"].(models.Achievement).ID == ua.AchievementID {
-				achievementsWithProgress[i]["earned_at"] = ua.EarnedAt
-				break
-			}
-		}
+	claims, err := utils.ValidateJWT(token)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{
+			"error":   true,
+			"message": "Неверный токен авторизации",
+		})
+	}
+
+	// Проверяем, что пользователь запрашивает свои достижения или имеет права
+	if claims.UserID != uint(userID) && !ac.hasPermission(claims.UserID, models.PermissionUsersView) {
+		return c.Status(403).JSON(fiber.Map{
+			"error":   true,
+			"message": "Можно просматривать только свои достижения",
+		})
+	}
+
+	limit := c.QueryInt("limit", 3)
+	if limit < 1 || limit > 20 {
+		limit = 3
+	}
+
+	closest, err := ac.achievements.Closest(uint(userID), limit)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error":   true,
+			"message": "Ошибка при получении достижений",
+		})
 	}
 
 	return c.JSON(fiber.Map{
-		"achievements": achievementsWithProgress,
-		"user_level":   userLevel,
+		"achievements": closest,
 		"error":        false,
-		"message":      "Достижения получены успешно",
+		"message":      "Ближайшие достижения получены успешно",
 	})
 }
 
@@ -150,7 +183,7 @@ func (ac *AchievementController) AwardAchievement(c *fiber.Ctx) error {
 		})
 	}
 
-	_, err = utils.ValidateJWT(token)
+	claims, err := utils.ValidateJWT(token)
 	if err != nil {
 		return c.Status(401).JSON(fiber.Map{
 			"error":   true,
@@ -158,6 +191,13 @@ func (ac *AchievementController) AwardAchievement(c *fiber.Ctx) error {
 		})
 	}
 
+	if !ac.hasPermission(claims.UserID, models.PermissionAchievementsAward) {
+		return c.Status(403).JSON(fiber.Map{
+			"error":   true,
+			"message": "Недостаточно прав для награждения достижениями",
+		})
+	}
+
 	// Проверяем, что пользователь существует
 	var user models.User
 	if err := ac.db.First(&user, userID).Error; err != nil {
@@ -188,30 +228,22 @@ func (ac *AchievementController) AwardAchievement(c *fiber.Ctx) error {
 		})
 	}
 
-	// Проверяем, не получено ли уже это достижение
-	var existingUserAchievement models.UserAchievement
-	if err := ac.db.Where("user_id = ? AND achievement_id = ?", userID, achievementID).First(&existingUserAchievement).Error; err == nil {
-		return c.Status(400).JSON(fiber.Map{
-			"error":   true,
-			"message": "Достижение уже получено",
-		})
-	}
-
-	// Создаем запись о получении достижения
-	userAchievement := models.UserAchievement{
-		UserID:        uint(userID),
-		AchievementID: uint(achievementID),
-	}
-
-	if err := ac.db.Create(&userAchievement).Error; err != nil {
+	// Выдаем достижение (повторно получить его нельзя) и начисляем очки
+	if err := ac.achievements.Award(uint(userID), &achievement); err != nil {
+		if err == services.ErrAchievementAlreadyEarned {
+			return c.Status(400).JSON(fiber.Map{
+				"error":   true,
+				"message": "Достижение уже получено",
+			})
+		}
 		return c.Status(500).JSON(fiber.Map{
 			"error":   true,
 			"message": "Ошибка при награждении достижением",
 		})
 	}
 
-	// Обновляем уровень пользователя
-	ac.updateUserLevel(uint(userID), achievement.Points)
+	var userAchievement models.UserAchievement
+	ac.db.Where("user_id = ? AND achievement_id = ?", userID, achievementID).First(&userAchievement)
 
 	return c.JSON(fiber.Map{
 		"user_achievement": userAchievement,
@@ -220,25 +252,8 @@ func (ac *AchievementController) AwardAchievement(c *fiber.Ctx) error {
 	})
 }
 
-// updateUserLevel обновляет уровень пользователя
-func (ac *AchievementController) updateUserLevel(userID uint, points int) {
-	var userLevel models.UserLevel
-	if err := ac.db.Where("user_id = ?", userID).First(&userLevel).Error; err != nil {
-		// Создаем новый уровень, если его нет
-		userLevel = models.UserLevel{
-			UserID: userID,
-			Level:  1,
-			Points: points,
-		}
-		ac.db.Create(&userLevel)
-	} else {
-		// Обновляем существующий уровень
-		userLevel.Points += points
-		// Проверяем, нужно ли повысить уровень (каждые 100 очков = новый уровень)
-		newLevel := (userLevel.Points / 100) + 1
-		if newLevel > userLevel.Level {
-			userLevel.Level = newLevel
-		}
-		ac.db.Save(&userLevel)
-	}
+// hasPermission проверяет, есть ли у пользователя разрешение платформы
+func (ac *AchievementController) hasPermission(userID uint, permission string) bool {
+	allowed, err := ac.permissions.HasPermission(userID, permission)
+	return err == nil && allowed
 }
@@ -0,0 +1,139 @@
+package controllers
+
+import (
+	"strconv"
+	"time"
+
+	"toloko-backend/services"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// AuditController обрабатывает HTTP запросы к журналу аудита
+type AuditController struct {
+	db           *gorm.DB
+	auditService *services.AuditService
+	Permissions  *services.PermissionService
+}
+
+// NewAuditController создает новый контроллер журнала аудита
+func NewAuditController(db *gorm.DB) *AuditController {
+	return &AuditController{
+		db:           db,
+		auditService: services.NewAuditService(db),
+		Permissions:  services.NewPermissionService(db),
+	}
+}
+
+// GetAuditLogs возвращает записи журнала аудита с фильтрами и пагинацией.
+// Фильтры: actor_id, action, target_type, target_id, from, to (RFC3339).
+func (c *AuditController) GetAuditLogs(ctx *fiber.Ctx) error {
+	filter := services.AuditFilter{
+		Action:     ctx.Query("action"),
+		TargetType: ctx.Query("target_type"),
+	}
+
+	if value := ctx.Query("actor_id"); value != "" {
+		actorID, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			return ctx.Status(400).JSON(fiber.Map{
+				"error": "Invalid actor_id",
+			})
+		}
+		filter.ActorID = uint(actorID)
+	}
+
+	if value := ctx.Query("target_id"); value != "" {
+		targetID, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			return ctx.Status(400).JSON(fiber.Map{
+				"error": "Invalid target_id",
+			})
+		}
+		filter.TargetID = uint(targetID)
+	}
+
+	from, err := parseTimeQuery(ctx, "from")
+	if err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Invalid from time, expected RFC3339",
+		})
+	}
+	filter.From = from
+
+	to, err := parseTimeQuery(ctx, "to")
+	if err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Invalid to time, expected RFC3339",
+		})
+	}
+	filter.To = to
+
+	page := ctx.QueryInt("page", 1)
+	if page < 1 {
+		page = 1
+	}
+	limit := ctx.QueryInt("limit", 20)
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	logs, total, err := c.auditService.List(filter, page, limit)
+	if err != nil {
+		return ctx.Status(500).JSON(fiber.Map{
+			"error": "Failed to get audit log",
+		})
+	}
+
+	return ctx.JSON(fiber.Map{
+		"success": true,
+		"logs":    logs,
+		"pagination": fiber.Map{
+			"page":        page,
+			"limit":       limit,
+			"total_count": total,
+			"total_pages": (total + int64(limit) - 1) / int64(limit),
+		},
+	})
+}
+
+// GetAuditLog возвращает запись журнала аудита по ID
+func (c *AuditController) GetAuditLog(ctx *fiber.Ctx) error {
+	id, err := strconv.ParseUint(ctx.Params("id"), 10, 32)
+	if err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Invalid audit log ID",
+		})
+	}
+
+	entry, err := c.auditService.Get(uint(id))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return ctx.Status(404).JSON(fiber.Map{
+				"error": "Audit log entry not found",
+			})
+		}
+		return ctx.Status(500).JSON(fiber.Map{
+			"error": "Failed to get audit log entry",
+		})
+	}
+
+	return ctx.JSON(fiber.Map{
+		"success": true,
+		"log":     entry,
+	})
+}
+
+// parseTimeQuery разбирает необязательный параметр запроса в формате RFC3339
+func parseTimeQuery(ctx *fiber.Ctx, param string) (*time.Time, error) {
+	value := ctx.Query(param)
+	if value == "" {
+		return nil, nil
+	}
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, err
+	}
+	return &parsed, nil
+}
@@ -1,10 +1,14 @@
 package controllers
 
 import (
+	"log"
 	"regexp"
+	"strconv"
 	"strings"
+	"time"
 
 	"toloko-backend/models"
+	"toloko-backend/services"
 	"toloko-backend/utils"
 
 	"github.com/gofiber/fiber/v2"
@@ -13,12 +17,20 @@ import (
 
 // AuthController контроллер для аутентификации
 type AuthController struct {
-	DB *gorm.DB
+	DB           *gorm.DB
+	LoginGuard   *services.LoginGuard
+	TwoFactor    *services.TwoFactorService
+	Achievements *services.AchievementService
 }
 
 // NewAuthController создает новый экземпляр AuthController
 func NewAuthController(db *gorm.DB) *AuthController {
-	return &AuthController{DB: db}
+	return &AuthController{
+		DB:           db,
+		LoginGuard:   services.NewLoginGuard(services.NewRateLimitStore(db)),
+		TwoFactor:    services.NewTwoFactorService(db),
+		Achievements: services.NewAchievementService(db),
+	}
 }
 
 // RegisterRequest структура запроса регистрации
@@ -30,6 +42,12 @@ type RegisterRequest struct {
 	AcceptTerms     bool   `json:"accept_terms" validate:"required"`
 }
 
+// TwoFactorLoginRequest структура запроса второго шага входа
+type TwoFactorLoginRequest struct {
+	ChallengeToken string `json:"challenge_token" validate:"required"`
+	Code           string `json:"code" validate:"required"` // Код TOTP или код восстановления
+}
+
 // LoginRequest структура запроса входа
 type LoginRequest struct {
 	Email    string `json:"email" validate:"required,email"`
@@ -52,10 +70,13 @@ type OAuthRequest struct {
 
 // AuthResponse структура ответа аутентификации
 type AuthResponse struct {
-	Success bool   `json:"success"`
-	Message string `json:"message"`
-	Token   string `json:"token,omitempty"`
-	User    struct {
+	Success                bool   `json:"success"`
+	Message                string `json:"message"`
+	Token                  string `json:"token,omitempty"`
+	RequiresTwoFactor      bool   `json:"requires_two_factor,omitempty"`       // Требуется ввод кода 2FA
+	ChallengeToken         string `json:"challenge_token,omitempty"`           // Токен для второго шага входа
+	TwoFactorSetupRequired bool   `json:"two_factor_setup_required,omitempty"` // Роли пользователя требуют включить 2FA
+	User                   struct {
 		ID    uint   `json:"id"`
 		Name  string `json:"name"`
 		Email string `json:"email"`
@@ -115,6 +136,9 @@ func (ac *AuthController) Register(c *fiber.Ctx) error {
 		})
 	}
 
+	// Начисляем достижение за регистрацию
+	ac.Achievements.Track(user.ID, models.AchievementEventRegistered)
+
 	// Генерируем JWT токен
 	token, err := utils.GenerateJWT(user.ID, user.Email)
 	if err != nil {
@@ -160,21 +184,29 @@ func (ac *AuthController) Login(c *fiber.Ctx) error {
 		})
 	}
 
+	// Проверяем, не заблокирован ли вход после неудачных попыток
+	lockedFor, err := ac.LoginGuard.Check(req.Email, c.IP())
+	if err != nil {
+		log.Printf("Ошибка проверки блокировки входа: %v", err)
+	}
+	if lockedFor > 0 {
+		return ac.respondLoginLocked(c, lockedFor)
+	}
+
 	// Ищем пользователя
 	var user models.User
 	if err := ac.DB.Where("email = ?", strings.ToLower(req.Email)).First(&user).Error; err != nil {
-		return c.Status(401).JSON(AuthResponse{
-			Success: false,
-			Message: "Неверный email или пароль",
-		})
+		return ac.respondLoginFailed(c, req.Email)
 	}
 
 	// Проверяем пароль
 	if !utils.CheckPasswordHash(req.Password, user.PasswordHash) {
-		return c.Status(401).JSON(AuthResponse{
-			Success: false,
-			Message: "Неверный email или пароль",
-		})
+		return ac.respondLoginFailed(c, req.Email)
+	}
+
+	// Сбрасываем счетчик неудачных попыток
+	if err := ac.LoginGuard.Reset(req.Email, c.IP()); err != nil {
+		log.Printf("Ошибка сброса счетчика попыток входа: %v", err)
 	}
 
 	// Проверяем активность пользователя
@@ -185,29 +217,57 @@ func (ac *AuthController) Login(c *fiber.Ctx) error {
 		})
 	}
 
-	// Генерируем JWT токен
-	token, err := utils.GenerateJWT(user.ID, user.Email)
+	return ac.respondWithLogin(c, &user, "Успешный вход в систему")
+}
+
+// VerifyTwoFactor обрабатывает второй шаг входа с кодом 2FA
+func (ac *AuthController) VerifyTwoFactor(c *fiber.Ctx) error {
+	var req TwoFactorLoginRequest
+
+	// Парсим JSON
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(AuthResponse{
+			Success: false,
+			Message: "Неверный формат данных",
+		})
+	}
+
+	if req.ChallengeToken == "" || req.Code == "" {
+		return c.Status(400).JSON(AuthResponse{
+			Success: false,
+			Message: "Токен входа и код обязательны",
+		})
+	}
+
+	userID, err := ac.TwoFactor.CompleteChallenge(req.ChallengeToken, req.Code)
 	if err != nil {
+		switch err {
+		case services.ErrInvalidTwoFactorCode:
+			return c.Status(401).JSON(AuthResponse{
+				Success: false,
+				Message: "Неверный код",
+			})
+		case services.ErrInvalidTwoFactorChallenge:
+			return c.Status(401).JSON(AuthResponse{
+				Success: false,
+				Message: "Срок действия входа истек, войдите заново",
+			})
+		}
 		return c.Status(500).JSON(AuthResponse{
 			Success: false,
-			Message: "Ошибка при создании токена",
+			Message: "Ошибка при проверке кода",
 		})
 	}
 
-	return c.JSON(AuthResponse{
-		Success: true,
-		Message: "Успешный вход в систему",
-		Token:   token,
-		User: struct {
-			ID    uint   `json:"id"`
-			Name  string `json:"name"`
-			Email string `json:"email"`
-		}{
-			ID:    user.ID,
-			Name:  user.Name,
-			Email: user.Email,
-		},
-	})
+	var user models.User
+	if err := ac.DB.First(&user, userID).Error; err != nil || !user.IsActive {
+		return c.Status(401).JSON(AuthResponse{
+			Success: false,
+			Message: "Аккаунт заблокирован",
+		})
+	}
+
+	return ac.respondWithToken(c, &user, "Успешный вход в систему")
 }
 
 // Recover обрабатывает запрос на восстановление пароля
@@ -270,7 +330,7 @@ func (ac *AuthController) OAuth(c *fiber.Ctx) error {
 
 	// Ищем пользователя по OAuth ID
 	var user models.User
-	err := ac.DB.Where("oauth_provider = ? AND oauth_id = ?", req.Provider, req.OAuthID).First(&user).Error
+	err := ac.DB.Where(&models.User{OAuthProvider: req.Provider, OAuthID: req.OAuthID}).First(&user).Error
 
 	if err != nil {
 		// Пользователь не найден, создаем нового
@@ -288,9 +348,48 @@ func (ac *AuthController) OAuth(c *fiber.Ctx) error {
 				Message: "Ошибка при создании пользователя",
 			})
 		}
+
+		// Начисляем достижение за регистрацию
+		ac.Achievements.Track(user.ID, models.AchievementEventRegistered)
 	}
 
-	// Генерируем JWT токен
+	// Вход через провайдера проходит ту же проверку 2FA, что и вход по паролю
+	return ac.respondWithLogin(c, &user, "Успешная авторизация через "+req.Provider)
+}
+
+// respondWithLogin завершает первый шаг входа: при включенной 2FA выдает
+// токен запроса кода, иначе сразу JWT токен
+func (ac *AuthController) respondWithLogin(c *fiber.Ctx, user *models.User, message string) error {
+	// Если включена 2FA, JWT выдается только после ввода кода
+	twoFactorEnabled, err := ac.TwoFactor.IsEnabled(user.ID)
+	if err != nil {
+		return c.Status(500).JSON(AuthResponse{
+			Success: false,
+			Message: "Ошибка при проверке двухфакторной аутентификации",
+		})
+	}
+	if twoFactorEnabled {
+		challengeToken, err := ac.TwoFactor.CreateChallenge(user.ID)
+		if err != nil {
+			return c.Status(500).JSON(AuthResponse{
+				Success: false,
+				Message: "Ошибка при создании запроса кода",
+			})
+		}
+
+		return c.JSON(AuthResponse{
+			Success:           true,
+			Message:           "Введите код двухфакторной аутентификации",
+			RequiresTwoFactor: true,
+			ChallengeToken:    challengeToken,
+		})
+	}
+
+	return ac.respondWithToken(c, user, message)
+}
+
+// respondWithToken выдает JWT токен пользователю
+func (ac *AuthController) respondWithToken(c *fiber.Ctx, user *models.User, message string) error {
 	token, err := utils.GenerateJWT(user.ID, user.Email)
 	if err != nil {
 		return c.Status(500).JSON(AuthResponse{
@@ -299,10 +398,21 @@ func (ac *AuthController) OAuth(c *fiber.Ctx) error {
 		})
 	}
 
+	// Сообщаем клиенту, если роли пользователя требуют включить 2FA
+	setupRequired, err := ac.TwoFactor.IsRequired(user.ID)
+	if err != nil {
+		log.Printf("Ошибка проверки обязательности 2FA: %v", err)
+	}
+	if setupRequired {
+		enabled, err := ac.TwoFactor.IsEnabled(user.ID)
+		setupRequired = err == nil && !enabled
+	}
+
 	return c.JSON(AuthResponse{
-		Success: true,
-		Message: "Успешная авторизация через " + req.Provider,
-		Token:   token,
+		Success:                true,
+		Message:                message,
+		Token:                  token,
+		TwoFactorSetupRequired: setupRequired,
 		User: struct {
 			ID    uint   `json:"id"`
 			Name  string `json:"name"`
@@ -315,6 +425,32 @@ func (ac *AuthController) OAuth(c *fiber.Ctx) error {
 	})
 }
 
+// respondLoginFailed учитывает неудачную попытку входа и формирует ответ
+func (ac *AuthController) respondLoginFailed(c *fiber.Ctx, email string) error {
+	lockout, err := ac.LoginGuard.RegisterFailure(email, c.IP())
+	if err != nil {
+		log.Printf("Ошибка учета неудачной попытки входа: %v", err)
+	}
+	if lockout > 0 {
+		return ac.respondLoginLocked(c, lockout)
+	}
+
+	return c.Status(401).JSON(AuthResponse{
+		Success: false,
+		Message: "Неверный email или пароль",
+	})
+}
+
+// respondLoginLocked формирует ответ о временной блокировке входа
+func (ac *AuthController) respondLoginLocked(c *fiber.Ctx, lockedFor time.Duration) error {
+	seconds := int((lockedFor + time.Second - 1) / time.Second)
+	c.Set(fiber.HeaderRetryAfter, strconv.Itoa(seconds))
+	return c.Status(fiber.StatusTooManyRequests).JSON(AuthResponse{
+		Success: false,
+		Message: "Слишком много неудачных попыток входа. Повторите через " + strconv.Itoa(seconds) + " сек.",
+	})
+}
+
 // Вспомогательные методы валидации
 
 func (ac *AuthController) validateRegisterRequest(req *RegisterRequest) error {
@@ -0,0 +1,185 @@
+package controllers
+
+import (
+	"errors"
+	"strconv"
+
+	"toloko-backend/models"
+	"toloko-backend/services"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// CertificateController обрабатывает HTTP запросы для волонтерских часов и сертификатов
+type CertificateController struct {
+	db          *gorm.DB
+	hours       *services.HoursService
+	permissions *services.PermissionService
+}
+
+// NewCertificateController создает новый контроллер сертификатов
+func NewCertificateController(db *gorm.DB) *CertificateController {
+	return &CertificateController{
+		db:          db,
+		hours:       services.NewHoursService(db),
+		permissions: services.NewPermissionService(db),
+	}
+}
+
+// IssueCertificateRequest структура запроса выдачи сертификата
+type IssueCertificateRequest struct {
+	Year *int `json:"year"` // пусто - за все время
+}
+
+// GetHoursSummary возвращает подтвержденные волонтерские часы пользователя по годам и типам ивентов (?year=)
+func (c *CertificateController) GetHoursSummary(ctx *fiber.Ctx) error {
+	userID := ctx.Locals("user_id").(uint)
+	targetID, err := strconv.ParseUint(ctx.Params("id"), 10, 32)
+	if err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	// Часы видит сам пользователь и администраторы
+	if uint(targetID) != userID {
+		allowed, err := c.permissions.HasPermission(userID, models.PermissionPointsManage)
+		if err != nil || !allowed {
+			return ctx.Status(403).JSON(fiber.Map{
+				"error": "You can only view your own volunteer hours",
+			})
+		}
+	}
+
+	year, err := yearQuery(ctx)
+	if err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Invalid year",
+		})
+	}
+
+	summary, err := c.hours.Summary(uint(targetID), year)
+	if err != nil {
+		return ctx.Status(500).JSON(fiber.Map{
+			"error": "Failed to get volunteer hours",
+		})
+	}
+
+	return ctx.JSON(fiber.Map{
+		"success": true,
+		"summary": summary,
+	})
+}
+
+// IssueCertificate выдает текущему пользователю сертификат на подтвержденные часы за год или за все время
+func (c *CertificateController) IssueCertificate(ctx *fiber.Ctx) error {
+	userID := ctx.Locals("user_id").(uint)
+
+	var req IssueCertificateRequest
+	if len(ctx.Body()) > 0 {
+		if err := ctx.BodyParser(&req); err != nil {
+			return ctx.Status(400).JSON(fiber.Map{
+				"error": "Invalid request body",
+			})
+		}
+	}
+
+	certificate, err := c.hours.IssueCertificate(userID, req.Year)
+	if err != nil {
+		if errors.Is(err, services.ErrNoConfirmedHours) {
+			return ctx.Status(400).JSON(fiber.Map{
+				"error": "No confirmed volunteer hours for this period",
+			})
+		}
+		return ctx.Status(500).JSON(fiber.Map{
+			"error": "Failed to issue certificate",
+		})
+	}
+
+	return ctx.Status(201).JSON(fiber.Map{
+		"success":      true,
+		"certificate":  certificate,
+		"verify_url":   c.verifyURL(ctx, certificate.Code),
+		"download_url": ctx.BaseURL() + "/api/certificates/" + certificate.Code + "/download",
+	})
+}
+
+// GetCertificates возвращает сертификаты текущего пользователя
+func (c *CertificateController) GetCertificates(ctx *fiber.Ctx) error {
+	userID := ctx.Locals("user_id").(uint)
+
+	certificates, err := c.hours.Certificates(userID)
+	if err != nil {
+		return ctx.Status(500).JSON(fiber.Map{
+			"error": "Failed to get certificates",
+		})
+	}
+
+	return ctx.JSON(fiber.Map{
+		"success":      true,
+		"certificates": certificates,
+	})
+}
+
+// DownloadCertificate отдает готовый к печати HTML сертификата его владельцу
+func (c *CertificateController) DownloadCertificate(ctx *fiber.Ctx) error {
+	userID := ctx.Locals("user_id").(uint)
+
+	certificate, err := c.hours.Verify(ctx.Params("code"))
+	if err != nil || certificate.UserID != userID {
+		return ctx.Status(404).JSON(fiber.Map{
+			"error": "Certificate not found",
+		})
+	}
+
+	html, err := c.hours.RenderCertificate(certificate, c.verifyURL(ctx, certificate.Code))
+	if err != nil {
+		return ctx.Status(500).JSON(fiber.Map{
+			"error": "Failed to render certificate",
+		})
+	}
+
+	ctx.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+	ctx.Set(fiber.HeaderContentDisposition, `attachment; filename="certificate-`+certificate.Code+`.html"`)
+	return ctx.Send(html)
+}
+
+// VerifyCertificate подтверждает подлинность сертификата по коду (публичный доступ)
+func (c *CertificateController) VerifyCertificate(ctx *fiber.Ctx) error {
+	certificate, err := c.hours.Verify(ctx.Params("code"))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return ctx.Status(404).JSON(fiber.Map{
+				"valid": false,
+				"error": "Certificate not found",
+			})
+		}
+		return ctx.Status(500).JSON(fiber.Map{
+			"error": "Failed to verify certificate",
+		})
+	}
+
+	return ctx.JSON(fiber.Map{
+		"valid":       true,
+		"certificate": certificate,
+	})
+}
+
+// verifyURL возвращает публичную ссылку проверки сертификата
+func (c *CertificateController) verifyURL(ctx *fiber.Ctx, code string) string {
+	return ctx.BaseURL() + "/certificates/" + code
+}
+
+// yearQuery разбирает необязательный параметр ?year=
+func yearQuery(ctx *fiber.Ctx) (*int, error) {
+	raw := ctx.Query("year")
+	if raw == "" {
+		return nil, nil
+	}
+	year, err := strconv.Atoi(raw)
+	if err != nil || year < 2000 || year > 9999 {
+		return nil, errors.New("invalid year")
+	}
+	return &year, nil
+}
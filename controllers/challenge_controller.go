@@ -0,0 +1,575 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"toloko-backend/models"
+	"toloko-backend/services"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// ChallengeController обрабатывает HTTP запросы для челленджей
+type ChallengeController struct {
+	db          *gorm.DB
+	challenges  *services.ChallengeService
+	permissions *services.PermissionService
+}
+
+// NewChallengeController создает новый контроллер челленджей
+func NewChallengeController(db *gorm.DB) *ChallengeController {
+	return &ChallengeController{
+		db:          db,
+		challenges:  services.NewChallengeService(db),
+		permissions: services.NewPermissionService(db),
+	}
+}
+
+// CreateChallengeRequest структура запроса создания челленджа
+type CreateChallengeRequest struct {
+	CommunityID         *uint     `json:"community_id"` // пусто - челлендж платформы
+	Title               string    `json:"title"`
+	Description         string    `json:"description"`
+	Metric              string    `json:"metric"`
+	ImpactUnit          string    `json:"impact_unit"`
+	Goal                float64   `json:"goal"`
+	GoalScope           string    `json:"goal_scope"` // individual (по умолчанию) или collective
+	StartsAt            time.Time `json:"starts_at"`
+	EndsAt              time.Time `json:"ends_at"`
+	RewardPoints        int       `json:"reward_points"`
+	RewardAchievementID *uint     `json:"reward_achievement_id"`
+}
+
+// JoinChallengeRequest структура запроса участия в челлендже
+type JoinChallengeRequest struct {
+	TeamID *uint `json:"team_id"` // пусто - личное участие
+}
+
+// CreateChallengeTeamRequest структура запроса создания команды
+type CreateChallengeTeamRequest struct {
+	Name string `json:"name"`
+}
+
+// ChallengeContributionRequest структура запроса записи результата на ивенте
+type ChallengeContributionRequest struct {
+	EventID  uint    `json:"event_id"`
+	Quantity float64 `json:"quantity"`
+}
+
+// ConfirmChallengeContributionRequest структура запроса подтверждения результата
+type ConfirmChallengeContributionRequest struct {
+	Quantity *float64 `json:"quantity"` // исправленное количество (необязательно)
+}
+
+// CreateChallenge создает челлендж сообщества (администраторы и модераторы сообщества) или платформы (администраторы)
+func (c *ChallengeController) CreateChallenge(ctx *fiber.Ctx) error {
+	userID := ctx.Locals("user_id").(uint)
+
+	var req CreateChallengeRequest
+	if err := ctx.BodyParser(&req); err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	req.Title = strings.TrimSpace(req.Title)
+	req.ImpactUnit = strings.TrimSpace(req.ImpactUnit)
+	if req.GoalScope == "" {
+		req.GoalScope = models.ChallengeGoalIndividual
+	}
+	if message := validateChallengeRequest(&req); message != "" {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": message,
+		})
+	}
+
+	var allowed bool
+	var err error
+	if req.CommunityID != nil {
+		var community models.Community
+		if err := c.db.First(&community, *req.CommunityID).Error; err != nil {
+			return ctx.Status(404).JSON(fiber.Map{
+				"error": "Community not found",
+			})
+		}
+		allowed, err = c.permissions.HasCommunityPermission(userID, community.ID, models.PermissionCommunityChallengesManage)
+	} else {
+		allowed, err = c.permissions.HasPermission(userID, models.PermissionChallengesManage)
+	}
+	if err != nil || !allowed {
+		return ctx.Status(403).JSON(fiber.Map{
+			"error": "Not allowed to create challenges here",
+		})
+	}
+
+	// Награды сообщества попадают в общий журнал очков, поэтому они ограничены,
+	// а достижения платформы выдаются только за челленджи платформы
+	if req.CommunityID != nil {
+		if req.RewardAchievementID != nil {
+			return ctx.Status(400).JSON(fiber.Map{
+				"error": "Achievement rewards are available only for platform challenges",
+			})
+		}
+		if req.RewardPoints > models.MaxCommunityChallengeRewardPoints {
+			if allowed, err := c.permissions.HasPermission(userID, models.PermissionPointsManage); err != nil || !allowed {
+				return ctx.Status(400).JSON(fiber.Map{
+					"error": fmt.Sprintf("Community challenge reward cannot exceed %d points", models.MaxCommunityChallengeRewardPoints),
+				})
+			}
+		}
+	}
+
+	if req.RewardAchievementID != nil {
+		var achievement models.Achievement
+		if err := c.db.Where("id = ? AND is_active = ?", *req.RewardAchievementID, true).First(&achievement).Error; err != nil {
+			return ctx.Status(400).JSON(fiber.Map{
+				"error": "Reward achievement not found",
+			})
+		}
+	}
+
+	challenge := models.Challenge{
+		CommunityID:         req.CommunityID,
+		CreatorID:           userID,
+		Title:               req.Title,
+		Description:         strings.TrimSpace(req.Description),
+		Metric:              req.Metric,
+		ImpactUnit:          req.ImpactUnit,
+		Goal:                req.Goal,
+		GoalScope:           req.GoalScope,
+		StartsAt:            req.StartsAt,
+		EndsAt:              req.EndsAt,
+		RewardPoints:        req.RewardPoints,
+		RewardAchievementID: req.RewardAchievementID,
+	}
+	if err := c.db.Create(&challenge).Error; err != nil {
+		return ctx.Status(500).JSON(fiber.Map{
+			"error": "Failed to create challenge",
+		})
+	}
+
+	return ctx.Status(201).JSON(fiber.Map{
+		"success":   true,
+		"challenge": challenge,
+	})
+}
+
+// GetChallenges возвращает челленджи, доступные пользователю (?community_id=&status=upcoming|active|finished)
+func (c *ChallengeController) GetChallenges(ctx *fiber.Ctx) error {
+	userID := ctx.Locals("user_id").(uint)
+
+	// Челленджи закрытых сообществ видят только их участники
+	query := c.db.Model(&models.Challenge{}).Where(
+		"community_id IS NULL OR community_id IN (?) OR community_id IN (?)",
+		c.db.Model(&models.Community{}).Select("id").Where("visibility <> ?", models.CommunityVisibilityInvite),
+		c.db.Model(&models.CommunityRole{}).Select("community_id").Where("user_id = ?", userID),
+	)
+
+	if raw := ctx.Query("community_id"); raw != "" {
+		communityID, err := strconv.ParseUint(raw, 10, 32)
+		if err != nil {
+			return ctx.Status(400).JSON(fiber.Map{
+				"error": "Invalid community ID",
+			})
+		}
+		query = query.Where("community_id = ?", communityID)
+	}
+
+	now := time.Now()
+	switch ctx.Query("status") {
+	case "":
+	case models.ChallengeStatusUpcoming:
+		query = query.Where("starts_at > ?", now)
+	case models.ChallengeStatusActive:
+		query = query.Where("starts_at <= ? AND ends_at >= ?", now, now)
+	case models.ChallengeStatusFinished:
+		query = query.Where("ends_at < ?", now)
+	default:
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Invalid status",
+		})
+	}
+
+	var challenges []models.Challenge
+	if err := query.Order("starts_at DESC, id DESC").Find(&challenges).Error; err != nil {
+		return ctx.Status(500).JSON(fiber.Map{
+			"error": "Failed to get challenges",
+		})
+	}
+
+	return ctx.JSON(fiber.Map{
+		"success":    true,
+		"challenges": challenges,
+	})
+}
+
+// GetChallenge возвращает челлендж с текущим прогрессом и участием пользователя
+func (c *ChallengeController) GetChallenge(ctx *fiber.Ctx) error {
+	userID := ctx.Locals("user_id").(uint)
+	challenge, ferr := c.loadChallenge(ctx, userID)
+	if ferr != nil {
+		return ctx.Status(ferr.Code).JSON(fiber.Map{
+			"error": ferr.Message,
+		})
+	}
+
+	progress, err := c.challenges.Progress(challenge)
+	if err != nil {
+		return ctx.Status(500).JSON(fiber.Map{
+			"error": "Failed to get challenge progress",
+		})
+	}
+
+	var me *services.ChallengeStanding
+	for i := range progress.Individuals {
+		if progress.Individuals[i].UserID == userID {
+			me = &progress.Individuals[i]
+			break
+		}
+	}
+
+	return ctx.JSON(fiber.Map{
+		"success":   true,
+		"challenge": challenge,
+		"progress": fiber.Map{
+			"status":         progress.Status,
+			"goal":           progress.Goal,
+			"goal_scope":     progress.GoalScope,
+			"total_progress": progress.TotalProgress,
+			"goal_reached":   progress.GoalReached,
+			"participants":   progress.Participants,
+			"teams":          len(progress.Teams),
+			"completed":      progress.Completed,
+		},
+		"me": me,
+	})
+}
+
+// GetChallengeLeaderboard возвращает таблицу участников или команд челленджа (?type=individual|team)
+func (c *ChallengeController) GetChallengeLeaderboard(ctx *fiber.Ctx) error {
+	userID := ctx.Locals("user_id").(uint)
+	challenge, ferr := c.loadChallenge(ctx, userID)
+	if ferr != nil {
+		return ctx.Status(ferr.Code).JSON(fiber.Map{
+			"error": ferr.Message,
+		})
+	}
+
+	progress, err := c.challenges.Progress(challenge)
+	if err != nil {
+		return ctx.Status(500).JSON(fiber.Map{
+			"error": "Failed to get challenge leaderboard",
+		})
+	}
+
+	standings := progress.Individuals
+	switch ctx.Query("type", "individual") {
+	case "individual":
+	case "team":
+		standings = progress.Teams
+	default:
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Invalid leaderboard type",
+		})
+	}
+
+	return ctx.JSON(fiber.Map{
+		"success":     true,
+		"goal":        challenge.Goal,
+		"metric":      challenge.Metric,
+		"impact_unit": challenge.ImpactUnit,
+		"standings":   standings,
+	})
+}
+
+// JoinChallenge добавляет текущего пользователя в челлендж лично или в команду
+func (c *ChallengeController) JoinChallenge(ctx *fiber.Ctx) error {
+	userID := ctx.Locals("user_id").(uint)
+	challenge, ferr := c.loadChallenge(ctx, userID)
+	if ferr != nil {
+		return ctx.Status(ferr.Code).JSON(fiber.Map{
+			"error": ferr.Message,
+		})
+	}
+
+	var req JoinChallengeRequest
+	if len(ctx.Body()) > 0 {
+		if err := ctx.BodyParser(&req); err != nil {
+			return ctx.Status(400).JSON(fiber.Map{
+				"error": "Invalid request body",
+			})
+		}
+	}
+
+	participant, err := c.challenges.Join(challenge, userID, req.TeamID)
+	if err != nil {
+		return c.challengeError(ctx, err, "Failed to join challenge")
+	}
+
+	// Результаты, набранные до вступления в период челленджа, тоже засчитываются
+	c.evaluate(challenge)
+
+	return ctx.Status(201).JSON(fiber.Map{
+		"success":     true,
+		"participant": participant,
+	})
+}
+
+// CreateChallengeTeam создает команду челленджа, текущий пользователь становится капитаном
+func (c *ChallengeController) CreateChallengeTeam(ctx *fiber.Ctx) error {
+	userID := ctx.Locals("user_id").(uint)
+	challenge, ferr := c.loadChallenge(ctx, userID)
+	if ferr != nil {
+		return ctx.Status(ferr.Code).JSON(fiber.Map{
+			"error": ferr.Message,
+		})
+	}
+
+	var req CreateChallengeTeamRequest
+	if err := ctx.BodyParser(&req); err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" || len([]rune(req.Name)) > 100 {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Team name must be 1-100 characters",
+		})
+	}
+
+	team, err := c.challenges.CreateTeam(challenge, userID, req.Name)
+	if err != nil {
+		return c.challengeError(ctx, err, "Failed to create team")
+	}
+
+	c.evaluate(challenge)
+
+	return ctx.Status(201).JSON(fiber.Map{
+		"success": true,
+		"team":    team,
+	})
+}
+
+// AddChallengeContribution записывает результат пользователя на ивенте для челленджа с метрикой impact
+func (c *ChallengeController) AddChallengeContribution(ctx *fiber.Ctx) error {
+	userID := ctx.Locals("user_id").(uint)
+	challenge, ferr := c.loadChallenge(ctx, userID)
+	if ferr != nil {
+		return ctx.Status(ferr.Code).JSON(fiber.Map{
+			"error": ferr.Message,
+		})
+	}
+
+	var req ChallengeContributionRequest
+	if err := ctx.BodyParser(&req); err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	if req.EventID == 0 || req.Quantity <= 0 {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Event ID and a positive quantity are required",
+		})
+	}
+
+	contribution, err := c.challenges.Contribute(challenge, userID, req.EventID, req.Quantity)
+	if err != nil {
+		return c.challengeError(ctx, err, "Failed to save contribution")
+	}
+
+	return ctx.Status(201).JSON(fiber.Map{
+		"success":      true,
+		"message":      "Contribution will count toward the challenge once the event organizer confirms it",
+		"contribution": contribution,
+	})
+}
+
+// GetPendingContributions возвращает неподтвержденные результаты на ивентах текущего пользователя
+func (c *ChallengeController) GetPendingContributions(ctx *fiber.Ctx) error {
+	userID := ctx.Locals("user_id").(uint)
+	challenge, ferr := c.loadChallenge(ctx, userID)
+	if ferr != nil {
+		return ctx.Status(ferr.Code).JSON(fiber.Map{
+			"error": ferr.Message,
+		})
+	}
+
+	contributions, err := c.challenges.PendingContributions(challenge, userID)
+	if err != nil {
+		return ctx.Status(500).JSON(fiber.Map{
+			"error": "Failed to get contributions",
+		})
+	}
+
+	return ctx.JSON(fiber.Map{
+		"success":       true,
+		"contributions": contributions,
+	})
+}
+
+// ConfirmChallengeContribution подтверждает результат участника (только организатор ивента)
+func (c *ChallengeController) ConfirmChallengeContribution(ctx *fiber.Ctx) error {
+	userID := ctx.Locals("user_id").(uint)
+	challenge, contributionID, ferr := c.loadContributionParams(ctx, userID)
+	if ferr != nil {
+		return ctx.Status(ferr.Code).JSON(fiber.Map{
+			"error": ferr.Message,
+		})
+	}
+
+	var req ConfirmChallengeContributionRequest
+	if len(ctx.Body()) > 0 {
+		if err := ctx.BodyParser(&req); err != nil {
+			return ctx.Status(400).JSON(fiber.Map{
+				"error": "Invalid request body",
+			})
+		}
+	}
+	if req.Quantity != nil && *req.Quantity <= 0 {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Quantity must be positive",
+		})
+	}
+
+	contribution, err := c.challenges.ConfirmContribution(challenge, contributionID, userID, req.Quantity)
+	if err != nil {
+		return c.challengeError(ctx, err, "Failed to confirm contribution")
+	}
+
+	progress := c.evaluate(challenge)
+
+	return ctx.JSON(fiber.Map{
+		"success":      true,
+		"contribution": contribution,
+		"progress":     progress,
+	})
+}
+
+// RejectChallengeContribution отклоняет результат участника (только организатор ивента)
+func (c *ChallengeController) RejectChallengeContribution(ctx *fiber.Ctx) error {
+	userID := ctx.Locals("user_id").(uint)
+	challenge, contributionID, ferr := c.loadContributionParams(ctx, userID)
+	if ferr != nil {
+		return ctx.Status(ferr.Code).JSON(fiber.Map{
+			"error": ferr.Message,
+		})
+	}
+
+	if err := c.challenges.RejectContribution(challenge, contributionID, userID); err != nil {
+		return c.challengeError(ctx, err, "Failed to reject contribution")
+	}
+
+	return ctx.JSON(fiber.Map{
+		"success": true,
+		"message": "Contribution rejected",
+	})
+}
+
+// loadChallenge загружает челлендж, проверяя доступ к закрытому сообществу
+func (c *ChallengeController) loadChallenge(ctx *fiber.Ctx, userID uint) (*models.Challenge, *fiber.Error) {
+	challengeID, err := strconv.ParseUint(ctx.Params("id"), 10, 32)
+	if err != nil {
+		return nil, fiber.NewError(400, "Invalid challenge ID")
+	}
+
+	var challenge models.Challenge
+	if err := c.db.Preload("Community").Preload("RewardAchievement").First(&challenge, challengeID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fiber.NewError(404, "Challenge not found")
+		}
+		return nil, fiber.NewError(500, "Failed to get challenge")
+	}
+
+	if challenge.Community != nil && challenge.Community.IsPrivate() {
+		allowed, err := c.permissions.HasCommunityPermission(userID, challenge.Community.ID, models.PermissionCommunityView)
+		if err != nil || !allowed {
+			return nil, fiber.NewError(403, "Challenge belongs to a private community")
+		}
+	}
+	return &challenge, nil
+}
+
+// loadContributionParams загружает челлендж и разбирает ID результата из пути
+func (c *ChallengeController) loadContributionParams(ctx *fiber.Ctx, userID uint) (*models.Challenge, uint, *fiber.Error) {
+	challenge, ferr := c.loadChallenge(ctx, userID)
+	if ferr != nil {
+		return nil, 0, ferr
+	}
+	contributionID, err := strconv.ParseUint(ctx.Params("contribution_id"), 10, 32)
+	if err != nil {
+		return nil, 0, fiber.NewError(400, "Invalid contribution ID")
+	}
+	return challenge, uint(contributionID), nil
+}
+
+// evaluate пересчитывает прогресс челленджа и рассылает обновление; ошибки не прерывают запрос
+func (c *ChallengeController) evaluate(challenge *models.Challenge) *services.ChallengeProgress {
+	progress, err := c.challenges.Evaluate(challenge)
+	if err != nil {
+		log.Printf("Ошибка пересчета челленджа %d: %v", challenge.ID, err)
+		return nil
+	}
+	return progress
+}
+
+// challengeError преобразует ошибку сервиса челленджей в HTTP ответ
+func (c *ChallengeController) challengeError(ctx *fiber.Ctx, err error, fallback string) error {
+	status := 500
+	message := fallback
+	switch {
+	case errors.Is(err, services.ErrChallengeFinished):
+		status, message = 400, "Challenge is finished"
+	case errors.Is(err, services.ErrChallengeNotStarted):
+		status, message = 400, "Challenge has not started yet"
+	case errors.Is(err, services.ErrAlreadyInChallenge):
+		status, message = 409, "Already participating in this challenge"
+	case errors.Is(err, services.ErrChallengeTeamExists):
+		status, message = 409, "Team name is already taken"
+	case errors.Is(err, services.ErrChallengeTeamNotFound):
+		status, message = 404, "Team not found"
+	case errors.Is(err, services.ErrNotChallengeParticipant):
+		status, message = 403, "Join the challenge first"
+	case errors.Is(err, services.ErrContributionNotAllowed):
+		status, message = 400, "Contributions are accepted only for impact challenges and events attended during the challenge"
+	case errors.Is(err, services.ErrContributionExists):
+		status, message = 409, "Contribution for this event is already recorded"
+	case errors.Is(err, services.ErrContributionNotFound):
+		status, message = 404, "Contribution not found"
+	case errors.Is(err, services.ErrContributionConfirmed):
+		status, message = 409, "Contribution is already confirmed"
+	case errors.Is(err, services.ErrNotContributionReviewer):
+		status, message = 403, "Only the event organizer can review this contribution"
+	}
+	return ctx.Status(status).JSON(fiber.Map{
+		"error": message,
+	})
+}
+
+// validateChallengeRequest проверяет параметры челленджа и возвращает текст ошибки
+func validateChallengeRequest(req *CreateChallengeRequest) string {
+	switch {
+	case req.Title == "" || len([]rune(req.Title)) > 200:
+		return "Title must be 1-200 characters"
+	case !models.IsValidChallengeMetric(req.Metric):
+		return "Metric must be one of: events, hours, impact"
+	case req.Metric == models.ChallengeMetricImpact && req.ImpactUnit == "":
+		return "Impact unit is required for impact challenges"
+	case req.Goal <= 0:
+		return "Goal must be positive"
+	case !models.IsValidChallengeGoalScope(req.GoalScope):
+		return "Goal scope must be one of: individual, collective"
+	case req.StartsAt.IsZero() || !req.EndsAt.After(req.StartsAt):
+		return "Challenge must end after it starts"
+	case !req.EndsAt.After(time.Now()):
+		return "Challenge must end in the future"
+	case req.RewardPoints < 0:
+		return "Reward points cannot be negative"
+	}
+	return ""
+}
@@ -1,10 +1,12 @@
 package controllers
 
 import (
+	"log"
 	"strconv"
 	"strings"
 
 	"toloko-backend/models"
+	"toloko-backend/services"
 	"toloko-backend/utils"
 
 	"github.com/gofiber/fiber/v2"
@@ -13,12 +15,24 @@ import (
 
 // CommentController контроллер для работы с комментариями
 type CommentController struct {
-	DB *gorm.DB
+	DB           *gorm.DB
+	Permissions  *services.PermissionService
+	Comments     *services.CommentService
+	Mentions     *services.MentionService
+	Moderation   *services.CommunityModerationService
+	Achievements *services.AchievementService
 }
 
 // NewCommentController создает новый экземпляр CommentController
 func NewCommentController(db *gorm.DB) *CommentController {
-	return &CommentController{DB: db}
+	return &CommentController{
+		DB:           db,
+		Permissions:  services.NewPermissionService(db),
+		Comments:     services.NewCommentService(db),
+		Mentions:     services.NewMentionService(db),
+		Moderation:   services.NewCommunityModerationService(db),
+		Achievements: services.NewAchievementService(db),
+	}
 }
 
 // CreateCommentRequest структура запроса создания комментария
@@ -47,6 +61,30 @@ type CommentsResponse struct {
 	Total   int64            `json:"total,omitempty"`
 }
 
+// CommentTreeResponse структура ответа с деревом комментариев
+type CommentTreeResponse struct {
+	Success bool                     `json:"success"`
+	Message string                   `json:"message"`
+	Data    []services.CommentThread `json:"data,omitempty"`
+	Total   int64                    `json:"total"` // количество комментариев верхнего уровня
+}
+
+// CommentRepliesResponse структура ответа со страницей ответов на комментарий
+type CommentRepliesResponse struct {
+	Success    bool             `json:"success"`
+	Message    string           `json:"message"`
+	Data       []models.Comment `json:"data,omitempty"`
+	NextCursor *uint            `json:"next_cursor,omitempty"`
+}
+
+// CommentLikeResponse структура ответа на лайк комментария
+type CommentLikeResponse struct {
+	Success    bool   `json:"success"`
+	Message    string `json:"message"`
+	Liked      bool   `json:"liked"`
+	LikesCount int    `json:"likes_count"`
+}
+
 // CreateComment создает новый комментарий к новости
 func (cc *CommentController) CreateComment(c *fiber.Ctx) error {
 	// Получаем пользователя из JWT токена
@@ -85,7 +123,7 @@ func (cc *CommentController) CreateComment(c *fiber.Ctx) error {
 
 	// Проверяем существование новости
 	var news models.News
-	if err := cc.DB.Preload("Community").First(&news, uint(newsID)).Error; err != nil {
+	if err := cc.DB.Preload("Community").Preload("Event").First(&news, uint(newsID)).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return c.Status(404).JSON(CommentResponse{
 				Success: false,
@@ -98,24 +136,32 @@ func (cc *CommentController) CreateComment(c *fiber.Ctx) error {
 		})
 	}
 
-	// Если указан parent_id, проверяем существование родительского комментария
-	if req.ParentID != nil {
-		var parentComment models.Comment
-		if err := cc.DB.Where("id = ? AND news_id = ?", *req.ParentID, newsID).First(&parentComment).Error; err != nil {
-			if err == gorm.ErrRecordNotFound {
-				return c.Status(404).JSON(CommentResponse{
-					Success: false,
-					Message: "Родительский комментарий не найден",
-				})
-			}
-			return c.Status(500).JSON(CommentResponse{
-				Success: false,
-				Message: "Ошибка при получении родительского комментария",
-			})
-		}
+	// Комментировать можно только опубликованные новости
+	if !news.IsPublished() {
+		return c.Status(400).JSON(CommentResponse{
+			Success: false,
+			Message: "Новость еще не опубликована",
+		})
+	}
+
+	// Анонсы закрытых ивентов комментируют только участники сообщества
+	if cc.isNewsHidden(c, &news) {
+		return c.Status(404).JSON(CommentResponse{
+			Success: false,
+			Message: "Новость не найдена",
+		})
+	}
+
+	// Заблокированные и временно ограниченные пользователи не могут комментировать
+	if err := cc.Moderation.CheckCanParticipate(news.CommunityID, userID); err != nil {
+		status, message := sanctionError(err)
+		return c.Status(status).JSON(CommentResponse{
+			Success: false,
+			Message: message,
+		})
 	}
 
-	// Создаем комментарий
+	// Создаем комментарий (для ответа проверяется родительский комментарий и глубина)
 	comment := models.Comment{
 		NewsID:   uint(newsID),
 		AuthorID: userID,
@@ -123,15 +169,41 @@ func (cc *CommentController) CreateComment(c *fiber.Ctx) error {
 		Content:  strings.TrimSpace(req.Content),
 	}
 
-	if err := cc.DB.Create(&comment).Error; err != nil {
+	if err := cc.Comments.Create(&comment); err != nil {
+		switch err {
+		case services.ErrCommentNotFound:
+			return c.Status(404).JSON(CommentResponse{
+				Success: false,
+				Message: "Родительский комментарий не найден",
+			})
+		case services.ErrCommentDeleted:
+			return c.Status(400).JSON(CommentResponse{
+				Success: false,
+				Message: "Нельзя ответить на удаленный комментарий",
+			})
+		case services.ErrCommentTooDeep:
+			return c.Status(400).JSON(CommentResponse{
+				Success: false,
+				Message: "Достигнута максимальная глубина ответов",
+			})
+		}
 		return c.Status(500).JSON(CommentResponse{
 			Success: false,
 			Message: "Ошибка при создании комментария",
 		})
 	}
 
+	// Разбираем упоминания и уведомляем упомянутых
+	if err := cc.Mentions.SyncComment(&comment, &news); err != nil {
+		log.Printf("Ошибка обработки упоминаний в комментарии %d: %v", comment.ID, err)
+	}
+
+	// Начисляем достижения за комментарий
+	cc.Achievements.Track(userID, models.AchievementEventCommentCreated)
+
 	// Загружаем комментарий с автором и новостью
-	cc.DB.Preload("Author").Preload("News").Preload("Parent").First(&comment, comment.ID)
+	cc.DB.Preload("Author").Preload("News").Preload("Parent").Preload("Mentions").First(&comment, comment.ID)
+	comment.HideDeletedAuthors()
 
 	return c.Status(201).JSON(CommentResponse{
 		Success: true,
@@ -164,18 +236,18 @@ func (cc *CommentController) GetComments(c *fiber.Ctx) error {
 
 	offset := (page - 1) * limit
 
-	// Проверяем существование новости
+	// Проверяем существование новости; комментарии скрытой новости не показываются
 	var news models.News
-	if err := cc.DB.First(&news, uint(newsID)).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return c.Status(404).JSON(CommentsResponse{
+	if err := cc.DB.Preload("Event").First(&news, uint(newsID)).Error; err != nil || cc.isNewsHidden(c, &news) {
+		if err != nil && err != gorm.ErrRecordNotFound {
+			return c.Status(500).JSON(CommentsResponse{
 				Success: false,
-				Message: "Новость не найдена",
+				Message: "Ошибка при получении новости",
 			})
 		}
-		return c.Status(500).JSON(CommentsResponse{
+		return c.Status(404).JSON(CommentsResponse{
 			Success: false,
-			Message: "Ошибка при получении новости",
+			Message: "Новость не найдена",
 		})
 	}
 
@@ -197,6 +269,9 @@ func (cc *CommentController) GetComments(c *fiber.Ctx) error {
 			Message: "Ошибка при получении комментариев",
 		})
 	}
+	for i := range comments {
+		comments[i].HideDeletedAuthors()
+	}
 
 	return c.JSON(CommentsResponse{
 		Success: true,
@@ -206,6 +281,186 @@ func (cc *CommentController) GetComments(c *fiber.Ctx) error {
 	})
 }
 
+// GetCommentTree получает комментарии верхнего уровня с первыми ответами (?page=&limit=&replies=)
+func (cc *CommentController) GetCommentTree(c *fiber.Ctx) error {
+	newsID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(400).JSON(CommentTreeResponse{
+			Success: false,
+			Message: "Неверный ID новости",
+		})
+	}
+
+	page := c.QueryInt("page", 1)
+	if page < 1 {
+		page = 1
+	}
+	limit := c.QueryInt("limit", 20)
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	replies := c.QueryInt("replies", 3)
+	if replies < 0 || replies > 20 {
+		replies = 3
+	}
+
+	// Комментарии скрытой новости не показываются
+	var news models.News
+	if err := cc.DB.Preload("Event").First(&news, uint(newsID)).Error; err != nil || cc.isNewsHidden(c, &news) {
+		if err != nil && err != gorm.ErrRecordNotFound {
+			return c.Status(500).JSON(CommentTreeResponse{
+				Success: false,
+				Message: "Ошибка при получении новости",
+			})
+		}
+		return c.Status(404).JSON(CommentTreeResponse{
+			Success: false,
+			Message: "Новость не найдена",
+		})
+	}
+
+	viewerID, _ := cc.getUserIDFromToken(c)
+	threads, total, err := cc.Comments.Tree(news.ID, viewerID, page, limit, replies)
+	if err != nil {
+		return c.Status(500).JSON(CommentTreeResponse{
+			Success: false,
+			Message: "Ошибка при получении комментариев",
+		})
+	}
+
+	return c.JSON(CommentTreeResponse{
+		Success: true,
+		Message: "Дерево комментариев получено",
+		Data:    threads,
+		Total:   total,
+	})
+}
+
+// GetReplies получает ответы на комментарий после курсора (?cursor=&limit=)
+func (cc *CommentController) GetReplies(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(400).JSON(CommentRepliesResponse{
+			Success: false,
+			Message: "Неверный ID комментария",
+		})
+	}
+
+	cursor, err := strconv.ParseUint(c.Query("cursor", "0"), 10, 32)
+	if err != nil {
+		return c.Status(400).JSON(CommentRepliesResponse{
+			Success: false,
+			Message: "Неверный курсор",
+		})
+	}
+	limit := c.QueryInt("limit", 20)
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	// Ответы на комментарии скрытой новости не показываются
+	var parent models.Comment
+	if err := cc.DB.Preload("News.Event").First(&parent, uint(id)).Error; err != nil || cc.isNewsHidden(c, &parent.News) {
+		if err != nil && err != gorm.ErrRecordNotFound {
+			return c.Status(500).JSON(CommentRepliesResponse{
+				Success: false,
+				Message: "Ошибка при получении комментария",
+			})
+		}
+		return c.Status(404).JSON(CommentRepliesResponse{
+			Success: false,
+			Message: "Комментарий не найден",
+		})
+	}
+
+	viewerID, _ := cc.getUserIDFromToken(c)
+	replies, next, err := cc.Comments.Replies(uint(id), viewerID, uint(cursor), limit)
+	if err != nil {
+		if err == services.ErrCommentNotFound {
+			return c.Status(404).JSON(CommentRepliesResponse{
+				Success: false,
+				Message: "Комментарий не найден",
+			})
+		}
+		return c.Status(500).JSON(CommentRepliesResponse{
+			Success: false,
+			Message: "Ошибка при получении ответов",
+		})
+	}
+
+	return c.JSON(CommentRepliesResponse{
+		Success:    true,
+		Message:    "Ответы получены",
+		Data:       replies,
+		NextCursor: next,
+	})
+}
+
+// LikeComment добавляет/убирает лайк комментария
+func (cc *CommentController) LikeComment(c *fiber.Ctx) error {
+	userID, err := cc.getUserIDFromToken(c)
+	if err != nil {
+		return c.Status(401).JSON(CommentLikeResponse{
+			Success: false,
+			Message: "Неавторизованный доступ",
+		})
+	}
+
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(400).JSON(CommentLikeResponse{
+			Success: false,
+			Message: "Неверный ID комментария",
+		})
+	}
+
+	// Комментарии скрытых новостей лайкнуть нельзя, как и прочитать
+	var comment models.Comment
+	if err := cc.DB.Preload("News.Event").First(&comment, uint(id)).Error; err != nil || cc.isNewsHidden(c, &comment.News) {
+		return c.Status(404).JSON(CommentLikeResponse{
+			Success: false,
+			Message: "Комментарий не найден",
+		})
+	}
+
+	liked, count, err := cc.Comments.ToggleLike(uint(id), userID)
+	if err != nil {
+		switch err {
+		case services.ErrCommentNotFound:
+			return c.Status(404).JSON(CommentLikeResponse{
+				Success: false,
+				Message: "Комментарий не найден",
+			})
+		case services.ErrCommentDeleted:
+			return c.Status(400).JSON(CommentLikeResponse{
+				Success: false,
+				Message: "Нельзя лайкнуть удаленный комментарий",
+			})
+		case services.ErrCommunityBanned, services.ErrCommunityMuted:
+			status, message := sanctionError(err)
+			return c.Status(status).JSON(CommentLikeResponse{
+				Success: false,
+				Message: message,
+			})
+		}
+		return c.Status(500).JSON(CommentLikeResponse{
+			Success: false,
+			Message: "Ошибка при обработке лайка",
+		})
+	}
+
+	message := "Лайк удален"
+	if liked {
+		message = "Лайк добавлен"
+	}
+	return c.JSON(CommentLikeResponse{
+		Success:    true,
+		Message:    message,
+		Liked:      liked,
+		LikesCount: count,
+	})
+}
+
 // GetComment получает комментарий по ID
 func (cc *CommentController) GetComment(c *fiber.Ctx) error {
 	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
@@ -218,24 +473,26 @@ func (cc *CommentController) GetComment(c *fiber.Ctx) error {
 
 	var comment models.Comment
 	if err := cc.DB.Preload("Author").
-		Preload("News").
+		Preload("News.Event").
 		Preload("Parent").
 		Preload("Children", func(db *gorm.DB) *gorm.DB {
 			return db.Preload("Author").Order("created_at ASC")
 		}).
-		First(&comment, uint(id)).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return c.Status(404).JSON(CommentResponse{
+		First(&comment, uint(id)).Error; err != nil || cc.isNewsHidden(c, &comment.News) {
+		if err != nil && err != gorm.ErrRecordNotFound {
+			return c.Status(500).JSON(CommentResponse{
 				Success: false,
-				Message: "Комментарий не найден",
+				Message: "Ошибка при получении комментария",
 			})
 		}
-		return c.Status(500).JSON(CommentResponse{
+		return c.Status(404).JSON(CommentResponse{
 			Success: false,
-			Message: "Ошибка при получении комментария",
+			Message: "Комментарий не найден",
 		})
 	}
 
+	comment.HideDeletedAuthors()
+
 	return c.JSON(CommentResponse{
 		Success: true,
 		Message: "Комментарий получен",
@@ -293,6 +550,22 @@ func (cc *CommentController) UpdateComment(c *fiber.Ctx) error {
 		})
 	}
 
+	// Заблокированный или заглушенный пользователь не может редактировать комментарии
+	if err := cc.Moderation.CheckCanParticipate(comment.News.CommunityID, userID); err != nil {
+		status, message := sanctionError(err)
+		return c.Status(status).JSON(CommentResponse{
+			Success: false,
+			Message: message,
+		})
+	}
+
+	if comment.IsDeleted {
+		return c.Status(400).JSON(CommentResponse{
+			Success: false,
+			Message: "Комментарий удален",
+		})
+	}
+
 	// Валидация
 	if err := cc.validateUpdateCommentRequest(&req); err != nil {
 		return c.Status(400).JSON(CommentResponse{
@@ -317,8 +590,15 @@ func (cc *CommentController) UpdateComment(c *fiber.Ctx) error {
 		})
 	}
 
+	// Обновляем упоминания: уведомление получат только впервые упомянутые
+	comment.Content = strings.TrimSpace(req.Content)
+	if err := cc.Mentions.SyncComment(&comment, &comment.News); err != nil {
+		log.Printf("Ошибка обработки упоминаний в комментарии %d: %v", comment.ID, err)
+	}
+
 	// Загружаем обновленный комментарий
-	cc.DB.Preload("Author").Preload("News").Preload("Parent").First(&comment, comment.ID)
+	cc.DB.Preload("Author").Preload("News").Preload("Parent").Preload("Mentions").First(&comment, comment.ID)
+	comment.HideDeletedAuthors()
 
 	return c.JSON(CommentResponse{
 		Success: true,
@@ -369,14 +649,27 @@ func (cc *CommentController) DeleteComment(c *fiber.Ctx) error {
 		})
 	}
 
-	// Удаляем комментарий (каскадное удаление для дочерних комментариев)
-	if err := cc.DB.Delete(&comment).Error; err != nil {
+	reason, err := parseRemovalReason(c)
+	if err != nil {
+		return c.Status(400).JSON(CommentResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+	}
+
+	// Удаляем комментарий: если на него есть ответы, остается заглушка «[deleted]»
+	if err := cc.Comments.Delete(&comment); err != nil {
 		return c.Status(500).JSON(CommentResponse{
 			Success: false,
 			Message: "Ошибка при удалении комментария",
 		})
 	}
 
+	// Удаление модератором записывается в журнал, автор получает причину
+	if err := cc.Moderation.RecordRemoval(comment.News.CommunityID, userID, comment.AuthorID, "comment", comment.ID, comment.Content, reason); err != nil {
+		log.Printf("Ошибка записи удаления комментария %d в журнал модерации: %v", comment.ID, err)
+	}
+
 	return c.JSON(CommentResponse{
 		Success: true,
 		Message: "Комментарий успешно удален",
@@ -405,12 +698,30 @@ func (cc *CommentController) getUserIDFromToken(c *fiber.Ctx) (uint, error) {
 	return claims.UserID, nil
 }
 
+// isNewsHidden проверяет, скрыта ли новость от текущего пользователя, так же как в NewsController:
+// неопубликованная новость видна только автору и редакторам, анонс ивента «только для участников» - участникам.
+// Новость должна быть загружена вместе с Event.
+func (cc *CommentController) isNewsHidden(c *fiber.Ctx, news *models.News) bool {
+	viewerID, err := cc.getUserIDFromToken(c)
+	authenticated := err == nil
+	hasPermission := func(permission string) bool {
+		allowed, err := cc.Permissions.HasCommunityPermission(viewerID, news.CommunityID, permission)
+		return err == nil && allowed
+	}
+
+	if !news.IsPublished() && (!authenticated || news.AuthorID != viewerID && !hasPermission(models.PermissionCommunityNewsManage)) {
+		return true
+	}
+	if news.Event != nil && news.Event.MembersOnly {
+		return !authenticated || !hasPermission(models.PermissionCommunityView)
+	}
+	return false
+}
+
 // canManageComments проверяет, может ли пользователь управлять комментариями в сообществе
 func (cc *CommentController) canManageComments(userID, communityID uint) bool {
-	var role models.CommunityRole
-	err := cc.DB.Where("community_id = ? AND user_id = ? AND role IN ?",
-		communityID, userID, []string{"admin", "moderator"}).First(&role).Error
-	return err == nil
+	allowed, err := cc.Permissions.HasCommunityPermission(userID, communityID, models.PermissionCommunityCommentsManage)
+	return err == nil && allowed
 }
 
 // validateCreateCommentRequest валидирует запрос создания комментария
@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"toloko-backend/models"
+	"toloko-backend/services"
 	"toloko-backend/utils"
 
 	"github.com/gofiber/fiber/v2"
@@ -13,26 +14,40 @@ import (
 
 // CommunityController контроллер для работы с сообществами
 type CommunityController struct {
-	DB *gorm.DB
+	DB           *gorm.DB
+	Permissions  *services.PermissionService
+	Discovery    *services.CommunityDiscoveryService
+	Achievements *services.AchievementService
 }
 
 // NewCommunityController создает новый экземпляр CommunityController
 func NewCommunityController(db *gorm.DB) *CommunityController {
-	return &CommunityController{DB: db}
+	return &CommunityController{
+		DB:           db,
+		Permissions:  services.NewPermissionService(db),
+		Discovery:    services.NewCommunityDiscoveryService(db),
+		Achievements: services.NewAchievementService(db),
+	}
 }
 
 // CreateCommunityRequest структура запроса создания сообщества
 type CreateCommunityRequest struct {
-	Name        string `json:"name" validate:"required,min=3,max=100"`
-	Description string `json:"description" validate:"max=500"`
-	City        string `json:"city" validate:"required,min=2,max=100"`
+	Name        string   `json:"name" validate:"required,min=3,max=100"`
+	Description string   `json:"description" validate:"max=500"`
+	City        string   `json:"city" validate:"required,min=2,max=100"`
+	Latitude    *float64 `json:"latitude" validate:"omitempty,min=-90,max=90"`
+	Longitude   *float64 `json:"longitude" validate:"omitempty,min=-180,max=180"`
+	Visibility  string   `json:"visibility" validate:"omitempty,oneof=open request invite"`
 }
 
 // UpdateCommunityRequest структура запроса обновления сообщества
 type UpdateCommunityRequest struct {
-	Name        string `json:"name" validate:"min=3,max=100"`
-	Description string `json:"description" validate:"max=500"`
-	City        string `json:"city" validate:"min=2,max=100"`
+	Name        string   `json:"name" validate:"min=3,max=100"`
+	Description string   `json:"description" validate:"max=500"`
+	City        string   `json:"city" validate:"min=2,max=100"`
+	Latitude    *float64 `json:"latitude" validate:"omitempty,min=-90,max=90"`
+	Longitude   *float64 `json:"longitude" validate:"omitempty,min=-180,max=180"`
+	Visibility  string   `json:"visibility" validate:"omitempty,oneof=open request invite"`
 }
 
 // CommunityResponse структура ответа с сообществом
@@ -44,10 +59,10 @@ type CommunityResponse struct {
 
 // CommunitiesResponse структура ответа со списком сообществ
 type CommunitiesResponse struct {
-	Success bool               `json:"success"`
-	Message string             `json:"message"`
-	Data    []models.Community `json:"data,omitempty"`
-	Total   int64              `json:"total,omitempty"`
+	Success bool                        `json:"success"`
+	Message string                      `json:"message"`
+	Data    []services.CommunitySummary `json:"data,omitempty"`
+	Total   int64                       `json:"total,omitempty"`
 }
 
 // CreateCommunity создает новое сообщество
@@ -83,30 +98,43 @@ func (cc *CommunityController) CreateCommunity(c *fiber.Ctx) error {
 		Name:        strings.TrimSpace(req.Name),
 		Description: strings.TrimSpace(req.Description),
 		City:        strings.TrimSpace(req.City),
+		Latitude:    req.Latitude,
+		Longitude:   req.Longitude,
+		Visibility:  req.Visibility,
+	}
+	if community.Visibility == "" {
+		community.Visibility = models.CommunityVisibilityOpen
 	}
 
-	if err := cc.DB.Create(&community).Error; err != nil {
+	// Сообщество, роль администратора создателя и запись в истории ролей создаются вместе
+	err = cc.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&community).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(&models.CommunityRole{
+			CommunityID: community.ID,
+			UserID:      userID,
+			Role:        models.CommunityRoleAdmin,
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.CommunityRoleChange{
+			CommunityID: community.ID,
+			UserID:      userID,
+			ActorID:     userID,
+			Action:      models.RoleChangeJoined,
+			ToRole:      models.CommunityRoleAdmin,
+		}).Error
+	})
+	if err != nil {
 		return c.Status(500).JSON(CommunityResponse{
 			Success: false,
 			Message: "Ошибка при создании сообщества",
 		})
 	}
 
-	// Создаем роль администратора для создателя
-	role := models.CommunityRole{
-		CommunityID: community.ID,
-		UserID:      userID,
-		Role:        "admin",
-	}
-
-	if err := cc.DB.Create(&role).Error; err != nil {
-		// Если не удалось создать роль, удаляем сообщество
-		cc.DB.Delete(&community)
-		return c.Status(500).JSON(CommunityResponse{
-			Success: false,
-			Message: "Ошибка при назначении роли",
-		})
-	}
+	// Начисляем достижения за создание сообщества
+	cc.Achievements.Track(userID, models.AchievementEventCommunityCreated)
 
 	// Загружаем сообщество с создателем
 	cc.DB.Preload("Creator").First(&community, community.ID)
@@ -118,10 +146,9 @@ func (cc *CommunityController) CreateCommunity(c *fiber.Ctx) error {
 	})
 }
 
-// GetCommunities получает список сообществ с фильтрацией по городу
+// GetCommunities ищет сообщества: текстовый поиск (?search=), город (?city=),
+// расстояние от точки (?lat=&lng=&radius= в км) и сортировка (?sort=newest|members|activity|distance)
 func (cc *CommunityController) GetCommunities(c *fiber.Ctx) error {
-	// Получаем параметры запроса
-	city := c.Query("city", "")
 	page, _ := strconv.Atoi(c.Query("page", "1"))
 	limit, _ := strconv.Atoi(c.Query("limit", "20"))
 
@@ -132,22 +159,49 @@ func (cc *CommunityController) GetCommunities(c *fiber.Ctx) error {
 		limit = 20
 	}
 
-	offset := (page - 1) * limit
-
-	// Строим запрос
-	query := cc.DB.Model(&models.Community{}).Preload("Creator")
-
-	if city != "" {
-		query = query.Where("city ILIKE ?", "%"+city+"%")
+	filter := services.CommunityDiscoveryFilter{
+		Search: c.Query("search"),
+		City:   c.Query("city"),
+		Sort:   c.Query("sort", services.CommunitySortNewest),
 	}
 
-	// Получаем общее количество
-	var total int64
-	query.Count(&total)
+	if c.Query("lat") != "" || c.Query("lng") != "" {
+		lat, latErr := strconv.ParseFloat(c.Query("lat"), 64)
+		lng, lngErr := strconv.ParseFloat(c.Query("lng"), 64)
+		if latErr != nil || lngErr != nil || lat < -90 || lat > 90 || lng < -180 || lng > 180 {
+			return c.Status(400).JSON(CommunitiesResponse{
+				Success: false,
+				Message: "Неверные координаты",
+			})
+		}
+		filter.Latitude, filter.Longitude = &lat, &lng
+
+		if radius := c.Query("radius"); radius != "" {
+			value, err := strconv.ParseFloat(radius, 64)
+			if err != nil || value <= 0 {
+				return c.Status(400).JSON(CommunitiesResponse{
+					Success: false,
+					Message: "Неверный радиус поиска",
+				})
+			}
+			filter.RadiusKm = value
+		}
+	}
 
-	// Получаем сообщества
-	var communities []models.Community
-	if err := query.Offset(offset).Limit(limit).Order("created_at DESC").Find(&communities).Error; err != nil {
+	communities, total, err := cc.Discovery.Discover(filter, page, limit)
+	if err != nil {
+		switch err {
+		case services.ErrInvalidCommunitySort:
+			return c.Status(400).JSON(CommunitiesResponse{
+				Success: false,
+				Message: "Неверная сортировка",
+			})
+		case services.ErrDistanceSortWithoutPoint:
+			return c.Status(400).JSON(CommunitiesResponse{
+				Success: false,
+				Message: "Для сортировки по расстоянию укажите координаты",
+			})
+		}
 		return c.Status(500).JSON(CommunitiesResponse{
 			Success: false,
 			Message: "Ошибка при получении списка сообществ",
@@ -162,6 +216,36 @@ func (cc *CommunityController) GetCommunities(c *fiber.Ctx) error {
 	})
 }
 
+// GetSuggestedCommunities получает сообщества, в которых состоят пользователи из подписок
+func (cc *CommunityController) GetSuggestedCommunities(c *fiber.Ctx) error {
+	userID, err := cc.getUserIDFromToken(c)
+	if err != nil {
+		return c.Status(401).JSON(CommunityResponse{
+			Success: false,
+			Message: "Неавторизованный доступ",
+		})
+	}
+
+	limit, _ := strconv.Atoi(c.Query("limit", "10"))
+	if limit < 1 || limit > 50 {
+		limit = 10
+	}
+
+	suggestions, err := cc.Discovery.Suggest(userID, limit)
+	if err != nil {
+		return c.Status(500).JSON(CommunityResponse{
+			Success: false,
+			Message: "Ошибка при получении рекомендаций",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Рекомендуемые сообщества получены",
+		"data":    suggestions,
+	})
+}
+
 // GetCommunity получает сообщество по ID
 func (cc *CommunityController) GetCommunity(c *fiber.Ctx) error {
 	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
@@ -173,7 +257,7 @@ func (cc *CommunityController) GetCommunity(c *fiber.Ctx) error {
 	}
 
 	var community models.Community
-	if err := cc.DB.Preload("Creator").Preload("Roles.User").First(&community, uint(id)).Error; err != nil {
+	if err := cc.DB.Preload("Creator").First(&community, uint(id)).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return c.Status(404).JSON(CommunityResponse{
 				Success: false,
@@ -186,6 +270,16 @@ func (cc *CommunityController) GetCommunity(c *fiber.Ctx) error {
 		})
 	}
 
+	// Состав закрытого сообщества видят только его участники
+	if !community.IsPrivate() || cc.canViewPrivateCommunity(c, community.ID) {
+		if err := cc.DB.Where("community_id = ?", community.ID).Preload("User").Find(&community.Roles).Error; err != nil {
+			return c.Status(500).JSON(CommunityResponse{
+				Success: false,
+				Message: "Ошибка при получении участников сообщества",
+			})
+		}
+	}
+
 	return c.JSON(CommunityResponse{
 		Success: true,
 		Message: "Сообщество получено",
@@ -254,6 +348,13 @@ func (cc *CommunityController) UpdateCommunity(c *fiber.Ctx) error {
 	if req.City != "" {
 		updates["city"] = strings.TrimSpace(req.City)
 	}
+	if req.Latitude != nil && req.Longitude != nil {
+		updates["latitude"] = *req.Latitude
+		updates["longitude"] = *req.Longitude
+	}
+	if req.Visibility != "" {
+		updates["visibility"] = req.Visibility
+	}
 
 	if len(updates) == 0 {
 		return c.Status(400).JSON(CommunityResponse{
@@ -323,15 +424,21 @@ func (cc *CommunityController) DeleteCommunity(c *fiber.Ctx) error {
 	}
 
 	// Проверяем права доступа (только создатель может удалить)
-	if community.CreatorID != userID {
+	if community.CreatorID != userID && !cc.hasPermission(userID, models.PermissionCommunitiesManage) {
 		return c.Status(403).JSON(CommunityResponse{
 			Success: false,
 			Message: "Только создатель может удалить сообщество",
 		})
 	}
 
-	// Удаляем сообщество (каскадное удаление)
-	if err := cc.DB.Delete(&community).Error; err != nil {
+	// Удаляем сообщество (каскадное удаление) и записываем удаление в журнал аудита
+	err = cc.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&community).Error; err != nil {
+			return err
+		}
+		return services.RecordAudit(tx, userID, models.AuditActionCommunityDelete, models.AuditTargetCommunity, community.ID, community, nil, services.AuditMetadataFromRequest(c))
+	})
+	if err != nil {
 		return c.Status(500).JSON(CommunityResponse{
 			Success: false,
 			Message: "Ошибка при удалении сообщества",
@@ -368,10 +475,24 @@ func (cc *CommunityController) getUserIDFromToken(c *fiber.Ctx) (uint, error) {
 
 // canManageCommunity проверяет, может ли пользователь управлять сообществом
 func (cc *CommunityController) canManageCommunity(userID, communityID uint) bool {
-	var role models.CommunityRole
-	err := cc.DB.Where("community_id = ? AND user_id = ? AND role IN ?",
-		communityID, userID, []string{"admin", "moderator"}).First(&role).Error
-	return err == nil
+	allowed, err := cc.Permissions.HasCommunityPermission(userID, communityID, models.PermissionCommunityManage)
+	return err == nil && allowed
+}
+
+// canViewPrivateCommunity проверяет, может ли автор запроса (если он авторизован) видеть закрытое сообщество
+func (cc *CommunityController) canViewPrivateCommunity(c *fiber.Ctx, communityID uint) bool {
+	userID, err := cc.getUserIDFromToken(c)
+	if err != nil {
+		return false
+	}
+	allowed, err := cc.Permissions.HasCommunityPermission(userID, communityID, models.PermissionCommunityView)
+	return err == nil && allowed
+}
+
+// hasPermission проверяет, есть ли у пользователя разрешение платформы
+func (cc *CommunityController) hasPermission(userID uint, permission string) bool {
+	allowed, err := cc.Permissions.HasPermission(userID, permission)
+	return err == nil && allowed
 }
 
 // validateCreateCommunityRequest валидирует запрос создания сообщества
@@ -391,7 +512,10 @@ func (cc *CommunityController) validateCreateCommunityRequest(req *CreateCommuni
 	if len(req.City) < 2 || len(req.City) > 100 {
 		return fiber.NewError(400, "Название города должно содержать от 2 до 100 символов")
 	}
-	return nil
+	if req.Visibility != "" && !models.IsValidCommunityVisibility(req.Visibility) {
+		return fiber.NewError(400, "Неверная видимость сообщества")
+	}
+	return validateCommunityCoordinates(req.Latitude, req.Longitude)
 }
 
 // validateUpdateCommunityRequest валидирует запрос обновления сообщества
@@ -405,5 +529,22 @@ func (cc *CommunityController) validateUpdateCommunityRequest(req *UpdateCommuni
 	if req.City != "" && (len(req.City) < 2 || len(req.City) > 100) {
 		return fiber.NewError(400, "Название города должно содержать от 2 до 100 символов")
 	}
+	if req.Visibility != "" && !models.IsValidCommunityVisibility(req.Visibility) {
+		return fiber.NewError(400, "Неверная видимость сообщества")
+	}
+	return validateCommunityCoordinates(req.Latitude, req.Longitude)
+}
+
+// validateCommunityCoordinates проверяет, что координаты указаны вместе и в допустимых пределах
+func validateCommunityCoordinates(lat, lng *float64) error {
+	if lat == nil && lng == nil {
+		return nil
+	}
+	if lat == nil || lng == nil {
+		return fiber.NewError(400, "Укажите широту и долготу вместе")
+	}
+	if *lat < -90 || *lat > 90 || *lng < -180 || *lng > 180 {
+		return fiber.NewError(400, "Неверные координаты")
+	}
 	return nil
 }
@@ -0,0 +1,766 @@
+package controllers
+
+import (
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"toloko-backend/models"
+	"toloko-backend/services"
+	"toloko-backend/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// CommunityMemberController контроллер для участия в сообществах
+type CommunityMemberController struct {
+	DB          *gorm.DB
+	Permissions *services.PermissionService
+	Membership  *services.CommunityMembershipService
+	Moderation  *services.CommunityModerationService
+}
+
+// NewCommunityMemberController создает новый экземпляр CommunityMemberController
+func NewCommunityMemberController(db *gorm.DB) *CommunityMemberController {
+	return &CommunityMemberController{
+		DB:          db,
+		Permissions: services.NewPermissionService(db),
+		Membership:  services.NewCommunityMembershipService(db),
+		Moderation:  services.NewCommunityModerationService(db),
+	}
+}
+
+// JoinCommunityRequest структура запроса вступления в сообщество
+type JoinCommunityRequest struct {
+	Message string `json:"message" validate:"max=500"`
+}
+
+// InviteMemberRequest структура запроса приглашения в сообщество
+type InviteMemberRequest struct {
+	UserID uint `json:"user_id" validate:"required"`
+}
+
+// ChangeMemberRoleRequest структура запроса изменения роли участника
+type ChangeMemberRoleRequest struct {
+	Role string `json:"role" validate:"required"`
+}
+
+// TransferOwnershipRequest структура запроса передачи владения сообществом
+type TransferOwnershipRequest struct {
+	UserID uint `json:"user_id" validate:"required"`
+}
+
+// SanctionRequest структура запроса бана или мута участника
+type SanctionRequest struct {
+	UserID          uint   `json:"user_id" validate:"required"`
+	Reason          string `json:"reason" validate:"max=500"`
+	DurationMinutes int    `json:"duration_minutes"` // для бана необязательно (бессрочно), для мута обязательно
+}
+
+// maxSanctionReasonLength максимальная длина причины санкции или удаления контента
+const maxSanctionReasonLength = 500
+
+// JoinCommunity вступает в сообщество или подает заявку на вступление
+func (mc *CommunityMemberController) JoinCommunity(c *fiber.Ctx) error {
+	userID, err := mc.getUserIDFromToken(c)
+	if err != nil {
+		return mc.respondError(c, 401, "Неавторизованный доступ")
+	}
+
+	community, err := mc.getCommunity(c)
+	if err != nil {
+		return mc.respondWithError(c, err)
+	}
+
+	var req JoinCommunityRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return mc.respondError(c, 400, "Неверный формат данных")
+		}
+	}
+	if len(req.Message) > 500 {
+		return mc.respondError(c, 400, "Сообщение не должно превышать 500 символов")
+	}
+
+	result, request, err := mc.Membership.Join(community.ID, userID, strings.TrimSpace(req.Message))
+	if err != nil {
+		switch err {
+		case services.ErrAlreadyMember:
+			return mc.respondError(c, 409, "Вы уже состоите в сообществе")
+		case services.ErrJoinRequestPending:
+			return mc.respondError(c, 409, "Заявка на вступление уже подана")
+		case services.ErrCommunityInviteOnly:
+			return mc.respondError(c, 403, "Вступить в сообщество можно только по приглашению")
+		case services.ErrCommunityBanned:
+			return mc.respondError(c, 403, "Вы заблокированы в этом сообществе")
+		}
+		return mc.respondError(c, 500, "Ошибка при вступлении в сообщество")
+	}
+
+	if result == services.JoinResultRequested {
+		return c.Status(202).JSON(fiber.Map{
+			"success": true,
+			"message": "Заявка на вступление отправлена",
+			"status":  result,
+			"data":    request,
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Вы вступили в сообщество",
+		"status":  result,
+	})
+}
+
+// LeaveCommunity выходит из сообщества или отменяет заявку на вступление
+func (mc *CommunityMemberController) LeaveCommunity(c *fiber.Ctx) error {
+	userID, err := mc.getUserIDFromToken(c)
+	if err != nil {
+		return mc.respondError(c, 401, "Неавторизованный доступ")
+	}
+
+	community, err := mc.getCommunity(c)
+	if err != nil {
+		return mc.respondWithError(c, err)
+	}
+
+	if err := mc.Membership.Leave(community.ID, userID); err != nil {
+		switch err {
+		case services.ErrNotMember:
+			return mc.respondError(c, 404, "Вы не состоите в сообществе")
+		case services.ErrLastCommunityAdmin:
+			return mc.respondError(c, 409, "Нельзя покинуть сообщество, в котором вы единственный администратор")
+		case services.ErrCommunityOwner:
+			return mc.respondError(c, 409, "Владелец не может покинуть сообщество, сначала передайте владение")
+		}
+		return mc.respondError(c, 500, "Ошибка при выходе из сообщества")
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Вы покинули сообщество",
+	})
+}
+
+// GetMembers получает список участников сообщества
+func (mc *CommunityMemberController) GetMembers(c *fiber.Ctx) error {
+	community, err := mc.getCommunity(c)
+	if err != nil {
+		return mc.respondWithError(c, err)
+	}
+
+	// Участников закрытого сообщества видят только его участники
+	if community.IsPrivate() {
+		userID, err := mc.getUserIDFromToken(c)
+		if err != nil {
+			return mc.respondError(c, 401, "Неавторизованный доступ")
+		}
+		if !mc.hasCommunityPermission(userID, community.ID, models.PermissionCommunityView) {
+			return mc.respondError(c, 403, "Сообщество закрыто")
+		}
+	}
+
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	limit, _ := strconv.Atoi(c.Query("limit", "50"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 50
+	}
+
+	members, total, err := mc.Membership.ListMembers(community.ID, c.Query("role"), page, limit)
+	if err != nil {
+		return mc.respondError(c, 500, "Ошибка при получении участников сообщества")
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Список участников получен",
+		"data":    members,
+		"total":   total,
+		"page":    page,
+		"limit":   limit,
+	})
+}
+
+// GetMembersCount получает количество участников сообщества
+func (mc *CommunityMemberController) GetMembersCount(c *fiber.Ctx) error {
+	community, err := mc.getCommunity(c)
+	if err != nil {
+		return mc.respondWithError(c, err)
+	}
+
+	total, byRole, err := mc.Membership.CountMembers(community.ID)
+	if err != nil {
+		return mc.respondError(c, 500, "Ошибка при подсчете участников сообщества")
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Количество участников получено",
+		"count":   total,
+		"by_role": byRole,
+	})
+}
+
+// GetJoinRequests получает заявки на вступление (для администраторов и модераторов)
+func (mc *CommunityMemberController) GetJoinRequests(c *fiber.Ctx) error {
+	community, _, err := mc.getManagedCommunity(c)
+	if err != nil {
+		return mc.respondWithError(c, err)
+	}
+
+	status := c.Query("status", models.MembershipStatusPending)
+	requests, err := mc.Membership.ListRequests(community.ID, status)
+	if err != nil {
+		return mc.respondError(c, 500, "Ошибка при получении заявок")
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Список заявок получен",
+		"data":    requests,
+	})
+}
+
+// ApproveJoinRequest одобряет заявку на вступление
+func (mc *CommunityMemberController) ApproveJoinRequest(c *fiber.Ctx) error {
+	return mc.reviewJoinRequest(c, true)
+}
+
+// RejectJoinRequest отклоняет заявку на вступление
+func (mc *CommunityMemberController) RejectJoinRequest(c *fiber.Ctx) error {
+	return mc.reviewJoinRequest(c, false)
+}
+
+// reviewJoinRequest рассматривает заявку на вступление
+func (mc *CommunityMemberController) reviewJoinRequest(c *fiber.Ctx, approve bool) error {
+	community, userID, err := mc.getManagedCommunity(c)
+	if err != nil {
+		return mc.respondWithError(c, err)
+	}
+
+	requestID, err := strconv.ParseUint(c.Params("request_id"), 10, 32)
+	if err != nil {
+		return mc.respondError(c, 400, "Неверный ID заявки")
+	}
+
+	var request *models.CommunityJoinRequest
+	message := "Заявка одобрена"
+	if approve {
+		request, err = mc.Membership.ApproveRequest(community.ID, uint(requestID), userID)
+	} else {
+		request, err = mc.Membership.RejectRequest(community.ID, uint(requestID), userID)
+		message = "Заявка отклонена"
+	}
+	if err != nil {
+		switch err {
+		case services.ErrJoinRequestNotFound:
+			return mc.respondError(c, 404, "Заявка не найдена")
+		case services.ErrCommunityBanned:
+			return mc.respondError(c, 409, "Пользователь заблокирован в сообществе")
+		}
+		return mc.respondError(c, 500, "Ошибка при рассмотрении заявки")
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": message,
+		"data":    request,
+	})
+}
+
+// InviteMember приглашает пользователя в сообщество
+func (mc *CommunityMemberController) InviteMember(c *fiber.Ctx) error {
+	community, userID, err := mc.getManagedCommunity(c)
+	if err != nil {
+		return mc.respondWithError(c, err)
+	}
+
+	var req InviteMemberRequest
+	if err := c.BodyParser(&req); err != nil || req.UserID == 0 {
+		return mc.respondError(c, 400, "Не указан пользователь")
+	}
+
+	var invitee models.User
+	if err := mc.DB.First(&invitee, req.UserID).Error; err != nil || !invitee.IsActive {
+		return mc.respondError(c, 404, "Пользователь не найден")
+	}
+
+	invitation, err := mc.Membership.Invite(community.ID, req.UserID, userID)
+	if err != nil {
+		switch err {
+		case services.ErrAlreadyMember:
+			return mc.respondError(c, 409, "Пользователь уже состоит в сообществе")
+		case services.ErrInvitationPending:
+			return mc.respondError(c, 409, "Приглашение уже отправлено")
+		case services.ErrCommunityBanned:
+			return mc.respondError(c, 409, "Пользователь заблокирован в сообществе")
+		}
+		return mc.respondError(c, 500, "Ошибка при создании приглашения")
+	}
+
+	return c.Status(201).JSON(fiber.Map{
+		"success": true,
+		"message": "Приглашение отправлено",
+		"data":    invitation,
+	})
+}
+
+// GetMyInvitations получает приглашения текущего пользователя
+func (mc *CommunityMemberController) GetMyInvitations(c *fiber.Ctx) error {
+	userID, err := mc.getUserIDFromToken(c)
+	if err != nil {
+		return mc.respondError(c, 401, "Неавторизованный доступ")
+	}
+
+	invitations, err := mc.Membership.ListInvitations(userID)
+	if err != nil {
+		return mc.respondError(c, 500, "Ошибка при получении приглашений")
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Список приглашений получен",
+		"data":    invitations,
+	})
+}
+
+// AcceptInvitation принимает приглашение в сообщество
+func (mc *CommunityMemberController) AcceptInvitation(c *fiber.Ctx) error {
+	return mc.respondInvitation(c, true)
+}
+
+// DeclineInvitation отклоняет приглашение в сообщество
+func (mc *CommunityMemberController) DeclineInvitation(c *fiber.Ctx) error {
+	return mc.respondInvitation(c, false)
+}
+
+// respondInvitation обрабатывает ответ на приглашение
+func (mc *CommunityMemberController) respondInvitation(c *fiber.Ctx, accept bool) error {
+	userID, err := mc.getUserIDFromToken(c)
+	if err != nil {
+		return mc.respondError(c, 401, "Неавторизованный доступ")
+	}
+
+	invitationID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return mc.respondError(c, 400, "Неверный ID приглашения")
+	}
+
+	message := "Приглашение принято"
+	if accept {
+		err = mc.Membership.AcceptInvitation(uint(invitationID), userID)
+	} else {
+		err = mc.Membership.DeclineInvitation(uint(invitationID), userID)
+		message = "Приглашение отклонено"
+	}
+	if err != nil {
+		switch err {
+		case services.ErrInvitationNotFound:
+			return mc.respondError(c, 404, "Приглашение не найдено")
+		case services.ErrCommunityBanned:
+			return mc.respondError(c, 403, "Вы заблокированы в этом сообществе")
+		}
+		return mc.respondError(c, 500, "Ошибка при обработке приглашения")
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": message,
+	})
+}
+
+// ChangeMemberRole изменяет роль участника сообщества
+func (mc *CommunityMemberController) ChangeMemberRole(c *fiber.Ctx) error {
+	community, actorID, err := mc.getManagedCommunity(c)
+	if err != nil {
+		return mc.respondWithError(c, err)
+	}
+
+	targetID, err := strconv.ParseUint(c.Params("user_id"), 10, 32)
+	if err != nil {
+		return mc.respondError(c, 400, "Неверный ID пользователя")
+	}
+
+	var req ChangeMemberRoleRequest
+	if err := c.BodyParser(&req); err != nil || req.Role == "" {
+		return mc.respondError(c, 400, "Не указана роль")
+	}
+
+	actorIsAdmin := mc.hasCommunityPermission(actorID, community.ID, models.PermissionCommunityAdminsManage)
+	membership, err := mc.Membership.ChangeRole(community.ID, uint(targetID), actorID, req.Role, actorIsAdmin)
+	if err != nil {
+		return mc.respondMembershipError(c, err, "Ошибка при изменении роли")
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Роль участника изменена",
+		"data":    membership,
+	})
+}
+
+// RemoveMember исключает участника из сообщества
+func (mc *CommunityMemberController) RemoveMember(c *fiber.Ctx) error {
+	community, actorID, err := mc.getManagedCommunity(c)
+	if err != nil {
+		return mc.respondWithError(c, err)
+	}
+
+	targetID, err := strconv.ParseUint(c.Params("user_id"), 10, 32)
+	if err != nil {
+		return mc.respondError(c, 400, "Неверный ID пользователя")
+	}
+	if uint(targetID) == actorID {
+		return mc.respondError(c, 400, "Чтобы выйти из сообщества, используйте выход из сообщества")
+	}
+
+	actorIsAdmin := mc.hasCommunityPermission(actorID, community.ID, models.PermissionCommunityAdminsManage)
+	if err := mc.Membership.RemoveMember(community.ID, uint(targetID), actorID, actorIsAdmin); err != nil {
+		return mc.respondMembershipError(c, err, "Ошибка при исключении участника")
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Участник исключен из сообщества",
+	})
+}
+
+// TransferOwnership передает владение сообществом другому участнику
+func (mc *CommunityMemberController) TransferOwnership(c *fiber.Ctx) error {
+	userID, err := mc.getUserIDFromToken(c)
+	if err != nil {
+		return mc.respondError(c, 401, "Неавторизованный доступ")
+	}
+
+	community, err := mc.getCommunity(c)
+	if err != nil {
+		return mc.respondWithError(c, err)
+	}
+
+	// Передать владение может только текущий владелец или администратор платформы
+	if community.CreatorID != userID {
+		allowed, err := mc.Permissions.HasPermission(userID, models.PermissionCommunitiesManage)
+		if err != nil || !allowed {
+			return mc.respondError(c, 403, "Передать владение может только владелец сообщества")
+		}
+	}
+
+	var req TransferOwnershipRequest
+	if err := c.BodyParser(&req); err != nil || req.UserID == 0 {
+		return mc.respondError(c, 400, "Не указан новый владелец")
+	}
+
+	updated, err := mc.Membership.TransferOwnership(community.ID, req.UserID, userID)
+	if err != nil {
+		return mc.respondMembershipError(c, err, "Ошибка при передаче владения")
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Владение сообществом передано",
+		"data":    updated,
+	})
+}
+
+// GetRoleHistory получает историю ролей сообщества (для администраторов и модераторов)
+func (mc *CommunityMemberController) GetRoleHistory(c *fiber.Ctx) error {
+	community, _, err := mc.getManagedCommunity(c)
+	if err != nil {
+		return mc.respondWithError(c, err)
+	}
+
+	userID, _ := strconv.ParseUint(c.Query("user_id", "0"), 10, 32)
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	limit, _ := strconv.Atoi(c.Query("limit", "50"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 50
+	}
+
+	changes, total, err := mc.Membership.GetRoleHistory(community.ID, uint(userID), page, limit)
+	if err != nil {
+		return mc.respondError(c, 500, "Ошибка при получении истории ролей")
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "История ролей получена",
+		"data":    changes,
+		"total":   total,
+		"page":    page,
+		"limit":   limit,
+	})
+}
+
+// BanMember блокирует пользователя в сообществе (бессрочно или на duration_minutes)
+func (mc *CommunityMemberController) BanMember(c *fiber.Ctx) error {
+	return mc.applySanction(c, models.SanctionTypeBan)
+}
+
+// MuteMember временно запрещает пользователю публиковать и оценивать контент в сообществе
+func (mc *CommunityMemberController) MuteMember(c *fiber.Ctx) error {
+	return mc.applySanction(c, models.SanctionTypeMute)
+}
+
+// applySanction применяет бан или мут к пользователю
+func (mc *CommunityMemberController) applySanction(c *fiber.Ctx, sanctionType string) error {
+	community, actorID, err := mc.getManagedCommunity(c)
+	if err != nil {
+		return mc.respondWithError(c, err)
+	}
+
+	var req SanctionRequest
+	if err := c.BodyParser(&req); err != nil || req.UserID == 0 {
+		return mc.respondError(c, 400, "Не указан пользователь")
+	}
+	if req.UserID == actorID {
+		return mc.respondError(c, 400, "Нельзя применить санкцию к себе")
+	}
+	reason := strings.TrimSpace(req.Reason)
+	if utf8.RuneCountInString(reason) > maxSanctionReasonLength {
+		return mc.respondError(c, 400, "Причина не должна превышать 500 символов")
+	}
+	if req.DurationMinutes < 0 || (sanctionType == models.SanctionTypeMute && req.DurationMinutes == 0) {
+		return mc.respondError(c, 400, "Укажите срок в минутах")
+	}
+
+	var target models.User
+	if err := mc.DB.First(&target, req.UserID).Error; err != nil {
+		return mc.respondError(c, 404, "Пользователь не найден")
+	}
+
+	var until *time.Time
+	if req.DurationMinutes > 0 {
+		expiresAt := time.Now().Add(time.Duration(req.DurationMinutes) * time.Minute)
+		until = &expiresAt
+	}
+
+	actorIsAdmin := mc.hasCommunityPermission(actorID, community.ID, models.PermissionCommunityAdminsManage)
+	var sanction *models.CommunitySanction
+	message := "Пользователь заблокирован в сообществе"
+	if sanctionType == models.SanctionTypeBan {
+		sanction, err = mc.Moderation.Ban(community.ID, req.UserID, actorID, reason, until, actorIsAdmin)
+	} else {
+		sanction, err = mc.Moderation.Mute(community.ID, req.UserID, actorID, reason, *until, actorIsAdmin)
+		message = "Пользователю временно запрещено писать в сообществе"
+	}
+	if err != nil {
+		return mc.respondMembershipError(c, err, "Ошибка при применении санкции")
+	}
+
+	return c.Status(201).JSON(fiber.Map{
+		"success": true,
+		"message": message,
+		"data":    sanction,
+	})
+}
+
+// UnbanMember снимает бан с пользователя
+func (mc *CommunityMemberController) UnbanMember(c *fiber.Ctx) error {
+	return mc.revokeSanction(c, models.SanctionTypeBan, "Бан снят")
+}
+
+// UnmuteMember досрочно снимает мут с пользователя
+func (mc *CommunityMemberController) UnmuteMember(c *fiber.Ctx) error {
+	return mc.revokeSanction(c, models.SanctionTypeMute, "Мут снят")
+}
+
+// revokeSanction снимает действующую санкцию указанного типа
+func (mc *CommunityMemberController) revokeSanction(c *fiber.Ctx, sanctionType, message string) error {
+	community, actorID, err := mc.getManagedCommunity(c)
+	if err != nil {
+		return mc.respondWithError(c, err)
+	}
+
+	targetID, err := strconv.ParseUint(c.Params("user_id"), 10, 32)
+	if err != nil {
+		return mc.respondError(c, 400, "Неверный ID пользователя")
+	}
+
+	if err := mc.Moderation.Revoke(community.ID, uint(targetID), actorID, sanctionType); err != nil {
+		return mc.respondMembershipError(c, err, "Ошибка при снятии санкции")
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": message,
+	})
+}
+
+// GetMemberSanctions получает санкции пользователя в сообществе и удаленный модераторами контент с причинами.
+// Доступно управляющим участниками и самому пользователю.
+func (mc *CommunityMemberController) GetMemberSanctions(c *fiber.Ctx) error {
+	userID, err := mc.getUserIDFromToken(c)
+	if err != nil {
+		return mc.respondError(c, 401, "Неавторизованный доступ")
+	}
+
+	community, err := mc.getCommunity(c)
+	if err != nil {
+		return mc.respondWithError(c, err)
+	}
+
+	targetID, err := strconv.ParseUint(c.Params("user_id"), 10, 32)
+	if err != nil {
+		return mc.respondError(c, 400, "Неверный ID пользователя")
+	}
+	if uint(targetID) != userID && !mc.hasCommunityPermission(userID, community.ID, models.PermissionCommunityMembersManage) {
+		return mc.respondError(c, 403, "Недостаточно прав для просмотра санкций")
+	}
+
+	sanctions, err := mc.Moderation.UserSanctions(community.ID, uint(targetID))
+	if err != nil {
+		return mc.respondError(c, 500, "Ошибка при получении санкций")
+	}
+	removals, err := mc.Moderation.UserRemovals(community.ID, uint(targetID))
+	if err != nil {
+		return mc.respondError(c, 500, "Ошибка при получении санкций")
+	}
+
+	return c.JSON(fiber.Map{
+		"success":  true,
+		"message":  "Санкции получены",
+		"data":     sanctions,
+		"removals": removals,
+	})
+}
+
+// GetModerationLog получает журнал модерации сообщества (?action=&user_id=&page=&limit=)
+func (mc *CommunityMemberController) GetModerationLog(c *fiber.Ctx) error {
+	community, _, err := mc.getManagedCommunity(c)
+	if err != nil {
+		return mc.respondWithError(c, err)
+	}
+
+	userID, _ := strconv.ParseUint(c.Query("user_id", "0"), 10, 32)
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	limit, _ := strconv.Atoi(c.Query("limit", "50"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 50
+	}
+
+	entries, total, err := mc.Moderation.Log(community.ID, c.Query("action"), uint(userID), page, limit)
+	if err != nil {
+		return mc.respondError(c, 500, "Ошибка при получении журнала модерации")
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Журнал модерации получен",
+		"data":    entries,
+		"total":   total,
+		"page":    page,
+		"limit":   limit,
+	})
+}
+
+// Вспомогательные методы
+
+// respondMembershipError отправляет ответ по ошибке управления участниками
+func (mc *CommunityMemberController) respondMembershipError(c *fiber.Ctx, err error, fallback string) error {
+	switch err {
+	case services.ErrNotMember:
+		return mc.respondError(c, 404, "Пользователь не состоит в сообществе")
+	case services.ErrInvalidCommunityRole:
+		return mc.respondError(c, 400, "Неверная роль")
+	case services.ErrInsufficientCommunityRole:
+		return mc.respondError(c, 403, "Модератор не может управлять администраторами")
+	case services.ErrCommunityOwner:
+		return mc.respondError(c, 409, "Владельца сообщества нельзя понизить или исключить")
+	case services.ErrLastCommunityAdmin:
+		return mc.respondError(c, 409, "В сообществе должен остаться хотя бы один администратор")
+	case services.ErrSanctionNotFound:
+		return mc.respondError(c, 404, "Действующая санкция не найдена")
+	case services.ErrInvalidSanctionDuration:
+		return mc.respondError(c, 400, "Неверный срок санкции")
+	}
+	return mc.respondError(c, 500, fallback)
+}
+
+// getCommunity находит сообщество из параметра маршрута
+func (mc *CommunityMemberController) getCommunity(c *fiber.Ctx) (*models.Community, error) {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return nil, fiber.NewError(400, "Неверный ID сообщества")
+	}
+
+	var community models.Community
+	if err := mc.DB.First(&community, uint(id)).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fiber.NewError(404, "Сообщество не найдено")
+		}
+		return nil, fiber.NewError(500, "Ошибка при получении сообщества")
+	}
+	return &community, nil
+}
+
+// getManagedCommunity находит сообщество и проверяет право управлять его участниками
+func (mc *CommunityMemberController) getManagedCommunity(c *fiber.Ctx) (*models.Community, uint, error) {
+	userID, err := mc.getUserIDFromToken(c)
+	if err != nil {
+		return nil, 0, fiber.NewError(401, "Неавторизованный доступ")
+	}
+
+	community, err := mc.getCommunity(c)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if !mc.hasCommunityPermission(userID, community.ID, models.PermissionCommunityMembersManage) {
+		return nil, 0, fiber.NewError(403, "Недостаточно прав для управления участниками")
+	}
+	return community, userID, nil
+}
+
+// respondWithError отправляет ответ по ошибке вспомогательного метода
+func (mc *CommunityMemberController) respondWithError(c *fiber.Ctx, err error) error {
+	if e, ok := err.(*fiber.Error); ok {
+		return mc.respondError(c, e.Code, e.Message)
+	}
+	return mc.respondError(c, 500, "Внутренняя ошибка сервера")
+}
+
+// respondError отправляет ответ с ошибкой
+func (mc *CommunityMemberController) respondError(c *fiber.Ctx, status int, message string) error {
+	return c.Status(status).JSON(fiber.Map{
+		"success": false,
+		"message": message,
+	})
+}
+
+// getUserIDFromToken извлекает ID пользователя из JWT токена
+func (mc *CommunityMemberController) getUserIDFromToken(c *fiber.Ctx) (uint, error) {
+	authHeader := c.Get("Authorization")
+	if authHeader == "" {
+		return 0, fiber.NewError(401, "Отсутствует токен авторизации")
+	}
+
+	tokenString := strings.TrimPrefix(authHeader, "Bearer ")
+	if tokenString == authHeader {
+		return 0, fiber.NewError(401, "Неверный формат токена")
+	}
+
+	claims, err := utils.ValidateJWT(tokenString)
+	if err != nil {
+		return 0, fiber.NewError(401, "Недействительный токен")
+	}
+
+	return claims.UserID, nil
+}
+
+// hasCommunityPermission проверяет разрешение пользователя в сообществе
+func (mc *CommunityMemberController) hasCommunityPermission(userID, communityID uint, permission string) bool {
+	allowed, err := mc.Permissions.HasCommunityPermission(userID, communityID, permission)
+	return err == nil && allowed
+}
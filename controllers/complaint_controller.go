@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"toloko-backend/models"
+	"toloko-backend/services"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/golang-jwt/jwt/v5"
@@ -13,12 +14,18 @@ import (
 
 // ComplaintController контроллер для управления жалобами
 type ComplaintController struct {
-	DB *gorm.DB
+	DB          *gorm.DB
+	Permissions *services.PermissionService
+	Reports     *services.ReportService
 }
 
 // NewComplaintController создает новый экземпляр ComplaintController
 func NewComplaintController(db *gorm.DB) *ComplaintController {
-	return &ComplaintController{DB: db}
+	return &ComplaintController{
+		DB:          db,
+		Permissions: services.NewPermissionService(db),
+		Reports:     services.NewReportService(db),
+	}
 }
 
 // SubmitComplaintRequest структура запроса подачи жалобы
@@ -28,6 +35,14 @@ type SubmitComplaintRequest struct {
 	ReasonText  string `json:"reason_text" validate:"required,min=10,max=1000"`
 }
 
+// SubmitReportRequest структура запроса жалобы на любой объект
+type SubmitReportRequest struct {
+	TargetType string `json:"target_type" validate:"required,oneof=user message news comment community attachment"`
+	TargetID   uint   `json:"target_id" validate:"required"`
+	ReasonCode string `json:"reason_code" validate:"required"`
+	ReasonText string `json:"reason_text" validate:"required,min=10,max=1000"`
+}
+
 // UpdateComplaintStatusRequest структура запроса обновления статуса жалобы
 type UpdateComplaintStatusRequest struct {
 	Status string `json:"status" validate:"required,oneof=open under_review resolved dismissed"`
@@ -93,42 +108,86 @@ func (cc *ComplaintController) SubmitComplaint(c *fiber.Ctx) error {
 	}
 
 	// Валидация запроса
-	if err := cc.validateComplaintRequest(&req, uint(eventID), userID); err != nil {
+	if err := cc.validateComplaintRequest(req.ReasonCode, req.ReasonText); err != nil {
 		return c.Status(400).JSON(ComplaintResponse{
 			Success: false,
 			Message: err.Error(),
 		})
 	}
 
-	// Проверяем, не подавал ли уже пользователь жалобу на этого участника в этом ивенте
-	var existingComplaint models.Complaint
-	if err := cc.DB.Where("event_id = ? AND from_user_id = ? AND about_user_id = ?",
-		eventID, userID, req.AboutUserID).First(&existingComplaint).Error; err == nil {
-		return c.Status(409).JSON(ComplaintResponse{
+	// Жалоба на участника подается на пользователя в контексте ивента
+	target, err := cc.Reports.EventParticipantTarget(uint(eventID), userID, req.AboutUserID)
+	if err != nil {
+		return cc.respondReportError(c, err)
+	}
+
+	complaint, err := cc.Reports.Submit(userID, target, req.ReasonCode, req.ReasonText)
+	if err != nil {
+		if err == services.ErrReportDuplicate {
+			return c.Status(409).JSON(ComplaintResponse{
+				Success: false,
+				Message: "Вы уже подали жалобу на этого участника в данном ивенте",
+			})
+		}
+		return cc.respondReportError(c, err)
+	}
+
+	// Загружаем полную информацию о жалобе
+	if err := cc.DB.Preload("FromUser").Preload("AboutUser").Preload("Event").First(complaint, complaint.ID).Error; err != nil {
+		return c.Status(500).JSON(ComplaintResponse{
 			Success: false,
-			Message: "Вы уже подали жалобу на этого участника в данном ивенте",
+			Message: "Ошибка при загрузке данных жалобы",
 		})
 	}
 
-	// Создаем жалобу
-	complaint := models.Complaint{
-		EventID:     uint(eventID),
-		FromUserID:  userID,
-		AboutUserID: req.AboutUserID,
-		ReasonCode:  req.ReasonCode,
-		ReasonText:  req.ReasonText,
-		Status:      models.ComplaintStatusOpen,
+	return c.Status(201).JSON(ComplaintResponse{
+		Success:   true,
+		Message:   "Жалоба успешно подана",
+		Complaint: complaint,
+	})
+}
+
+// SubmitReport подает жалобу на пользователя, сообщение, вложение, новость, комментарий или сообщество
+func (cc *ComplaintController) SubmitReport(c *fiber.Ctx) error {
+	userID, err := cc.getUserIDFromToken(c)
+	if err != nil {
+		return c.Status(401).JSON(ComplaintResponse{
+			Success: false,
+			Message: "Неавторизованный доступ",
+		})
 	}
 
-	if err := cc.DB.Create(&complaint).Error; err != nil {
-		return c.Status(500).JSON(ComplaintResponse{
+	var req SubmitReportRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(ComplaintResponse{
+			Success: false,
+			Message: "Неверный формат данных",
+		})
+	}
+	if req.TargetID == 0 {
+		return c.Status(400).JSON(ComplaintResponse{
 			Success: false,
-			Message: "Ошибка при создании жалобы",
+			Message: "Не указан объект жалобы",
+		})
+	}
+	if err := cc.validateComplaintRequest(req.ReasonCode, req.ReasonText); err != nil {
+		return c.Status(400).JSON(ComplaintResponse{
+			Success: false,
+			Message: err.Error(),
 		})
 	}
 
-	// Загружаем полную информацию о жалобе
-	if err := cc.DB.Preload("FromUser").Preload("AboutUser").Preload("Event").First(&complaint, complaint.ID).Error; err != nil {
+	target, err := cc.Reports.ResolveTarget(userID, req.TargetType, req.TargetID)
+	if err != nil {
+		return cc.respondReportError(c, err)
+	}
+
+	complaint, err := cc.Reports.Submit(userID, target, req.ReasonCode, req.ReasonText)
+	if err != nil {
+		return cc.respondReportError(c, err)
+	}
+
+	if err := cc.DB.Preload("FromUser").Preload("AboutUser").Preload("Community").First(complaint, complaint.ID).Error; err != nil {
 		return c.Status(500).JSON(ComplaintResponse{
 			Success: false,
 			Message: "Ошибка при загрузке данных жалобы",
@@ -138,7 +197,7 @@ func (cc *ComplaintController) SubmitComplaint(c *fiber.Ctx) error {
 	return c.Status(201).JSON(ComplaintResponse{
 		Success:   true,
 		Message:   "Жалоба успешно подана",
-		Complaint: &complaint,
+		Complaint: complaint,
 	})
 }
 
@@ -154,7 +213,7 @@ func (cc *ComplaintController) GetComplaints(c *fiber.Ctx) error {
 	}
 
 	// Проверяем права модератора (пока простая проверка - в реальном приложении нужна система ролей)
-	if !cc.isModerator(userID) {
+	if !cc.hasPermission(userID, models.PermissionComplaintsView) {
 		return c.Status(403).JSON(ComplaintsResponse{
 			Success: false,
 			Message: "Нет прав для просмотра жалоб",
@@ -166,6 +225,8 @@ func (cc *ComplaintController) GetComplaints(c *fiber.Ctx) error {
 	limit, _ := strconv.Atoi(c.Query("limit", "20"))
 	status := c.Query("status")
 	eventID := c.Query("event_id")
+	targetType := c.Query("target_type")
+	communityID := c.Query("community_id")
 
 	// Валидация параметров
 	if page < 1 {
@@ -181,13 +242,29 @@ func (cc *ComplaintController) GetComplaints(c *fiber.Ctx) error {
 	query := cc.DB.Model(&models.Complaint{}).
 		Preload("FromUser").
 		Preload("AboutUser").
-		Preload("Event")
+		Preload("Event").
+		Preload("Community")
 
 	// Фильтр по статусу
 	if status != "" {
 		query = query.Where("status = ?", status)
 	}
 
+	// Фильтр по типу объекта
+	if targetType != "" {
+		query = query.Where("target_type = ?", targetType)
+	}
+
+	// Жалобы на самого модератора рассматривают другие
+	query = query.Where("about_user_id <> ?", userID)
+
+	// По умолчанию - очередь платформы, жалобы на контент сообществ рассматривают их модераторы
+	if communityIDUint, err := strconv.ParseUint(communityID, 10, 32); err == nil {
+		query = query.Where("community_id = ?", communityIDUint)
+	} else {
+		query = query.Where("community_id IS NULL")
+	}
+
 	// Фильтр по ивенту
 	if eventID != "" {
 		if eventIDUint, err := strconv.ParseUint(eventID, 10, 32); err == nil {
@@ -232,14 +309,6 @@ func (cc *ComplaintController) UpdateComplaintStatus(c *fiber.Ctx) error {
 		})
 	}
 
-	// Проверяем права модератора
-	if !cc.isModerator(userID) {
-		return c.Status(403).JSON(ComplaintResponse{
-			Success: false,
-			Message: "Нет прав для изменения статуса жалобы",
-		})
-	}
-
 	// Получаем ID жалобы
 	complaintID, err := strconv.ParseUint(c.Params("id"), 10, 32)
 	if err != nil {
@@ -258,6 +327,14 @@ func (cc *ComplaintController) UpdateComplaintStatus(c *fiber.Ctx) error {
 		})
 	}
 
+	// Проверяем права модератора (платформы или сообщества, к которому относится жалоба)
+	if !cc.Reports.CanReview(userID, &complaint, models.PermissionComplaintsManage) {
+		return c.Status(403).JSON(ComplaintResponse{
+			Success: false,
+			Message: "Нет прав для изменения статуса жалобы",
+		})
+	}
+
 	var req UpdateComplaintStatusRequest
 	if err := c.BodyParser(&req); err != nil {
 		return c.Status(400).JSON(ComplaintResponse{
@@ -274,9 +351,22 @@ func (cc *ComplaintController) UpdateComplaintStatus(c *fiber.Ctx) error {
 		})
 	}
 
-	// Обновляем статус
+	// Обновляем статус и записываем изменение в журнал аудита
+	before := complaint
 	complaint.Status = req.Status
-	if err := cc.DB.Save(&complaint).Error; err != nil {
+	err = cc.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&complaint).Error; err != nil {
+			return err
+		}
+		// Подтвержденная жалоба на участника ивента отменяет его очки за этот ивент
+		if complaint.Status == models.ComplaintStatusResolved && before.Status != models.ComplaintStatusResolved {
+			if _, err := services.ReverseComplaintPoints(tx, &complaint); err != nil {
+				return err
+			}
+		}
+		return services.RecordAudit(tx, userID, models.AuditActionComplaintStatusUpdate, models.AuditTargetComplaint, complaint.ID, before, complaint, services.AuditMetadataFromRequest(c))
+	})
+	if err != nil {
 		return c.Status(500).JSON(ComplaintResponse{
 			Success: false,
 			Message: "Ошибка при обновлении статуса жалобы",
@@ -284,7 +374,7 @@ func (cc *ComplaintController) UpdateComplaintStatus(c *fiber.Ctx) error {
 	}
 
 	// Загружаем полную информацию о жалобе
-	if err := cc.DB.Preload("FromUser").Preload("AboutUser").Preload("Event").First(&complaint, complaint.ID).Error; err != nil {
+	if err := cc.DB.Preload("FromUser").Preload("AboutUser").Preload("Event").Preload("Community").First(&complaint, complaint.ID).Error; err != nil {
 		return c.Status(500).JSON(ComplaintResponse{
 			Success: false,
 			Message: "Ошибка при загрузке данных жалобы",
@@ -298,6 +388,76 @@ func (cc *ComplaintController) UpdateComplaintStatus(c *fiber.Ctx) error {
 	})
 }
 
+// GetCommunityReports получает очередь жалоб на контент сообщества (для модераторов сообщества)
+func (cc *ComplaintController) GetCommunityReports(c *fiber.Ctx) error {
+	userID, err := cc.getUserIDFromToken(c)
+	if err != nil {
+		return c.Status(401).JSON(ComplaintsResponse{
+			Success: false,
+			Message: "Неавторизованный доступ",
+		})
+	}
+
+	communityID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(400).JSON(ComplaintsResponse{
+			Success: false,
+			Message: "Неверный ID сообщества",
+		})
+	}
+
+	allowed, err := cc.Permissions.HasCommunityPermission(userID, uint(communityID), models.PermissionCommunityReportsManage)
+	if err != nil || !allowed {
+		return c.Status(403).JSON(ComplaintsResponse{
+			Success: false,
+			Message: "Нет прав для просмотра жалоб сообщества",
+		})
+	}
+
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	limit, _ := strconv.Atoi(c.Query("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	query := cc.DB.Model(&models.Complaint{}).
+		Preload("FromUser").
+		Preload("AboutUser").
+		Where("community_id = ? AND about_user_id <> ?", communityID, userID)
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if targetType := c.Query("target_type"); targetType != "" {
+		query = query.Where("target_type = ?", targetType)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return c.Status(500).JSON(ComplaintsResponse{
+			Success: false,
+			Message: "Ошибка при получении количества жалоб",
+		})
+	}
+
+	var complaints []models.Complaint
+	if err := query.Offset((page - 1) * limit).Limit(limit).Order("created_at DESC").Find(&complaints).Error; err != nil {
+		return c.Status(500).JSON(ComplaintsResponse{
+			Success: false,
+			Message: "Ошибка при получении списка жалоб",
+		})
+	}
+
+	return c.JSON(ComplaintsResponse{
+		Success:    true,
+		Message:    "Список жалоб сообщества получен",
+		Complaints: complaints,
+		Total:      total,
+	})
+}
+
 // GetComplaintReasons получает список доступных причин жалоб
 func (cc *ComplaintController) GetComplaintReasons(c *fiber.Ctx) error {
 	reasons := models.GetComplaintReasons()
@@ -407,51 +567,53 @@ func (cc *ComplaintController) getUserIDFromToken(c *fiber.Ctx) (uint, error) {
 	return 0, fiber.NewError(401, "Недействительный токен")
 }
 
-// validateComplaintRequest валидирует запрос жалобы
-func (cc *ComplaintController) validateComplaintRequest(req *SubmitComplaintRequest, eventID, fromUserID uint) error {
-	// Проверяем, что пользователь не жалуется сам на себя
-	if req.AboutUserID == fromUserID {
-		return fiber.NewError(400, "Нельзя подать жалобу на самого себя")
-	}
-
-	// Проверяем, что целевой пользователь участвовал в ивенте
-	var participant models.EventParticipant
-	if err := cc.DB.Where("event_id = ? AND user_id = ? AND status IN ?",
-		eventID, req.AboutUserID, []string{models.ParticipantStatusJoined, models.ParticipantStatusAccepted}).
-		First(&participant).Error; err != nil {
-		return fiber.NewError(400, "Пользователь не участвовал в этом ивенте")
-	}
-
-	// Проверяем, что жалующийся тоже участвовал в ивенте
-	if err := cc.DB.Where("event_id = ? AND user_id = ? AND status IN ?",
-		eventID, fromUserID, []string{models.ParticipantStatusJoined, models.ParticipantStatusAccepted}).
-		First(&participant).Error; err != nil {
-		return fiber.NewError(400, "Вы не участвовали в этом ивенте")
-	}
-
+// validateComplaintRequest валидирует причину и текст жалобы
+func (cc *ComplaintController) validateComplaintRequest(reasonCode, reasonText string) error {
 	// Проверяем код причины
 	reasons := models.GetComplaintReasons()
-	if _, exists := reasons[req.ReasonCode]; !exists {
+	if _, exists := reasons[reasonCode]; !exists {
 		return fiber.NewError(400, "Неверный код причины жалобы")
 	}
 
 	// Проверяем длину текста жалобы
-	if len(strings.TrimSpace(req.ReasonText)) < 10 {
+	if len(strings.TrimSpace(reasonText)) < 10 {
 		return fiber.NewError(400, "Текст жалобы должен содержать минимум 10 символов")
 	}
 
-	if len(req.ReasonText) > 1000 {
+	if len(reasonText) > 1000 {
 		return fiber.NewError(400, "Текст жалобы не должен превышать 1000 символов")
 	}
 
 	return nil
 }
 
-// isModerator проверяет, является ли пользователь модератором
-func (cc *ComplaintController) isModerator(userID uint) bool {
-	// В реальном приложении здесь должна быть проверка ролей пользователя
-	// Пока используем простую проверку - пользователь с ID 1 считается модератором
-	return userID == 1
+// respondReportError отправляет ответ по ошибке подачи жалобы
+func (cc *ComplaintController) respondReportError(c *fiber.Ctx, err error) error {
+	status, message := 500, "Ошибка при создании жалобы"
+	switch err {
+	case services.ErrInvalidReportTarget:
+		status, message = 400, "Неверный тип объекта жалобы"
+	case services.ErrReportTargetNotFound:
+		status, message = 404, "Объект жалобы не найден"
+	case services.ErrReportSelf:
+		status, message = 400, "Нельзя подать жалобу на самого себя"
+	case services.ErrReportDuplicate:
+		status, message = 409, "Вы уже подали жалобу на этот объект"
+	case services.ErrReportTargetNotParticipant:
+		status, message = 400, "Пользователь не участвовал в этом ивенте"
+	case services.ErrReporterNotParticipant:
+		status, message = 400, "Вы не участвовали в этом ивенте"
+	}
+	return c.Status(status).JSON(ComplaintResponse{
+		Success: false,
+		Message: message,
+	})
+}
+
+// hasPermission проверяет, есть ли у пользователя разрешение на работу с жалобами
+func (cc *ComplaintController) hasPermission(userID uint, permission string) bool {
+	allowed, err := cc.Permissions.HasPermission(userID, permission)
+	return err == nil && allowed
 }
 
 // isValidStatus проверяет, является ли статус валидным
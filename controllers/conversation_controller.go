@@ -88,10 +88,10 @@ func (c *ConversationController) GetConversation(ctx *fiber.Ctx) error {
 	unreadCount, _ := c.conversationService.GetUnreadCount(conversation.ID, userID)
 
 	return ctx.JSON(fiber.Map{
-		"success":       true,
-		"message":       "Conversation retrieved successfully",
-		"conversation":  conversation,
-		"unread_count":  unreadCount,
+		"success":      true,
+		"message":      "Conversation retrieved successfully",
+		"conversation": conversation,
+		"unread_count": unreadCount,
 	})
 }
 
@@ -128,8 +128,8 @@ func (c *ConversationController) CreateConversation(ctx *fiber.Ctx) error {
 	}
 
 	return ctx.Status(201).JSON(fiber.Map{
-		"success":     true,
-		"message":     "Conversation created successfully",
+		"success":      true,
+		"message":      "Conversation created successfully",
 		"conversation": conversation,
 	})
 }
@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"toloko-backend/models"
+	"toloko-backend/services"
 	"toloko-backend/utils"
 
 	"github.com/gofiber/fiber/v2"
@@ -12,12 +13,13 @@ import (
 
 // DashboardController контроллер для дашборда Home экрана
 type DashboardController struct {
-	db *gorm.DB
+	db      *gorm.DB
+	streaks *services.StreakService
 }
 
 // NewDashboardController создает новый экземпляр DashboardController
 func NewDashboardController(db *gorm.DB) *DashboardController {
-	return &DashboardController{db: db}
+	return &DashboardController{db: db, streaks: services.NewStreakService(db)}
 }
 
 // GetDashboardData получает все данные для дашборда Home экрана
@@ -116,6 +118,9 @@ func (dc *DashboardController) GetDashboardData(c *fiber.Ctx) error {
 	dc.db.Model(&models.User{}).Where("is_active = ?", true).Count(&communityStats.TotalUsers)
 	dc.db.Model(&models.Community{}).Where("is_active = ?", true).Count(&communityStats.TotalCommunities)
 
+	// Получаем серии участия пользователя
+	streaks, _ := dc.streaks.Stats(userID, now)
+
 	// Получаем события на сегодня
 	var todayEvents []models.Event
 	startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
@@ -146,6 +151,7 @@ func (dc *DashboardController) GetDashboardData(c *fiber.Ctx) error {
 		"leaderboard":         leaderboard,
 		"community_stats":     communityStats,
 		"today_events":        todayEvents,
+		"streaks":             streaks,
 		"error":               false,
 		"message":             "Данные дашборда получены успешно",
 	}
@@ -217,6 +223,9 @@ func (dc *DashboardController) GetUserDashboard(c *fiber.Ctx) error {
 	endOfDay := startOfDay.AddDate(0, 0, 1)
 	dc.db.Model(&models.Event{}).Where("start_time >= ? AND start_time < ? AND is_active = ?", startOfDay, endOfDay, true).Count(&feedStats.TodayEvents)
 
+	// Получаем серии участия пользователя
+	streaks, _ := dc.streaks.Stats(userID, now)
+
 	// Формируем ответ
 	response := fiber.Map{
 		"user": fiber.Map{
@@ -233,6 +242,7 @@ func (dc *DashboardController) GetUserDashboard(c *fiber.Ctx) error {
 		"stats":           userStats,
 		"upcoming_events": upcomingEvents,
 		"feed_stats":      feedStats,
+		"streaks":         streaks,
 		"error":           false,
 		"message":         "Данные дашборда получены успешно",
 	}
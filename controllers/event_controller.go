@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"toloko-backend/models"
+	"toloko-backend/services"
 	"toloko-backend/utils"
 
 	"github.com/gofiber/fiber/v2"
@@ -14,12 +15,22 @@ import (
 
 // EventController контроллер для управления ивентами
 type EventController struct {
-	DB *gorm.DB
+	DB              *gorm.DB
+	Permissions     *services.PermissionService
+	CommunityEvents *services.CommunityEventService
+	Achievements    *services.AchievementService
+	Reviews         *services.EventReviewService
 }
 
 // NewEventController создает новый экземпляр EventController
 func NewEventController(db *gorm.DB) *EventController {
-	return &EventController{DB: db}
+	return &EventController{
+		DB:              db,
+		Permissions:     services.NewPermissionService(db),
+		CommunityEvents: services.NewCommunityEventService(db),
+		Achievements:    services.NewAchievementService(db),
+		Reviews:         services.NewEventReviewService(db),
+	}
 }
 
 // CreateEventRequest структура запроса создания ивента
@@ -43,8 +54,10 @@ type CreateEventRequest struct {
 	WhatToBring      string                  `json:"what_to_bring" validate:"max=1000"`
 	ContactInfo      string                  `json:"contact_info" validate:"max=500"`
 	IsPublic         bool                    `json:"is_public"`
+	MembersOnly      bool                    `json:"members_only"`
 	IsRecurring      bool                    `json:"is_recurring"`
 	RecurringPattern string                  `json:"recurring_pattern" validate:"oneof=daily weekly monthly yearly"`
+	CommunityID      *uint                   `json:"community_id"`
 	Inventory        []EventInventoryRequest `json:"inventory"`
 	Photos           []string                `json:"photos"`
 }
@@ -77,6 +90,7 @@ type UpdateEventRequest struct {
 	WhatToBring      string                  `json:"what_to_bring" validate:"max=1000"`
 	ContactInfo      string                  `json:"contact_info" validate:"max=500"`
 	IsPublic         bool                    `json:"is_public"`
+	MembersOnly      bool                    `json:"members_only"`
 	IsRecurring      bool                    `json:"is_recurring"`
 	RecurringPattern string                  `json:"recurring_pattern" validate:"oneof=daily weekly monthly yearly"`
 	Inventory        []EventInventoryRequest `json:"inventory"`
@@ -90,9 +104,11 @@ type CreateInventoryRequest struct {
 
 // EventResponse структура ответа с ивентом
 type EventResponse struct {
-	Success bool          `json:"success"`
-	Message string        `json:"message"`
-	Event   *models.Event `json:"event,omitempty"`
+	Success             bool                    `json:"success"`
+	Message             string                  `json:"message"`
+	Event               *models.Event           `json:"event,omitempty"`
+	Reviews             *services.ReviewSummary `json:"reviews,omitempty"`              // сводка отзывов об ивенте
+	OrganizerReputation *services.ReviewSummary `json:"organizer_reputation,omitempty"` // репутация организатора по всем его ивентам
 }
 
 // EventsResponse структура ответа со списком ивентов
@@ -185,9 +201,33 @@ func (ec *EventController) CreateEvent(c *fiber.Ctx) error {
 		})
 	}
 
+	// Ивент от имени сообщества могут создавать его администраторы и модераторы
+	if req.CommunityID != nil {
+		var community models.Community
+		if err := ec.DB.First(&community, *req.CommunityID).Error; err != nil {
+			return c.Status(404).JSON(EventResponse{
+				Success: false,
+				Message: "Сообщество не найдено",
+			})
+		}
+		allowed, err := ec.Permissions.HasCommunityPermission(userID, community.ID, models.PermissionCommunityEventsManage)
+		if err != nil || !allowed {
+			return c.Status(403).JSON(EventResponse{
+				Success: false,
+				Message: "Недостаточно прав для создания ивента от имени сообщества",
+			})
+		}
+	} else if req.MembersOnly {
+		return c.Status(400).JSON(EventResponse{
+			Success: false,
+			Message: "Ивент только для участников должен принадлежать сообществу",
+		})
+	}
+
 	// Создаем ивент
 	event := models.Event{
 		CreatorID:        userID,
+		CommunityID:      req.CommunityID,
 		Title:            req.Title,
 		Description:      req.Description,
 		Latitude:         req.Latitude,
@@ -208,6 +248,7 @@ func (ec *EventController) CreateEvent(c *fiber.Ctx) error {
 		ContactInfo:      req.ContactInfo,
 		IsActive:         true,
 		IsPublic:         req.IsPublic,
+		MembersOnly:      req.MembersOnly,
 		IsRecurring:      req.IsRecurring,
 		RecurringPattern: req.RecurringPattern,
 	}
@@ -274,6 +315,15 @@ func (ec *EventController) CreateEvent(c *fiber.Ctx) error {
 		}
 	}
 
+	// Анонсируем ивент в новостях сообщества
+	if err := ec.CommunityEvents.Announce(tx, &event); err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(EventResponse{
+			Success: false,
+			Message: "Ошибка при публикации анонса ивента",
+		})
+	}
+
 	// Подтверждаем транзакцию
 	if err := tx.Commit().Error; err != nil {
 		return c.Status(500).JSON(EventResponse{
@@ -282,8 +332,11 @@ func (ec *EventController) CreateEvent(c *fiber.Ctx) error {
 		})
 	}
 
+	// Начисляем достижения за создание ивента
+	ec.Achievements.Track(userID, models.AchievementEventEventCreated)
+
 	// Загружаем полную информацию об ивенте
-	if err := ec.DB.Preload("Creator").Preload("Inventory.Inventory").Preload("Photos").First(&event, event.ID).Error; err != nil {
+	if err := ec.DB.Preload("Creator").Preload("Community").Preload("Inventory.Inventory").Preload("Photos").First(&event, event.ID).Error; err != nil {
 		return c.Status(500).JSON(EventResponse{
 			Success: false,
 			Message: "Ошибка при загрузке данных ивента",
@@ -326,12 +379,13 @@ func (ec *EventController) UpdateEvent(c *fiber.Ctx) error {
 		})
 	}
 
-	if event.CreatorID != userID {
+	if !ec.canManageEvent(userID, &event) {
 		return c.Status(403).JSON(EventResponse{
 			Success: false,
 			Message: "Нет прав для редактирования этого ивента",
 		})
 	}
+	before := event
 
 	var req UpdateEventRequest
 	if err := c.BodyParser(&req); err != nil {
@@ -397,6 +451,7 @@ func (ec *EventController) UpdateEvent(c *fiber.Ctx) error {
 		event.ContactInfo = req.ContactInfo
 	}
 	event.IsPublic = req.IsPublic
+	event.MembersOnly = req.MembersOnly && event.CommunityID != nil
 	event.IsRecurring = req.IsRecurring
 	if req.RecurringPattern != "" {
 		event.RecurringPattern = req.RecurringPattern
@@ -527,6 +582,15 @@ func (ec *EventController) UpdateEvent(c *fiber.Ctx) error {
 		}
 	}
 
+	// Записываем изменение в журнал аудита
+	if err := services.RecordAudit(tx, userID, models.AuditActionEventUpdate, models.AuditTargetEvent, event.ID, before, event, services.AuditMetadataFromRequest(c)); err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(EventResponse{
+			Success: false,
+			Message: "Ошибка при записи в журнал аудита",
+		})
+	}
+
 	// Подтверждаем транзакцию
 	if err := tx.Commit().Error; err != nil {
 		return c.Status(500).JSON(EventResponse{
@@ -579,7 +643,7 @@ func (ec *EventController) DeleteEvent(c *fiber.Ctx) error {
 		})
 	}
 
-	if event.CreatorID != userID {
+	if !ec.canManageEvent(userID, &event) {
 		return c.Status(403).JSON(EventResponse{
 			Success: false,
 			Message: "Нет прав для удаления этого ивента",
@@ -619,6 +683,15 @@ func (ec *EventController) DeleteEvent(c *fiber.Ctx) error {
 		})
 	}
 
+	// Отменяем очки, начисленные участникам и организатору
+	if _, err := services.ReverseEventPoints(tx, event.ID); err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(EventResponse{
+			Success: false,
+			Message: "Ошибка при отмене начисленных очков",
+		})
+	}
+
 	// Удаляем ивент
 	if err := tx.Delete(&event).Error; err != nil {
 		tx.Rollback()
@@ -628,6 +701,15 @@ func (ec *EventController) DeleteEvent(c *fiber.Ctx) error {
 		})
 	}
 
+	// Записываем удаление в журнал аудита
+	if err := services.RecordAudit(tx, userID, models.AuditActionEventDelete, models.AuditTargetEvent, event.ID, event, nil, services.AuditMetadataFromRequest(c)); err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(EventResponse{
+			Success: false,
+			Message: "Ошибка при записи в журнал аудита",
+		})
+	}
+
 	// Подтверждаем транзакцию
 	if err := tx.Commit().Error; err != nil {
 		return c.Status(500).JSON(EventResponse{
@@ -661,8 +743,15 @@ func (ec *EventController) GetEvents(c *fiber.Ctx) error {
 
 	offset := (page - 1) * limit
 
-	// Строим запрос
-	query := ec.DB.Model(&models.Event{}).Preload("Creator").Preload("Inventory.Inventory").Preload("Photos")
+	// Строим запрос (ивенты «только для участников» видны лишь участникам сообщества)
+	viewerID, _ := ec.getUserIDFromToken(c)
+	query := ec.DB.Model(&models.Event{}).Preload("Creator").Preload("Community").Preload("Inventory.Inventory").Preload("Photos").
+		Scopes(ec.CommunityEvents.VisibleTo(viewerID))
+
+	// Фильтр по сообществу-организатору
+	if communityID, err := strconv.ParseUint(c.Query("community_id"), 10, 32); err == nil {
+		query = query.Where("community_id = ?", communityID)
+	}
 
 	// Фильтр по статусу
 	now := time.Now()
@@ -722,17 +811,102 @@ func (ec *EventController) GetEvent(c *fiber.Ctx) error {
 
 	// Получаем ивент
 	var event models.Event
-	if err := ec.DB.Preload("Creator").Preload("Inventory.Inventory").Preload("Photos").Preload("Participants.User").First(&event, eventID).Error; err != nil {
+	if err := ec.DB.Preload("Creator").Preload("Community").Preload("Inventory.Inventory").Preload("Photos").Preload("Participants.User").First(&event, eventID).Error; err != nil {
+		return c.Status(404).JSON(EventResponse{
+			Success: false,
+			Message: "Ивент не найден",
+		})
+	}
+
+	// Ивент «только для участников» скрыт от посторонних
+	viewerID, _ := ec.getUserIDFromToken(c)
+	if visible, err := ec.CommunityEvents.CanView(&event, viewerID); err != nil || !visible {
 		return c.Status(404).JSON(EventResponse{
 			Success: false,
 			Message: "Ивент не найден",
 		})
 	}
 
+	// Сводка отзывов необязательна: ошибка не мешает показать ивент
+	reviews, _ := ec.Reviews.EventSummary(event.ID)
+	organizerReputation, _ := ec.Reviews.OrganizerSummary(event.CreatorID)
+
 	return c.JSON(EventResponse{
-		Success: true,
-		Message: "Ивент найден",
-		Event:   &event,
+		Success:             true,
+		Message:             "Ивент найден",
+		Event:               &event,
+		Reviews:             reviews,
+		OrganizerReputation: organizerReputation,
+	})
+}
+
+// GetCommunityEvents возвращает календарь ивентов сообщества за месяц или неделю
+func (ec *EventController) GetCommunityEvents(c *fiber.Ctx) error {
+	communityID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(400).JSON(EventsResponse{
+			Success: false,
+			Message: "Неверный ID сообщества",
+		})
+	}
+
+	var community models.Community
+	if err := ec.DB.First(&community, uint(communityID)).Error; err != nil {
+		return c.Status(404).JSON(EventsResponse{
+			Success: false,
+			Message: "Сообщество не найдено",
+		})
+	}
+
+	// Участники видят все ивенты сообщества, посторонние - только публичные
+	member := false
+	if viewerID, err := ec.getUserIDFromToken(c); err == nil {
+		allowed, err := ec.Permissions.HasCommunityPermission(viewerID, community.ID, models.PermissionCommunityView)
+		member = err == nil && allowed
+	}
+	if community.IsPrivate() && !member {
+		return c.Status(403).JSON(EventsResponse{
+			Success: false,
+			Message: "Сообщество закрыто",
+		})
+	}
+
+	date := time.Now()
+	if value := c.Query("date"); value != "" {
+		date, err = time.Parse("2006-01-02", value)
+		if err != nil {
+			return c.Status(400).JSON(EventsResponse{
+				Success: false,
+				Message: "Неверный формат даты (ожидается YYYY-MM-DD)",
+			})
+		}
+	}
+
+	view := c.Query("view", services.CalendarViewMonth)
+	from, to, err := services.CalendarRange(view, date)
+	if err != nil {
+		return c.Status(400).JSON(EventsResponse{
+			Success: false,
+			Message: "Неверный режим календаря (month или week)",
+		})
+	}
+
+	events, err := ec.CommunityEvents.ListCalendar(community.ID, from, to, member)
+	if err != nil {
+		return c.Status(500).JSON(EventsResponse{
+			Success: false,
+			Message: "Ошибка при получении календаря сообщества",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Календарь сообщества получен",
+		"view":    view,
+		"from":    from,
+		"to":      to,
+		"events":  events,
+		"total":   len(events),
 	})
 }
 
@@ -917,6 +1091,14 @@ func (ec *EventController) JoinEvent(c *fiber.Ctx) error {
 		})
 	}
 
+	// Ивент «только для участников» доступен лишь участникам сообщества
+	if visible, err := ec.CommunityEvents.CanView(&event, userID); err != nil || !visible {
+		return c.Status(403).JSON(fiber.Map{
+			"success": false,
+			"message": "Событие доступно только участникам сообщества",
+		})
+	}
+
 	// Проверяем, что событие еще не началось
 	if time.Now().After(event.StartTime) {
 		return c.Status(400).JSON(fiber.Map{
@@ -925,6 +1107,14 @@ func (ec *EventController) JoinEvent(c *fiber.Ctx) error {
 		})
 	}
 
+	// Создатель не может вступить в собственный ивент как участник
+	if event.CreatorID == userID {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Создатель события не может присоединиться к нему как участник",
+		})
+	}
+
 	// Проверяем, не присоединился ли уже пользователь
 	var existingParticipant models.EventParticipant
 	if err := ec.DB.Where("event_id = ? AND user_id = ?", eventID, userID).First(&existingParticipant).Error; err == nil {
@@ -1060,6 +1250,21 @@ func (ec *EventController) getUserIDFromToken(c *fiber.Ctx) (uint, error) {
 	return claims.UserID, nil
 }
 
+// canManageEvent проверяет, может ли пользователь управлять ивентом
+func (ec *EventController) canManageEvent(userID uint, event *models.Event) bool {
+	if event.CreatorID == userID {
+		return true
+	}
+	if event.CommunityID != nil {
+		allowed, err := ec.Permissions.HasCommunityPermission(userID, *event.CommunityID, models.PermissionCommunityEventsManage)
+		if err == nil && allowed {
+			return true
+		}
+	}
+	allowed, err := ec.Permissions.HasPermission(userID, models.PermissionEventsManage)
+	return err == nil && allowed
+}
+
 // validateCreateEventRequest валидирует запрос создания ивента
 func (ec *EventController) validateCreateEventRequest(req *CreateEventRequest) error {
 	if strings.TrimSpace(req.Title) == "" {
@@ -0,0 +1,250 @@
+package controllers
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"toloko-backend/models"
+	"toloko-backend/services"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// EventReviewController обрабатывает HTTP запросы для отзывов об ивентах и организаторах
+type EventReviewController struct {
+	db              *gorm.DB
+	reviews         *services.EventReviewService
+	communityEvents *services.CommunityEventService
+}
+
+// NewEventReviewController создает новый контроллер отзывов
+func NewEventReviewController(db *gorm.DB) *EventReviewController {
+	return &EventReviewController{
+		db:              db,
+		reviews:         services.NewEventReviewService(db),
+		communityEvents: services.NewCommunityEventService(db),
+	}
+}
+
+// ReplyReviewRequest структура запроса ответа организатора на отзыв
+type ReplyReviewRequest struct {
+	Reply string `json:"reply"`
+}
+
+// SubmitReview оставляет отзыв об ивенте после его завершения
+func (c *EventReviewController) SubmitReview(ctx *fiber.Ctx) error {
+	userID := ctx.Locals("user_id").(uint)
+
+	event, ferr := c.loadEvent(ctx)
+	if ferr != nil {
+		return ctx.Status(ferr.Code).JSON(fiber.Map{
+			"error": ferr.Message,
+		})
+	}
+
+	var req services.ReviewInput
+	if err := ctx.BodyParser(&req); err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	req.Text = strings.TrimSpace(req.Text)
+	if req.Score < 1 || req.Score > 10 {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Score must be between 1 and 10",
+		})
+	}
+	for _, aspect := range []int{req.Organization, req.Safety, req.MatchedDescription} {
+		if aspect < 1 || aspect > 5 {
+			return ctx.Status(400).JSON(fiber.Map{
+				"error": "Organization, safety and matched_description must be between 1 and 5",
+			})
+		}
+	}
+	if len([]rune(req.Text)) > 2000 {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Review text must not exceed 2000 characters",
+		})
+	}
+
+	review, err := c.reviews.Submit(event, userID, req)
+	if err != nil {
+		return c.reviewError(ctx, err, "Failed to save review")
+	}
+
+	return ctx.Status(201).JSON(fiber.Map{
+		"success": true,
+		"review":  review,
+	})
+}
+
+// GetEventReviews возвращает отзывы об ивенте и их сводку
+func (c *EventReviewController) GetEventReviews(ctx *fiber.Ctx) error {
+	userID := ctx.Locals("user_id").(uint)
+
+	event, ferr := c.loadEvent(ctx)
+	if ferr != nil {
+		return ctx.Status(ferr.Code).JSON(fiber.Map{
+			"error": ferr.Message,
+		})
+	}
+	if visible, err := c.communityEvents.CanView(event, userID); err != nil || !visible {
+		return ctx.Status(404).JSON(fiber.Map{
+			"error": "Event not found",
+		})
+	}
+
+	page, limit := reviewPagination(ctx)
+	reviews, total, err := c.reviews.ListByEvent(event.ID, page, limit)
+	if err != nil {
+		return ctx.Status(500).JSON(fiber.Map{
+			"error": "Failed to get reviews",
+		})
+	}
+	summary, err := c.reviews.EventSummary(event.ID)
+	if err != nil {
+		return ctx.Status(500).JSON(fiber.Map{
+			"error": "Failed to get reviews",
+		})
+	}
+
+	return ctx.JSON(fiber.Map{
+		"success": true,
+		"reviews": reviews,
+		"summary": summary,
+		"pagination": fiber.Map{
+			"page":        page,
+			"limit":       limit,
+			"total_count": total,
+			"total_pages": (total + int64(limit) - 1) / int64(limit),
+		},
+	})
+}
+
+// ReplyToReview сохраняет ответ организатора на отзыв
+func (c *EventReviewController) ReplyToReview(ctx *fiber.Ctx) error {
+	userID := ctx.Locals("user_id").(uint)
+
+	event, ferr := c.loadEvent(ctx)
+	if ferr != nil {
+		return ctx.Status(ferr.Code).JSON(fiber.Map{
+			"error": ferr.Message,
+		})
+	}
+	reviewID, err := strconv.ParseUint(ctx.Params("reviewId"), 10, 32)
+	if err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Invalid review ID",
+		})
+	}
+
+	var req ReplyReviewRequest
+	if err := ctx.BodyParser(&req); err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	req.Reply = strings.TrimSpace(req.Reply)
+	if req.Reply == "" || len([]rune(req.Reply)) > 2000 {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Reply must be between 1 and 2000 characters",
+		})
+	}
+
+	review, err := c.reviews.Reply(event, userID, uint(reviewID), req.Reply)
+	if err != nil {
+		return c.reviewError(ctx, err, "Failed to save reply")
+	}
+
+	return ctx.JSON(fiber.Map{
+		"success": true,
+		"review":  review,
+	})
+}
+
+// GetOrganizerReviews возвращает отзывы об ивентах организатора и его репутацию
+func (c *EventReviewController) GetOrganizerReviews(ctx *fiber.Ctx) error {
+	userID := ctx.Locals("user_id").(uint)
+	organizerID, err := strconv.ParseUint(ctx.Params("id"), 10, 32)
+	if err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	page, limit := reviewPagination(ctx)
+	reviews, total, err := c.reviews.ListByOrganizer(uint(organizerID), userID, page, limit)
+	if err != nil {
+		return ctx.Status(500).JSON(fiber.Map{
+			"error": "Failed to get reviews",
+		})
+	}
+	summary, err := c.reviews.OrganizerSummary(uint(organizerID))
+	if err != nil {
+		return ctx.Status(500).JSON(fiber.Map{
+			"error": "Failed to get reviews",
+		})
+	}
+
+	return ctx.JSON(fiber.Map{
+		"success": true,
+		"reviews": reviews,
+		"summary": summary,
+		"pagination": fiber.Map{
+			"page":        page,
+			"limit":       limit,
+			"total_count": total,
+			"total_pages": (total + int64(limit) - 1) / int64(limit),
+		},
+	})
+}
+
+// loadEvent загружает ивент из параметра маршрута
+func (c *EventReviewController) loadEvent(ctx *fiber.Ctx) (*models.Event, *fiber.Error) {
+	eventID, err := strconv.ParseUint(ctx.Params("id"), 10, 32)
+	if err != nil {
+		return nil, fiber.NewError(400, "Invalid event ID")
+	}
+
+	var event models.Event
+	if err := c.db.First(&event, eventID).Error; err != nil {
+		return nil, fiber.NewError(404, "Event not found")
+	}
+	return &event, nil
+}
+
+// reviewPagination разбирает параметры страницы списка отзывов
+func reviewPagination(ctx *fiber.Ctx) (int, int) {
+	page := ctx.QueryInt("page", 1)
+	if page < 1 {
+		page = 1
+	}
+	limit := ctx.QueryInt("limit", 20)
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	return page, limit
+}
+
+// reviewError преобразует ошибку сервиса отзывов в HTTP ответ
+func (c *EventReviewController) reviewError(ctx *fiber.Ctx, err error, fallback string) error {
+	status := 500
+	message := fallback
+	switch {
+	case errors.Is(err, services.ErrEventNotCompleted):
+		status, message = 400, "Event is not completed yet"
+	case errors.Is(err, services.ErrReviewNotAllowed):
+		status, message = 403, "Only attended participants can review the event"
+	case errors.Is(err, services.ErrReviewExists):
+		status, message = 409, "You have already reviewed this event"
+	case errors.Is(err, services.ErrReviewNotFound):
+		status, message = 404, "Review not found"
+	case errors.Is(err, services.ErrNotEventOrganizer):
+		status, message = 403, "Only the event organizer can reply to reviews"
+	}
+	return ctx.Status(status).JSON(fiber.Map{
+		"error": message,
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"toloko-backend/models"
+	"toloko-backend/services"
 	"toloko-backend/utils"
 
 	"github.com/gofiber/fiber/v2"
@@ -13,12 +14,16 @@ import (
 
 // FeedController контроллер для управления лентой событий
 type FeedController struct {
-	DB *gorm.DB
+	DB              *gorm.DB
+	CommunityEvents *services.CommunityEventService
 }
 
 // NewFeedController создает новый экземпляр FeedController
 func NewFeedController(db *gorm.DB) *FeedController {
-	return &FeedController{DB: db}
+	return &FeedController{
+		DB:              db,
+		CommunityEvents: services.NewCommunityEventService(db),
+	}
 }
 
 // FeedResponse структура ответа ленты
@@ -55,8 +60,8 @@ func (fc *FeedController) GetFeed(c *fiber.Ctx) error {
 		})
 	}
 
-	// Если пользователь ни на кого не подписан, возвращаем пустую ленту
-	if len(subscriptionIDs) == 0 {
+	// Если пользователь ни на кого не подписан и не состоит в сообществах, возвращаем пустую ленту
+	if len(subscriptionIDs) == 0 && !fc.hasMemberships(userID) {
 		return c.JSON(FeedResponse{
 			Success: true,
 			Message: "Лента пуста - вы ни на кого не подписаны",
@@ -69,13 +74,14 @@ func (fc *FeedController) GetFeed(c *fiber.Ctx) error {
 		})
 	}
 
-	// Получаем события от подписанных пользователей
+	// Получаем события от подписанных пользователей и сообществ пользователя
 	var events []models.Event
 	var total int64
+	sources := fc.feedSources(userID, subscriptionIDs)
 
 	// Подсчитываем общее количество событий
-	fc.DB.Model(&models.Event{}).
-		Where("creator_id IN ? AND is_active = ?", subscriptionIDs, true).
+	fc.DB.Model(&models.Event{}).Scopes(sources...).
+		Where("is_active = ?", true).
 		Count(&total)
 
 	// Получаем события с пагинацией
@@ -84,7 +90,8 @@ func (fc *FeedController) GetFeed(c *fiber.Ctx) error {
 		Preload("Photos").
 		Preload("Inventory.Inventory").
 		Preload("Participants.User").
-		Where("creator_id IN ? AND is_active = ?", subscriptionIDs, true).
+		Scopes(sources...).
+		Where("is_active = ?", true).
 		Order("start_time ASC").
 		Limit(limit).
 		Offset(offset).
@@ -120,6 +127,53 @@ func (fc *FeedController) GetFeed(c *fiber.Ctx) error {
 	})
 }
 
+// GetActivities возвращает ленту активности: новые уровни и другие события текущего пользователя и его подписок
+func (fc *FeedController) GetActivities(c *fiber.Ctx) error {
+	// Получаем ID пользователя из JWT токена
+	userID, err := fc.getUserIDFromToken(c)
+	if err != nil {
+		return c.Status(401).JSON(FeedResponse{
+			Success: false,
+			Message: "Необходима авторизация",
+		})
+	}
+
+	page, limit := fc.getPaginationParams(c)
+
+	subscriptions := fc.DB.Model(&models.Subscription{}).Select("subscribed_to_id").Where("subscriber_id = ?", userID)
+	query := fc.DB.Model(&models.FeedActivity{}).Where("user_id = ? OR user_id IN (?)", userID, subscriptions)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return c.Status(500).JSON(FeedResponse{
+			Success: false,
+			Message: "Ошибка при получении ленты активности",
+		})
+	}
+
+	var activities []models.FeedActivity
+	if err := query.Session(&gorm.Session{}).Preload("User").
+		Order("created_at DESC, id DESC").
+		Limit(limit).Offset((page - 1) * limit).
+		Find(&activities).Error; err != nil {
+		return c.Status(500).JSON(FeedResponse{
+			Success: false,
+			Message: "Ошибка при получении ленты активности",
+		})
+	}
+
+	return c.JSON(FeedResponse{
+		Success: true,
+		Message: "Лента активности получена успешно",
+		Data: fiber.Map{
+			"activities": activities,
+			"total":      total,
+			"page":       page,
+			"limit":      limit,
+		},
+	})
+}
+
 // GetFeedWithFilters возвращает ленту с дополнительными фильтрами
 func (fc *FeedController) GetFeedWithFilters(c *fiber.Ctx) error {
 	// Получаем ID пользователя из JWT токена
@@ -151,8 +205,8 @@ func (fc *FeedController) GetFeedWithFilters(c *fiber.Ctx) error {
 		})
 	}
 
-	// Если пользователь ни на кого не подписан, возвращаем пустую ленту
-	if len(subscriptionIDs) == 0 {
+	// Если пользователь ни на кого не подписан и не состоит в сообществах, возвращаем пустую ленту
+	if len(subscriptionIDs) == 0 && !fc.hasMemberships(userID) {
 		return c.JSON(FeedResponse{
 			Success: true,
 			Message: "Лента пуста - вы ни на кого не подписаны",
@@ -167,7 +221,8 @@ func (fc *FeedController) GetFeedWithFilters(c *fiber.Ctx) error {
 
 	// Строим запрос с фильтрами
 	query := fc.DB.Model(&models.Event{}).
-		Where("creator_id IN ? AND is_active = ?", subscriptionIDs, true)
+		Scopes(fc.feedSources(userID, subscriptionIDs)...).
+		Where("is_active = ?", true)
 
 	// Применяем фильтр по статусу
 	now := time.Now()
@@ -265,6 +320,7 @@ func (fc *FeedController) GetRecommendedEvents(c *fiber.Ctx) error {
 
 	// Подсчитываем общее количество рекомендуемых событий
 	fc.DB.Model(&models.Event{}).
+		Scopes(fc.CommunityEvents.VisibleTo(userID)).
 		Where("creator_id NOT IN ? AND is_active = ? AND start_time > ?", excludeIDs, true, time.Now()).
 		Count(&total)
 
@@ -274,6 +330,7 @@ func (fc *FeedController) GetRecommendedEvents(c *fiber.Ctx) error {
 		Preload("Photos").
 		Preload("Inventory.Inventory").
 		Preload("Participants.User").
+		Scopes(fc.CommunityEvents.VisibleTo(userID)).
 		Where("creator_id NOT IN ? AND is_active = ? AND start_time > ?", excludeIDs, true, time.Now()).
 		Order("start_time ASC").
 		Limit(limit).
@@ -387,6 +444,21 @@ func (fc *FeedController) getUserIDFromToken(c *fiber.Ctx) (uint, error) {
 	return claims.UserID, nil
 }
 
+// feedSources возвращает scopes ленты: ивенты подписок и сообществ пользователя, доступные ему
+func (fc *FeedController) feedSources(userID uint, subscriptionIDs []uint) []func(*gorm.DB) *gorm.DB {
+	return []func(*gorm.DB) *gorm.DB{
+		fc.CommunityEvents.FromFollowed(userID, subscriptionIDs),
+		fc.CommunityEvents.VisibleTo(userID),
+	}
+}
+
+// hasMemberships проверяет, состоит ли пользователь хотя бы в одном сообществе
+func (fc *FeedController) hasMemberships(userID uint) bool {
+	var count int64
+	fc.DB.Model(&models.CommunityRole{}).Where("user_id = ?", userID).Count(&count)
+	return count > 0
+}
+
 // getPaginationParams извлекает параметры пагинации из запроса
 func (fc *FeedController) getPaginationParams(c *fiber.Ctx) (int, int) {
 	page := 1
@@ -1,9 +1,12 @@
 package controllers
 
 import (
+	"errors"
 	"strconv"
+	"strings"
 
 	"toloko-backend/models"
+	"toloko-backend/services"
 	"toloko-backend/utils"
 
 	"github.com/gofiber/fiber/v2"
@@ -12,12 +15,22 @@ import (
 
 // LevelController контроллер для управления уровнями пользователей
 type LevelController struct {
-	db *gorm.DB
+	db          *gorm.DB
+	permissions *services.PermissionService
+	points      *services.PointsService
+	levels      *services.LevelService
+	rankings    *services.LeaderboardService
 }
 
 // NewLevelController создает новый экземпляр LevelController
 func NewLevelController(db *gorm.DB) *LevelController {
-	return &LevelController{db: db}
+	return &LevelController{
+		db:          db,
+		permissions: services.NewPermissionService(db),
+		points:      services.NewPointsService(db),
+		levels:      services.NewLevelService(db),
+		rankings:    services.NewLeaderboardService(db),
+	}
 }
 
 // GetUserLevel получает уровень пользователя
@@ -48,7 +61,7 @@ func (lc *LevelController) GetUserLevel(c *fiber.Ctx) error {
 	}
 
 	// Проверяем, что пользователь запрашивает свой уровень или имеет права
-	if claims.UserID != uint(userID) {
+	if claims.UserID != uint(userID) && !lc.hasPermission(claims.UserID, models.PermissionUsersView) {
 		return c.Status(403).JSON(fiber.Map{
 			"error":   true,
 			"message": "Можно просматривать только свой уровень",
@@ -79,12 +92,24 @@ func (lc *LevelController) GetUserLevel(c *fiber.Ctx) error {
 		}
 	}
 
-	// Вычисляем прогресс до следующего уровня
-	currentLevelPoints := (userLevel.Level - 1) * 100
-	nextLevelPoints := userLevel.Level * 100
+	// Вычисляем прогресс до следующего уровня по кривой уровней
+	curve, err := lc.levels.Curve()
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error":   true,
+			"message": "Ошибка при получении кривой уровней",
+		})
+	}
+	current := services.LevelFor(curve, userLevel.Points)
+	currentLevelPoints := current.Threshold
 	progressPoints := userLevel.Points - currentLevelPoints
-	pointsToNextLevel := nextLevelPoints - userLevel.Points
-	progressPercentage := float64(progressPoints) / float64(100) * 100
+	var nextLevelPoints, pointsToNextLevel interface{}
+	progressPercentage := float64(100)
+	if next := services.NextLevel(curve, current.Level); next != nil {
+		nextLevelPoints = next.Threshold
+		pointsToNextLevel = next.Threshold - userLevel.Points
+		progressPercentage = float64(progressPoints) / float64(next.Threshold-current.Threshold) * 100
+	}
 
 	// Получаем статистику пользователя
 	var stats struct {
@@ -104,6 +129,7 @@ func (lc *LevelController) GetUserLevel(c *fiber.Ctx) error {
 
 	response := fiber.Map{
 		"user_level": userLevel,
+		"rank":       current,
 		"progress": fiber.Map{
 			"current_level_points": currentLevelPoints,
 			"next_level_points":    nextLevelPoints,
@@ -139,14 +165,25 @@ func (lc *LevelController) GetLeaderboard(c *fiber.Ctx) error {
 	var totalCount int64
 	lc.db.Model(&models.UserLevel{}).Count(&totalCount)
 
+	curve, err := lc.levels.Curve()
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error":   true,
+			"message": "Ошибка при получении кривой уровней",
+		})
+	}
+
 	// Формируем ответ
 	var leaderboard []fiber.Map
 	for i, ul := range userLevels {
+		level := services.LevelFor(curve, ul.Points)
 		leaderboard = append(leaderboard, fiber.Map{
-			"rank":   offset + i + 1,
-			"user":   ul.User,
-			"level":  ul.Level,
-			"points": ul.Points,
+			"rank":      offset + i + 1,
+			"user":      ul.User,
+			"level":     ul.Level,
+			"title":     level.Title,
+			"icon_path": level.IconPath,
+			"points":    ul.Points,
 		})
 	}
 
@@ -163,16 +200,161 @@ func (lc *LevelController) GetLeaderboard(c *fiber.Ctx) error {
 	})
 }
 
-// AddPoints добавляет очки пользователю
-func (lc *LevelController) AddPoints(c *fiber.Ctx) error {
-	userID, err := strconv.ParseUint(c.Params("id"), 10, 32)
-	if err != nil {
+// GetRankings получает таблицу лидеров за период в разрезе города, сообщества или типа ивента.
+// Таблицы пересчитываются периодически, поэтому в ответе есть время последнего пересчета.
+// Авторизованный пользователь получает свое место, даже если оно не попало на страницу.
+func (lc *LevelController) GetRankings(c *fiber.Ctx) error {
+	period := c.Query("period", models.LeaderboardPeriodAll)
+	if !models.IsValidLeaderboardPeriod(period) {
 		return c.Status(400).JSON(fiber.Map{
 			"error":   true,
-			"message": "Неверный ID пользователя",
+			"message": "Неверный период: допустимы week, month, year, all",
+		})
+	}
+
+	scope := c.Query("scope", models.LeaderboardScopeGlobal)
+	if !models.IsValidLeaderboardScope(scope) {
+		return c.Status(400).JSON(fiber.Map{
+			"error":   true,
+			"message": "Неверный срез: допустимы global, city, event_city, community, event_type",
+		})
+	}
+
+	// Необязательная авторизация: нужна для своего места и закрытых сообществ
+	var viewerID uint
+	if token := c.Get("Authorization"); token != "" {
+		if claims, err := utils.ValidateJWT(token); err == nil {
+			viewerID = claims.UserID
+		}
+	}
+
+	var key string
+	switch scope {
+	case models.LeaderboardScopeCity, models.LeaderboardScopeEventCity:
+		key = strings.TrimSpace(c.Query("city"))
+		if key == "" {
+			return c.Status(400).JSON(fiber.Map{
+				"error":   true,
+				"message": "Не указан город",
+			})
+		}
+	case models.LeaderboardScopeEventType:
+		key = c.Query("event_type")
+		if _, ok := models.GetEventTypes()[key]; !ok {
+			return c.Status(400).JSON(fiber.Map{
+				"error":   true,
+				"message": "Неверный тип ивента",
+			})
+		}
+	case models.LeaderboardScopeCommunity:
+		communityID, err := strconv.ParseUint(c.Query("community_id"), 10, 32)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{
+				"error":   true,
+				"message": "Неверный ID сообщества",
+			})
+		}
+
+		var community models.Community
+		if err := lc.db.First(&community, communityID).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return c.Status(404).JSON(fiber.Map{
+					"error":   true,
+					"message": "Сообщество не найдено",
+				})
+			}
+			return c.Status(500).JSON(fiber.Map{
+				"error":   true,
+				"message": "Ошибка при получении сообщества",
+			})
+		}
+
+		// Рейтинг закрытого сообщества видят только его участники
+		if community.IsPrivate() && !lc.canViewCommunity(viewerID, community.ID) {
+			return c.Status(403).JSON(fiber.Map{
+				"error":   true,
+				"message": "Нет доступа к закрытому сообществу",
+			})
+		}
+		key = strconv.FormatUint(communityID, 10)
+	}
+
+	page := c.QueryInt("page", 1)
+	limit := c.QueryInt("limit", 10)
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+
+	entries, total, err := lc.rankings.Page(period, scope, key, page, limit)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error":   true,
+			"message": "Ошибка при получении таблицы лидеров",
 		})
 	}
 
+	var me *models.LeaderboardEntry
+	if viewerID != 0 {
+		if me, err = lc.rankings.UserRank(period, scope, key, viewerID); err != nil {
+			return c.Status(500).JSON(fiber.Map{
+				"error":   true,
+				"message": "Ошибка при получении места пользователя",
+			})
+		}
+	}
+
+	var refreshedAt interface{}
+	if len(entries) > 0 {
+		refreshedAt = entries[0].RefreshedAt
+	} else if me != nil {
+		refreshedAt = me.RefreshedAt
+	}
+
+	return c.JSON(fiber.Map{
+		"period":       period,
+		"scope":        scope,
+		"scope_key":    services.NormalizeLeaderboardKey(scope, key),
+		"entries":      entries,
+		"me":           me,
+		"refreshed_at": refreshedAt,
+		"pagination": fiber.Map{
+			"page":        page,
+			"limit":       limit,
+			"total_count": total,
+			"total_pages": (total + int64(limit) - 1) / int64(limit),
+		},
+		"error":   false,
+		"message": "Таблица лидеров получена успешно",
+	})
+}
+
+// UpdateLevelDefinitionsRequest структура запроса замены кривой уровней
+type UpdateLevelDefinitionsRequest struct {
+	Levels []models.LevelDefinition `json:"levels"`
+}
+
+// GetLevelDefinitions получает кривую уровней: пороги очков, звания и иконки
+func (lc *LevelController) GetLevelDefinitions(c *fiber.Ctx) error {
+	curve, err := lc.levels.Curve()
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error":   true,
+			"message": "Ошибка при получении кривой уровней",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"levels":  curve,
+		"error":   false,
+		"message": "Кривая уровней получена успешно",
+	})
+}
+
+// UpdateLevelDefinitions заменяет кривую уровней и пересчитывает уровни всех пользователей
+func (lc *LevelController) UpdateLevelDefinitions(c *fiber.Ctx) error {
 	// Проверяем авторизацию
 	token := c.Get("Authorization")
 	if token == "" {
@@ -190,77 +372,120 @@ func (lc *LevelController) AddPoints(c *fiber.Ctx) error {
 		})
 	}
 
-	// Проверяем, что пользователь добавляет очки себе или имеет права
-	if claims.UserID != uint(userID) {
+	if !lc.hasPermission(claims.UserID, models.PermissionPointsManage) {
 		return c.Status(403).JSON(fiber.Map{
 			"error":   true,
-			"message": "Можно добавлять очки только себе",
+			"message": "Недостаточно прав для изменения уровней",
 		})
 	}
 
-	var requestData struct {
-		Points int    `json:"points"`
-		Reason string `json:"reason"`
-	}
-
-	if err := c.BodyParser(&requestData); err != nil {
+	var req UpdateLevelDefinitionsRequest
+	if err := c.BodyParser(&req); err != nil {
 		return c.Status(400).JSON(fiber.Map{
 			"error":   true,
 			"message": "Неверный формат данных",
 		})
 	}
 
-	if requestData.Points <= 0 {
+	changed, err := lc.levels.ReplaceCurve(req.Levels)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidLevelCurve) {
+			return c.Status(400).JSON(fiber.Map{
+				"error":   true,
+				"message": "Неверная кривая уровней: уровни должны идти по порядку с первого, первый уровень - с 0 очков, пороги - возрастать, у каждого уровня должно быть звание",
+			})
+		}
+		return c.Status(500).JSON(fiber.Map{
+			"error":   true,
+			"message": "Ошибка при сохранении кривой уровней",
+		})
+	}
+
+	curve, _ := lc.levels.Curve()
+	return c.JSON(fiber.Map{
+		"levels":        curve,
+		"users_updated": changed,
+		"error":         false,
+		"message":       "Кривая уровней обновлена",
+	})
+}
+
+// GetPointsHistory получает журнал начисления очков пользователя
+func (lc *LevelController) GetPointsHistory(c *fiber.Ctx) error {
+	userID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
 		return c.Status(400).JSON(fiber.Map{
 			"error":   true,
-			"message": "Количество очков должно быть положительным",
+			"message": "Неверный ID пользователя",
 		})
 	}
 
-	// Получаем или создаем уровень пользователя
-	var userLevel models.UserLevel
-	if err := lc.db.Where("user_id = ?", userID).First(&userLevel).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			userLevel = models.UserLevel{
-				UserID: uint(userID),
-				Level:  1,
-				Points: requestData.Points,
-			}
-			if err := lc.db.Create(&userLevel).Error; err != nil {
-				return c.Status(500).JSON(fiber.Map{
-					"error":   true,
-					"message": "Ошибка при создании уровня пользователя",
-				})
-			}
-		} else {
-			return c.Status(500).JSON(fiber.Map{
-				"error":   true,
-				"message": "Ошибка при получении уровня пользователя",
-			})
-		}
-	} else {
-		// Обновляем существующий уровень
-		userLevel.Points += requestData.Points
-
-		// Проверяем, нужно ли повысить уровень
-		newLevel := (userLevel.Points / 100) + 1
-		if newLevel > userLevel.Level {
-			userLevel.Level = newLevel
-		}
+	// Проверяем авторизацию
+	token := c.Get("Authorization")
+	if token == "" {
+		return c.Status(401).JSON(fiber.Map{
+			"error":   true,
+			"message": "Необходима авторизация",
+		})
+	}
 
-		if err := lc.db.Save(&userLevel).Error; err != nil {
-			return c.Status(500).JSON(fiber.Map{
-				"error":   true,
-				"message": "Ошибка при обновлении уровня пользователя",
-			})
-		}
+	claims, err := utils.ValidateJWT(token)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{
+			"error":   true,
+			"message": "Неверный токен авторизации",
+		})
+	}
+
+	// Проверяем, что пользователь запрашивает свой журнал или имеет права
+	if claims.UserID != uint(userID) && !lc.hasPermission(claims.UserID, models.PermissionPointsManage) {
+		return c.Status(403).JSON(fiber.Map{
+			"error":   true,
+			"message": "Можно просматривать только свой журнал очков",
+		})
+	}
+
+	page := c.QueryInt("page", 1)
+	limit := c.QueryInt("limit", 20)
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	entries, total, err := lc.points.History(uint(userID), page, limit)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error":   true,
+			"message": "Ошибка при получении журнала очков",
+		})
 	}
 
 	return c.JSON(fiber.Map{
-		"user_level":   userLevel,
-		"added_points": requestData.Points,
-		"reason":       requestData.Reason,
-		"error":        false,
-		"message":      "Очки успешно добавлены",
+		"entries": entries,
+		"pagination": fiber.Map{
+			"page":        page,
+			"limit":       limit,
+			"total_count": total,
+			"total_pages": (total + int64(limit) - 1) / int64(limit),
+		},
+		"error":   false,
+		"message": "Журнал очков получен успешно",
 	})
 }
+
+// canViewCommunity проверяет, может ли пользователь (если он авторизован) видеть закрытое сообщество
+func (lc *LevelController) canViewCommunity(userID, communityID uint) bool {
+	if userID == 0 {
+		return false
+	}
+	allowed, err := lc.permissions.HasCommunityPermission(userID, communityID, models.PermissionCommunityView)
+	return err == nil && allowed
+}
+
+// hasPermission проверяет, есть ли у пользователя разрешение платформы
+func (lc *LevelController) hasPermission(userID uint, permission string) bool {
+	allowed, err := lc.permissions.HasPermission(userID, permission)
+	return err == nil && allowed
+}
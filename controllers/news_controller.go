@@ -1,10 +1,15 @@
 package controllers
 
 import (
+	"errors"
+	"log"
 	"strconv"
 	"strings"
+	"time"
+	"unicode/utf8"
 
 	"toloko-backend/models"
+	"toloko-backend/services"
 	"toloko-backend/utils"
 
 	"github.com/gofiber/fiber/v2"
@@ -13,24 +18,47 @@ import (
 
 // NewsController контроллер для работы с новостями
 type NewsController struct {
-	DB *gorm.DB
+	DB           *gorm.DB
+	Permissions  *services.PermissionService
+	News         *services.NewsService
+	Media        *services.NewsMediaService
+	Mentions     *services.MentionService
+	Moderation   *services.CommunityModerationService
+	Achievements *services.AchievementService
 }
 
 // NewNewsController создает новый экземпляр NewsController
 func NewNewsController(db *gorm.DB) *NewsController {
-	return &NewsController{DB: db}
+	return &NewsController{
+		DB:           db,
+		Permissions:  services.NewPermissionService(db),
+		News:         services.NewNewsService(db),
+		Media:        services.NewNewsMediaService(db),
+		Mentions:     services.NewMentionService(db),
+		Moderation:   services.NewCommunityModerationService(db),
+		Achievements: services.NewAchievementService(db),
+	}
 }
 
 // CreateNewsRequest структура запроса создания новости
 type CreateNewsRequest struct {
 	Content   string `json:"content" validate:"required,min=10,max=2000"`
 	PhotoPath string `json:"photo_path" validate:"max=255"`
+	Status    string `json:"status" validate:"omitempty,oneof=draft scheduled published"` // по умолчанию published
+	PublishAt string `json:"publish_at"`                                                  // RFC3339, обязательно для scheduled
 }
 
 // UpdateNewsRequest структура запроса обновления новости
 type UpdateNewsRequest struct {
 	Content   string `json:"content" validate:"min=10,max=2000"`
 	PhotoPath string `json:"photo_path" validate:"max=255"`
+	Status    string `json:"status" validate:"omitempty,oneof=draft scheduled published"`
+	PublishAt string `json:"publish_at"`
+}
+
+// DeleteContentRequest структура запроса удаления новости или комментария
+type DeleteContentRequest struct {
+	Reason string `json:"reason" validate:"max=500"` // причина удаления модератором, видна автору
 }
 
 // NewsResponse структура ответа с новостью
@@ -48,6 +76,40 @@ type NewsListResponse struct {
 	Total   int64         `json:"total,omitempty"`
 }
 
+// UpdateNewsMediaRequest структура запроса изменения подписи изображения
+type UpdateNewsMediaRequest struct {
+	Caption string `json:"caption" validate:"max=500"`
+}
+
+// ReorderNewsMediaRequest структура запроса изменения порядка изображений
+type ReorderNewsMediaRequest struct {
+	MediaIDs []uint `json:"media_ids" validate:"required"` // все изображения новости в новом порядке
+}
+
+// NewsMediaResponse структура ответа с изображениями новости
+type NewsMediaResponse struct {
+	Success bool               `json:"success"`
+	Message string             `json:"message"`
+	Data    []models.NewsMedia `json:"data,omitempty"`
+}
+
+// maxNewsMediaCaptionLength максимальная длина подписи изображения
+const maxNewsMediaCaptionLength = 500
+
+// NewsPreview предпросмотр новости перед публикацией
+type NewsPreview struct {
+	News         *models.News        `json:"news"`
+	Notification models.Notification `json:"notification"` // уведомление, которое получат участники
+	Recipients   int                 `json:"recipients"`   // количество получателей уведомления
+}
+
+// NewsPreviewResponse структура ответа с предпросмотром новости
+type NewsPreviewResponse struct {
+	Success bool         `json:"success"`
+	Message string       `json:"message"`
+	Data    *NewsPreview `json:"data,omitempty"`
+}
+
 // CreateNews создает новую новость в сообществе
 func (nc *NewsController) CreateNews(c *fiber.Ctx) error {
 	// Получаем пользователя из JWT токена
@@ -107,6 +169,27 @@ func (nc *NewsController) CreateNews(c *fiber.Ctx) error {
 		})
 	}
 
+	// Заблокированные и временно ограниченные пользователи не могут публиковать
+	if err := nc.Moderation.CheckCanParticipate(uint(communityID), userID); err != nil {
+		status, message := sanctionError(err)
+		return c.Status(status).JSON(NewsResponse{
+			Success: false,
+			Message: message,
+		})
+	}
+
+	status := req.Status
+	if status == "" {
+		status = models.NewsStatusPublished
+	}
+	publishAt, err := parsePublishAt(status, req.PublishAt)
+	if err != nil {
+		return c.Status(400).JSON(NewsResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+	}
+
 	// Создаем новость
 	news := models.News{
 		CommunityID: uint(communityID),
@@ -114,6 +197,8 @@ func (nc *NewsController) CreateNews(c *fiber.Ctx) error {
 		Content:     strings.TrimSpace(req.Content),
 		PhotoPath:   strings.TrimSpace(req.PhotoPath),
 		LikesCount:  0,
+		Status:      status,
+		PublishAt:   publishAt,
 	}
 
 	if err := nc.DB.Create(&news).Error; err != nil {
@@ -123,8 +208,21 @@ func (nc *NewsController) CreateNews(c *fiber.Ctx) error {
 		})
 	}
 
+	// Разбираем упоминания (упомянутые в черновике получат уведомление при публикации)
+	if err := nc.Mentions.SyncNews(&news); err != nil {
+		log.Printf("Ошибка обработки упоминаний в новости %d: %v", news.ID, err)
+	}
+
+	// Уведомляем участников сообщества о сразу опубликованной новости
+	if news.IsPublished() {
+		if err := nc.News.NotifyPublished(&news); err != nil {
+			log.Printf("Ошибка рассылки уведомлений о новости %d: %v", news.ID, err)
+		}
+		nc.Achievements.Track(userID, models.AchievementEventNewsPublished)
+	}
+
 	// Загружаем новость с автором и сообществом
-	nc.DB.Preload("Author").Preload("Community").First(&news, news.ID)
+	nc.DB.Preload("Author").Preload("Community").Preload("Media", services.OrderedNewsMedia).Preload("Mentions").First(&news, news.ID)
 
 	return c.Status(201).JSON(NewsResponse{
 		Success: true,
@@ -172,15 +270,43 @@ func (nc *NewsController) GetNews(c *fiber.Ctx) error {
 		})
 	}
 
+	viewerID, err := nc.getUserIDFromToken(c)
+	authenticated := err == nil
+	var scopes []func(*gorm.DB) *gorm.DB
+
+	// Анонсы ивентов «только для участников» видят только участники сообщества
+	if !authenticated || !nc.isCommunityMember(viewerID, uint(communityID)) {
+		scopes = append(scopes, services.ExcludeMembersOnlyAnnouncements)
+	}
+
+	// Черновики и отложенные новости видят только редакторы (?status=draft|scheduled|published|all)
+	status := models.NewsStatusPublished
+	if authenticated && nc.canManageNews(viewerID, uint(communityID)) {
+		status = c.Query("status", models.NewsStatusPublished)
+	}
+	switch status {
+	case "all":
+	case models.NewsStatusDraft, models.NewsStatusScheduled, models.NewsStatusPublished:
+		scopes = append(scopes, func(db *gorm.DB) *gorm.DB { return db.Where("status = ?", status) })
+	default:
+		return c.Status(400).JSON(NewsListResponse{
+			Success: false,
+			Message: "Неверный статус новостей",
+		})
+	}
+
 	// Получаем общее количество новостей
 	var total int64
-	nc.DB.Model(&models.News{}).Where("community_id = ?", communityID).Count(&total)
+	nc.DB.Model(&models.News{}).Where("community_id = ?", communityID).Scopes(scopes...).Count(&total)
 
 	// Получаем новости
 	var news []models.News
-	query := nc.DB.Where("community_id = ?", communityID).
+	query := nc.DB.Where("community_id = ?", communityID).Scopes(scopes...).
 		Preload("Author").
 		Preload("Community").
+		Preload("Event").
+		Preload("Media", services.OrderedNewsMedia).
+		Preload("Mentions").
 		Preload("Comments", func(db *gorm.DB) *gorm.DB {
 			return db.Preload("Author").Where("parent_id IS NULL").Order("created_at ASC")
 		}).
@@ -188,12 +314,15 @@ func (nc *NewsController) GetNews(c *fiber.Ctx) error {
 			return db.Preload("Author").Order("created_at ASC")
 		})
 
-	if err := query.Offset(offset).Limit(limit).Order("created_at DESC").Find(&news).Error; err != nil {
+	if err := query.Offset(offset).Limit(limit).Order("COALESCE(published_at, created_at) DESC, id DESC").Find(&news).Error; err != nil {
 		return c.Status(500).JSON(NewsListResponse{
 			Success: false,
 			Message: "Ошибка при получении новостей",
 		})
 	}
+	for i := range news {
+		news[i].HideDeletedCommentAuthors()
+	}
 
 	return c.JSON(NewsListResponse{
 		Success: true,
@@ -216,6 +345,9 @@ func (nc *NewsController) GetNewsByID(c *fiber.Ctx) error {
 	var news models.News
 	if err := nc.DB.Preload("Author").
 		Preload("Community").
+		Preload("Event").
+		Preload("Media", services.OrderedNewsMedia).
+		Preload("Mentions").
 		Preload("Comments", func(db *gorm.DB) *gorm.DB {
 			return db.Preload("Author").Where("parent_id IS NULL").Order("created_at ASC")
 		}).
@@ -236,6 +368,14 @@ func (nc *NewsController) GetNewsByID(c *fiber.Ctx) error {
 		})
 	}
 
+	if nc.isNewsHidden(c, &news) {
+		return c.Status(404).JSON(NewsResponse{
+			Success: false,
+			Message: "Новость не найдена",
+		})
+	}
+	news.HideDeletedCommentAuthors()
+
 	return c.JSON(NewsResponse{
 		Success: true,
 		Message: "Новость получена",
@@ -243,6 +383,64 @@ func (nc *NewsController) GetNewsByID(c *fiber.Ctx) error {
 	})
 }
 
+// PreviewNews показывает автору и редакторам, как новость будет выглядеть после публикации
+func (nc *NewsController) PreviewNews(c *fiber.Ctx) error {
+	userID, err := nc.getUserIDFromToken(c)
+	if err != nil {
+		return c.Status(401).JSON(NewsPreviewResponse{
+			Success: false,
+			Message: "Неавторизованный доступ",
+		})
+	}
+
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(400).JSON(NewsPreviewResponse{
+			Success: false,
+			Message: "Неверный ID новости",
+		})
+	}
+
+	var news models.News
+	if err := nc.DB.Preload("Author").Preload("Community").Preload("Event").Preload("Media", services.OrderedNewsMedia).Preload("Mentions").First(&news, uint(id)).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return c.Status(404).JSON(NewsPreviewResponse{
+				Success: false,
+				Message: "Новость не найдена",
+			})
+		}
+		return c.Status(500).JSON(NewsPreviewResponse{
+			Success: false,
+			Message: "Ошибка при получении новости",
+		})
+	}
+
+	if !nc.canEditNews(userID, &news) {
+		return c.Status(403).JSON(NewsPreviewResponse{
+			Success: false,
+			Message: "Недостаточно прав для предпросмотра новости",
+		})
+	}
+
+	recipients, err := nc.News.Recipients(&news)
+	if err != nil {
+		return c.Status(500).JSON(NewsPreviewResponse{
+			Success: false,
+			Message: "Ошибка при подготовке предпросмотра",
+		})
+	}
+
+	return c.JSON(NewsPreviewResponse{
+		Success: true,
+		Message: "Предпросмотр новости",
+		Data: &NewsPreview{
+			News:         &news,
+			Notification: nc.News.BuildNotification(&news),
+			Recipients:   len(recipients),
+		},
+	})
+}
+
 // UpdateNews обновляет новость
 func (nc *NewsController) UpdateNews(c *fiber.Ctx) error {
 	// Получаем пользователя из JWT токена
@@ -286,13 +484,22 @@ func (nc *NewsController) UpdateNews(c *fiber.Ctx) error {
 	}
 
 	// Проверяем права доступа (автор или модератор/админ сообщества)
-	if news.AuthorID != userID && !nc.canManageNews(userID, news.CommunityID) {
+	if !nc.canEditNews(userID, &news) {
 		return c.Status(403).JSON(NewsResponse{
 			Success: false,
 			Message: "Недостаточно прав для редактирования новости",
 		})
 	}
 
+	// Заблокированный или заглушенный пользователь не может редактировать новости
+	if err := nc.Moderation.CheckCanParticipate(news.CommunityID, userID); err != nil {
+		status, message := sanctionError(err)
+		return c.Status(status).JSON(NewsResponse{
+			Success: false,
+			Message: message,
+		})
+	}
+
 	// Валидация
 	if err := nc.validateUpdateNewsRequest(&req); err != nil {
 		return c.Status(400).JSON(NewsResponse{
@@ -310,7 +517,37 @@ func (nc *NewsController) UpdateNews(c *fiber.Ctx) error {
 		updates["photo_path"] = strings.TrimSpace(req.PhotoPath)
 	}
 
-	if len(updates) == 0 {
+	// Смена статуса: опубликованную новость нельзя вернуть в черновики
+	publish := false
+	if req.Status != "" || req.PublishAt != "" {
+		status := req.Status
+		if status == "" {
+			status = news.Status
+		}
+		if news.IsPublished() && status != models.NewsStatusPublished {
+			return c.Status(400).JSON(NewsResponse{
+				Success: false,
+				Message: "Опубликованную новость нельзя вернуть в черновики",
+			})
+		}
+
+		switch status {
+		case models.NewsStatusPublished:
+			publish = !news.IsPublished()
+		case models.NewsStatusDraft, models.NewsStatusScheduled:
+			publishAt, err := parsePublishAt(status, req.PublishAt)
+			if err != nil {
+				return c.Status(400).JSON(NewsResponse{
+					Success: false,
+					Message: err.Error(),
+				})
+			}
+			updates["status"] = status
+			updates["publish_at"] = publishAt
+		}
+	}
+
+	if len(updates) == 0 && !publish {
 		return c.Status(400).JSON(NewsResponse{
 			Success: false,
 			Message: "Нет данных для обновления",
@@ -318,15 +555,35 @@ func (nc *NewsController) UpdateNews(c *fiber.Ctx) error {
 	}
 
 	// Обновляем новость
-	if err := nc.DB.Model(&news).Updates(updates).Error; err != nil {
-		return c.Status(500).JSON(NewsResponse{
-			Success: false,
-			Message: "Ошибка при обновлении новости",
-		})
+	if len(updates) > 0 {
+		if err := nc.DB.Model(&news).Updates(updates).Error; err != nil {
+			return c.Status(500).JSON(NewsResponse{
+				Success: false,
+				Message: "Ошибка при обновлении новости",
+			})
+		}
+	}
+
+	// Обновляем упоминания при изменении текста
+	if _, ok := updates["content"]; ok {
+		news.Content = updates["content"].(string)
+		if err := nc.Mentions.SyncNews(&news); err != nil {
+			log.Printf("Ошибка обработки упоминаний в новости %d: %v", news.ID, err)
+		}
+	}
+
+	// Публикуем черновик или отложенную новость немедленно
+	if publish {
+		if _, err := nc.News.Publish(news.ID); err != nil {
+			return c.Status(500).JSON(NewsResponse{
+				Success: false,
+				Message: "Ошибка при публикации новости",
+			})
+		}
 	}
 
 	// Загружаем обновленную новость
-	nc.DB.Preload("Author").Preload("Community").First(&news, news.ID)
+	nc.DB.Preload("Author").Preload("Community").Preload("Media", services.OrderedNewsMedia).Preload("Mentions").First(&news, news.ID)
 
 	return c.JSON(NewsResponse{
 		Success: true,
@@ -377,13 +634,43 @@ func (nc *NewsController) DeleteNews(c *fiber.Ctx) error {
 		})
 	}
 
-	// Удаляем новость (каскадное удаление)
-	if err := nc.DB.Delete(&news).Error; err != nil {
+	reason, err := parseRemovalReason(c)
+	if err != nil {
+		return c.Status(400).JSON(NewsResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+	}
+
+	// Удаляем новость вместе с изображениями (остальное - каскадное удаление)
+	var mediaPaths []string
+	err = nc.DB.Transaction(func(tx *gorm.DB) error {
+		paths, err := services.DeleteNewsMedia(tx, []uint{news.ID})
+		if err != nil {
+			return err
+		}
+		mediaPaths = paths
+		if err := services.DeleteMentions(tx, models.MentionSourceNews, []uint{news.ID}); err != nil {
+			return err
+		}
+		comments := tx.Model(&models.Comment{}).Select("id").Where("news_id = ?", news.ID)
+		if err := services.DeleteMentions(tx, models.MentionSourceComment, comments); err != nil {
+			return err
+		}
+		return tx.Delete(&news).Error
+	})
+	if err != nil {
 		return c.Status(500).JSON(NewsResponse{
 			Success: false,
 			Message: "Ошибка при удалении новости",
 		})
 	}
+	services.RemoveFiles(mediaPaths)
+
+	// Удаление модератором записывается в журнал, автор получает причину
+	if err := nc.Moderation.RecordRemoval(news.CommunityID, userID, news.AuthorID, "news", news.ID, news.Content, reason); err != nil {
+		log.Printf("Ошибка записи удаления новости %d в журнал модерации: %v", news.ID, err)
+	}
 
 	return c.JSON(NewsResponse{
 		Success: true,
@@ -412,7 +699,7 @@ func (nc *NewsController) LikeNews(c *fiber.Ctx) error {
 
 	// Проверяем существование новости
 	var news models.News
-	if err := nc.DB.First(&news, uint(id)).Error; err != nil {
+	if err := nc.DB.Preload("Event").First(&news, uint(id)).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return c.Status(404).JSON(NewsResponse{
 				Success: false,
@@ -425,6 +712,22 @@ func (nc *NewsController) LikeNews(c *fiber.Ctx) error {
 		})
 	}
 
+	// Лайкать можно только опубликованные и видимые пользователю новости
+	if !news.IsPublished() || nc.isNewsHidden(c, &news) {
+		return c.Status(404).JSON(NewsResponse{
+			Success: false,
+			Message: "Новость не найдена",
+		})
+	}
+
+	if err := nc.Moderation.CheckCanParticipate(news.CommunityID, userID); err != nil {
+		status, message := sanctionError(err)
+		return c.Status(status).JSON(NewsResponse{
+			Success: false,
+			Message: message,
+		})
+	}
+
 	// Проверяем, есть ли уже лайк от этого пользователя
 	var existingLike models.NewsLike
 	err = nc.DB.Where("news_id = ? AND user_id = ?", id, userID).First(&existingLike).Error
@@ -474,8 +777,239 @@ func (nc *NewsController) LikeNews(c *fiber.Ctx) error {
 	})
 }
 
+// UploadNewsMedia загружает изображения в новость (multipart: images - файлы, captions - подписи по порядку)
+func (nc *NewsController) UploadNewsMedia(c *fiber.Ctx) error {
+	userID, news, err := nc.findEditableNews(c)
+	if err != nil {
+		return nc.respondNewsMediaError(c, err)
+	}
+
+	form, err := c.MultipartForm()
+	if err != nil {
+		return c.Status(400).JSON(NewsMediaResponse{
+			Success: false,
+			Message: "Ошибка при обработке файлов",
+		})
+	}
+
+	files := form.File["images"]
+	if len(files) == 0 {
+		return c.Status(400).JSON(NewsMediaResponse{
+			Success: false,
+			Message: "Необходимо загрузить хотя бы одно изображение",
+		})
+	}
+
+	captions := form.Value["captions"]
+	uploads := make([]services.NewsMediaUpload, len(files))
+	for i, file := range files {
+		uploads[i].File = file
+		if i < len(captions) {
+			uploads[i].Caption = strings.TrimSpace(captions[i])
+		}
+		if utf8.RuneCountInString(uploads[i].Caption) > maxNewsMediaCaptionLength {
+			return c.Status(400).JSON(NewsMediaResponse{
+				Success: false,
+				Message: "Подпись не должна превышать 500 символов",
+			})
+		}
+	}
+
+	media, err := nc.Media.Upload(news.ID, userID, uploads)
+	if err != nil {
+		return nc.respondNewsMediaError(c, err)
+	}
+
+	return c.Status(201).JSON(NewsMediaResponse{
+		Success: true,
+		Message: "Изображения загружены",
+		Data:    media,
+	})
+}
+
+// UpdateNewsMedia изменяет подпись изображения
+func (nc *NewsController) UpdateNewsMedia(c *fiber.Ctx) error {
+	_, news, err := nc.findEditableNews(c)
+	if err != nil {
+		return nc.respondNewsMediaError(c, err)
+	}
+
+	mediaID, err := strconv.ParseUint(c.Params("media_id"), 10, 32)
+	if err != nil {
+		return c.Status(400).JSON(NewsMediaResponse{
+			Success: false,
+			Message: "Неверный ID изображения",
+		})
+	}
+
+	var req UpdateNewsMediaRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(NewsMediaResponse{
+			Success: false,
+			Message: "Неверный формат данных",
+		})
+	}
+	caption := strings.TrimSpace(req.Caption)
+	if utf8.RuneCountInString(caption) > maxNewsMediaCaptionLength {
+		return c.Status(400).JSON(NewsMediaResponse{
+			Success: false,
+			Message: "Подпись не должна превышать 500 символов",
+		})
+	}
+
+	media, err := nc.Media.UpdateCaption(news.ID, uint(mediaID), caption)
+	if err != nil {
+		return nc.respondNewsMediaError(c, err)
+	}
+
+	return c.JSON(NewsMediaResponse{
+		Success: true,
+		Message: "Подпись обновлена",
+		Data:    []models.NewsMedia{*media},
+	})
+}
+
+// ReorderNewsMedia задает порядок изображений новости
+func (nc *NewsController) ReorderNewsMedia(c *fiber.Ctx) error {
+	_, news, err := nc.findEditableNews(c)
+	if err != nil {
+		return nc.respondNewsMediaError(c, err)
+	}
+
+	var req ReorderNewsMediaRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(NewsMediaResponse{
+			Success: false,
+			Message: "Неверный формат данных",
+		})
+	}
+
+	media, err := nc.Media.Reorder(news.ID, req.MediaIDs)
+	if err != nil {
+		return nc.respondNewsMediaError(c, err)
+	}
+
+	return c.JSON(NewsMediaResponse{
+		Success: true,
+		Message: "Порядок изображений обновлен",
+		Data:    media,
+	})
+}
+
+// DeleteNewsMedia удаляет изображение из новости
+func (nc *NewsController) DeleteNewsMedia(c *fiber.Ctx) error {
+	_, news, err := nc.findEditableNews(c)
+	if err != nil {
+		return nc.respondNewsMediaError(c, err)
+	}
+
+	mediaID, err := strconv.ParseUint(c.Params("media_id"), 10, 32)
+	if err != nil {
+		return c.Status(400).JSON(NewsMediaResponse{
+			Success: false,
+			Message: "Неверный ID изображения",
+		})
+	}
+
+	if err := nc.Media.Delete(news.ID, uint(mediaID)); err != nil {
+		return nc.respondNewsMediaError(c, err)
+	}
+
+	return c.JSON(NewsMediaResponse{
+		Success: true,
+		Message: "Изображение удалено",
+	})
+}
+
+// GetNewsMediaFile возвращает файл изображения новости с теми же правами доступа, что и у новости
+func (nc *NewsController) GetNewsMediaFile(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(400).JSON(NewsMediaResponse{
+			Success: false,
+			Message: "Неверный ID новости",
+		})
+	}
+	mediaID, err := strconv.ParseUint(c.Params("media_id"), 10, 32)
+	if err != nil {
+		return c.Status(400).JSON(NewsMediaResponse{
+			Success: false,
+			Message: "Неверный ID изображения",
+		})
+	}
+
+	var news models.News
+	if err := nc.DB.Preload("Event").First(&news, uint(id)).Error; err != nil || nc.isNewsHidden(c, &news) {
+		return c.Status(404).JSON(NewsMediaResponse{
+			Success: false,
+			Message: "Новость не найдена",
+		})
+	}
+
+	media, err := nc.Media.Get(news.ID, uint(mediaID))
+	if err != nil {
+		return nc.respondNewsMediaError(c, err)
+	}
+
+	c.Set(fiber.HeaderContentType, media.MimeType)
+	return c.SendFile(media.FilePath)
+}
+
 // Вспомогательные методы
 
+// findEditableNews загружает новость из параметра :id и проверяет, что пользователь может ее редактировать
+func (nc *NewsController) findEditableNews(c *fiber.Ctx) (uint, *models.News, error) {
+	userID, err := nc.getUserIDFromToken(c)
+	if err != nil {
+		return 0, nil, fiber.NewError(401, "Неавторизованный доступ")
+	}
+
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return 0, nil, fiber.NewError(400, "Неверный ID новости")
+	}
+
+	var news models.News
+	if err := nc.DB.First(&news, uint(id)).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return 0, nil, fiber.NewError(404, "Новость не найдена")
+		}
+		return 0, nil, fiber.NewError(500, "Ошибка при получении новости")
+	}
+
+	if !nc.canEditNews(userID, &news) {
+		return 0, nil, fiber.NewError(403, "Недостаточно прав для редактирования новости")
+	}
+	if err := nc.Moderation.CheckCanParticipate(news.CommunityID, userID); err != nil {
+		return 0, nil, fiber.NewError(sanctionError(err))
+	}
+	return userID, &news, nil
+}
+
+// respondNewsMediaError преобразует ошибку работы с изображениями в HTTP ответ
+func (nc *NewsController) respondNewsMediaError(c *fiber.Ctx, err error) error {
+	status, message := 500, "Ошибка при обработке изображений"
+	var fiberErr *fiber.Error
+	switch {
+	case errors.As(err, &fiberErr):
+		status, message = fiberErr.Code, fiberErr.Message
+	case errors.Is(err, services.ErrNewsMediaNotFound):
+		status, message = 404, "Изображение не найдено"
+	case errors.Is(err, services.ErrNewsMediaLimit):
+		status, message = 400, "В новости может быть не более 10 изображений"
+	case errors.Is(err, services.ErrNewsMediaTooLarge):
+		status, message = 400, "Размер изображения не должен превышать 10 МБ"
+	case errors.Is(err, services.ErrNewsMediaType):
+		status, message = 400, "Поддерживаются только изображения JPEG, PNG, GIF и WebP"
+	case errors.Is(err, services.ErrNewsMediaOrder):
+		status, message = 400, "Укажите все изображения новости в новом порядке"
+	}
+	return c.Status(status).JSON(NewsMediaResponse{
+		Success: false,
+		Message: message,
+	})
+}
+
 // getUserIDFromToken извлекает ID пользователя из JWT токена
 func (nc *NewsController) getUserIDFromToken(c *fiber.Ctx) (uint, error) {
 	authHeader := c.Get("Authorization")
@@ -498,10 +1032,76 @@ func (nc *NewsController) getUserIDFromToken(c *fiber.Ctx) (uint, error) {
 
 // canManageNews проверяет, может ли пользователь управлять новостями в сообществе
 func (nc *NewsController) canManageNews(userID, communityID uint) bool {
-	var role models.CommunityRole
-	err := nc.DB.Where("community_id = ? AND user_id = ? AND role IN ?",
-		communityID, userID, []string{"admin", "moderator"}).First(&role).Error
-	return err == nil
+	allowed, err := nc.Permissions.HasCommunityPermission(userID, communityID, models.PermissionCommunityNewsManage)
+	return err == nil && allowed
+}
+
+// isNewsHidden проверяет, скрыта ли новость от текущего пользователя:
+// неопубликованная новость видна только редакторам, анонс ивента «только для участников» - участникам.
+// Новость должна быть загружена вместе с Event.
+func (nc *NewsController) isNewsHidden(c *fiber.Ctx, news *models.News) bool {
+	viewerID, err := nc.getUserIDFromToken(c)
+	hidden := !news.IsPublished() && (err != nil || !nc.canEditNews(viewerID, news))
+	if news.Event != nil && news.Event.MembersOnly {
+		hidden = hidden || err != nil || !nc.isCommunityMember(viewerID, news.CommunityID)
+	}
+	return hidden
+}
+
+// canEditNews проверяет, может ли пользователь редактировать новость (автор или редактор сообщества)
+func (nc *NewsController) canEditNews(userID uint, news *models.News) bool {
+	return news.AuthorID == userID || nc.canManageNews(userID, news.CommunityID)
+}
+
+// parsePublishAt проверяет время публикации: для отложенной новости оно обязательно и должно быть в будущем
+func parsePublishAt(status, value string) (*time.Time, error) {
+	if status != models.NewsStatusScheduled {
+		return nil, nil
+	}
+	if value == "" {
+		return nil, fiber.NewError(400, "Для отложенной публикации укажите время публикации")
+	}
+	publishAt, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, fiber.NewError(400, "Неверный формат времени публикации")
+	}
+	if !publishAt.After(time.Now()) {
+		return nil, fiber.NewError(400, "Время публикации должно быть в будущем")
+	}
+	return &publishAt, nil
+}
+
+// parseRemovalReason читает необязательную причину удаления контента из тела запроса
+func parseRemovalReason(c *fiber.Ctx) (string, error) {
+	if len(c.Body()) == 0 {
+		return "", nil
+	}
+	var req DeleteContentRequest
+	if err := c.BodyParser(&req); err != nil {
+		return "", fiber.NewError(400, "Неверный формат данных")
+	}
+	reason := strings.TrimSpace(req.Reason)
+	if utf8.RuneCountInString(reason) > maxSanctionReasonLength {
+		return "", fiber.NewError(400, "Причина не должна превышать 500 символов")
+	}
+	return reason, nil
+}
+
+// sanctionError возвращает статус и сообщение ответа на ошибку проверки санкций сообщества
+func sanctionError(err error) (int, string) {
+	switch err {
+	case services.ErrCommunityBanned:
+		return 403, "Вы заблокированы в этом сообществе"
+	case services.ErrCommunityMuted:
+		return 403, "Вам временно запрещено писать в этом сообществе"
+	}
+	return 500, "Ошибка при проверке ограничений сообщества"
+}
+
+// isCommunityMember проверяет, состоит ли пользователь в сообществе
+func (nc *NewsController) isCommunityMember(userID, communityID uint) bool {
+	allowed, err := nc.Permissions.HasCommunityPermission(userID, communityID, models.PermissionCommunityView)
+	return err == nil && allowed
 }
 
 // validateCreateNewsRequest валидирует запрос создания новости
@@ -515,7 +1115,7 @@ func (nc *NewsController) validateCreateNewsRequest(req *CreateNewsRequest) erro
 	if len(req.PhotoPath) > 255 {
 		return fiber.NewError(400, "Путь к фото не должен превышать 255 символов")
 	}
-	return nil
+	return validateNewsStatus(req.Status)
 }
 
 // validateUpdateNewsRequest валидирует запрос обновления новости
@@ -526,5 +1126,14 @@ func (nc *NewsController) validateUpdateNewsRequest(req *UpdateNewsRequest) erro
 	if req.PhotoPath != "" && len(req.PhotoPath) > 255 {
 		return fiber.NewError(400, "Путь к фото не должен превышать 255 символов")
 	}
-	return nil
+	return validateNewsStatus(req.Status)
+}
+
+// validateNewsStatus проверяет статус новости из запроса
+func validateNewsStatus(status string) error {
+	switch status {
+	case "", models.NewsStatusDraft, models.NewsStatusScheduled, models.NewsStatusPublished:
+		return nil
+	}
+	return fiber.NewError(400, "Неверный статус новости")
 }
@@ -0,0 +1,116 @@
+package controllers
+
+import (
+	"strconv"
+
+	"toloko-backend/services"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// NotificationController обрабатывает HTTP запросы для уведомлений
+type NotificationController struct {
+	db                  *gorm.DB
+	notificationService *services.NotificationService
+}
+
+// NewNotificationController создает новый контроллер уведомлений
+func NewNotificationController(db *gorm.DB) *NotificationController {
+	return &NotificationController{
+		db:                  db,
+		notificationService: services.NewNotificationService(db),
+	}
+}
+
+// GetNotifications возвращает уведомления текущего пользователя (?unread=true - только непрочитанные)
+func (c *NotificationController) GetNotifications(ctx *fiber.Ctx) error {
+	userID := ctx.Locals("user_id").(uint)
+
+	page := ctx.QueryInt("page", 1)
+	if page < 1 {
+		page = 1
+	}
+	limit := ctx.QueryInt("limit", 20)
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	notifications, total, err := c.notificationService.List(userID, ctx.QueryBool("unread", false), page, limit)
+	if err != nil {
+		return ctx.Status(500).JSON(fiber.Map{
+			"error": "Failed to get notifications",
+		})
+	}
+
+	return ctx.JSON(fiber.Map{
+		"success":       true,
+		"notifications": notifications,
+		"pagination": fiber.Map{
+			"page":        page,
+			"limit":       limit,
+			"total_count": total,
+			"total_pages": (total + int64(limit) - 1) / int64(limit),
+		},
+	})
+}
+
+// GetUnreadCount возвращает количество непрочитанных уведомлений
+func (c *NotificationController) GetUnreadCount(ctx *fiber.Ctx) error {
+	userID := ctx.Locals("user_id").(uint)
+
+	count, err := c.notificationService.UnreadCount(userID)
+	if err != nil {
+		return ctx.Status(500).JSON(fiber.Map{
+			"error": "Failed to count notifications",
+		})
+	}
+
+	return ctx.JSON(fiber.Map{
+		"success":      true,
+		"unread_count": count,
+	})
+}
+
+// MarkRead отмечает уведомление прочитанным
+func (c *NotificationController) MarkRead(ctx *fiber.Ctx) error {
+	userID := ctx.Locals("user_id").(uint)
+	notificationID, err := strconv.ParseUint(ctx.Params("id"), 10, 32)
+	if err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Invalid notification ID",
+		})
+	}
+
+	if err := c.notificationService.MarkRead(userID, uint(notificationID)); err != nil {
+		if err == services.ErrNotificationNotFound {
+			return ctx.Status(404).JSON(fiber.Map{
+				"error": "Notification not found",
+			})
+		}
+		return ctx.Status(500).JSON(fiber.Map{
+			"error": "Failed to mark notification as read",
+		})
+	}
+
+	return ctx.JSON(fiber.Map{
+		"message": "Notification marked as read",
+	})
+}
+
+// MarkAllRead отмечает все уведомления пользователя прочитанными
+func (c *NotificationController) MarkAllRead(ctx *fiber.Ctx) error {
+	userID := ctx.Locals("user_id").(uint)
+
+	updated, err := c.notificationService.MarkAllRead(userID)
+	if err != nil {
+		return ctx.Status(500).JSON(fiber.Map{
+			"error": "Failed to mark notifications as read",
+		})
+	}
+
+	return ctx.JSON(fiber.Map{
+		"message": "All notifications marked as read",
+		"updated": updated,
+	})
+}
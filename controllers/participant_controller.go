@@ -1,11 +1,13 @@
 package controllers
 
 import (
+	"math"
 	"strconv"
 	"strings"
 	"time"
 
 	"toloko-backend/models"
+	"toloko-backend/services"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/golang-jwt/jwt/v5"
@@ -14,12 +16,18 @@ import (
 
 // ParticipantController контроллер для управления участниками ивентов
 type ParticipantController struct {
-	DB *gorm.DB
+	DB           *gorm.DB
+	Achievements *services.AchievementService
+	Challenges   *services.ChallengeService
 }
 
 // NewParticipantController создает новый экземпляр ParticipantController
 func NewParticipantController(db *gorm.DB) *ParticipantController {
-	return &ParticipantController{DB: db}
+	return &ParticipantController{
+		DB:           db,
+		Achievements: services.NewAchievementService(db),
+		Challenges:   services.NewChallengeService(db),
+	}
 }
 
 // JoinEventRequest структура запроса вступления в ивент
@@ -490,13 +498,25 @@ func (pc *ParticipantController) updateApplicationStatus(c *fiber.Ctx, status, m
 	}
 
 	// Обновляем статус
+	before := participant
 	participant.Status = status
 	if status == models.ParticipantStatusAccepted {
 		now := time.Now()
 		participant.JoinedAt = &now
 	}
 
-	if err := pc.DB.Save(&participant).Error; err != nil {
+	action := models.AuditActionApplicationReject
+	if status == models.ParticipantStatusAccepted {
+		action = models.AuditActionApplicationApprove
+	}
+
+	err = pc.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&participant).Error; err != nil {
+			return err
+		}
+		return services.RecordAudit(tx, userID, action, models.AuditTargetApplication, participant.ID, before, participant, services.AuditMetadataFromRequest(c))
+	})
+	if err != nil {
 		return c.Status(500).JSON(ParticipantResponse{
 			Success: false,
 			Message: "Ошибка при обновлении статуса заявки",
@@ -760,23 +780,355 @@ func (pc *ParticipantController) CompleteEvent(c *fiber.Ctx) error {
 		})
 	}
 
-	// Деактивируем ивент
+	// Деактивируем ивент. Организатор получает очки, если ивент уже начался
+	// и хотя бы один участник, кроме него самого, отмечен на месте.
 	event.IsActive = false
-	if err := pc.DB.Save(&event).Error; err != nil {
+	err = services.TransactionWithNotifications(pc.DB, func(tx *gorm.DB) error {
+		if err := tx.Save(&event).Error; err != nil {
+			return err
+		}
+		if time.Now().Before(event.StartTime) {
+			return nil
+		}
+
+		var checkedIn int64
+		if err := tx.Model(&models.EventParticipant{}).
+			Where("event_id = ? AND user_id <> ? AND checked_in_at IS NOT NULL", event.ID, event.CreatorID).
+			Count(&checkedIn).Error; err != nil {
+			return err
+		}
+		if checkedIn == 0 {
+			return nil
+		}
+		_, err := services.GrantOrganizationPoints(tx, &event)
+		return err
+	})
+	if err != nil {
 		return c.Status(500).JSON(ParticipantResponse{
 			Success: false,
 			Message: "Ошибка при завершении ивента",
 		})
 	}
 
+	// Завершенный ивент продлевает серии участия
+	var participantIDs []uint
+	pc.DB.Model(&models.EventParticipant{}).
+		Where("event_id = ? AND (checked_in_at IS NOT NULL OR status IN ?)", event.ID,
+			[]string{models.ParticipantStatusJoined, models.ParticipantStatusAccepted}).
+		Pluck("user_id", &participantIDs)
+	for _, participantID := range participantIDs {
+		pc.Achievements.TrackStreaks(participantID)
+	}
+
 	return c.JSON(ParticipantResponse{
 		Success: true,
 		Message: "Ивент успешно завершен",
 	})
 }
 
+// CheckInParticipant отмечает присутствие участника на ивенте и начисляет ему очки за участие
+func (pc *ParticipantController) CheckInParticipant(c *fiber.Ctx) error {
+	// Получаем пользователя из JWT токена
+	userID, err := pc.getUserIDFromToken(c)
+	if err != nil {
+		return c.Status(401).JSON(ParticipantResponse{
+			Success: false,
+			Message: "Неавторизованный доступ",
+		})
+	}
+
+	// Получаем ID ивента и участника
+	eventID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(400).JSON(ParticipantResponse{
+			Success: false,
+			Message: "Неверный ID ивента",
+		})
+	}
+
+	participantID, err := strconv.ParseUint(c.Params("participant_id"), 10, 32)
+	if err != nil {
+		return c.Status(400).JSON(ParticipantResponse{
+			Success: false,
+			Message: "Неверный ID участника",
+		})
+	}
+
+	// Проверяем существование ивента и права доступа
+	var event models.Event
+	if err := pc.DB.First(&event, eventID).Error; err != nil {
+		return c.Status(404).JSON(ParticipantResponse{
+			Success: false,
+			Message: "Ивент не найден",
+		})
+	}
+
+	if event.CreatorID != userID {
+		return c.Status(403).JSON(ParticipantResponse{
+			Success: false,
+			Message: "Нет прав для отметки участников",
+		})
+	}
+
+	if !event.IsActive {
+		return c.Status(400).JSON(ParticipantResponse{
+			Success: false,
+			Message: "Ивент уже завершен",
+		})
+	}
+
+	if !event.IsCheckInOpen(time.Now()) {
+		return c.Status(400).JSON(ParticipantResponse{
+			Success: false,
+			Message: "Отмечать участников можно только во время ивента",
+		})
+	}
+
+	// Отметить можно только участника, который вступил или чья заявка одобрена
+	var participant models.EventParticipant
+	if err := pc.DB.Where("id = ? AND event_id = ? AND status IN ?", participantID, eventID,
+		[]string{models.ParticipantStatusJoined, models.ParticipantStatusAccepted}).
+		First(&participant).Error; err != nil {
+		return c.Status(404).JSON(ParticipantResponse{
+			Success: false,
+			Message: "Участник не найден",
+		})
+	}
+
+	// Организатор не может отметить самого себя
+	if participant.UserID == event.CreatorID {
+		return c.Status(400).JSON(ParticipantResponse{
+			Success: false,
+			Message: "Организатор не может отметить себя как участника",
+		})
+	}
+
+	if participant.CheckedInAt != nil {
+		return c.Status(409).JSON(ParticipantResponse{
+			Success: false,
+			Message: "Участник уже отмечен",
+		})
+	}
+
+	now := time.Now()
+	participant.CheckedInAt = &now
+	err = services.TransactionWithNotifications(pc.DB, func(tx *gorm.DB) error {
+		if err := tx.Model(&participant).Update("checked_in_at", &now).Error; err != nil {
+			return err
+		}
+		_, err := services.GrantParticipationPoints(tx, participant.UserID, event.ID)
+		return err
+	})
+	if err != nil {
+		return c.Status(500).JSON(ParticipantResponse{
+			Success: false,
+			Message: "Ошибка при отметке участника",
+		})
+	}
+
+	// Посещение засчитывается в достижения и челленджи участника
+	pc.Achievements.Track(participant.UserID, models.AchievementEventParticipated)
+	pc.Challenges.TrackUser(participant.UserID)
+
+	return c.JSON(ParticipantResponse{
+		Success:     true,
+		Message:     "Участник отмечен",
+		Participant: &participant,
+	})
+}
+
+// MaxParticipantHours максимальное число часов, которое организатор может указать участнику за один ивент
+const MaxParticipantHours = 168
+
+// UpdateParticipantHoursRequest структура запроса исправления волонтерских часов
+type UpdateParticipantHoursRequest struct {
+	Hours float64 `json:"hours"`
+}
+
+// CheckOutParticipant отмечает уход участника с ивента; часы считаются по времени прихода и ухода
+func (pc *ParticipantController) CheckOutParticipant(c *fiber.Ctx) error {
+	_, participant, ferr := pc.loadOrganizerParticipant(c)
+	if ferr != nil {
+		return c.Status(ferr.Code).JSON(ParticipantResponse{
+			Success: false,
+			Message: ferr.Message,
+		})
+	}
+
+	if participant.CheckedInAt == nil {
+		return c.Status(400).JSON(ParticipantResponse{
+			Success: false,
+			Message: "Участник не отмечен на ивенте",
+		})
+	}
+	if participant.CheckedOutAt != nil {
+		return c.Status(409).JSON(ParticipantResponse{
+			Success: false,
+			Message: "Уход участника уже отмечен",
+		})
+	}
+
+	now := time.Now()
+	participant.CheckedOutAt = &now
+	if err := pc.DB.Model(participant).Update("checked_out_at", &now).Error; err != nil {
+		return c.Status(500).JSON(ParticipantResponse{
+			Success: false,
+			Message: "Ошибка при отметке ухода участника",
+		})
+	}
+
+	return c.JSON(ParticipantResponse{
+		Success:     true,
+		Message:     "Уход участника отмечен",
+		Participant: participant,
+	})
+}
+
+// UpdateParticipantHours исправляет волонтерские часы участника до их подтверждения (только для создателя)
+func (pc *ParticipantController) UpdateParticipantHours(c *fiber.Ctx) error {
+	var req UpdateParticipantHoursRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(ParticipantResponse{
+			Success: false,
+			Message: "Неверный формат данных",
+		})
+	}
+	if req.Hours <= 0 || req.Hours > MaxParticipantHours {
+		return c.Status(400).JSON(ParticipantResponse{
+			Success: false,
+			Message: "Часы должны быть больше 0 и не больше " + strconv.Itoa(MaxParticipantHours),
+		})
+	}
+
+	_, participant, ferr := pc.loadOrganizerParticipant(c)
+	if ferr != nil {
+		return c.Status(ferr.Code).JSON(ParticipantResponse{
+			Success: false,
+			Message: ferr.Message,
+		})
+	}
+
+	// Подтвержденные часы уже засчитаны в сертификаты и челленджи, менять их нельзя
+	if participant.HoursConfirmedAt != nil {
+		return c.Status(409).JSON(ParticipantResponse{
+			Success: false,
+			Message: "Часы участника уже подтверждены",
+		})
+	}
+
+	hours := math.Round(req.Hours*100) / 100
+	participant.Hours = &hours
+	if err := pc.DB.Model(participant).Update("hours", hours).Error; err != nil {
+		return c.Status(500).JSON(ParticipantResponse{
+			Success: false,
+			Message: "Ошибка при обновлении часов участника",
+		})
+	}
+
+	return c.JSON(ParticipantResponse{
+		Success:     true,
+		Message:     "Часы участника обновлены",
+		Participant: participant,
+	})
+}
+
+// ConfirmParticipantHours подтверждает волонтерские часы участника после окончания ивента (только для создателя)
+func (pc *ParticipantController) ConfirmParticipantHours(c *fiber.Ctx) error {
+	event, participant, ferr := pc.loadOrganizerParticipant(c)
+	if ferr != nil {
+		return c.Status(ferr.Code).JSON(ParticipantResponse{
+			Success: false,
+			Message: ferr.Message,
+		})
+	}
+
+	if event.IsActive && event.EndTime.After(time.Now()) {
+		return c.Status(400).JSON(ParticipantResponse{
+			Success: false,
+			Message: "Часы можно подтвердить только после окончания ивента",
+		})
+	}
+	if participant.HoursConfirmedAt != nil {
+		return c.Status(409).JSON(ParticipantResponse{
+			Success: false,
+			Message: "Часы участника уже подтверждены",
+		})
+	}
+
+	// Неотмеченному участнику часы подтверждаются, только если организатор указал их вручную
+	if participant.CheckedInAt == nil && participant.Hours == nil {
+		return c.Status(400).JSON(ParticipantResponse{
+			Success: false,
+			Message: "Участник не отмечен на ивенте, укажите его часы вручную",
+		})
+	}
+
+	// Фиксируем рассчитанные часы, чтобы они не менялись при последующей правке ивента
+	hours := participant.VolunteerHours(event)
+	now := time.Now()
+	participant.Hours = &hours
+	participant.HoursConfirmedAt = &now
+	participant.HoursConfirmedBy = &event.CreatorID
+	if err := pc.DB.Model(participant).Updates(map[string]interface{}{
+		"hours":              hours,
+		"hours_confirmed_at": &now,
+		"hours_confirmed_by": event.CreatorID,
+	}).Error; err != nil {
+		return c.Status(500).JSON(ParticipantResponse{
+			Success: false,
+			Message: "Ошибка при подтверждении часов участника",
+		})
+	}
+
+	// Подтвержденные часы засчитываются в челленджи участника
+	pc.Challenges.TrackUser(participant.UserID)
+	pc.Achievements.TrackStreaks(participant.UserID)
+
+	return c.JSON(ParticipantResponse{
+		Success:     true,
+		Message:     "Часы участника подтверждены",
+		Participant: participant,
+	})
+}
+
 // Вспомогательные методы
 
+// loadOrganizerParticipant загружает ивент и его участника, проверяя, что запрос сделал создатель ивента
+func (pc *ParticipantController) loadOrganizerParticipant(c *fiber.Ctx) (*models.Event, *models.EventParticipant, *fiber.Error) {
+	userID, err := pc.getUserIDFromToken(c)
+	if err != nil {
+		return nil, nil, fiber.NewError(401, "Неавторизованный доступ")
+	}
+
+	eventID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return nil, nil, fiber.NewError(400, "Неверный ID ивента")
+	}
+
+	participantID, err := strconv.ParseUint(c.Params("participant_id"), 10, 32)
+	if err != nil {
+		return nil, nil, fiber.NewError(400, "Неверный ID участника")
+	}
+
+	var event models.Event
+	if err := pc.DB.First(&event, eventID).Error; err != nil {
+		return nil, nil, fiber.NewError(404, "Ивент не найден")
+	}
+
+	if event.CreatorID != userID {
+		return nil, nil, fiber.NewError(403, "Нет прав для управления участниками")
+	}
+
+	var participant models.EventParticipant
+	if err := pc.DB.Where("id = ? AND event_id = ? AND status IN ?", participantID, eventID,
+		[]string{models.ParticipantStatusJoined, models.ParticipantStatusAccepted}).
+		First(&participant).Error; err != nil {
+		return nil, nil, fiber.NewError(404, "Участник не найден")
+	}
+
+	return &event, &participant, nil
+}
+
 // getUserIDFromToken извлекает ID пользователя из JWT токена
 func (pc *ParticipantController) getUserIDFromToken(c *fiber.Ctx) (uint, error) {
 	authHeader := c.Get("Authorization")
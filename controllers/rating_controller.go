@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"toloko-backend/models"
+	"toloko-backend/services"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/golang-jwt/jwt/v5"
@@ -15,12 +16,13 @@ import (
 
 // RatingController контроллер для управления рейтингами
 type RatingController struct {
-	DB *gorm.DB
+	DB         *gorm.DB
+	Reputation *services.ReputationService
 }
 
 // NewRatingController создает новый экземпляр RatingController
 func NewRatingController(db *gorm.DB) *RatingController {
-	return &RatingController{DB: db}
+	return &RatingController{DB: db, Reputation: services.NewReputationService(db)}
 }
 
 // SubmitRatingsRequest структура запроса отправки рейтингов
@@ -57,9 +59,10 @@ type RatingsResponse struct {
 
 // UserRatingResponse структура ответа с рейтингом пользователя
 type UserRatingResponse struct {
-	Success       bool                      `json:"success"`
-	Message       string                    `json:"message"`
-	RatingSummary *models.UserRatingSummary `json:"rating_summary,omitempty"`
+	Success       bool                             `json:"success"`
+	Message       string                           `json:"message"`
+	RatingSummary *models.UserRatingSummary        `json:"rating_summary,omitempty"`
+	Breakdown     []services.RatingBreakdownBucket `json:"breakdown,omitempty"`
 }
 
 // SubmitRatings отправляет рейтинги участников
@@ -164,8 +167,8 @@ func (rc *RatingController) SubmitRatings(c *fiber.Ctx) error {
 			})
 		}
 
-		// Обновляем сводку рейтингов целевого пользователя
-		if err := rc.updateUserRatingSummary(tx, ratingReq.TargetUserID); err != nil {
+		// Учитываем оценку в репутации целевого пользователя
+		if err := rc.Reputation.ApplyRating(tx, &rating); err != nil {
 			tx.Rollback()
 			return c.Status(500).JSON(RatingResponse{
 				Success: false,
@@ -334,29 +337,20 @@ func (rc *RatingController) GetUserRatings(c *fiber.Ctx) error {
 		})
 	}
 
-	// Получаем сводку рейтингов пользователя
-	var ratingSummary models.UserRatingSummary
-	if err := rc.DB.Where("user_id = ?", userID).First(&ratingSummary).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			// Если сводки нет, создаем пустую
-			ratingSummary = models.UserRatingSummary{
-				UserID:       uint(userID),
-				TotalScore:   0,
-				VotesCount:   0,
-				AverageScore: 0.0,
-			}
-		} else {
-			return c.Status(500).JSON(UserRatingResponse{
-				Success: false,
-				Message: "Ошибка при получении рейтинга пользователя",
-			})
-		}
+	// Получаем сводку рейтингов с текущей репутацией и гистограммой оценок
+	ratingSummary, breakdown, err := rc.Reputation.Summary(uint(userID), time.Now())
+	if err != nil {
+		return c.Status(500).JSON(UserRatingResponse{
+			Success: false,
+			Message: "Ошибка при получении рейтинга пользователя",
+		})
 	}
 
 	return c.JSON(UserRatingResponse{
 		Success:       true,
 		Message:       "Рейтинг пользователя получен",
-		RatingSummary: &ratingSummary,
+		RatingSummary: ratingSummary,
+		Breakdown:     breakdown,
 	})
 }
 
@@ -518,51 +512,6 @@ func (rc *RatingController) validateRatingsRequest(req *SubmitRatingsRequest, ev
 	return nil
 }
 
-// updateUserRatingSummary обновляет сводку рейтингов пользователя
-func (rc *RatingController) updateUserRatingSummary(tx *gorm.DB, userID uint) error {
-	// Получаем все рейтинги пользователя
-	var ratings []models.Rating
-	if err := tx.Where("to_user_id = ?", userID).Find(&ratings).Error; err != nil {
-		return err
-	}
-
-	// Вычисляем общую сумму и количество
-	totalScore := 0
-	votesCount := len(ratings)
-
-	for _, rating := range ratings {
-		totalScore += rating.Score
-	}
-
-	// Вычисляем средний рейтинг
-	averageScore := 0.0
-	if votesCount > 0 {
-		averageScore = float64(totalScore) / float64(votesCount)
-	}
-
-	// Обновляем или создаем сводку
-	var summary models.UserRatingSummary
-	if err := tx.Where("user_id = ?", userID).First(&summary).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			// Создаем новую сводку
-			summary = models.UserRatingSummary{
-				UserID:       userID,
-				TotalScore:   totalScore,
-				VotesCount:   votesCount,
-				AverageScore: averageScore,
-			}
-			return tx.Create(&summary).Error
-		}
-		return err
-	}
-
-	// Обновляем существующую сводку
-	summary.TotalScore = totalScore
-	summary.VotesCount = votesCount
-	summary.AverageScore = averageScore
-	return tx.Save(&summary).Error
-}
-
 // validatePhotoFile валидирует загружаемый файл фотографии
 func (rc *RatingController) validatePhotoFile(file *multipart.FileHeader) error {
 	// Проверяем размер файла (максимум 10MB)
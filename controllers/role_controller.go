@@ -0,0 +1,212 @@
+package controllers
+
+import (
+	"sort"
+	"strconv"
+
+	"toloko-backend/models"
+	"toloko-backend/services"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// RoleController обрабатывает HTTP запросы для управления ролями платформы
+type RoleController struct {
+	db          *gorm.DB
+	Permissions *services.PermissionService
+}
+
+// NewRoleController создает новый контроллер ролей
+func NewRoleController(db *gorm.DB) *RoleController {
+	return &RoleController{
+		db:          db,
+		Permissions: services.NewPermissionService(db),
+	}
+}
+
+// GrantRoleRequest структура запроса выдачи роли
+type GrantRoleRequest struct {
+	Role string `json:"role" validate:"required"`
+}
+
+// GetRoles возвращает список доступных ролей и их разрешений
+func (c *RoleController) GetRoles(ctx *fiber.Ctx) error {
+	roles := models.GetRoles()
+
+	names := make([]string, 0, len(roles))
+	for role := range roles {
+		names = append(names, role)
+	}
+	sort.Strings(names)
+
+	result := make([]fiber.Map, 0, len(names))
+	for _, role := range names {
+		result = append(result, fiber.Map{
+			"role":        role,
+			"title":       roles[role],
+			"permissions": models.GetRolePermissions(role),
+		})
+	}
+
+	return ctx.JSON(fiber.Map{
+		"success": true,
+		"message": "Roles retrieved successfully",
+		"roles":   result,
+	})
+}
+
+// GetRoleHolders возвращает список пользователей с ролями
+func (c *RoleController) GetRoleHolders(ctx *fiber.Ctx) error {
+	role := ctx.Query("role")
+	if role != "" && !models.IsValidRole(role) {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Unknown role",
+		})
+	}
+
+	userRoles, err := c.Permissions.GetRoleHolders(role)
+	if err != nil {
+		return ctx.Status(500).JSON(fiber.Map{
+			"error": "Failed to get role holders",
+		})
+	}
+
+	return ctx.JSON(fiber.Map{
+		"success":    true,
+		"message":    "Role holders retrieved successfully",
+		"user_roles": userRoles,
+	})
+}
+
+// GetUserRoles возвращает роли и разрешения пользователя
+func (c *RoleController) GetUserRoles(ctx *fiber.Ctx) error {
+	targetUserID, err := strconv.ParseUint(ctx.Params("user_id"), 10, 32)
+	if err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	return c.respondWithRoles(ctx, uint(targetUserID))
+}
+
+// GetMyRoles возвращает роли и разрешения текущего пользователя
+func (c *RoleController) GetMyRoles(ctx *fiber.Ctx) error {
+	userID := ctx.Locals("user_id").(uint)
+	return c.respondWithRoles(ctx, userID)
+}
+
+// GrantRole выдает пользователю глобальную роль
+func (c *RoleController) GrantRole(ctx *fiber.Ctx) error {
+	userID := ctx.Locals("user_id").(uint)
+	targetUserID, err := strconv.ParseUint(ctx.Params("user_id"), 10, 32)
+	if err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	var req GrantRoleRequest
+	if err := ctx.BodyParser(&req); err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	// Проверяем, что пользователь существует
+	var user models.User
+	if err := c.db.First(&user, targetUserID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return ctx.Status(404).JSON(fiber.Map{
+				"error": "User not found",
+			})
+		}
+		return ctx.Status(500).JSON(fiber.Map{
+			"error": "Failed to get user",
+		})
+	}
+
+	userRole, err := c.Permissions.GrantRole(uint(targetUserID), req.Role, userID)
+	if err != nil {
+		switch err {
+		case services.ErrUnknownRole:
+			return ctx.Status(400).JSON(fiber.Map{
+				"error": "Unknown role",
+			})
+		case services.ErrRoleAlreadyGranted:
+			return ctx.Status(409).JSON(fiber.Map{
+				"error": "Role is already granted",
+			})
+		}
+		return ctx.Status(500).JSON(fiber.Map{
+			"error": "Failed to grant role",
+		})
+	}
+
+	return ctx.Status(201).JSON(fiber.Map{
+		"success":   true,
+		"message":   "Role granted successfully",
+		"user_role": userRole,
+	})
+}
+
+// RevokeRole отзывает у пользователя глобальную роль
+func (c *RoleController) RevokeRole(ctx *fiber.Ctx) error {
+	targetUserID, err := strconv.ParseUint(ctx.Params("user_id"), 10, 32)
+	if err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	if err := c.Permissions.RevokeRole(uint(targetUserID), ctx.Params("role")); err != nil {
+		switch err {
+		case services.ErrUnknownRole:
+			return ctx.Status(400).JSON(fiber.Map{
+				"error": "Unknown role",
+			})
+		case services.ErrRoleNotGranted:
+			return ctx.Status(404).JSON(fiber.Map{
+				"error": "Role is not granted",
+			})
+		case services.ErrLastAdmin:
+			return ctx.Status(409).JSON(fiber.Map{
+				"error": "Cannot revoke the last admin",
+			})
+		}
+		return ctx.Status(500).JSON(fiber.Map{
+			"error": "Failed to revoke role",
+		})
+	}
+
+	return ctx.JSON(fiber.Map{
+		"success": true,
+		"message": "Role revoked successfully",
+	})
+}
+
+// respondWithRoles формирует ответ с ролями и разрешениями пользователя
+func (c *RoleController) respondWithRoles(ctx *fiber.Ctx, userID uint) error {
+	roles, err := c.Permissions.GetUserRoles(userID)
+	if err != nil {
+		return ctx.Status(500).JSON(fiber.Map{
+			"error": "Failed to get user roles",
+		})
+	}
+
+	permissions, err := c.Permissions.GetUserPermissions(userID)
+	if err != nil {
+		return ctx.Status(500).JSON(fiber.Map{
+			"error": "Failed to get user permissions",
+		})
+	}
+
+	return ctx.JSON(fiber.Map{
+		"success":     true,
+		"message":     "User roles retrieved successfully",
+		"user_id":     userID,
+		"roles":       roles,
+		"permissions": permissions,
+	})
+}
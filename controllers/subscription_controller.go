@@ -4,6 +4,7 @@ import (
 	"strconv"
 
 	"toloko-backend/models"
+	"toloko-backend/services"
 	"toloko-backend/utils"
 
 	"github.com/gofiber/fiber/v2"
@@ -12,12 +13,16 @@ import (
 
 // SubscriptionController контроллер для управления подписками
 type SubscriptionController struct {
-	DB *gorm.DB
+	DB           *gorm.DB
+	Achievements *services.AchievementService
 }
 
 // NewSubscriptionController создает новый экземпляр SubscriptionController
 func NewSubscriptionController(db *gorm.DB) *SubscriptionController {
-	return &SubscriptionController{DB: db}
+	return &SubscriptionController{
+		DB:           db,
+		Achievements: services.NewAchievementService(db),
+	}
 }
 
 // SubscribeRequest структура запроса подписки
@@ -93,6 +98,10 @@ func (sc *SubscriptionController) Subscribe(c *fiber.Ctx) error {
 		})
 	}
 
+	// Начисляем достижения за подписку и за нового подписчика
+	sc.Achievements.Track(userID, models.AchievementEventSubscribed)
+	sc.Achievements.Track(uint(subscribedToID), models.AchievementEventSubscriberGained)
+
 	// Загружаем данные подписки с информацией о пользователе
 	var createdSubscription models.Subscription
 	sc.DB.Preload("SubscribedTo").First(&createdSubscription, subscription.ID)
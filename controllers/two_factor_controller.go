@@ -0,0 +1,189 @@
+package controllers
+
+import (
+	"toloko-backend/models"
+	"toloko-backend/services"
+	"toloko-backend/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// TwoFactorController обрабатывает HTTP запросы для настройки двухфакторной аутентификации
+type TwoFactorController struct {
+	db               *gorm.DB
+	twoFactorService *services.TwoFactorService
+}
+
+// NewTwoFactorController создает новый контроллер двухфакторной аутентификации
+func NewTwoFactorController(db *gorm.DB) *TwoFactorController {
+	return &TwoFactorController{
+		db:               db,
+		twoFactorService: services.NewTwoFactorService(db),
+	}
+}
+
+// TwoFactorCodeRequest структура запроса с кодом 2FA
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" validate:"required"`
+}
+
+// GetStatus возвращает состояние 2FA текущего пользователя
+func (c *TwoFactorController) GetStatus(ctx *fiber.Ctx) error {
+	userID := ctx.Locals("user_id").(uint)
+
+	enabled, err := c.twoFactorService.IsEnabled(userID)
+	if err != nil {
+		return ctx.Status(500).JSON(fiber.Map{
+			"error": "Failed to get two-factor status",
+		})
+	}
+
+	required, err := c.twoFactorService.IsRequired(userID)
+	if err != nil {
+		return ctx.Status(500).JSON(fiber.Map{
+			"error": "Failed to get two-factor status",
+		})
+	}
+
+	var recoveryCodesLeft int64
+	if enabled {
+		recoveryCodesLeft, err = c.twoFactorService.CountRecoveryCodes(userID)
+		if err != nil {
+			return ctx.Status(500).JSON(fiber.Map{
+				"error": "Failed to get two-factor status",
+			})
+		}
+	}
+
+	return ctx.JSON(fiber.Map{
+		"success":             true,
+		"enabled":             enabled,
+		"required":            required,
+		"recovery_codes_left": recoveryCodesLeft,
+	})
+}
+
+// Enroll начинает настройку 2FA и возвращает секрет и otpauth URI
+func (c *TwoFactorController) Enroll(ctx *fiber.Ctx) error {
+	userID := ctx.Locals("user_id").(uint)
+
+	var user models.User
+	if err := c.db.First(&user, userID).Error; err != nil {
+		return ctx.Status(404).JSON(fiber.Map{
+			"error": "User not found",
+		})
+	}
+
+	settings, err := c.twoFactorService.Enroll(userID)
+	if err != nil {
+		if err == services.ErrTwoFactorAlreadyEnabled {
+			return ctx.Status(409).JSON(fiber.Map{
+				"error": "Two-factor authentication is already enabled",
+			})
+		}
+		return ctx.Status(500).JSON(fiber.Map{
+			"error": "Failed to enroll two-factor authentication",
+		})
+	}
+
+	return ctx.JSON(fiber.Map{
+		"success":     true,
+		"message":     "Scan the URI with an authenticator app and confirm with a code",
+		"secret":      settings.Secret,
+		"otpauth_uri": utils.BuildOTPAuthURI(services.TwoFactorIssuer, user.Email, settings.Secret),
+	})
+}
+
+// Confirm включает 2FA после проверки кода и возвращает коды восстановления
+func (c *TwoFactorController) Confirm(ctx *fiber.Ctx) error {
+	userID := ctx.Locals("user_id").(uint)
+
+	var req TwoFactorCodeRequest
+	if err := ctx.BodyParser(&req); err != nil || req.Code == "" {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Code is required",
+		})
+	}
+
+	codes, err := c.twoFactorService.Confirm(userID, req.Code)
+	if err != nil {
+		return c.respondWithError(ctx, err)
+	}
+
+	return ctx.JSON(fiber.Map{
+		"success":        true,
+		"message":        "Two-factor authentication enabled. Store the recovery codes in a safe place",
+		"recovery_codes": codes,
+	})
+}
+
+// RegenerateRecoveryCodes заменяет коды восстановления
+func (c *TwoFactorController) RegenerateRecoveryCodes(ctx *fiber.Ctx) error {
+	userID := ctx.Locals("user_id").(uint)
+
+	var req TwoFactorCodeRequest
+	if err := ctx.BodyParser(&req); err != nil || req.Code == "" {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Code is required",
+		})
+	}
+
+	codes, err := c.twoFactorService.RegenerateRecoveryCodes(userID, req.Code)
+	if err != nil {
+		return c.respondWithError(ctx, err)
+	}
+
+	return ctx.JSON(fiber.Map{
+		"success":        true,
+		"message":        "Recovery codes regenerated",
+		"recovery_codes": codes,
+	})
+}
+
+// Disable отключает 2FA
+func (c *TwoFactorController) Disable(ctx *fiber.Ctx) error {
+	userID := ctx.Locals("user_id").(uint)
+
+	var req TwoFactorCodeRequest
+	if err := ctx.BodyParser(&req); err != nil || req.Code == "" {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Code is required",
+		})
+	}
+
+	if err := c.twoFactorService.Disable(userID, req.Code); err != nil {
+		return c.respondWithError(ctx, err)
+	}
+
+	return ctx.JSON(fiber.Map{
+		"success": true,
+		"message": "Two-factor authentication disabled",
+	})
+}
+
+// respondWithError преобразует ошибку сервиса в HTTP ответ
+func (c *TwoFactorController) respondWithError(ctx *fiber.Ctx, err error) error {
+	switch err {
+	case services.ErrInvalidTwoFactorCode:
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Invalid code",
+		})
+	case services.ErrTwoFactorNotEnrolled, services.ErrTwoFactorNotEnabled:
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Two-factor authentication is not set up",
+		})
+	case services.ErrTwoFactorAlreadyEnabled:
+		return ctx.Status(409).JSON(fiber.Map{
+			"error": "Two-factor authentication is already enabled",
+		})
+	case services.ErrTwoFactorRequired:
+		return ctx.Status(403).JSON(fiber.Map{
+			"error": "Two-factor authentication is required for your roles",
+		})
+	}
+
+	return ctx.Status(500).JSON(fiber.Map{
+		"error": "Failed to process two-factor request",
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"toloko-backend/models"
+	"toloko-backend/services"
 	"toloko-backend/utils"
 
 	"github.com/gofiber/fiber/v2"
@@ -13,12 +14,20 @@ import (
 
 // UserController контроллер для управления профилями пользователей
 type UserController struct {
-	db *gorm.DB
+	db       *gorm.DB
+	mentions *services.MentionService
+	reviews  *services.EventReviewService
+	streaks  *services.StreakService
 }
 
 // NewUserController создает новый экземпляр UserController
 func NewUserController(db *gorm.DB) *UserController {
-	return &UserController{db: db}
+	return &UserController{
+		db:       db,
+		mentions: services.NewMentionService(db),
+		reviews:  services.NewEventReviewService(db),
+		streaks:  services.NewStreakService(db),
+	}
 }
 
 // GetProfile получает профиль пользователя по ID
@@ -95,12 +104,20 @@ func (uc *UserController) GetProfile(c *fiber.Ctx) error {
 	var userLevel models.UserLevel
 	uc.db.Where("user_id = ?", userID).First(&userLevel)
 
+	// Получаем репутацию организатора по отзывам участников
+	organizerReputation, _ := uc.reviews.OrganizerSummary(uint(userID))
+
+	// Получаем серии и регулярность участия
+	streaks, _ := uc.streaks.Stats(uint(userID), time.Now())
+
 	response := fiber.Map{
-		"user":    user,
-		"stats":   stats,
-		"level":   userLevel,
-		"error":   false,
-		"message": "Профиль получен успешно",
+		"user":                 user,
+		"stats":                stats,
+		"level":                userLevel,
+		"organizer_reputation": organizerReputation,
+		"streaks":              streaks,
+		"error":                false,
+		"message":              "Профиль получен успешно",
 	}
 
 	return c.JSON(response)
@@ -158,6 +175,7 @@ func (uc *UserController) UpdateProfile(c *fiber.Ctx) error {
 	// Обновляем только разрешенные поля
 	var updateData struct {
 		Name     string `json:"name"`
+		Username string `json:"username"`
 		Avatar   string `json:"avatar"`
 		Bio      string `json:"bio"`
 		Location string `json:"location"`
@@ -176,6 +194,24 @@ func (uc *UserController) UpdateProfile(c *fiber.Ctx) error {
 	if updateData.Name != "" {
 		user.Name = updateData.Name
 	}
+	if updateData.Username != "" {
+		username := models.NormalizeUsername(updateData.Username)
+		if !models.IsValidUsername(username) {
+			return c.Status(400).JSON(fiber.Map{
+				"error":   true,
+				"message": "Имя пользователя должно содержать от 3 до 30 латинских букв, цифр или «_»",
+			})
+		}
+		var taken int64
+		uc.db.Model(&models.User{}).Where("username = ? AND id <> ?", username, user.ID).Count(&taken)
+		if taken > 0 {
+			return c.Status(409).JSON(fiber.Map{
+				"error":   true,
+				"message": "Имя пользователя уже занято",
+			})
+		}
+		user.Username = &username
+	}
 	user.Avatar = updateData.Avatar
 	user.Bio = updateData.Bio
 	user.Location = updateData.Location
@@ -197,6 +233,29 @@ func (uc *UserController) UpdateProfile(c *fiber.Ctx) error {
 	})
 }
 
+// AutocompleteUsers подсказывает пользователей для упоминания по началу имени (?q=&limit=)
+func (uc *UserController) AutocompleteUsers(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uint)
+
+	limit := c.QueryInt("limit", 10)
+	if limit < 1 || limit > 20 {
+		limit = 10
+	}
+
+	users, err := uc.mentions.Autocomplete(userID, c.Query("q"), limit)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error":   true,
+			"message": "Ошибка при поиске пользователей",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"users": users,
+		"error": false,
+	})
+}
+
 // GetUserEvents получает события пользователя
 func (uc *UserController) GetUserEvents(c *fiber.Ctx) error {
 	userID, err := strconv.ParseUint(c.Params("id"), 10, 32)
@@ -314,20 +373,39 @@ func (uc *UserController) GetUserCommunities(c *fiber.Ctx) error {
 		}
 	}
 
-	// Получаем сообщества, созданные пользователем
-	var communities []models.Community
-	if err := uc.db.Where("created_by = ?", userID).Order("created_at DESC").Find(&communities).Error; err != nil {
+	// Закрытые сообщества видны только самому пользователю
+	isOwner := false
+	if claims, err := utils.ValidateJWT(c.Get("Authorization")); err == nil {
+		isOwner = claims.UserID == uint(userID)
+	}
+
+	// Получаем сообщества, в которых состоит пользователь
+	membershipQuery := uc.db.Model(&models.CommunityRole{}).
+		Joins("JOIN communities ON communities.id = community_roles.community_id").
+		Where("community_roles.user_id = ?", userID)
+	if !isOwner {
+		membershipQuery = membershipQuery.Where("communities.visibility <> ?", models.CommunityVisibilityInvite)
+	}
+
+	var memberships []models.CommunityRole
+	if err := membershipQuery.Preload("Community").Order("community_roles.created_at DESC").Find(&memberships).Error; err != nil {
 		return c.Status(500).JSON(fiber.Map{
 			"error":   true,
 			"message": "Ошибка при получении сообществ",
 		})
 	}
 
+	communities := make([]models.Community, 0, len(memberships))
+	communityIDs := make([]uint, 0, len(memberships))
+	for _, membership := range memberships {
+		communities = append(communities, membership.Community)
+		communityIDs = append(communityIDs, membership.CommunityID)
+	}
+
 	// Получаем новости из сообществ пользователя
 	var news []models.News
-	if err := uc.db.Joins("JOIN communities ON news.community_id = communities.id").
-		Where("communities.created_by = ?", userID).
-		Order("news.created_at DESC").
+	if err := uc.db.Where("community_id IN ? AND status = ?", communityIDs, models.NewsStatusPublished).
+		Order("COALESCE(published_at, created_at) DESC").
 		Find(&news).Error; err != nil {
 		return c.Status(500).JSON(fiber.Map{
 			"error":   true,
@@ -337,6 +415,7 @@ func (uc *UserController) GetUserCommunities(c *fiber.Ctx) error {
 
 	return c.JSON(fiber.Map{
 		"communities": communities,
+		"memberships": memberships,
 		"news":        news,
 		"error":       false,
 		"message":     "Данные получены успешно",
@@ -26,7 +26,7 @@ func setupEventTestDB() *gorm.DB {
 	}
 
 	// Автомиграция
-	db.AutoMigrate(&models.User{}, &models.Event{}, &models.Inventory{}, &models.EventInventory{}, &models.EventPhoto{}, &models.EventParticipant{})
+	db.AutoMigrate(&models.User{}, &models.Event{}, &models.Inventory{}, &models.EventInventory{}, &models.EventPhoto{}, &models.EventParticipant{}, &models.AuditLog{}, &models.Community{}, &models.CommunityRole{}, &models.News{}, &models.EventReview{})
 
 	// Создаем тестового пользователя
 	user := models.User{
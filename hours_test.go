@@ -0,0 +1,137 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"toloko-backend/models"
+	"toloko-backend/routes"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVolunteerHoursAndCertificates(t *testing.T) {
+	app, db := setupPointsTestApp()
+	db.AutoMigrate(&models.Certificate{})
+	routes.SetupCertificateRoutes(app, db)
+
+	organizerID, organizerAuth := createCommunityMemberTestUser(db, "organizer@example.com")
+	organizerToken := generateTestJWT(organizerID)
+	volunteerID, volunteerAuth := createCommunityMemberTestUser(db, "volunteer@example.com")
+	volunteerToken := generateTestJWT(volunteerID)
+	_, strangerAuth := createCommunityMemberTestUser(db, "stranger@example.com")
+
+	start := time.Date(2025, time.June, 1, 10, 0, 0, 0, time.UTC)
+	// Уборка идет сейчас, чтобы отметить приход и уход; после отметок она переносится в прошлое
+	cleanup := models.Event{CreatorID: organizerID, Title: "Уборка парка", EventType: models.EventTypeEnvironmental, StartTime: time.Now().Add(-time.Hour), EndTime: time.Now().Add(3 * time.Hour), IsActive: true}
+	marathon := models.Event{CreatorID: organizerID, Title: "Забег", EventType: models.EventTypeSports, StartTime: start.AddDate(1, 0, 0), EndTime: start.AddDate(1, 0, 0).Add(3 * time.Hour), IsActive: true}
+	upcoming := models.Event{CreatorID: organizerID, Title: "Посадка деревьев", StartTime: time.Now().Add(24 * time.Hour), EndTime: time.Now().Add(26 * time.Hour), IsActive: true}
+	for _, event := range []*models.Event{&cleanup, &marathon, &upcoming} {
+		db.Create(event)
+	}
+	participants := make([]models.EventParticipant, 0, 3)
+	for _, event := range []models.Event{cleanup, marathon, upcoming} {
+		participant := models.EventParticipant{EventID: event.ID, UserID: volunteerID, Status: models.ParticipantStatusJoined}
+		db.Create(&participant)
+		participants = append(participants, participant)
+	}
+	participantPath := func(event models.Event, participant models.EventParticipant, action string) string {
+		return fmt.Sprintf("/events/%d/participants/%d/%s", event.ID, participant.ID, action)
+	}
+
+	// Уход отмечает только организатор и только после отметки прихода
+	status, _ := communityRequest(app, "POST", participantPath(cleanup, participants[0], "check-out"), organizerToken, nil)
+	assert.Equal(t, 400, status)
+	status, _ = communityRequest(app, "POST", participantPath(cleanup, participants[0], "check-in"), organizerToken, nil)
+	assert.Equal(t, 200, status)
+	status, _ = communityRequest(app, "POST", participantPath(cleanup, participants[0], "check-out"), volunteerToken, nil)
+	assert.Equal(t, 403, status)
+	status, _ = communityRequest(app, "POST", participantPath(cleanup, participants[0], "check-out"), organizerToken, nil)
+	assert.Equal(t, 200, status)
+	status, _ = communityRequest(app, "POST", participantPath(cleanup, participants[0], "check-out"), organizerToken, nil)
+	assert.Equal(t, 409, status)
+	db.Model(&cleanup).Updates(map[string]interface{}{"start_time": start, "end_time": start.Add(4 * time.Hour)})
+
+	// Организатор исправляет часы в разумных пределах
+	status, _ = communityRequest(app, "PUT", participantPath(cleanup, participants[0], "hours"), organizerToken, map[string]interface{}{"hours": 0})
+	assert.Equal(t, 400, status)
+	status, _ = communityRequest(app, "PUT", participantPath(cleanup, participants[0], "hours"), organizerToken, map[string]interface{}{"hours": 3.5})
+	assert.Equal(t, 200, status)
+
+	// Подтверждаются только часы прошедших ивентов, один раз
+	status, result := communityRequest(app, "POST", participantPath(cleanup, participants[0], "hours/confirm"), organizerToken, nil)
+	assert.Equal(t, 200, status)
+	assert.Equal(t, 3.5, result["participant"].(map[string]interface{})["hours"])
+	status, _ = communityRequest(app, "POST", participantPath(cleanup, participants[0], "hours/confirm"), organizerToken, nil)
+	assert.Equal(t, 409, status)
+	status, _ = communityRequest(app, "PUT", participantPath(cleanup, participants[0], "hours"), organizerToken, map[string]interface{}{"hours": 8})
+	assert.Equal(t, 409, status)
+	status, _ = communityRequest(app, "POST", participantPath(upcoming, participants[2], "hours/confirm"), organizerToken, nil)
+	assert.Equal(t, 400, status)
+
+	// Неотмеченному участнику часы без ручной правки не подтверждаются
+	status, _ = communityRequest(app, "POST", participantPath(marathon, participants[1], "hours/confirm"), organizerToken, nil)
+	assert.Equal(t, 400, status)
+
+	// Без отметки ухода часы считаются по продолжительности ивента
+	checkedInAt := marathon.StartTime
+	db.Model(&participants[1]).Update("checked_in_at", &checkedInAt)
+	status, result = communityRequest(app, "POST", participantPath(marathon, participants[1], "hours/confirm"), organizerToken, nil)
+	assert.Equal(t, 200, status)
+	assert.Equal(t, float64(3), result["participant"].(map[string]interface{})["hours"])
+
+	// Сводка по годам и типам ивентов видна только самому пользователю
+	hoursPath := fmt.Sprintf("/api/users/%d/hours", volunteerID)
+	status, result = communityRequest(app, "GET", hoursPath, volunteerAuth, nil)
+	assert.Equal(t, 200, status)
+	summary := result["summary"].(map[string]interface{})
+	assert.Equal(t, 6.5, summary["total_hours"])
+	assert.Equal(t, float64(2), summary["events_count"])
+	assert.Len(t, summary["by_year"], 2)
+	assert.Len(t, summary["by_event_type"], 2)
+
+	status, result = communityRequest(app, "GET", hoursPath+"?year=2025", volunteerAuth, nil)
+	assert.Equal(t, 200, status)
+	assert.Equal(t, 3.5, result["summary"].(map[string]interface{})["total_hours"])
+	status, _ = communityRequest(app, "GET", hoursPath, strangerAuth, nil)
+	assert.Equal(t, 403, status)
+
+	// Сертификат выдается только на подтвержденные часы
+	status, _ = communityRequest(app, "POST", "/api/certificates", organizerAuth, map[string]interface{}{})
+	assert.Equal(t, 400, status)
+	status, result = communityRequest(app, "POST", "/api/certificates", volunteerAuth, map[string]interface{}{"year": 2025})
+	assert.Equal(t, 201, status)
+	code := result["certificate"].(map[string]interface{})["code"].(string)
+
+	// Подлинность проверяется публично по коду без учета регистра
+	status, result = communityRequest(app, "GET", "/certificates/"+strings.ToLower(code), "", nil)
+	assert.Equal(t, 200, status)
+	assert.Equal(t, true, result["valid"])
+	assert.Equal(t, 3.5, result["certificate"].(map[string]interface{})["total_hours"])
+	status, result = communityRequest(app, "GET", "/certificates/AAAA-BBBB-CCCC", "", nil)
+	assert.Equal(t, 404, status)
+	assert.Equal(t, false, result["valid"])
+
+	// Файл для печати скачивает только владелец
+	download := func(token string) (int, string) {
+		req := httptest.NewRequest("GET", "/api/certificates/"+code+"/download", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := app.Test(req)
+		if err != nil {
+			return 0, ""
+		}
+		body, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(body)
+	}
+	status, body := download(volunteerAuth)
+	assert.Equal(t, 200, status)
+	assert.Contains(t, body, "volunteer@example.com")
+	assert.Contains(t, body, code)
+	assert.Contains(t, body, "в 2025 году")
+	status, _ = download(strangerAuth)
+	assert.Equal(t, 404, status)
+}
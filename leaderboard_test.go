@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"toloko-backend/models"
+	"toloko-backend/services"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLeaderboardScopes(t *testing.T) {
+	app, db := setupLevelTestApp()
+	aliceID, aliceToken := createCommunityMemberTestUser(db, "alice@example.com")
+	bobID, _ := createCommunityMemberTestUser(db, "bob@example.com")
+	carolID, carolToken := createCommunityMemberTestUser(db, "carol@example.com")
+	db.Model(&models.User{}).Where("id IN ?", []uint{aliceID, bobID}).Update("location", "Казань")
+	db.Model(&models.User{}).Where("id = ?", carolID).Update("location", " Москва ")
+
+	moscow := models.Event{CreatorID: carolID, Title: "Уборка парка", City: "Москва", EventType: models.EventTypeEnvironmental, StartTime: time.Now(), EndTime: time.Now()}
+	kazan := models.Event{CreatorID: carolID, Title: "Забег", City: "Казань", EventType: models.EventTypeSports, StartTime: time.Now(), EndTime: time.Now()}
+	db.Create(&moscow)
+	db.Create(&kazan)
+
+	// Алиса: 40 очков в Москве; Боб: 20 в Москве и 20 в Казани; Кэрол: 50 за организацию
+	services.GrantParticipationPoints(db, aliceID, moscow.ID)
+	services.GrantParticipationPoints(db, aliceID, moscow.ID+100)
+	services.GrantParticipationPoints(db, bobID, moscow.ID)
+	services.GrantParticipationPoints(db, bobID, kazan.ID)
+	services.GrantOrganizationPoints(db, &kazan)
+
+	// Очки прошлого года не попадают в таблицу за год
+	old := models.PointsEntry{UserID: bobID, Amount: 500, ReasonCode: models.PointsReasonParticipation, IdempotencyKey: "old"}
+	db.Create(&old)
+	db.Model(&old).Update("created_at", time.Now().AddDate(-1, -1, 0))
+
+	community := models.Community{CreatorID: aliceID, Name: "Закрытый клуб", City: "Казань", Visibility: models.CommunityVisibilityInvite}
+	db.Create(&community)
+	db.Create(&models.CommunityRole{CommunityID: community.ID, UserID: aliceID, Role: models.CommunityRoleAdmin})
+	db.Create(&models.CommunityRole{CommunityID: community.ID, UserID: bobID, Role: models.CommunityRoleMember})
+
+	// Таблицы отдаются из предрассчитанных данных: до пересчета они пусты
+	status, result := levelRequest(app, "GET", "/api/levels/rankings", "", nil)
+	assert.Equal(t, 200, status)
+	assert.Empty(t, result["entries"])
+	assert.NoError(t, services.NewLeaderboardService(db).Refresh(time.Now()))
+
+	rankings := func(query, token string) (int, []interface{}, map[string]interface{}) {
+		status, result := levelRequest(app, "GET", "/api/levels/rankings?"+query, token, nil)
+		entries, _ := result["entries"].([]interface{})
+		me, _ := result["me"].(map[string]interface{})
+		return status, entries, me
+	}
+	place := func(entry interface{}) (float64, float64, float64) {
+		row := entry.(map[string]interface{})
+		return row["user_id"].(float64), row["points"].(float64), row["rank"].(float64)
+	}
+
+	// За все время Боб первый благодаря старым очкам
+	_, entries, _ := rankings("period=all", "")
+	if assert.Len(t, entries, 3) {
+		user, points, rank := place(entries[0])
+		assert.Equal(t, []float64{float64(bobID), 540, 1}, []float64{user, points, rank})
+	}
+
+	// За год старые очки не учитываются: Алиса и Боб делят второе место
+	_, entries, _ = rankings("period=year", "")
+	if assert.Len(t, entries, 3) {
+		user, points, _ := place(entries[0])
+		assert.Equal(t, []float64{float64(carolID), 50}, []float64{user, points})
+		_, _, rank := place(entries[2])
+		assert.Equal(t, float64(2), rank)
+	}
+
+	// Свое место возвращается, даже если оно не попало на страницу
+	_, entries, me := rankings("period=year&limit=1", aliceToken)
+	assert.Len(t, entries, 1)
+	if assert.NotNil(t, me) {
+		assert.Equal(t, float64(aliceID), me["user_id"])
+		assert.Equal(t, float64(2), me["rank"])
+	}
+
+	// Город пользователя сравнивается без учета регистра и пробелов
+	_, entries, _ = rankings("period=month&scope=city&city="+"%20казань", "")
+	assert.Len(t, entries, 2)
+	_, entries, _ = rankings("period=month&scope=city&city=москва", "")
+	if assert.Len(t, entries, 1) {
+		user, _, _ := place(entries[0])
+		assert.Equal(t, float64(carolID), user)
+	}
+
+	// Город ивента: в Москве очки получили только участники московского ивента
+	_, entries, _ = rankings("period=week&scope=event_city&city=Москва", "")
+	if assert.Len(t, entries, 2) {
+		_, points, rank := place(entries[0])
+		assert.Equal(t, []float64{20, 1}, []float64{points, rank})
+	}
+
+	_, entries, _ = rankings(fmt.Sprintf("scope=event_type&event_type=%s", models.EventTypeSports), "")
+	assert.Len(t, entries, 2)
+
+	// Рейтинг закрытого сообщества доступен только его участникам
+	communityQuery := fmt.Sprintf("scope=community&community_id=%d", community.ID)
+	status, _, _ = rankings(communityQuery, "")
+	assert.Equal(t, 403, status)
+	status, _, _ = rankings(communityQuery, carolToken)
+	assert.Equal(t, 403, status)
+	status, entries, _ = rankings(communityQuery, aliceToken)
+	assert.Equal(t, 200, status)
+	assert.Len(t, entries, 2)
+
+	// Неверные параметры отклоняются
+	status, _, _ = rankings("period=decade", "")
+	assert.Equal(t, 400, status)
+	status, _, _ = rankings("scope=city", "")
+	assert.Equal(t, 400, status)
+	status, _, _ = rankings("scope=event_type&event_type=unknown", "")
+	assert.Equal(t, 400, status)
+}
+
+func TestLeaderboardPeriodStart(t *testing.T) {
+	// Среда 15 октября 2025 года
+	now := time.Date(2025, time.October, 15, 18, 30, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2025, time.October, 13, 0, 0, 0, 0, time.UTC), services.LeaderboardPeriodStart(models.LeaderboardPeriodWeek, now))
+	assert.Equal(t, time.Date(2025, time.October, 1, 0, 0, 0, 0, time.UTC), services.LeaderboardPeriodStart(models.LeaderboardPeriodMonth, now))
+	assert.Equal(t, time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC), services.LeaderboardPeriodStart(models.LeaderboardPeriodYear, now))
+	assert.True(t, services.LeaderboardPeriodStart(models.LeaderboardPeriodAll, now).IsZero())
+
+	// Воскресенье относится к неделе, начавшейся в понедельник
+	sunday := time.Date(2025, time.October, 19, 10, 0, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2025, time.October, 13, 0, 0, 0, 0, time.UTC), services.LeaderboardPeriodStart(models.LeaderboardPeriodWeek, sunday))
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"toloko-backend/controllers"
+	"toloko-backend/models"
+	"toloko-backend/routes"
+	"toloko-backend/services"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// setupLevelTestApp создает тестовое приложение с маршрутами уровней и ленты
+func setupLevelTestApp() (*fiber.App, *gorm.DB) {
+	db := setupCommunitiesTestDB()
+	db.AutoMigrate(&models.UserRole{}, &models.EventParticipant{})
+
+	app := fiber.New()
+	routes.SetupLevelRoutes(app, controllers.NewLevelController(db))
+	routes.SetupFeedRoutes(app, controllers.NewFeedController(db))
+	return app, db
+}
+
+// levelRequest выполняет запрос к маршрутам уровней, которые принимают токен без префикса Bearer
+func levelRequest(app *fiber.App, method, path, token string, body interface{}) (int, map[string]interface{}) {
+	data, _ := json.Marshal(body)
+	req := httptest.NewRequest(method, path, bytes.NewBuffer(data))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", token)
+
+	resp, err := app.Test(req)
+	if err != nil {
+		return 0, nil
+	}
+	var result map[string]interface{}
+	json.NewDecoder(resp.Body).Decode(&result)
+	return resp.StatusCode, result
+}
+
+func TestLevelUpEvent(t *testing.T) {
+	app, db := setupLevelTestApp()
+	volunteerID, volunteerToken := createCommunityMemberTestUser(db, "volunteer@example.com")
+	followerID, followerToken := createCommunityMemberTestUser(db, "follower@example.com")
+	_, strangerToken := createCommunityMemberTestUser(db, "stranger@example.com")
+	db.Create(&models.Subscription{SubscriberID: followerID, SubscribedToID: volunteerID})
+	assert.NoError(t, services.NewLevelService(db).SeedDefaultCurve())
+
+	// 120 очков переводят на второй уровень, событие повышения создается один раз
+	for eventID := uint(1); eventID <= 6; eventID++ {
+		_, err := services.GrantParticipationPoints(db, volunteerID, eventID)
+		assert.NoError(t, err)
+	}
+	var activities int64
+	db.Model(&models.FeedActivity{}).Count(&activities)
+	assert.Equal(t, int64(1), activities)
+
+	var notification models.Notification
+	assert.NoError(t, db.Where("user_id = ? AND type = ?", volunteerID, models.NotificationTypeLevelUp).First(&notification).Error)
+	assert.Contains(t, notification.Body, "Помощник")
+
+	// Прогресс считается по кривой: уровень 2 начинается со 100 очков, уровень 3 - с 300
+	status, result := levelRequest(app, "GET", "/api/levels/user/1", volunteerToken, nil)
+	assert.Equal(t, 200, status)
+	assert.Equal(t, "Помощник", result["rank"].(map[string]interface{})["title"])
+	progress := result["progress"].(map[string]interface{})
+	assert.Equal(t, float64(100), progress["current_level_points"])
+	assert.Equal(t, float64(300), progress["next_level_points"])
+	assert.Equal(t, float64(180), progress["points_to_next_level"])
+
+	// Запись о новом уровне видят подписчики, но не посторонние
+	status, result = communityRequest(app, "GET", "/feed/activities", followerToken, nil)
+	assert.Equal(t, 200, status)
+	if items := result["data"].(map[string]interface{})["activities"].([]interface{}); assert.Len(t, items, 1) {
+		assert.Equal(t, models.FeedActivityLevelUp, items[0].(map[string]interface{})["type"])
+	}
+	status, result = communityRequest(app, "GET", "/feed/activities", strangerToken, nil)
+	assert.Equal(t, 200, status)
+	assert.Equal(t, float64(0), result["data"].(map[string]interface{})["total"])
+}
+
+func TestUpdateLevelCurve(t *testing.T) {
+	app, db := setupLevelTestApp()
+	adminID, adminToken := createCommunityMemberTestUser(db, "admin@example.com")
+	volunteerID, volunteerToken := createCommunityMemberTestUser(db, "volunteer@example.com")
+	db.Create(&models.UserRole{UserID: adminID, Role: models.RoleAdmin})
+	for eventID := uint(1); eventID <= 8; eventID++ {
+		services.GrantParticipationPoints(db, volunteerID, eventID)
+	}
+
+	status, result := levelRequest(app, "GET", "/api/levels/definitions", "", nil)
+	assert.Equal(t, 200, status)
+	assert.Len(t, result["levels"], len(services.DefaultLevelCurve))
+
+	curve := map[string]interface{}{"levels": []map[string]interface{}{
+		{"level": 1, "threshold": 0, "title": "Новичок"},
+		{"level": 2, "threshold": 50, "title": "Помощник"},
+		{"level": 3, "threshold": 150, "title": "Эко-страж", "icon_path": "/icons/levels/eco-guardian.png"},
+	}}
+
+	// Менять кривую могут только администраторы, кривая проверяется
+	status, _ = levelRequest(app, "PUT", "/api/levels/definitions", volunteerToken, curve)
+	assert.Equal(t, 403, status)
+	status, _ = levelRequest(app, "PUT", "/api/levels/definitions", adminToken, map[string]interface{}{"levels": []map[string]interface{}{
+		{"level": 1, "threshold": 10, "title": "Новичок"},
+	}})
+	assert.Equal(t, 400, status)
+
+	// Смена кривой пересчитывает уровни без уведомлений о повышении
+	status, result = levelRequest(app, "PUT", "/api/levels/definitions", adminToken, curve)
+	assert.Equal(t, 200, status)
+	assert.Equal(t, float64(1), result["users_updated"])
+
+	var level models.UserLevel
+	db.Where("user_id = ?", volunteerID).First(&level)
+	assert.Equal(t, 3, level.Level)
+	var notifications int64
+	db.Model(&models.Notification{}).Where("type = ?", models.NotificationTypeLevelUp).Count(&notifications)
+	assert.Equal(t, int64(1), notifications)
+
+	status, result = levelRequest(app, "GET", "/api/levels/leaderboard", "", nil)
+	assert.Equal(t, 200, status)
+	assert.Equal(t, "Эко-страж", result["leaderboard"].([]interface{})[0].(map[string]interface{})["title"])
+}
+
+// notificationRecorder запоминает уведомления, доставленные в реальном времени
+type notificationRecorder struct {
+	messages []services.WSMessage
+}
+
+func (r *notificationRecorder) SendToUser(userID uint, message services.WSMessage) {
+	r.messages = append(r.messages, message)
+}
+
+func TestLevelUpAnnouncedOnce(t *testing.T) {
+	_, db := setupLevelTestApp()
+	volunteerID, _ := createCommunityMemberTestUser(db, "volunteer@example.com")
+	assert.NoError(t, services.NewLevelService(db).SeedDefaultCurve())
+
+	recorder := &notificationRecorder{}
+	services.SetNotificationPusher(recorder)
+	defer services.SetNotificationPusher(nil)
+
+	// Уведомление уходит клиенту только после фиксации транзакции
+	err := services.TransactionWithNotifications(db, func(tx *gorm.DB) error {
+		for eventID := uint(1); eventID <= 6; eventID++ {
+			if _, err := services.GrantParticipationPoints(tx, volunteerID, eventID); err != nil {
+				return err
+			}
+		}
+		assert.Empty(t, recorder.messages)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Len(t, recorder.messages, 1)
+
+	// Откат транзакции не доставляет уведомлений
+	recorder.messages = nil
+	err = services.TransactionWithNotifications(db, func(tx *gorm.DB) error {
+		services.NewNotificationService(tx).NotifyMany([]uint{volunteerID}, models.Notification{Type: models.NotificationTypeLevelUp, Title: "Черновик"})
+		return gorm.ErrInvalidTransaction
+	})
+	assert.Error(t, err)
+	assert.Empty(t, recorder.messages)
+
+	// После отмены очков уровень теряется, но повторное получение не объявляется
+	_, err = services.ReverseEventPoints(db, 1)
+	assert.NoError(t, err)
+	_, err = services.ReverseEventPoints(db, 2)
+	assert.NoError(t, err)
+	var level models.UserLevel
+	db.Where("user_id = ?", volunteerID).First(&level)
+	assert.Equal(t, 1, level.Level)
+	assert.Equal(t, 2, level.HighestLevel)
+
+	for eventID := uint(7); eventID <= 8; eventID++ {
+		_, err := services.GrantParticipationPoints(db, volunteerID, eventID)
+		assert.NoError(t, err)
+	}
+	db.Where("user_id = ?", volunteerID).First(&level)
+	assert.Equal(t, 2, level.Level)
+
+	var notifications, activities int64
+	db.Model(&models.Notification{}).Where("type = ?", models.NotificationTypeLevelUp).Count(&notifications)
+	db.Model(&models.FeedActivity{}).Where("type = ?", models.FeedActivityLevelUp).Count(&activities)
+	assert.Equal(t, int64(1), notifications)
+	assert.Equal(t, int64(1), activities)
+	assert.Empty(t, recorder.messages)
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"log"
 	"os"
+	"strings"
 	"time"
 
 	"toloko-backend/controllers"
@@ -24,8 +25,13 @@ func main() {
 		log.Fatal("Failed to connect to database:", err)
 	}
 
+	// Повторно выданные достижения удаляются до появления уникального индекса
+	if err := models.DedupeUserAchievements(db); err != nil {
+		log.Printf("Ошибка удаления повторных достижений: %v", err)
+	}
+
 	// Автомиграция
-	db.AutoMigrate(&models.User{}, &models.Event{}, &models.Inventory{}, &models.EventInventory{}, &models.EventPhoto{}, &models.EventParticipant{}, &models.ParticipantInventory{}, &models.EventPhotoPost{}, &models.Rating{}, &models.UserRatingSummary{}, &models.Complaint{}, &models.Subscription{}, &models.Community{}, &models.CommunityRole{}, &models.News{}, &models.Comment{}, &models.NewsLike{}, &models.Achievement{}, &models.UserAchievement{}, &models.UserLevel{}, &models.PinnedPost{}, &models.Conversation{}, &models.Message{}, &models.Attachment{}, &models.Block{}, &models.UserPresence{})
+	db.AutoMigrate(&models.User{}, &models.Event{}, &models.Inventory{}, &models.EventInventory{}, &models.EventPhoto{}, &models.EventParticipant{}, &models.ParticipantInventory{}, &models.EventPhotoPost{}, &models.Rating{}, &models.UserRatingSummary{}, &models.UserRatingBucket{}, &models.Complaint{}, &models.Subscription{}, &models.Community{}, &models.CommunityRole{}, &models.News{}, &models.Comment{}, &models.NewsLike{}, &models.Achievement{}, &models.UserAchievement{}, &models.UserLevel{}, &models.PinnedPost{}, &models.Conversation{}, &models.Message{}, &models.Attachment{}, &models.Block{}, &models.UserPresence{}, &models.UserRole{}, &models.RateLimitCounter{}, &models.TwoFactorAuth{}, &models.TwoFactorRecoveryCode{}, &models.TwoFactorChallenge{}, &models.AccountDeletionRequest{}, &models.AuditLog{}, &models.CommunityJoinRequest{}, &models.CommunityInvitation{}, &models.CommunityRoleChange{}, &models.Notification{}, &models.NewsMedia{}, &models.CommentLike{}, &models.Mention{}, &models.CommunitySanction{}, &models.CommunityModerationLog{}, &models.PointsEntry{}, &models.LevelDefinition{}, &models.FeedActivity{}, &models.LeaderboardEntry{}, &models.Certificate{}, &models.Challenge{}, &models.ChallengeTeam{}, &models.ChallengeParticipant{}, &models.ChallengeContribution{}, &models.EventReview{})
 
 	// Создание системного пользователя
 	initSystemUser(db)
@@ -36,6 +42,52 @@ func main() {
 	// Инициализация базовых достижений
 	initDefaultAchievements(db)
 
+	// Инициализация кривой уровней
+	if err := services.NewLevelService(db).SeedDefaultCurve(); err != nil {
+		log.Printf("Ошибка инициализации уровней: %v", err)
+	}
+
+	// Назначение администраторов платформы
+	initAdminRoles(db)
+
+	// Объекты жалоб, поданных до появления жалоб на контент
+	if err := models.BackfillComplaintTargets(db); err != nil {
+		log.Printf("Ошибка заполнения объектов жалоб: %v", err)
+	}
+
+	// Имена для упоминаний пользователям, зарегистрированным раньше
+	if err := models.BackfillUsernames(db); err != nil {
+		log.Printf("Ошибка назначения имен пользователей: %v", err)
+	}
+
+	// Счетчики ответов и глубина комментариев, оставленных до появления дерева
+	if err := models.BackfillCommentTree(db); err != nil {
+		log.Printf("Ошибка пересчета дерева комментариев: %v", err)
+	}
+
+	// Журнал очков: баланс пользователей пересчитывается по начислениям за полученные достижения
+	var pointsEntries int64
+	db.Model(&models.PointsEntry{}).Count(&pointsEntries)
+	if pointsEntries == 0 {
+		if err := services.NewPointsService(db).Backfill(); err != nil {
+			log.Printf("Ошибка заполнения журнала очков: %v", err)
+		}
+	}
+
+	// Ивенты у записей журнала, созданных до появления таблиц лидеров по городам и типам ивентов
+	if err := models.BackfillPointsEventIDs(db); err != nil {
+		log.Printf("Ошибка заполнения ивентов в журнале очков: %v", err)
+	}
+	if err := services.NewReputationService(db).BackfillReputation(); err != nil {
+		log.Printf("Ошибка пересчета репутации пользователей: %v", err)
+	}
+
+	// go run . backfill-achievements - выдать достижения по существующей истории и завершить работу
+	if len(os.Args) > 1 && os.Args[1] == "backfill-achievements" {
+		backfillAchievements(db)
+		return
+	}
+
 	// Создание Fiber приложения
 	app := fiber.New(fiber.Config{
 		ErrorHandler: func(c *fiber.Ctx, err error) error {
@@ -67,12 +119,18 @@ func main() {
 		AllowCredentials: true,
 	}))
 
+	// Ограничение частоты запросов
+	rateLimiter := services.NewRateLimiter(services.NewRateLimitStore(db), services.LoadRateLimitPolicies())
+	go rateLimiter.RunCleanup(10 * time.Minute)
+	routes.SetupRateLimits(app, rateLimiter)
+
 	// Инициализация контроллеров
 	authController := controllers.NewAuthController(db)
 	eventController := controllers.NewEventController(db)
 	subscriptionController := controllers.NewSubscriptionController(db)
 	feedController := controllers.NewFeedController(db)
 	communityController := controllers.NewCommunityController(db)
+	communityMemberController := controllers.NewCommunityMemberController(db)
 	newsController := controllers.NewNewsController(db)
 	commentController := controllers.NewCommentController(db)
 	userController := controllers.NewUserController(db)
@@ -89,6 +147,7 @@ func main() {
 	routes.SetupSubscriptionRoutes(app, subscriptionController)
 	routes.SetupFeedRoutes(app, feedController)
 	routes.SetupCommunityRoutes(app, communityController)
+	routes.SetupCommunityMemberRoutes(app, communityMemberController)
 	routes.SetupNewsRoutes(app, newsController)
 	routes.SetupCommentRoutes(app, commentController)
 	routes.SetupUserRoutes(app, userController)
@@ -105,10 +164,46 @@ func main() {
 	routes.SetupAttachmentRoutes(app, db)
 	routes.SetupBlockRoutes(app, db)
 
+	// Настройка маршрутов администрирования ролей
+	routes.SetupRoleRoutes(app, db)
+
+	// Настройка маршрутов двухфакторной аутентификации
+	routes.SetupTwoFactorRoutes(app, db)
+
+	// Настройка маршрутов журнала аудита
+	routes.SetupAuditRoutes(app, db)
+
+	// Настройка маршрутов экспорта данных и удаления аккаунта
+	routes.SetupAccountRoutes(app, db)
+	go services.NewAccountService(db).RunDeletionWorker(time.Hour)
+
+	// Настройка маршрутов уведомлений
+	routes.SetupNotificationRoutes(app, db)
+
+	// Настройка маршрутов волонтерских часов и сертификатов
+	routes.SetupCertificateRoutes(app, db)
+
+	// Настройка маршрутов челленджей
+	routes.SetupChallengeRoutes(app, db)
+
+	// Настройка маршрутов отзывов об ивентах и организаторах
+	routes.SetupReviewRoutes(app, db)
+
 	// Инициализация WebSocket хаба
 	hub := services.NewHub(db)
+	hub.SetRateLimiter(rateLimiter)
 	go hub.Run()
 
+	// Уведомления доставляются подключенным клиентам через хаб, отложенные новости публикуются в фоне
+	services.SetNotificationPusher(hub)
+
+	// Прогресс челленджей рассылается подписчикам тем сообществ и платформы
+	services.SetTopicPublisher(hub)
+	go services.NewNewsService(db).RunPublisher(time.Minute)
+
+	// Таблицы лидеров пересчитываются из журнала очков раз в час
+	go services.NewLeaderboardService(db).RunRefresher(time.Hour)
+
 	// WebSocket маршрут
 	app.Get("/ws", websocket.New(func(c *websocket.Conn) {
 		hub.HandleWebSocket(c)
@@ -154,6 +249,36 @@ func initSystemUser(db *gorm.DB) {
 	}
 }
 
+// initAdminRoles выдает роль администратора пользователям из переменной окружения ADMIN_EMAILS
+func initAdminRoles(db *gorm.DB) {
+	adminEmails := os.Getenv("ADMIN_EMAILS")
+	if adminEmails == "" {
+		return
+	}
+
+	permissionService := services.NewPermissionService(db)
+	for _, email := range strings.Split(adminEmails, ",") {
+		email = strings.TrimSpace(email)
+		if email == "" {
+			continue
+		}
+
+		var user models.User
+		if err := db.Where("email = ?", email).First(&user).Error; err != nil {
+			log.Printf("Администратор %s не найден: %v", email, err)
+			continue
+		}
+
+		if _, err := permissionService.GrantRole(user.ID, models.RoleAdmin, 0); err != nil {
+			if err != services.ErrRoleAlreadyGranted {
+				log.Printf("Ошибка при назначении администратора %s: %v", email, err)
+			}
+			continue
+		}
+		log.Printf("Назначен администратор: %s", email)
+	}
+}
+
 // initDefaultInventory инициализирует базовый инвентарь в системе
 func initDefaultInventory(db *gorm.DB) {
 	// Получаем ID системного пользователя
@@ -210,21 +335,40 @@ func initDefaultInventory(db *gorm.DB) {
 func initDefaultAchievements(db *gorm.DB) {
 	// Список базовых достижений
 	defaultAchievements := []models.Achievement{
-		{Name: "Первые шаги", Description: "Создайте аккаунт в системе", IconPath: "/icons/first-steps.png", Points: 10, Category: "registration", IsActive: true},
-		{Name: "Волонтер-новичок", Description: "Участвуйте в первом мероприятии", IconPath: "/icons/volunteer-beginner.png", Points: 25, Category: "participation", IsActive: true},
-		{Name: "Активный участник", Description: "Участвуйте в 5 мероприятиях", IconPath: "/icons/active-participant.png", Points: 50, Category: "participation", IsActive: true},
-		{Name: "Опытный волонтер", Description: "Участвуйте в 10 мероприятиях", IconPath: "/icons/experienced-volunteer.png", Points: 100, Category: "participation", IsActive: true},
-		{Name: "Лидер мероприятий", Description: "Участвуйте в 25 мероприятиях", IconPath: "/icons/event-leader.png", Points: 250, Category: "participation", IsActive: true},
-		{Name: "Организатор", Description: "Создайте первое мероприятие", IconPath: "/icons/organizer.png", Points: 75, Category: "organization", IsActive: true},
-		{Name: "Активный организатор", Description: "Создайте 5 мероприятий", IconPath: "/icons/active-organizer.png", Points: 150, Category: "organization", IsActive: true},
-		{Name: "Создатель сообщества", Description: "Создайте первое сообщество", IconPath: "/icons/community-creator.png", Points: 100, Category: "community", IsActive: true},
-		{Name: "Активный автор", Description: "Создайте 10 новостей", IconPath: "/icons/active-author.png", Points: 50, Category: "content", IsActive: true},
-		{Name: "Комментатор", Description: "Оставьте 50 комментариев", IconPath: "/icons/commentator.png", Points: 25, Category: "content", IsActive: true},
-		{Name: "Социальный активист", Description: "Подпишитесь на 10 пользователей", IconPath: "/icons/social-activist.png", Points: 30, Category: "social", IsActive: true},
-		{Name: "Популярный пользователь", Description: "Получите 10 подписчиков", IconPath: "/icons/popular-user.png", Points: 40, Category: "social", IsActive: true},
-		{Name: "Эксперт по уборке", Description: "Участвуйте в 5 уборках", IconPath: "/icons/cleaning-expert.png", Points: 75, Category: "specialization", IsActive: true},
-		{Name: "Экологический активист", Description: "Участвуйте в 3 экологических мероприятиях", IconPath: "/icons/eco-activist.png", Points: 60, Category: "specialization", IsActive: true},
-		{Name: "Помощник животных", Description: "Участвуйте в 3 мероприятиях помощи животным", IconPath: "/icons/animal-helper.png", Points: 60, Category: "specialization", IsActive: true},
+		{Name: "Первые шаги", Description: "Создайте аккаунт в системе", IconPath: "/icons/first-steps.png", Points: 10, Category: "registration", IsActive: true,
+			CriterionEvent: models.AchievementEventRegistered, CriterionThreshold: 1},
+		{Name: "Волонтер-новичок", Description: "Участвуйте в первом мероприятии", IconPath: "/icons/volunteer-beginner.png", Points: 25, Category: "participation", IsActive: true,
+			CriterionEvent: models.AchievementEventParticipated, CriterionThreshold: 1},
+		{Name: "Активный участник", Description: "Участвуйте в 5 мероприятиях", IconPath: "/icons/active-participant.png", Points: 50, Category: "participation", IsActive: true,
+			CriterionEvent: models.AchievementEventParticipated, CriterionThreshold: 5},
+		{Name: "Опытный волонтер", Description: "Участвуйте в 10 мероприятиях", IconPath: "/icons/experienced-volunteer.png", Points: 100, Category: "participation", IsActive: true,
+			CriterionEvent: models.AchievementEventParticipated, CriterionThreshold: 10},
+		{Name: "Лидер мероприятий", Description: "Участвуйте в 25 мероприятиях", IconPath: "/icons/event-leader.png", Points: 250, Category: "participation", IsActive: true,
+			CriterionEvent: models.AchievementEventParticipated, CriterionThreshold: 25},
+		{Name: "Организатор", Description: "Создайте первое мероприятие", IconPath: "/icons/organizer.png", Points: 75, Category: "organization", IsActive: true,
+			CriterionEvent: models.AchievementEventEventCreated, CriterionThreshold: 1},
+		{Name: "Активный организатор", Description: "Создайте 5 мероприятий", IconPath: "/icons/active-organizer.png", Points: 150, Category: "organization", IsActive: true,
+			CriterionEvent: models.AchievementEventEventCreated, CriterionThreshold: 5},
+		{Name: "Создатель сообщества", Description: "Создайте первое сообщество", IconPath: "/icons/community-creator.png", Points: 100, Category: "community", IsActive: true,
+			CriterionEvent: models.AchievementEventCommunityCreated, CriterionThreshold: 1},
+		{Name: "Активный автор", Description: "Создайте 10 новостей", IconPath: "/icons/active-author.png", Points: 50, Category: "content", IsActive: true,
+			CriterionEvent: models.AchievementEventNewsPublished, CriterionThreshold: 10},
+		{Name: "Комментатор", Description: "Оставьте 50 комментариев", IconPath: "/icons/commentator.png", Points: 25, Category: "content", IsActive: true,
+			CriterionEvent: models.AchievementEventCommentCreated, CriterionThreshold: 50},
+		{Name: "Социальный активист", Description: "Подпишитесь на 10 пользователей", IconPath: "/icons/social-activist.png", Points: 30, Category: "social", IsActive: true,
+			CriterionEvent: models.AchievementEventSubscribed, CriterionThreshold: 10},
+		{Name: "Популярный пользователь", Description: "Получите 10 подписчиков", IconPath: "/icons/popular-user.png", Points: 40, Category: "social", IsActive: true,
+			CriterionEvent: models.AchievementEventSubscriberGained, CriterionThreshold: 10},
+		{Name: "Эксперт по уборке", Description: "Участвуйте в 5 уборках", IconPath: "/icons/cleaning-expert.png", Points: 75, Category: "specialization", IsActive: true,
+			CriterionEvent: models.AchievementEventParticipated, CriterionThreshold: 5, CriterionKeyword: "уборк"},
+		{Name: "Экологический активист", Description: "Участвуйте в 3 экологических мероприятиях", IconPath: "/icons/eco-activist.png", Points: 60, Category: "specialization", IsActive: true,
+			CriterionEvent: models.AchievementEventParticipated, CriterionThreshold: 3, CriterionEventType: models.EventTypeEnvironmental},
+		{Name: "Помощник животных", Description: "Участвуйте в 3 мероприятиях помощи животным", IconPath: "/icons/animal-helper.png", Points: 60, Category: "specialization", IsActive: true,
+			CriterionEvent: models.AchievementEventParticipated, CriterionThreshold: 3, CriterionKeyword: "животн"},
+		{Name: "Четыре недели подряд", Description: "Участвуйте в ивентах 4 недели подряд", IconPath: "/icons/weekly-streak.png", Points: 50, Category: "consistency", IsActive: true,
+			CriterionEvent: models.AchievementEventWeeklyStreak, CriterionThreshold: 4},
+		{Name: "Полгода без перерыва", Description: "Участвуйте в ивентах 6 месяцев подряд", IconPath: "/icons/monthly-streak.png", Points: 150, Category: "consistency", IsActive: true,
+			CriterionEvent: models.AchievementEventMonthlyStreak, CriterionThreshold: 6},
 	}
 
 	// Проверяем, есть ли уже достижения в базе
@@ -244,4 +388,33 @@ func initDefaultAchievements(db *gorm.DB) {
 	} else {
 		log.Printf("Базовые достижения уже существуют (%d элементов)", count)
 	}
+
+	// Достижения за серии добавлены после первичной инициализации
+	for _, achievement := range defaultAchievements {
+		if achievement.IsStreakCriterion() {
+			db.Where("name = ?", achievement.Name).FirstOrCreate(&achievement)
+		}
+	}
+
+	// Критерии базовых достижений, созданных до появления автоматической выдачи
+	for _, achievement := range defaultAchievements {
+		db.Model(&models.Achievement{}).
+			Where("name = ? AND (criterion_event = '' OR criterion_event IS NULL)", achievement.Name).
+			Updates(map[string]interface{}{
+				"criterion_event":      achievement.CriterionEvent,
+				"criterion_threshold":  achievement.CriterionThreshold,
+				"criterion_event_type": achievement.CriterionEventType,
+				"criterion_keyword":    achievement.CriterionKeyword,
+			})
+	}
+}
+
+// backfillAchievements выдает достижения по истории участия, ивентов, сообществ, новостей, комментариев и подписок
+func backfillAchievements(db *gorm.DB) {
+	log.Println("Пересчет достижений по существующей истории...")
+	awarded, err := services.NewAchievementService(db).Backfill()
+	if err != nil {
+		log.Fatalf("Ошибка пересчета достижений (выдано %d): %v", awarded, err)
+	}
+	log.Printf("Пересчет достижений завершен, выдано: %d", awarded)
 }
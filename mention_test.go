@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"toloko-backend/controllers"
+	"toloko-backend/models"
+	"toloko-backend/routes"
+	"toloko-backend/services"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewsAndCommentMentions(t *testing.T) {
+	db := setupCommunitiesTestDB()
+	app := setupCommunitiesTestApp(db)
+
+	authorID, authorToken := createCommunityMemberTestUser(db, "author@example.com")
+	aliceID, _ := createCommunityMemberTestUser(db, "alice@example.com")
+	bobID, _ := createCommunityMemberTestUser(db, "bob@example.com")
+	blockerID, _ := createCommunityMemberTestUser(db, "blocker@example.com")
+	db.Create(&models.Block{BlockerID: blockerID, BlockedID: authorID})
+	community := createCommunitiesTestCommunity(db, authorID)
+
+	status, result := communityRequest(app, "POST", fmt.Sprintf("/communities/%d/news", community.ID), authorToken, map[string]interface{}{
+		"content": "Спасибо @Alice и @blocker! Пишите на team@bob.org, @nobody",
+	})
+	assert.Equal(t, 201, status)
+	data := result["data"].(map[string]interface{})
+	newsID := uint(data["id"].(float64))
+
+	// Упоминание сохраняется с ID пользователя и позицией в символах
+	mentions := data["mentions"].([]interface{})
+	if assert.Len(t, mentions, 1) {
+		mention := mentions[0].(map[string]interface{})
+		assert.Equal(t, float64(aliceID), mention["user_id"])
+		assert.Equal(t, "alice", mention["username"])
+		assert.Equal(t, float64(8), mention["offset"])
+		assert.Equal(t, float64(6), mention["length"])
+	}
+
+	var notified []uint
+	db.Model(&models.Notification{}).Where("type = ?", models.NotificationTypeMention).Pluck("user_id", &notified)
+	assert.Equal(t, []uint{aliceID}, notified)
+
+	// При редактировании комментария уведомление получают только впервые упомянутые
+	status, result = communityRequest(app, "POST", fmt.Sprintf("/news/%d/comments", newsID), authorToken, map[string]interface{}{"content": "@alice привет"})
+	assert.Equal(t, 201, status)
+	commentID := uint(result["data"].(map[string]interface{})["id"].(float64))
+	status, result = communityRequest(app, "PUT", fmt.Sprintf("/comments/%d", commentID), authorToken, map[string]interface{}{"content": "@alice и @bob привет"})
+	assert.Equal(t, 200, status)
+	assert.Len(t, result["data"].(map[string]interface{})["mentions"], 2)
+
+	var aliceCount, bobCount int64
+	db.Model(&models.Notification{}).Where("type = ? AND user_id = ?", models.NotificationTypeMention, aliceID).Count(&aliceCount)
+	db.Model(&models.Notification{}).Where("type = ? AND user_id = ? AND entity_type = ?", models.NotificationTypeMention, bobID, models.MentionSourceComment).Count(&bobCount)
+	assert.Equal(t, int64(2), aliceCount)
+	assert.Equal(t, int64(1), bobCount)
+
+	// В закрытом сообществе упомянуть можно только участников
+	db.Model(community).Update("visibility", models.CommunityVisibilityInvite)
+	status, result = communityRequest(app, "PUT", fmt.Sprintf("/news/%d", newsID), authorToken, map[string]interface{}{"content": "Обновление для @alice"})
+	assert.Equal(t, 200, status)
+	assert.Empty(t, result["data"].(map[string]interface{})["mentions"])
+}
+
+func TestMembersOnlyEventMentions(t *testing.T) {
+	db := setupCommunitiesTestDB()
+	db.AutoMigrate(&models.EventParticipant{})
+	app := setupCommunitiesTestApp(db)
+
+	authorID, authorToken := createCommunityMemberTestUser(db, "author@example.com")
+	aliceID, _ := createCommunityMemberTestUser(db, "alice@example.com")
+	bobID, _ := createCommunityMemberTestUser(db, "bob@example.com")
+	community := createCommunitiesTestCommunity(db, authorID)
+	db.Create(&models.CommunityRole{CommunityID: community.ID, UserID: bobID, Role: models.CommunityRoleMember})
+
+	// Анонс ивента «только для участников» в открытом сообществе скрыт от посторонних
+	event := createCommunityTestEvent(db, authorID, community.ID, time.Now().Add(24*time.Hour), true)
+	news := models.News{CommunityID: community.ID, AuthorID: authorID, EventID: &event.ID, Content: "Анонс закрытого ивента"}
+	db.Create(&news)
+
+	status, result := communityRequest(app, "PUT", fmt.Sprintf("/news/%d", news.ID), authorToken, map[string]interface{}{"content": "Ждем @alice и @bob"})
+	assert.Equal(t, 200, status)
+	if mentions := result["data"].(map[string]interface{})["mentions"].([]interface{}); assert.Len(t, mentions, 1) {
+		assert.Equal(t, float64(bobID), mentions[0].(map[string]interface{})["user_id"])
+	}
+
+	status, result = communityRequest(app, "POST", fmt.Sprintf("/news/%d/comments", news.ID), authorToken, map[string]interface{}{"content": "@alice, @bob, не забудьте перчатки"})
+	assert.Equal(t, 201, status)
+	assert.Len(t, result["data"].(map[string]interface{})["mentions"], 1)
+
+	var aliceCount int64
+	db.Model(&models.Notification{}).Where("type = ? AND user_id = ?", models.NotificationTypeMention, aliceID).Count(&aliceCount)
+	assert.Equal(t, int64(0), aliceCount)
+}
+
+func TestMentionAutocomplete(t *testing.T) {
+	db := setupCommunitiesTestDB()
+	app := setupCommunitiesTestApp(db)
+	routes.SetupUserRoutes(app, controllers.NewUserController(db))
+
+	viewerID, token := createCommunityMemberTestUser(db, "viewer@example.com")
+	createCommunityMemberTestUser(db, "anna@example.com")
+	createCommunityMemberTestUser(db, "anton@example.com")
+	hiddenID, _ := createCommunityMemberTestUser(db, "andrey@example.com")
+	db.Model(&models.User{}).Where("id = ?", hiddenID).Update("is_public", false)
+	blockedID, _ := createCommunityMemberTestUser(db, "angela@example.com")
+	db.Create(&models.Block{BlockerID: viewerID, BlockedID: blockedID})
+	createCommunityMemberTestUser(db, "boris@example.com")
+
+	status, _ := communityRequest(app, "GET", "/api/users/autocomplete?q=an", "", nil)
+	assert.Equal(t, 401, status)
+
+	status, result := communityRequest(app, "GET", "/api/users/autocomplete?q=@AN", token, nil)
+	assert.Equal(t, 200, status)
+	var usernames []string
+	for _, item := range result["users"].([]interface{}) {
+		usernames = append(usernames, item.(map[string]interface{})["username"].(string))
+	}
+	assert.Equal(t, []string{"anna", "anton"}, usernames)
+}
+
+func TestMessageMentions(t *testing.T) {
+	db := setupTestDB()
+	user1ID, user2ID := createTestUsers(db)
+	outsiderID, _ := createCommunityMemberTestUser(db, "outsider@example.com")
+	conversation := models.Conversation{UserAID: user1ID, UserBID: user2ID}
+	db.Create(&conversation)
+
+	// Упомянуть в личном сообщении можно только участника диалога
+	message, err := services.NewMessageService(db).CreateMessage(conversation.ID, user1ID, user2ID, "@user2 и @outsider, смотрите", "")
+	assert.NoError(t, err)
+	if assert.Len(t, message.Mentions, 1) {
+		assert.Equal(t, user2ID, message.Mentions[0].UserID)
+	}
+
+	var count int64
+	db.Model(&models.Mention{}).Where("user_id = ?", outsiderID).Count(&count)
+	assert.Equal(t, int64(0), count)
+}
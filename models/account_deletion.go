@@ -0,0 +1,45 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Статусы запроса на удаление аккаунта
+const (
+	AccountDeletionStatusPending   = "pending"
+	AccountDeletionStatusCancelled = "cancelled"
+	AccountDeletionStatusCompleted = "completed"
+)
+
+// AccountDeletionRequest представляет запрос пользователя на удаление аккаунта.
+// До наступления ScheduledAt запрос можно отменить, после этого аккаунт обезличивается.
+type AccountDeletionRequest struct {
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	UserID      uint       `json:"user_id" gorm:"not null;index"`
+	Status      string     `json:"status" gorm:"not null;size:20;default:'pending';index"`
+	ScheduledAt time.Time  `json:"scheduled_at" gorm:"not null;index"` // Момент удаления после окончания льготного периода
+	CancelledAt *time.Time `json:"cancelled_at"`
+	CompletedAt *time.Time `json:"completed_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// BeforeCreate хук для установки времени создания
+func (r *AccountDeletionRequest) BeforeCreate(tx *gorm.DB) error {
+	r.CreatedAt = time.Now()
+	r.UpdatedAt = time.Now()
+	return nil
+}
+
+// BeforeUpdate хук для обновления времени изменения
+func (r *AccountDeletionRequest) BeforeUpdate(tx *gorm.DB) error {
+	r.UpdatedAt = time.Now()
+	return nil
+}
+
+// IsPending проверяет, ожидает ли запрос исполнения
+func (r *AccountDeletionRequest) IsPending() bool {
+	return r.Status == AccountDeletionStatusPending
+}
@@ -6,6 +6,20 @@ import (
 	"gorm.io/gorm"
 )
 
+// Доменные события, по которым начисляются достижения
+const (
+	AchievementEventRegistered       = "user.registered"      // регистрация пользователя
+	AchievementEventParticipated     = "event.participated"   // участие в ивенте (организатор отметил присутствие)
+	AchievementEventEventCreated     = "event.created"        // создание ивента
+	AchievementEventCommunityCreated = "community.created"    // создание сообщества
+	AchievementEventNewsPublished    = "news.published"       // публикация новости
+	AchievementEventCommentCreated   = "comment.created"      // комментарий к новости
+	AchievementEventSubscribed       = "subscription.created" // подписка на пользователя
+	AchievementEventSubscriberGained = "subscriber.gained"    // новый подписчик
+	AchievementEventWeeklyStreak     = "streak.weekly"        // самая длинная серия недель с участием в ивентах
+	AchievementEventMonthlyStreak    = "streak.monthly"       // самая длинная серия месяцев с участием в ивентах
+)
+
 // Achievement представляет модель достижения в системе
 type Achievement struct {
 	ID          uint      `json:"id" gorm:"primaryKey"`
@@ -17,13 +31,20 @@ type Achievement struct {
 	IsActive    bool      `json:"is_active" gorm:"default:true"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
+
+	// Критерий автоматического получения: число доменных событий не меньше порога.
+	// Пустой CriterionEvent - достижение выдается только вручную.
+	CriterionEvent     string `json:"criterion_event" gorm:"size:50;index"`
+	CriterionThreshold int    `json:"criterion_threshold" gorm:"default:1"`
+	CriterionEventType string `json:"criterion_event_type" gorm:"size:50"` // фильтр по типу ивента
+	CriterionKeyword   string `json:"criterion_keyword" gorm:"size:100"`   // фильтр по названию ивента
 }
 
 // UserAchievement представляет связь пользователя с достижением
 type UserAchievement struct {
 	ID            uint      `json:"id" gorm:"primaryKey"`
-	UserID        uint      `json:"user_id" gorm:"not null"`
-	AchievementID uint      `json:"achievement_id" gorm:"not null"`
+	UserID        uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_user_achievement"`
+	AchievementID uint      `json:"achievement_id" gorm:"not null;uniqueIndex:idx_user_achievement"`
 	EarnedAt      time.Time `json:"earned_at"`
 	CreatedAt     time.Time `json:"created_at"`
 	UpdatedAt     time.Time `json:"updated_at"`
@@ -35,11 +56,12 @@ type UserAchievement struct {
 
 // UserLevel представляет уровень пользователя
 type UserLevel struct {
-	ID        uint      `json:"id" gorm:"primaryKey"`
-	UserID    uint      `json:"user_id" gorm:"not null;uniqueIndex"`
-	Level     int       `json:"level" gorm:"default:1"`
-	Points    int       `json:"points" gorm:"default:0"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	UserID       uint      `json:"user_id" gorm:"not null;uniqueIndex"`
+	Level        int       `json:"level" gorm:"default:1"`
+	HighestLevel int       `json:"highest_level" gorm:"default:0"` // максимальный достигнутый уровень: повышение до него не объявляется повторно
+	Points       int       `json:"points" gorm:"default:0"`
+	UpdatedAt    time.Time `json:"updated_at"`
 
 	// Связи
 	User User `json:"user" gorm:"foreignKey:UserID"`
@@ -59,6 +81,32 @@ type PinnedPost struct {
 	User User `json:"user" gorm:"foreignKey:UserID"`
 }
 
+// HasCriterion проверяет, выдается ли достижение автоматически
+func (a *Achievement) HasCriterion() bool {
+	return a.CriterionEvent != ""
+}
+
+// IsStreakCriterion проверяет, считает ли критерий длину серии участия, а не число событий
+func (a *Achievement) IsStreakCriterion() bool {
+	return a.CriterionEvent == AchievementEventWeeklyStreak || a.CriterionEvent == AchievementEventMonthlyStreak
+}
+
+// IsEventCriterion проверяет, считает ли критерий ивенты, к которым применимы фильтры по типу и названию
+func (a *Achievement) IsEventCriterion() bool {
+	return a.CriterionEvent == AchievementEventParticipated || a.CriterionEvent == AchievementEventEventCreated
+}
+
+// DedupeUserAchievements удаляет повторно выданные достижения, оставляя самую раннюю запись.
+// Вызывается до миграции, добавляющей уникальный индекс.
+func DedupeUserAchievements(db *gorm.DB) error {
+	if !db.Migrator().HasTable(&UserAchievement{}) {
+		return nil
+	}
+	return db.Exec(`DELETE FROM user_achievements WHERE id NOT IN (
+		SELECT MIN(id) FROM user_achievements GROUP BY user_id, achievement_id
+	)`).Error
+}
+
 // BeforeCreate хук для установки времени создания
 func (ua *UserAchievement) BeforeCreate(tx *gorm.DB) error {
 	ua.CreatedAt = time.Now()
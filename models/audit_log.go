@@ -0,0 +1,87 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrAuditLogImmutable записи журнала аудита нельзя изменять или удалять
+var ErrAuditLogImmutable = errors.New("audit log entries are append-only")
+
+// Действия, записываемые в журнал аудита
+const (
+	AuditActionComplaintStatusUpdate = "complaint.status_update"
+	AuditActionEventUpdate           = "event.update"
+	AuditActionEventDelete           = "event.delete"
+	AuditActionApplicationApprove    = "application.approve"
+	AuditActionApplicationReject     = "application.reject"
+	AuditActionCommunityDelete       = "community.delete"
+)
+
+// Типы объектов журнала аудита
+const (
+	AuditTargetComplaint   = "complaint"
+	AuditTargetEvent       = "event"
+	AuditTargetApplication = "event_participant"
+	AuditTargetCommunity   = "community"
+)
+
+// AuditChange представляет изменение одного поля
+type AuditChange struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+// AuditLog представляет запись журнала аудита.
+// Записи только добавляются: изменение и удаление запрещены хуками.
+type AuditLog struct {
+	ID          uint                   `json:"id" gorm:"primaryKey"`
+	ActorID     uint                   `json:"actor_id" gorm:"not null;index"`
+	Action      string                 `json:"action" gorm:"not null;size:100;index"`
+	TargetType  string                 `json:"target_type" gorm:"not null;size:50;index:idx_audit_target"`
+	TargetID    uint                   `json:"target_id" gorm:"not null;index:idx_audit_target"`
+	ChangesJSON string                 `json:"-" gorm:"column:changes;type:text"`
+	Changes     map[string]AuditChange `json:"changes" gorm:"-"`
+	IPAddress   string                 `json:"ip_address" gorm:"size:64"`
+	UserAgent   string                 `json:"user_agent" gorm:"size:255"`
+	Method      string                 `json:"method" gorm:"size:10"`
+	Path        string                 `json:"path" gorm:"size:255"`
+	CreatedAt   time.Time              `json:"created_at" gorm:"index"`
+
+	// Связи
+	Actor User `json:"actor" gorm:"foreignKey:ActorID"`
+}
+
+// BeforeCreate хук для установки времени создания и сериализации изменений
+func (a *AuditLog) BeforeCreate(tx *gorm.DB) error {
+	a.CreatedAt = time.Now()
+	if a.Changes != nil {
+		data, err := json.Marshal(a.Changes)
+		if err != nil {
+			return err
+		}
+		a.ChangesJSON = string(data)
+	}
+	return nil
+}
+
+// BeforeUpdate запрещает изменение записей журнала
+func (a *AuditLog) BeforeUpdate(tx *gorm.DB) error {
+	return ErrAuditLogImmutable
+}
+
+// BeforeDelete запрещает удаление записей журнала
+func (a *AuditLog) BeforeDelete(tx *gorm.DB) error {
+	return ErrAuditLogImmutable
+}
+
+// AfterFind хук для разбора изменений из JSON
+func (a *AuditLog) AfterFind(tx *gorm.DB) error {
+	if a.ChangesJSON == "" {
+		return nil
+	}
+	return json.Unmarshal([]byte(a.ChangesJSON), &a.Changes)
+}
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Certificate представляет сертификат о волонтерских часах.
+// Данные сертификата фиксируются при выдаче и проверяются по коду.
+type Certificate struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	Code        string    `json:"code" gorm:"not null;size:20;uniqueIndex"` // код проверки подлинности
+	UserID      uint      `json:"user_id" gorm:"not null;index"`
+	UserName    string    `json:"user_name" gorm:"not null;size:100"`
+	Year        *int      `json:"year"` // год, за который выдан сертификат; пусто - за все время
+	TotalHours  float64   `json:"total_hours" gorm:"not null"`
+	EventsCount int       `json:"events_count" gorm:"not null"`
+	IssuedAt    time.Time `json:"issued_at"`
+
+	// Связи
+	User User `json:"-" gorm:"foreignKey:UserID"`
+}
+
+// BeforeCreate хук для установки времени выдачи
+func (c *Certificate) BeforeCreate(tx *gorm.DB) error {
+	c.IssuedAt = time.Now()
+	return nil
+}
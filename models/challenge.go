@@ -0,0 +1,163 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Метрики челленджей
+const (
+	ChallengeMetricEvents = "events" // посещенные ивенты (с отметкой о присутствии)
+	ChallengeMetricHours  = "hours"  // подтвержденные волонтерские часы
+	ChallengeMetricImpact = "impact" // результат в натуральных единицах, например собранные мешки
+)
+
+// Области цели челленджа
+const (
+	ChallengeGoalIndividual = "individual" // цель у каждого участника или команды
+	ChallengeGoalCollective = "collective" // общая цель всех участников, например 1000 мешков на сообщество
+)
+
+// MaxCommunityChallengeRewardPoints наибольшая награда за челлендж сообщества;
+// большую награду может назначить только пользователь с правом управления очками
+const MaxCommunityChallengeRewardPoints = 100
+
+// Статусы челленджей по времени проведения
+const (
+	ChallengeStatusUpcoming = "upcoming"
+	ChallengeStatusActive   = "active"
+	ChallengeStatusFinished = "finished"
+)
+
+// Challenge представляет ограниченный по времени челлендж сообщества или платформы.
+// Цель относится к каждому участнику или команде либо ко всем участникам вместе;
+// за ее достижение выдается награда.
+type Challenge struct {
+	ID                  uint       `json:"id" gorm:"primaryKey"`
+	CommunityID         *uint      `json:"community_id" gorm:"index"` // пусто - челлендж платформы
+	CreatorID           uint       `json:"creator_id" gorm:"not null"`
+	Title               string     `json:"title" gorm:"not null;size:200"`
+	Description         string     `json:"description" gorm:"type:text"`
+	Metric              string     `json:"metric" gorm:"not null;size:20"`
+	ImpactUnit          string     `json:"impact_unit" gorm:"size:50"` // единица результата для метрики impact
+	Goal                float64    `json:"goal" gorm:"not null"`
+	GoalScope           string     `json:"goal_scope" gorm:"not null;size:20;default:'individual'"`
+	GoalReachedAt       *time.Time `json:"goal_reached_at"` // общая цель достигнута
+	StartsAt            time.Time  `json:"starts_at" gorm:"not null;index"`
+	EndsAt              time.Time  `json:"ends_at" gorm:"not null;index"`
+	RewardPoints        int        `json:"reward_points" gorm:"default:0"`
+	RewardAchievementID *uint      `json:"reward_achievement_id"`
+	CreatedAt           time.Time  `json:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at"`
+
+	// Связи
+	Community         *Community   `json:"community,omitempty" gorm:"foreignKey:CommunityID"`
+	Creator           User         `json:"creator" gorm:"foreignKey:CreatorID"`
+	RewardAchievement *Achievement `json:"reward_achievement,omitempty" gorm:"foreignKey:RewardAchievementID"`
+}
+
+// ChallengeTeam представляет команду челленджа; прогресс команды - сумма прогресса ее участников
+type ChallengeTeam struct {
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	ChallengeID uint       `json:"challenge_id" gorm:"not null;uniqueIndex:idx_challenge_team_name"`
+	Name        string     `json:"name" gorm:"not null;size:100;uniqueIndex:idx_challenge_team_name"`
+	CaptainID   uint       `json:"captain_id" gorm:"not null"`
+	CompletedAt *time.Time `json:"completed_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+
+	// Связи
+	Captain User `json:"captain" gorm:"foreignKey:CaptainID"`
+}
+
+// ChallengeParticipant представляет участие пользователя в челлендже, лично или в составе команды
+type ChallengeParticipant struct {
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	ChallengeID uint       `json:"challenge_id" gorm:"not null;uniqueIndex:idx_challenge_participant"`
+	UserID      uint       `json:"user_id" gorm:"not null;uniqueIndex:idx_challenge_participant"`
+	TeamID      *uint      `json:"team_id" gorm:"index"`
+	CompletedAt *time.Time `json:"completed_at"` // участник (или его команда) достиг цели и получил награду
+	CreatedAt   time.Time  `json:"created_at"`
+
+	// Связи
+	User User           `json:"user" gorm:"foreignKey:UserID"`
+	Team *ChallengeTeam `json:"team,omitempty" gorm:"foreignKey:TeamID"`
+}
+
+// ChallengeContribution представляет результат участника на ивенте для метрики impact.
+// Результат засчитывается в прогресс только после подтверждения организатором ивента.
+type ChallengeContribution struct {
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	ChallengeID uint       `json:"challenge_id" gorm:"not null;uniqueIndex:idx_challenge_contribution"`
+	UserID      uint       `json:"user_id" gorm:"not null;uniqueIndex:idx_challenge_contribution"`
+	EventID     uint       `json:"event_id" gorm:"not null;uniqueIndex:idx_challenge_contribution"`
+	Quantity    float64    `json:"quantity" gorm:"not null"`
+	ConfirmedAt *time.Time `json:"confirmed_at" gorm:"index"` // организатор ивента подтвердил результат
+	ConfirmedBy *uint      `json:"confirmed_by"`
+	CreatedAt   time.Time  `json:"created_at"`
+
+	// Связи
+	User  User  `json:"user" gorm:"foreignKey:UserID"`
+	Event Event `json:"event" gorm:"foreignKey:EventID"`
+}
+
+// IsValidChallengeMetric проверяет, что метрика челленджа поддерживается
+func IsValidChallengeMetric(metric string) bool {
+	switch metric {
+	case ChallengeMetricEvents, ChallengeMetricHours, ChallengeMetricImpact:
+		return true
+	}
+	return false
+}
+
+// IsValidChallengeGoalScope проверяет, что область цели челленджа поддерживается
+func IsValidChallengeGoalScope(scope string) bool {
+	return scope == ChallengeGoalIndividual || scope == ChallengeGoalCollective
+}
+
+// IsCollective проверяет, что цель челленджа общая для всех участников
+func (c *Challenge) IsCollective() bool {
+	return c.GoalScope == ChallengeGoalCollective
+}
+
+// Status возвращает статус челленджа на указанный момент
+func (c *Challenge) Status(now time.Time) string {
+	if now.Before(c.StartsAt) {
+		return ChallengeStatusUpcoming
+	}
+	if now.After(c.EndsAt) {
+		return ChallengeStatusFinished
+	}
+	return ChallengeStatusActive
+}
+
+// BeforeCreate хук для установки времени создания
+func (c *Challenge) BeforeCreate(tx *gorm.DB) error {
+	c.CreatedAt = time.Now()
+	c.UpdatedAt = time.Now()
+	return nil
+}
+
+// BeforeUpdate хук для установки времени обновления
+func (c *Challenge) BeforeUpdate(tx *gorm.DB) error {
+	c.UpdatedAt = time.Now()
+	return nil
+}
+
+// BeforeCreate хук для установки времени создания
+func (t *ChallengeTeam) BeforeCreate(tx *gorm.DB) error {
+	t.CreatedAt = time.Now()
+	return nil
+}
+
+// BeforeCreate хук для установки времени создания
+func (p *ChallengeParticipant) BeforeCreate(tx *gorm.DB) error {
+	p.CreatedAt = time.Now()
+	return nil
+}
+
+// BeforeCreate хук для установки времени создания
+func (c *ChallengeContribution) BeforeCreate(tx *gorm.DB) error {
+	c.CreatedAt = time.Now()
+	return nil
+}
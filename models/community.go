@@ -13,6 +13,9 @@ type Community struct {
 	Name        string    `json:"name" gorm:"not null;size:100"`
 	Description string    `json:"description" gorm:"size:500"`
 	City        string    `json:"city" gorm:"not null;size:100"`
+	Latitude    *float64  `json:"latitude"` // Координаты для поиска поблизости (необязательно)
+	Longitude   *float64  `json:"longitude"`
+	Visibility  string    `json:"visibility" gorm:"not null;size:20;default:'open'"` // "open", "request", "invite"
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
 
@@ -22,11 +25,12 @@ type Community struct {
 	News    []News          `json:"news" gorm:"foreignKey:CommunityID"`
 }
 
-// CommunityRole представляет роли пользователей в сообществе
+// CommunityRole представляет участие пользователя в сообществе и его роль.
+// У пользователя не больше одной записи в каждом сообществе.
 type CommunityRole struct {
 	ID          uint      `json:"id" gorm:"primaryKey"`
-	CommunityID uint      `json:"community_id" gorm:"not null"`
-	UserID      uint      `json:"user_id" gorm:"not null"`
+	CommunityID uint      `json:"community_id" gorm:"not null;uniqueIndex:idx_community_member"`
+	UserID      uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_community_member"`
 	Role        string    `json:"role" gorm:"not null;size:20"` // "admin", "moderator", "member"
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
@@ -36,39 +40,114 @@ type CommunityRole struct {
 	User      User      `json:"user" gorm:"foreignKey:UserID"`
 }
 
+// Статусы новости
+const (
+	NewsStatusDraft     = "draft"     // черновик, виден только редакторам
+	NewsStatusScheduled = "scheduled" // будет опубликована в PublishAt
+	NewsStatusPublished = "published"
+)
+
 // News представляет новость в сообществе
 type News struct {
-	ID          uint      `json:"id" gorm:"primaryKey"`
-	CommunityID uint      `json:"community_id" gorm:"not null"`
-	AuthorID    uint      `json:"author_id" gorm:"not null"`
-	Content     string    `json:"content" gorm:"not null;type:text"`
-	PhotoPath   string    `json:"photo_path" gorm:"size:255"`
-	LikesCount  int       `json:"likes_count" gorm:"default:0"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	CommunityID uint       `json:"community_id" gorm:"not null"`
+	AuthorID    uint       `json:"author_id" gorm:"not null"`
+	EventID     *uint      `json:"event_id" gorm:"index"` // Анонс ивента сообщества
+	Content     string     `json:"content" gorm:"not null;type:text"`
+	PhotoPath   string     `json:"photo_path" gorm:"size:255"` // Устарело: изображения загружаются через NewsMedia
+	LikesCount  int        `json:"likes_count" gorm:"default:0"`
+	Status      string     `json:"status" gorm:"not null;size:20;default:'published';index"` // "draft", "scheduled", "published"
+	PublishAt   *time.Time `json:"publish_at" gorm:"index"`                                  // Время отложенной публикации
+	PublishedAt *time.Time `json:"published_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
 
 	// Связи
-	Community Community  `json:"community" gorm:"foreignKey:CommunityID"`
-	Author    User       `json:"author" gorm:"foreignKey:AuthorID"`
-	Comments  []Comment  `json:"comments" gorm:"foreignKey:NewsID"`
-	Likes     []NewsLike `json:"likes" gorm:"foreignKey:NewsID"`
+	Community Community   `json:"community" gorm:"foreignKey:CommunityID"`
+	Author    User        `json:"author" gorm:"foreignKey:AuthorID"`
+	Event     *Event      `json:"event,omitempty" gorm:"foreignKey:EventID"`
+	Comments  []Comment   `json:"comments" gorm:"foreignKey:NewsID"`
+	Likes     []NewsLike  `json:"likes" gorm:"foreignKey:NewsID"`
+	Media     []NewsMedia `json:"media" gorm:"foreignKey:NewsID"`
+	Mentions  []Mention   `json:"mentions" gorm:"polymorphic:Source;polymorphicValue:news"`
 }
 
+// DeletedCommentContent текст, который остается на месте удаленного комментария с ответами
+const DeletedCommentContent = "[deleted]"
+
 // Comment представляет комментарий к новости
 type Comment struct {
-	ID        uint      `json:"id" gorm:"primaryKey"`
-	NewsID    uint      `json:"news_id" gorm:"not null"`
-	AuthorID  uint      `json:"author_id" gorm:"not null"`
-	ParentID  *uint     `json:"parent_id"` // Для дерева комментариев
-	Content   string    `json:"content" gorm:"not null;type:text"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	NewsID       uint      `json:"news_id" gorm:"not null"`
+	AuthorID     uint      `json:"author_id" gorm:"not null"`
+	ParentID     *uint     `json:"parent_id" gorm:"index"`          // Для дерева комментариев
+	Depth        int       `json:"depth" gorm:"not null;default:0"` // 0 - комментарий верхнего уровня
+	Content      string    `json:"content" gorm:"not null;type:text"`
+	RepliesCount int       `json:"replies_count" gorm:"default:0"` // Количество прямых ответов
+	LikesCount   int       `json:"likes_count" gorm:"default:0"`
+	IsDeleted    bool      `json:"is_deleted" gorm:"default:false"` // Удален, но оставлен ради ответов
+	LikedByMe    bool      `json:"liked_by_me" gorm:"-"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
 
 	// Связи
 	News     News      `json:"news" gorm:"foreignKey:NewsID"`
 	Author   User      `json:"author" gorm:"foreignKey:AuthorID"`
 	Parent   *Comment  `json:"parent" gorm:"foreignKey:ParentID"`
 	Children []Comment `json:"children" gorm:"foreignKey:ParentID"`
+	Mentions []Mention `json:"mentions" gorm:"polymorphic:Source;polymorphicValue:comment"`
+}
+
+// HideDeletedAuthors скрывает автора у удаленного комментария, его родителя и загруженных ответов
+func (c *Comment) HideDeletedAuthors() {
+	if c.IsDeleted {
+		c.AuthorID = 0
+		c.Author = User{}
+	}
+	if c.Parent != nil {
+		c.Parent.HideDeletedAuthors()
+	}
+	for i := range c.Children {
+		c.Children[i].HideDeletedAuthors()
+	}
+}
+
+// HideDeletedCommentAuthors скрывает авторов удаленных комментариев, загруженных вместе с новостью
+func (n *News) HideDeletedCommentAuthors() {
+	for i := range n.Comments {
+		n.Comments[i].HideDeletedAuthors()
+	}
+}
+
+// BackfillCommentTree пересчитывает счетчики ответов и глубину комментариев, созданных до появления дерева
+func BackfillCommentTree(db *gorm.DB) error {
+	replies := db.Table("comments AS reply").Select("COUNT(*)").Where("reply.parent_id = comments.id")
+	if err := db.Model(&Comment{}).Where("replies_count <> (?)", replies).
+		UpdateColumn("replies_count", replies).Error; err != nil {
+		return err
+	}
+
+	if err := db.Model(&Comment{}).Where("parent_id IS NULL AND depth <> 0").UpdateColumn("depth", 0).Error; err != nil {
+		return err
+	}
+
+	// Глубина проставляется уровень за уровнем, начиная с комментариев верхнего уровня
+	var level []uint
+	if err := db.Model(&Comment{}).Where("parent_id IS NULL").Pluck("id", &level).Error; err != nil {
+		return err
+	}
+	for depth := 1; len(level) > 0; depth++ {
+		if err := db.Model(&Comment{}).Where("parent_id IN ? AND depth <> ?", level, depth).
+			UpdateColumn("depth", depth).Error; err != nil {
+			return err
+		}
+		var next []uint
+		if err := db.Model(&Comment{}).Where("parent_id IN ?", level).Pluck("id", &next).Error; err != nil {
+			return err
+		}
+		level = next
+	}
+	return nil
 }
 
 // NewsLike представляет лайк новости
@@ -113,9 +192,20 @@ func (cr *CommunityRole) BeforeUpdate(tx *gorm.DB) error {
 func (n *News) BeforeCreate(tx *gorm.DB) error {
 	n.CreatedAt = time.Now()
 	n.UpdatedAt = time.Now()
+	if n.Status == "" {
+		n.Status = NewsStatusPublished
+	}
+	if n.Status == NewsStatusPublished && n.PublishedAt == nil {
+		n.PublishedAt = &n.CreatedAt
+	}
 	return nil
 }
 
+// IsPublished проверяет, опубликована ли новость
+func (n *News) IsPublished() bool {
+	return n.Status == NewsStatusPublished
+}
+
 // BeforeUpdate хук для обновления времени изменения
 func (n *News) BeforeUpdate(tx *gorm.DB) error {
 	n.UpdatedAt = time.Now()
@@ -135,6 +225,20 @@ func (c *Comment) BeforeUpdate(tx *gorm.DB) error {
 	return nil
 }
 
+// CommentLike представляет лайк комментария
+type CommentLike struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	CommentID uint      `json:"comment_id" gorm:"not null;uniqueIndex:idx_comment_like"`
+	UserID    uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_comment_like"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// BeforeCreate хук для установки времени создания
+func (cl *CommentLike) BeforeCreate(tx *gorm.DB) error {
+	cl.CreatedAt = time.Now()
+	return nil
+}
+
 // BeforeCreate хук для установки времени создания
 func (nl *NewsLike) BeforeCreate(tx *gorm.DB) error {
 	nl.CreatedAt = time.Now()
@@ -158,10 +262,15 @@ func (cr *CommunityRole) IsMember() bool {
 
 // CanManageCommunity проверяет, может ли пользователь управлять сообществом
 func (cr *CommunityRole) CanManageCommunity() bool {
-	return cr.IsAdmin() || cr.IsModerator()
+	return cr.HasPermission(PermissionCommunityManage)
 }
 
 // CanManageNews проверяет, может ли пользователь управлять новостями
 func (cr *CommunityRole) CanManageNews() bool {
-	return cr.IsAdmin() || cr.IsModerator()
+	return cr.HasPermission(PermissionCommunityNewsManage)
+}
+
+// HasPermission проверяет, дает ли роль в сообществе указанное разрешение
+func (cr *CommunityRole) HasPermission(permission string) bool {
+	return CommunityRoleHasPermission(cr.Role, permission)
 }
@@ -0,0 +1,144 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Константы вступления в сообщество
+const (
+	// Видимость сообщества
+	CommunityVisibilityOpen    = "open"    // вступление без подтверждения
+	CommunityVisibilityRequest = "request" // вступление по заявке
+	CommunityVisibilityInvite  = "invite"  // вступление только по приглашению
+
+	// Статусы заявок и приглашений
+	MembershipStatusPending   = "pending"
+	MembershipStatusApproved  = "approved"
+	MembershipStatusRejected  = "rejected"
+	MembershipStatusCancelled = "cancelled"
+
+	// Действия в истории ролей
+	RoleChangeJoined      = "joined"
+	RoleChangeLeft        = "left"
+	RoleChangePromoted    = "promoted"
+	RoleChangeDemoted     = "demoted"
+	RoleChangeRemoved     = "removed"
+	RoleChangeTransferred = "ownership_transferred"
+)
+
+// CommunityJoinRequest представляет заявку на вступление в сообщество
+type CommunityJoinRequest struct {
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	CommunityID uint       `json:"community_id" gorm:"not null;index"`
+	UserID      uint       `json:"user_id" gorm:"not null;index"`
+	Message     string     `json:"message" gorm:"size:500"`
+	Status      string     `json:"status" gorm:"not null;size:20;default:'pending'"`
+	ReviewedBy  *uint      `json:"reviewed_by"`
+	ReviewedAt  *time.Time `json:"reviewed_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+
+	// Связи
+	Community Community `json:"community" gorm:"foreignKey:CommunityID"`
+	User      User      `json:"user" gorm:"foreignKey:UserID"`
+}
+
+// CommunityInvitation представляет приглашение пользователя в сообщество
+type CommunityInvitation struct {
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	CommunityID uint       `json:"community_id" gorm:"not null;index"`
+	UserID      uint       `json:"user_id" gorm:"not null;index"`
+	InvitedBy   uint       `json:"invited_by" gorm:"not null"`
+	Status      string     `json:"status" gorm:"not null;size:20;default:'pending'"`
+	RespondedAt *time.Time `json:"responded_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+
+	// Связи
+	Community Community `json:"community" gorm:"foreignKey:CommunityID"`
+	User      User      `json:"user" gorm:"foreignKey:UserID"`
+	Inviter   User      `json:"inviter" gorm:"foreignKey:InvitedBy"`
+}
+
+// CommunityRoleChange представляет запись истории ролей в сообществе
+type CommunityRoleChange struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	CommunityID uint      `json:"community_id" gorm:"not null;index"`
+	UserID      uint      `json:"user_id" gorm:"not null;index"`
+	ActorID     uint      `json:"actor_id" gorm:"not null"`
+	Action      string    `json:"action" gorm:"not null;size:30"`
+	FromRole    string    `json:"from_role" gorm:"size:20"` // пусто, если пользователь не состоял в сообществе
+	ToRole      string    `json:"to_role" gorm:"size:20"`   // пусто, если пользователь покинул сообщество
+	CreatedAt   time.Time `json:"created_at"`
+
+	// Связи
+	User  User `json:"user" gorm:"foreignKey:UserID"`
+	Actor User `json:"actor" gorm:"foreignKey:ActorID"`
+}
+
+// BeforeCreate хук для установки времени создания
+func (rc *CommunityRoleChange) BeforeCreate(tx *gorm.DB) error {
+	rc.CreatedAt = time.Now()
+	return nil
+}
+
+// BeforeCreate хук для установки времени создания
+func (r *CommunityJoinRequest) BeforeCreate(tx *gorm.DB) error {
+	r.CreatedAt = time.Now()
+	r.UpdatedAt = time.Now()
+	return nil
+}
+
+// BeforeUpdate хук для обновления времени изменения
+func (r *CommunityJoinRequest) BeforeUpdate(tx *gorm.DB) error {
+	r.UpdatedAt = time.Now()
+	return nil
+}
+
+// BeforeCreate хук для установки времени создания
+func (i *CommunityInvitation) BeforeCreate(tx *gorm.DB) error {
+	i.CreatedAt = time.Now()
+	i.UpdatedAt = time.Now()
+	return nil
+}
+
+// BeforeUpdate хук для обновления времени изменения
+func (i *CommunityInvitation) BeforeUpdate(tx *gorm.DB) error {
+	i.UpdatedAt = time.Now()
+	return nil
+}
+
+// IsValidCommunityRole проверяет, существует ли роль в сообществе
+func IsValidCommunityRole(role string) bool {
+	_, exists := communityRolePermissions[role]
+	return exists
+}
+
+// CommunityRoleRank возвращает старшинство роли в сообществе (чем больше, тем старше)
+func CommunityRoleRank(role string) int {
+	switch role {
+	case CommunityRoleAdmin:
+		return 3
+	case CommunityRoleModerator:
+		return 2
+	case CommunityRoleMember:
+		return 1
+	}
+	return 0
+}
+
+// IsValidCommunityVisibility проверяет значение видимости сообщества
+func IsValidCommunityVisibility(visibility string) bool {
+	switch visibility {
+	case CommunityVisibilityOpen, CommunityVisibilityRequest, CommunityVisibilityInvite:
+		return true
+	}
+	return false
+}
+
+// IsPrivate проверяет, закрыто ли сообщество для посторонних
+func (c *Community) IsPrivate() bool {
+	return c.Visibility == CommunityVisibilityInvite
+}
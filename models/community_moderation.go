@@ -0,0 +1,84 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Типы санкций в сообществе
+const (
+	SanctionTypeBan  = "ban"  // пользователь исключен и не может вернуться в сообщество
+	SanctionTypeMute = "mute" // пользователь временно не может публиковать и оценивать контент
+)
+
+// Действия в журнале модерации сообщества
+const (
+	ModerationActionBan            = "ban"
+	ModerationActionUnban          = "unban"
+	ModerationActionMute           = "mute"
+	ModerationActionUnmute         = "unmute"
+	ModerationActionNewsRemoved    = "news_removed"
+	ModerationActionCommentRemoved = "comment_removed"
+)
+
+// CommunitySanction представляет бан или мут пользователя в сообществе
+type CommunitySanction struct {
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	CommunityID uint       `json:"community_id" gorm:"not null;index:idx_community_sanction"`
+	UserID      uint       `json:"user_id" gorm:"not null;index:idx_community_sanction"`
+	Type        string     `json:"type" gorm:"not null;size:20"` // "ban", "mute"
+	Reason      string     `json:"reason" gorm:"size:500"`
+	ExpiresAt   *time.Time `json:"expires_at"` // nil - бессрочно (только для бана)
+	CreatedBy   uint       `json:"created_by" gorm:"not null"`
+	RevokedAt   *time.Time `json:"revoked_at"`
+	RevokedBy   *uint      `json:"revoked_by"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+
+	// Связи
+	User    User `json:"user" gorm:"foreignKey:UserID"`
+	Creator User `json:"creator" gorm:"foreignKey:CreatedBy"`
+}
+
+// CommunityModerationLog представляет запись журнала модерации сообщества
+type CommunityModerationLog struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	CommunityID  uint      `json:"community_id" gorm:"not null;index"`
+	ActorID      uint      `json:"actor_id" gorm:"not null"`
+	Action       string    `json:"action" gorm:"not null;size:30"`
+	TargetUserID uint      `json:"target_user_id" gorm:"not null;index"`
+	TargetType   string    `json:"target_type" gorm:"size:20"` // "news", "comment"; пусто для санкций
+	TargetID     uint      `json:"target_id"`
+	Reason       string    `json:"reason" gorm:"size:500"`
+	Excerpt      string    `json:"excerpt" gorm:"size:255"` // отрывок удаленного контента
+	CreatedAt    time.Time `json:"created_at"`
+
+	// Связи
+	Actor      User `json:"actor" gorm:"foreignKey:ActorID"`
+	TargetUser User `json:"target_user" gorm:"foreignKey:TargetUserID"`
+}
+
+// IsActive проверяет, действует ли санкция в указанный момент
+func (s *CommunitySanction) IsActive(now time.Time) bool {
+	return s.RevokedAt == nil && (s.ExpiresAt == nil || s.ExpiresAt.After(now))
+}
+
+// BeforeCreate хук для установки времени создания
+func (s *CommunitySanction) BeforeCreate(tx *gorm.DB) error {
+	s.CreatedAt = time.Now()
+	s.UpdatedAt = time.Now()
+	return nil
+}
+
+// BeforeUpdate хук для обновления времени изменения
+func (s *CommunitySanction) BeforeUpdate(tx *gorm.DB) error {
+	s.UpdatedAt = time.Now()
+	return nil
+}
+
+// BeforeCreate хук для установки времени создания
+func (l *CommunityModerationLog) BeforeCreate(tx *gorm.DB) error {
+	l.CreatedAt = time.Now()
+	return nil
+}
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"math"
 	"time"
 
 	"gorm.io/gorm"
@@ -10,6 +11,7 @@ import (
 type Event struct {
 	ID               uint      `json:"id" gorm:"primaryKey"`
 	CreatorID        uint      `json:"creator_id" gorm:"not null"`
+	CommunityID      *uint     `json:"community_id" gorm:"index"` // Сообщество-организатор (необязательно)
 	Title            string    `json:"title" gorm:"not null;size:255"`
 	Description      string    `json:"description" gorm:"type:text"`
 	Latitude         float64   `json:"latitude" gorm:"not null"`
@@ -30,6 +32,7 @@ type Event struct {
 	ContactInfo      string    `json:"contact_info" gorm:"type:text"`                     // Контактная информация
 	IsActive         bool      `json:"is_active" gorm:"default:true"`
 	IsPublic         bool      `json:"is_public" gorm:"default:true"`     // Публичное событие
+	MembersOnly      bool      `json:"members_only" gorm:"default:false"` // Только для участников сообщества
 	IsRecurring      bool      `json:"is_recurring" gorm:"default:false"` // Повторяющееся событие
 	RecurringPattern string    `json:"recurring_pattern" gorm:"size:50"`  // Паттерн повторения
 	CreatedAt        time.Time `json:"created_at"`
@@ -37,6 +40,7 @@ type Event struct {
 
 	// Связи
 	Creator      User               `json:"creator" gorm:"foreignKey:CreatorID"`
+	Community    *Community         `json:"community,omitempty" gorm:"foreignKey:CommunityID"`
 	Inventory    []EventInventory   `json:"inventory" gorm:"foreignKey:EventID"`
 	Photos       []EventPhoto       `json:"photos" gorm:"foreignKey:EventID"`
 	Participants []EventParticipant `json:"participants" gorm:"foreignKey:EventID"`
@@ -72,14 +76,19 @@ type EventPhoto struct {
 
 // EventParticipant представляет участника ивента с расширенным функционалом
 type EventParticipant struct {
-	ID        uint       `json:"id" gorm:"primaryKey"`
-	EventID   uint       `json:"event_id" gorm:"not null"`
-	UserID    uint       `json:"user_id" gorm:"not null"`
-	Status    string     `json:"status" gorm:"not null;default:'pending'"` // 'pending', 'accepted', 'rejected', 'joined', 'left', 'removed'
-	JoinedAt  *time.Time `json:"joined_at"`
-	LeftAt    *time.Time `json:"left_at"`
-	CreatedAt time.Time  `json:"created_at"`
-	UpdatedAt time.Time  `json:"updated_at"`
+	ID               uint       `json:"id" gorm:"primaryKey"`
+	EventID          uint       `json:"event_id" gorm:"not null"`
+	UserID           uint       `json:"user_id" gorm:"not null"`
+	Status           string     `json:"status" gorm:"not null;default:'pending'"` // 'pending', 'accepted', 'rejected', 'joined', 'left', 'removed'
+	JoinedAt         *time.Time `json:"joined_at"`
+	LeftAt           *time.Time `json:"left_at"`
+	CheckedInAt      *time.Time `json:"checked_in_at"`      // организатор отметил присутствие на ивенте
+	CheckedOutAt     *time.Time `json:"checked_out_at"`     // организатор отметил уход участника
+	Hours            *float64   `json:"hours"`              // часы, исправленные организатором; пусто - рассчитываются автоматически
+	HoursConfirmedAt *time.Time `json:"hours_confirmed_at"` // организатор подтвердил волонтерские часы
+	HoursConfirmedBy *uint      `json:"hours_confirmed_by"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
 
 	// Связи
 	Event Event `json:"event" gorm:"foreignKey:EventID"`
@@ -123,6 +132,7 @@ type Rating struct {
 	FromUserID uint      `json:"from_user_id" gorm:"not null"`
 	ToUserID   uint      `json:"to_user_id" gorm:"not null"`
 	Score      int       `json:"score" gorm:"not null;check:score >= 1 AND score <= 10"`
+	Weight     float64   `json:"-" gorm:"not null;default:1"` // вес оценки в репутации (снижается для оценок одних и тех же людей)
 	Comment    string    `json:"comment" gorm:"type:text"`
 	CreatedAt  time.Time `json:"created_at"`
 	UpdatedAt  time.Time `json:"updated_at"`
@@ -133,35 +143,80 @@ type Rating struct {
 	ToUser   User  `json:"to_user" gorm:"foreignKey:ToUserID"`
 }
 
-// UserRatingSummary представляет сводку рейтингов пользователя
+// UserRatingSummary представляет сводку рейтингов пользователя.
+// WeightedScore и WeightTotal хранят взвешенные суммы оценок с затуханием на момент DecayedAt,
+// поэтому сводка обновляется при каждой новой оценке без пересчета всех рейтингов.
 type UserRatingSummary struct {
-	UserID       uint      `json:"user_id" gorm:"primaryKey"`
-	TotalScore   int       `json:"total_score" gorm:"not null;default:0"`
-	VotesCount   int       `json:"votes_count" gorm:"not null;default:0"`
-	AverageScore float64   `json:"average_score" gorm:"not null;default:0.0"`
-	UpdatedAt    time.Time `json:"updated_at"`
+	UserID        uint       `json:"user_id" gorm:"primaryKey"`
+	TotalScore    int        `json:"total_score" gorm:"not null;default:0"`
+	VotesCount    int        `json:"votes_count" gorm:"not null;default:0"`
+	AverageScore  float64    `json:"average_score" gorm:"not null;default:0.0"`
+	WeightedScore float64    `json:"-" gorm:"not null;default:0"`
+	WeightTotal   float64    `json:"-" gorm:"not null;default:0"`
+	Reputation    float64    `json:"reputation" gorm:"not null;default:0;index"` // байесовская оценка на момент DecayedAt
+	DecayedAt     *time.Time `json:"-"`
+	UpdatedAt     time.Time  `json:"updated_at"`
 
 	// Связи
 	User User `json:"user" gorm:"foreignKey:UserID"`
 }
 
-// Complaint представляет жалобу на участника
+// UserRatingBucket количество оценок пользователя с определенным баллом (гистограмма рейтинга)
+type UserRatingBucket struct {
+	UserID uint `json:"-" gorm:"primaryKey;autoIncrement:false"`
+	Score  int  `json:"score" gorm:"primaryKey;autoIncrement:false"`
+	Count  int  `json:"count" gorm:"not null;default:0"`
+}
+
+// Complaint представляет жалобу на пользователя или его контент
 type Complaint struct {
 	ID          uint       `json:"id" gorm:"primaryKey"`
-	EventID     uint       `json:"event_id" gorm:"not null"`
+	TargetType  string     `json:"target_type" gorm:"not null;size:20;default:'user';index:idx_complaint_target"` // тип объекта жалобы
+	TargetID    uint       `json:"target_id" gorm:"not null;default:0;index:idx_complaint_target"`
+	EventID     *uint      `json:"event_id" gorm:"index"`     // ивент, в котором произошел инцидент (жалоба на участника)
+	CommunityID *uint      `json:"community_id" gorm:"index"` // жалоба на контент сообщества рассматривается его модераторами
 	FromUserID  uint       `json:"from_user_id" gorm:"not null"`
-	AboutUserID uint       `json:"about_user_id" gorm:"not null"`
+	AboutUserID uint       `json:"about_user_id" gorm:"not null"`         // автор контента или сам пользователь
 	ReasonCode  string     `json:"reason_code" gorm:"not null;size:50"`   // код причины жалобы
 	ReasonText  string     `json:"reason_text" gorm:"type:text"`          // текст жалобы
+	Snapshot    string     `json:"snapshot" gorm:"type:text"`             // JSON копия контента на момент жалобы
 	Status      string     `json:"status" gorm:"not null;default:'open'"` // 'open', 'under_review', 'resolved', 'dismissed'
 	ResolvedAt  *time.Time `json:"resolved_at"`
 	CreatedAt   time.Time  `json:"created_at"`
 	UpdatedAt   time.Time  `json:"updated_at"`
 
 	// Связи
-	Event     Event `json:"event" gorm:"foreignKey:EventID"`
-	FromUser  User  `json:"from_user" gorm:"foreignKey:FromUserID"`
-	AboutUser User  `json:"about_user" gorm:"foreignKey:AboutUserID"`
+	Event     *Event     `json:"event,omitempty" gorm:"foreignKey:EventID"`
+	Community *Community `json:"community,omitempty" gorm:"foreignKey:CommunityID"`
+	FromUser  User       `json:"from_user" gorm:"foreignKey:FromUserID"`
+	AboutUser User       `json:"about_user" gorm:"foreignKey:AboutUserID"`
+}
+
+// Типы объектов жалоб
+const (
+	ComplaintTargetUser       = "user"
+	ComplaintTargetMessage    = "message"
+	ComplaintTargetNews       = "news"
+	ComplaintTargetComment    = "comment"
+	ComplaintTargetCommunity  = "community"
+	ComplaintTargetAttachment = "attachment"
+)
+
+// IsValidComplaintTarget проверяет, можно ли пожаловаться на объект указанного типа
+func IsValidComplaintTarget(targetType string) bool {
+	switch targetType {
+	case ComplaintTargetUser, ComplaintTargetMessage, ComplaintTargetNews,
+		ComplaintTargetComment, ComplaintTargetCommunity, ComplaintTargetAttachment:
+		return true
+	}
+	return false
+}
+
+// BackfillComplaintTargets заполняет объект жалобы у жалоб на участников, поданных до появления типов объектов
+func BackfillComplaintTargets(db *gorm.DB) error {
+	return db.Model(&Complaint{}).
+		Where("target_type = ? AND target_id = 0", ComplaintTargetUser).
+		Update("target_id", gorm.Expr("about_user_id")).Error
 }
 
 // Константы для статусов и причин жалоб
@@ -267,6 +322,14 @@ func GetJoinModes() map[string]string {
 	}
 }
 
+// EventCheckInLead за сколько до начала ивента организатор может отмечать пришедших участников
+const EventCheckInLead = 30 * time.Minute
+
+// IsCheckInOpen проверяет, что присутствие можно отмечать: с EventCheckInLead до начала и до окончания ивента
+func (e *Event) IsCheckInOpen(now time.Time) bool {
+	return !now.Before(e.StartTime.Add(-EventCheckInLead)) && !now.After(e.EndTime)
+}
+
 // BeforeCreate хук для установки времени создания
 func (e *Event) BeforeCreate(tx *gorm.DB) error {
 	e.CreatedAt = time.Now()
@@ -330,6 +393,23 @@ func (ep *EventParticipant) BeforeUpdate(tx *gorm.DB) error {
 	return nil
 }
 
+// VolunteerHours возвращает волонтерские часы участника: исправленные организатором,
+// по времени прихода и ухода, если оба отмечены, иначе по продолжительности ивента
+func (ep *EventParticipant) VolunteerHours(event *Event) float64 {
+	if ep.Hours != nil {
+		return *ep.Hours
+	}
+
+	duration := event.EndTime.Sub(event.StartTime)
+	if ep.CheckedInAt != nil && ep.CheckedOutAt != nil && ep.CheckedOutAt.After(*ep.CheckedInAt) {
+		duration = ep.CheckedOutAt.Sub(*ep.CheckedInAt)
+	}
+	if duration < 0 {
+		return 0
+	}
+	return math.Round(duration.Hours()*100) / 100
+}
+
 // BeforeCreate хук для ParticipantInventory
 func (pi *ParticipantInventory) BeforeCreate(tx *gorm.DB) error {
 	pi.CreatedAt = time.Now()
@@ -385,6 +465,13 @@ func (urs *UserRatingSummary) BeforeUpdate(tx *gorm.DB) error {
 func (c *Complaint) BeforeCreate(tx *gorm.DB) error {
 	c.CreatedAt = time.Now()
 	c.UpdatedAt = time.Now()
+	// Жалоба без объекта - жалоба на пользователя
+	if c.TargetType == "" {
+		c.TargetType = ComplaintTargetUser
+	}
+	if c.TargetType == ComplaintTargetUser && c.TargetID == 0 {
+		c.TargetID = c.AboutUserID
+	}
 	return nil
 }
 
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Типы записей ленты активности
+const (
+	FeedActivityLevelUp = "level.up" // пользователь получил новый уровень
+)
+
+// FeedActivity представляет запись ленты активности пользователя, которую видят его подписчики
+type FeedActivity struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	UserID     uint      `json:"user_id" gorm:"not null;index"`
+	Type       string    `json:"type" gorm:"not null;size:50"`
+	Title      string    `json:"title" gorm:"not null;size:255"`
+	Body       string    `json:"body" gorm:"type:text"`
+	EntityType string    `json:"entity_type" gorm:"size:50"` // связанный объект, например "level"
+	EntityID   uint      `json:"entity_id"`
+	CreatedAt  time.Time `json:"created_at" gorm:"index"`
+
+	// Связи
+	User User `json:"user" gorm:"foreignKey:UserID"`
+}
+
+// BeforeCreate хук для установки времени создания
+func (a *FeedActivity) BeforeCreate(tx *gorm.DB) error {
+	a.CreatedAt = time.Now()
+	return nil
+}
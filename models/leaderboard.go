@@ -0,0 +1,55 @@
+package models
+
+import "time"
+
+// Периоды таблиц лидеров
+const (
+	LeaderboardPeriodWeek  = "week"
+	LeaderboardPeriodMonth = "month"
+	LeaderboardPeriodYear  = "year"
+	LeaderboardPeriodAll   = "all"
+)
+
+// Срезы таблиц лидеров
+const (
+	LeaderboardScopeGlobal    = "global"     // все пользователи
+	LeaderboardScopeCity      = "city"       // пользователи из города (User.Location)
+	LeaderboardScopeEventCity = "event_city" // очки за ивенты в городе (Event.City)
+	LeaderboardScopeCommunity = "community"  // участники сообщества
+	LeaderboardScopeEventType = "event_type" // очки за ивенты указанного типа
+)
+
+// LeaderboardEntry представляет строку предрассчитанной таблицы лидеров.
+// Таблицы периодически пересчитываются из журнала очков целиком.
+type LeaderboardEntry struct {
+	ID          uint      `json:"-" gorm:"primaryKey"`
+	Period      string    `json:"period" gorm:"not null;size:10;uniqueIndex:idx_leaderboard_user;index:idx_leaderboard_rank"`
+	ScopeType   string    `json:"scope_type" gorm:"not null;size:20;uniqueIndex:idx_leaderboard_user;index:idx_leaderboard_rank"`
+	ScopeKey    string    `json:"scope_key" gorm:"not null;size:100;uniqueIndex:idx_leaderboard_user;index:idx_leaderboard_rank"` // город, ID сообщества или тип ивента; пусто для global
+	UserID      uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_leaderboard_user"`
+	Points      int       `json:"points" gorm:"not null"`
+	Rank        int       `json:"rank" gorm:"not null;index:idx_leaderboard_rank"`
+	PeriodStart time.Time `json:"period_start"`
+	RefreshedAt time.Time `json:"refreshed_at"`
+
+	// Связи
+	User User `json:"user" gorm:"foreignKey:UserID"`
+}
+
+// IsValidLeaderboardPeriod проверяет, что период таблицы лидеров поддерживается
+func IsValidLeaderboardPeriod(period string) bool {
+	switch period {
+	case LeaderboardPeriodWeek, LeaderboardPeriodMonth, LeaderboardPeriodYear, LeaderboardPeriodAll:
+		return true
+	}
+	return false
+}
+
+// IsValidLeaderboardScope проверяет, что срез таблицы лидеров поддерживается
+func IsValidLeaderboardScope(scope string) bool {
+	switch scope {
+	case LeaderboardScopeGlobal, LeaderboardScopeCity, LeaderboardScopeEventCity, LeaderboardScopeCommunity, LeaderboardScopeEventType:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// LevelDefinition описывает уровень на кривой прогресса: минимальное число очков, звание и иконку
+type LevelDefinition struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Level     int       `json:"level" gorm:"not null;uniqueIndex"`
+	Threshold int       `json:"threshold" gorm:"not null"` // очки, необходимые для уровня
+	Title     string    `json:"title" gorm:"not null;size:100"`
+	IconPath  string    `json:"icon_path" gorm:"size:255"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// BeforeCreate хук для установки времени создания
+func (l *LevelDefinition) BeforeCreate(tx *gorm.DB) error {
+	l.CreatedAt = time.Now()
+	l.UpdatedAt = time.Now()
+	return nil
+}
+
+// BeforeUpdate хук для обновления времени изменения
+func (l *LevelDefinition) BeforeUpdate(tx *gorm.DB) error {
+	l.UpdatedAt = time.Now()
+	return nil
+}
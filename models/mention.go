@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Типы объектов, в которых могут быть упоминания
+const (
+	MentionSourceNews    = "news"
+	MentionSourceComment = "comment"
+	MentionSourceMessage = "message"
+)
+
+// Mention представляет упоминание пользователя (@username) в тексте новости, комментария или сообщения
+type Mention struct {
+	ID         uint      `json:"-" gorm:"primaryKey"`
+	SourceType string    `json:"-" gorm:"not null;size:20;index:idx_mention_source"`
+	SourceID   uint      `json:"-" gorm:"not null;index:idx_mention_source"`
+	UserID     uint      `json:"user_id" gorm:"not null;index"`
+	Username   string    `json:"username" gorm:"not null;size:30"`
+	Offset     int       `json:"offset" gorm:"column:char_offset;not null"` // Позиция «@» в символах текста
+	Length     int       `json:"length" gorm:"not null"`                    // Длина упоминания вместе с «@»
+	CreatedAt  time.Time `json:"-"`
+}
+
+// BeforeCreate хук для установки времени создания
+func (m *Mention) BeforeCreate(tx *gorm.DB) error {
+	m.CreatedAt = time.Now()
+	return nil
+}
@@ -34,6 +34,7 @@ type Message struct {
 	Conversation Conversation `json:"conversation" gorm:"foreignKey:ConversationID"`
 	FromUser     User         `json:"from_user" gorm:"foreignKey:FromUserID"`
 	ToUser       User         `json:"to_user" gorm:"foreignKey:ToUserID"`
+	Mentions     []Mention    `json:"mentions" gorm:"polymorphic:Source;polymorphicValue:message"`
 }
 
 // AttachmentData представляет данные о вложениях в JSON формате
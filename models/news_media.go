@@ -0,0 +1,39 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// NewsMedia представляет изображение в новости сообщества
+type NewsMedia struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	NewsID     uint      `json:"news_id" gorm:"not null;index"`
+	UploaderID uint      `json:"uploader_id" gorm:"not null;index"`
+	FilePath   string    `json:"-" gorm:"not null;size:255"`
+	MimeType   string    `json:"mime_type" gorm:"not null;size:50"` // Определяется по содержимому файла
+	Size       int64     `json:"size" gorm:"not null"`
+	Caption    string    `json:"caption" gorm:"size:500"`
+	Position   int       `json:"position" gorm:"not null;default:0"` // Порядок изображения в новости
+	URL        string    `json:"url" gorm:"-"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// BeforeCreate хук для установки времени создания
+func (m *NewsMedia) BeforeCreate(tx *gorm.DB) error {
+	m.CreatedAt = time.Now()
+	return nil
+}
+
+// AfterFind хук для формирования ссылки на файл
+func (m *NewsMedia) AfterFind(tx *gorm.DB) error {
+	m.SetURL()
+	return nil
+}
+
+// SetURL формирует ссылку для скачивания изображения
+func (m *NewsMedia) SetURL() {
+	m.URL = fmt.Sprintf("/news/%d/media/%d/file", m.NewsID, m.ID)
+}
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Типы уведомлений
+const (
+	NotificationTypeNewsPublished = "news.published"
+	NotificationTypeMention       = "mention"
+	NotificationTypeSanction      = "community.sanction" // бан или мут в сообществе
+	NotificationTypeRemoved       = "content.removed"    // модератор удалил новость или комментарий
+	NotificationTypeAchievement   = "achievement.earned" // пользователь получил достижение
+	NotificationTypeLevelUp       = "level.up"           // пользователь получил новый уровень
+	NotificationTypeEventReview   = "event.review"       // участник оставил отзыв об ивенте организатора
+	NotificationTypeReviewReply   = "event.review_reply" // организатор ответил на отзыв
+)
+
+// Notification представляет уведомление пользователя
+type Notification struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	UserID     uint       `json:"user_id" gorm:"not null;index:idx_notification_user"`
+	Type       string     `json:"type" gorm:"not null;size:50"`
+	Title      string     `json:"title" gorm:"not null;size:255"`
+	Body       string     `json:"body" gorm:"type:text"`
+	EntityType string     `json:"entity_type" gorm:"size:50"` // Связанный объект, например "news"
+	EntityID   uint       `json:"entity_id"`
+	IsRead     bool       `json:"is_read" gorm:"default:false;index:idx_notification_user"`
+	ReadAt     *time.Time `json:"read_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// BeforeCreate хук для установки времени создания
+func (n *Notification) BeforeCreate(tx *gorm.DB) error {
+	n.CreatedAt = time.Now()
+	return nil
+}
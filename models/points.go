@@ -0,0 +1,58 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Причины начисления очков
+const (
+	PointsReasonParticipation = "participation" // участие в ивенте с отметкой о присутствии
+	PointsReasonOrganization  = "organization"  // проведение завершенного ивента
+	PointsReasonAchievement   = "achievement"   // получение достижения
+	PointsReasonReversal      = "reversal"      // отмена ранее начисленных очков
+	PointsReasonChallenge     = "challenge"     // выполнение цели челленджа
+)
+
+// Источники начисления очков
+const (
+	PointsSourceEvent       = "event"
+	PointsSourceAchievement = "achievement"
+	PointsSourceComplaint   = "complaint"
+	PointsSourceChallenge   = "challenge"
+)
+
+// PointsEntry представляет запись журнала очков. Записи только добавляются:
+// отмена начисления - это новая запись с отрицательной суммой, ссылающаяся на исходную.
+type PointsEntry struct {
+	ID             uint      `json:"id" gorm:"primaryKey"`
+	UserID         uint      `json:"user_id" gorm:"not null;index"`
+	Amount         int       `json:"amount" gorm:"not null"`
+	ReasonCode     string    `json:"reason_code" gorm:"not null;size:30"`
+	SourceType     string    `json:"source_type" gorm:"size:30;index:idx_points_source"`
+	SourceID       uint      `json:"source_id" gorm:"index:idx_points_source"`
+	EventID        *uint     `json:"event_id" gorm:"index"`                  // ивент, за который начислены очки (в том числе для отмены)
+	IdempotencyKey string    `json:"-" gorm:"not null;size:150;uniqueIndex"` // повторное начисление по тому же ключу игнорируется
+	ReversalOf     *uint     `json:"reversal_of" gorm:"uniqueIndex"`         // отмененная запись
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// BackfillPointsEventIDs заполняет ивент у записей журнала, созданных до появления этого поля
+func BackfillPointsEventIDs(db *gorm.DB) error {
+	if err := db.Model(&PointsEntry{}).
+		Where("event_id IS NULL AND reversal_of IS NULL AND source_type = ?", PointsSourceEvent).
+		Update("event_id", gorm.Expr("source_id")).Error; err != nil {
+		return err
+	}
+	return db.Model(&PointsEntry{}).
+		Where("event_id IS NULL AND reversal_of IS NOT NULL").
+		Update("event_id", db.Model(&PointsEntry{}).Select("original.event_id").
+			Table("points_entries AS original").Where("original.id = points_entries.reversal_of")).Error
+}
+
+// BeforeCreate хук для установки времени создания
+func (e *PointsEntry) BeforeCreate(tx *gorm.DB) error {
+	e.CreatedAt = time.Now()
+	return nil
+}
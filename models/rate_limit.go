@@ -0,0 +1,12 @@
+package models
+
+import (
+	"time"
+)
+
+// RateLimitCounter представляет счетчик запросов в окне ограничения частоты
+type RateLimitCounter struct {
+	Key     string    `json:"key" gorm:"primaryKey;size:255"`
+	Count   int       `json:"count" gorm:"not null;default:0"`
+	ResetAt time.Time `json:"reset_at" gorm:"not null;index"`
+}
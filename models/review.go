@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// EventReview представляет отзыв участника о прошедшем ивенте и его организаторе
+type EventReview struct {
+	ID                 uint       `json:"id" gorm:"primaryKey"`
+	EventID            uint       `json:"event_id" gorm:"not null;uniqueIndex:idx_event_review_user"`
+	UserID             uint       `json:"user_id" gorm:"not null;uniqueIndex:idx_event_review_user"`
+	OrganizerID        uint       `json:"organizer_id" gorm:"not null;index"`                     // создатель ивента на момент отзыва
+	Score              int        `json:"score" gorm:"not null;check:score >= 1 AND score <= 10"` // общая оценка ивента
+	Organization       int        `json:"organization" gorm:"not null"`                           // организация, 1-5
+	Safety             int        `json:"safety" gorm:"not null"`                                 // безопасность, 1-5
+	MatchedDescription int        `json:"matched_description" gorm:"not null"`                    // соответствие описанию, 1-5
+	Text               string     `json:"text" gorm:"type:text"`
+	Reply              string     `json:"reply" gorm:"type:text"` // ответ организатора
+	RepliedAt          *time.Time `json:"replied_at"`
+	CreatedAt          time.Time  `json:"created_at"`
+	UpdatedAt          time.Time  `json:"updated_at"`
+
+	// Связи
+	User  User  `json:"user" gorm:"foreignKey:UserID"`
+	Event Event `json:"-" gorm:"foreignKey:EventID"`
+}
+
+// BeforeCreate хук для установки времени создания
+func (r *EventReview) BeforeCreate(tx *gorm.DB) error {
+	r.CreatedAt = time.Now()
+	r.UpdatedAt = time.Now()
+	return nil
+}
+
+// BeforeUpdate хук для установки времени обновления
+func (r *EventReview) BeforeUpdate(tx *gorm.DB) error {
+	r.UpdatedAt = time.Now()
+	return nil
+}
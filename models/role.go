@@ -0,0 +1,156 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// UserRole представляет глобальную роль пользователя на платформе
+type UserRole struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	UserID    uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_user_role"`
+	Role      string    `json:"role" gorm:"not null;size:20;uniqueIndex:idx_user_role"` // "admin", "moderator", "support"
+	GrantedBy uint      `json:"granted_by" gorm:"default:0"`                            // ID пользователя, выдавшего роль (0 для системы)
+	CreatedAt time.Time `json:"created_at"`
+
+	// Связи
+	User User `json:"user" gorm:"foreignKey:UserID"`
+}
+
+// Константы ролей и разрешений
+const (
+	// Глобальные роли платформы
+	RoleAdmin     = "admin"
+	RoleModerator = "moderator"
+	RoleSupport   = "support"
+
+	// Роли в сообществе
+	CommunityRoleAdmin     = "admin"
+	CommunityRoleModerator = "moderator"
+	CommunityRoleMember    = "member"
+
+	// Разрешения платформы
+	PermissionRolesManage       = "roles.manage"
+	PermissionUsersView         = "users.view"
+	PermissionComplaintsView    = "complaints.view"
+	PermissionComplaintsManage  = "complaints.manage"
+	PermissionPointsManage      = "points.manage"
+	PermissionAchievementsAward = "achievements.award"
+	PermissionCommunitiesManage = "communities.manage" // управление любым сообществом
+	PermissionEventsManage      = "events.manage"      // управление любым ивентом
+	PermissionAuditView         = "audit.view"
+	PermissionChallengesManage  = "challenges.manage" // челленджи от имени платформы
+
+	// Разрешения в рамках сообщества
+	PermissionCommunityView             = "community.view" // просмотр закрытого сообщества
+	PermissionCommunityManage           = "community.manage"
+	PermissionCommunityMembersManage    = "community.members.manage" // заявки, приглашения и роли участников
+	PermissionCommunityAdminsManage     = "community.admins.manage"  // назначение и снятие администраторов
+	PermissionCommunityNewsManage       = "community.news.manage"
+	PermissionCommunityEventsManage     = "community.events.manage" // создание ивентов от имени сообщества
+	PermissionCommunityCommentsManage   = "community.comments.manage"
+	PermissionCommunityReportsManage    = "community.reports.manage"    // рассмотрение жалоб на контент сообщества
+	PermissionCommunityChallengesManage = "community.challenges.manage" // челленджи сообщества
+)
+
+// rolePermissions реестр разрешений глобальных ролей
+var rolePermissions = map[string][]string{
+	RoleAdmin: {
+		PermissionRolesManage,
+		PermissionUsersView,
+		PermissionComplaintsView,
+		PermissionComplaintsManage,
+		PermissionPointsManage,
+		PermissionAchievementsAward,
+		PermissionCommunitiesManage,
+		PermissionEventsManage,
+		PermissionAuditView,
+		PermissionChallengesManage,
+	},
+	RoleModerator: {
+		PermissionUsersView,
+		PermissionComplaintsView,
+		PermissionComplaintsManage,
+		PermissionCommunitiesManage,
+		PermissionEventsManage,
+	},
+	RoleSupport: {
+		PermissionUsersView,
+		PermissionComplaintsView,
+	},
+}
+
+// communityRolePermissions реестр разрешений ролей в сообществе
+var communityRolePermissions = map[string][]string{
+	CommunityRoleAdmin: {
+		PermissionCommunityView,
+		PermissionCommunityManage,
+		PermissionCommunityMembersManage,
+		PermissionCommunityAdminsManage,
+		PermissionCommunityNewsManage,
+		PermissionCommunityEventsManage,
+		PermissionCommunityCommentsManage,
+		PermissionCommunityReportsManage,
+		PermissionCommunityChallengesManage,
+	},
+	CommunityRoleModerator: {
+		PermissionCommunityView,
+		PermissionCommunityManage,
+		PermissionCommunityMembersManage,
+		PermissionCommunityNewsManage,
+		PermissionCommunityEventsManage,
+		PermissionCommunityCommentsManage,
+		PermissionCommunityReportsManage,
+		PermissionCommunityChallengesManage,
+	},
+	CommunityRoleMember: {
+		PermissionCommunityView,
+	},
+}
+
+// GetRoles возвращает список доступных глобальных ролей
+func GetRoles() map[string]string {
+	return map[string]string{
+		RoleAdmin:     "Администратор",
+		RoleModerator: "Модератор",
+		RoleSupport:   "Поддержка",
+	}
+}
+
+// IsValidRole проверяет, существует ли глобальная роль
+func IsValidRole(role string) bool {
+	_, exists := rolePermissions[role]
+	return exists
+}
+
+// GetRolePermissions возвращает разрешения глобальной роли
+func GetRolePermissions(role string) []string {
+	return rolePermissions[role]
+}
+
+// RoleHasPermission проверяет, дает ли глобальная роль указанное разрешение
+func RoleHasPermission(role, permission string) bool {
+	return containsPermission(rolePermissions[role], permission)
+}
+
+// CommunityRoleHasPermission проверяет, дает ли роль в сообществе указанное разрешение
+func CommunityRoleHasPermission(role, permission string) bool {
+	return containsPermission(communityRolePermissions[role], permission)
+}
+
+// containsPermission проверяет наличие разрешения в списке
+func containsPermission(permissions []string, permission string) bool {
+	for _, p := range permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// BeforeCreate хук для установки времени создания
+func (ur *UserRole) BeforeCreate(tx *gorm.DB) error {
+	ur.CreatedAt = time.Now()
+	return nil
+}
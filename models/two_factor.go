@@ -0,0 +1,71 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// TwoFactorAuth представляет настройки двухфакторной аутентификации пользователя
+type TwoFactorAuth struct {
+	ID           uint       `json:"id" gorm:"primaryKey"`
+	UserID       uint       `json:"user_id" gorm:"not null;uniqueIndex"`
+	Secret       string     `json:"-" gorm:"not null;size:64"`       // Секрет TOTP в base32
+	IsEnabled    bool       `json:"is_enabled" gorm:"default:false"` // Включается после подтверждения кодом
+	LastUsedStep int64      `json:"-" gorm:"default:0"`              // Последний принятый шаг TOTP (защита от повторов)
+	EnabledAt    *time.Time `json:"enabled_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+
+	// Связи
+	User User `json:"-" gorm:"foreignKey:UserID"`
+}
+
+// TwoFactorRecoveryCode представляет одноразовый код восстановления
+type TwoFactorRecoveryCode struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	UserID    uint       `json:"user_id" gorm:"not null;index"`
+	CodeHash  string     `json:"-" gorm:"not null;size:64;index"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// TwoFactorChallenge представляет незавершенный вход, ожидающий второй фактор
+type TwoFactorChallenge struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	UserID    uint      `json:"user_id" gorm:"not null;index"`
+	TokenHash string    `json:"-" gorm:"not null;size:64;uniqueIndex"`
+	Attempts  int       `json:"attempts" gorm:"default:0"`
+	ExpiresAt time.Time `json:"expires_at" gorm:"not null"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// BeforeCreate хук для установки времени создания
+func (tfa *TwoFactorAuth) BeforeCreate(tx *gorm.DB) error {
+	tfa.CreatedAt = time.Now()
+	tfa.UpdatedAt = time.Now()
+	return nil
+}
+
+// BeforeUpdate хук для обновления времени изменения
+func (tfa *TwoFactorAuth) BeforeUpdate(tx *gorm.DB) error {
+	tfa.UpdatedAt = time.Now()
+	return nil
+}
+
+// BeforeCreate хук для установки времени создания
+func (rc *TwoFactorRecoveryCode) BeforeCreate(tx *gorm.DB) error {
+	rc.CreatedAt = time.Now()
+	return nil
+}
+
+// BeforeCreate хук для установки времени создания
+func (ch *TwoFactorChallenge) BeforeCreate(tx *gorm.DB) error {
+	ch.CreatedAt = time.Now()
+	return nil
+}
+
+// IsExpired проверяет, истек ли срок действия входа
+func (ch *TwoFactorChallenge) IsExpired() bool {
+	return time.Now().After(ch.ExpiresAt)
+}
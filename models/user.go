@@ -11,13 +11,14 @@ import (
 
 // User представляет модель пользователя в системе
 type User struct {
-	ID            uint   `json:"id" gorm:"primaryKey"`
-	Name          string `json:"name" gorm:"not null"`
-	Email         string `json:"email" gorm:"uniqueIndex;not null"`
-	PasswordHash  string `json:"-" gorm:"not null"` // Скрываем хэш пароля в JSON
-	OAuthProvider string `json:"oauth_provider" gorm:"default:''"`
-	OAuthID       string `json:"oauth_id" gorm:"default:''"`
-	IsActive      bool   `json:"is_active" gorm:"default:true"`
+	ID            uint    `json:"id" gorm:"primaryKey"`
+	Name          string  `json:"name" gorm:"not null"`
+	Email         string  `json:"email" gorm:"uniqueIndex;not null"`
+	Username      *string `json:"username" gorm:"size:30;uniqueIndex"` // Имя для упоминаний (@username)
+	PasswordHash  string  `json:"-" gorm:"not null"`                   // Скрываем хэш пароля в JSON
+	OAuthProvider string  `json:"oauth_provider" gorm:"default:''"`
+	OAuthID       string  `json:"oauth_id" gorm:"default:''"`
+	IsActive      bool    `json:"is_active" gorm:"default:true"`
 	// Поля профиля
 	Avatar    string    `json:"avatar" gorm:"default:''"`        // URL аватара
 	Bio       string    `json:"bio" gorm:"type:text;default:''"` // Описание профиля
@@ -55,6 +56,13 @@ func InitDB() (*gorm.DB, error) {
 func (u *User) BeforeCreate(tx *gorm.DB) error {
 	u.CreatedAt = time.Now()
 	u.UpdatedAt = time.Now()
+	if u.Username == nil {
+		username, err := GenerateUsername(tx.Session(&gorm.Session{NewDB: true}), u.Email)
+		if err != nil {
+			return err
+		}
+		u.Username = &username
+	}
 	return nil
 }
 
@@ -0,0 +1,80 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// UsernameMinLength минимальная длина имени пользователя
+	UsernameMinLength = 3
+	// UsernameMaxLength максимальная длина имени пользователя
+	UsernameMaxLength = 30
+)
+
+// usernamePattern допустимое имя пользователя: латиница в нижнем регистре, цифры и «_»
+var usernamePattern = regexp.MustCompile(`^[a-z0-9_]{3,30}$`)
+
+// NormalizeUsername приводит имя пользователя к каноническому виду
+func NormalizeUsername(username string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(username), "@"))
+}
+
+// IsValidUsername проверяет формат имени пользователя
+func IsValidUsername(username string) bool {
+	return usernamePattern.MatchString(username)
+}
+
+// GenerateUsername подбирает свободное имя пользователя на основе email
+func GenerateUsername(db *gorm.DB, email string) (string, error) {
+	local := strings.ToLower(strings.SplitN(email, "@", 2)[0])
+	var b strings.Builder
+	for _, r := range local {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '_':
+			b.WriteRune(r)
+		case r == '.' || r == '-' || r == '+':
+			b.WriteRune('_')
+		}
+	}
+	base := strings.Trim(b.String(), "_")
+	if len(base) > UsernameMaxLength-4 {
+		base = base[:UsernameMaxLength-4]
+	}
+	if len(base) < UsernameMinLength {
+		base = strings.TrimSuffix("user_"+base, "_")
+	}
+
+	candidate := base
+	for suffix := 1; ; suffix++ {
+		var count int64
+		if err := db.Model(&User{}).Where("username = ?", candidate).Count(&count).Error; err != nil {
+			return "", err
+		}
+		if count == 0 {
+			return candidate, nil
+		}
+		candidate = fmt.Sprintf("%s%d", base, suffix)
+	}
+}
+
+// BackfillUsernames назначает имена пользователям, зарегистрированным до появления упоминаний
+func BackfillUsernames(db *gorm.DB) error {
+	var users []User
+	if err := db.Select("id", "email").Where("username IS NULL").Find(&users).Error; err != nil {
+		return err
+	}
+	for _, user := range users {
+		username, err := GenerateUsername(db, user.Email)
+		if err != nil {
+			return err
+		}
+		if err := db.Model(&User{}).Where("id = ?", user.ID).UpdateColumn("username", username).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"toloko-backend/models"
+	"toloko-backend/services"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+// testPNG минимальное содержимое, которое распознается как PNG
+var testPNG = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR\x00\x00\x00\x01\x00\x00\x00\x01\x08\x02\x00\x00\x00")
+
+// newsMediaFile загружаемый в тесте файл
+type newsMediaFile struct {
+	name    string
+	content []byte
+	caption string
+}
+
+// uploadNewsMedia отправляет multipart запрос загрузки изображений новости
+func uploadNewsMedia(app *fiber.App, newsID uint, token string, files ...newsMediaFile) (int, map[string]interface{}) {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	for _, file := range files {
+		part, _ := writer.CreateFormFile("images", file.name)
+		part.Write(file.content)
+		writer.WriteField("captions", file.caption)
+	}
+	writer.Close()
+
+	req := httptest.NewRequest("POST", fmt.Sprintf("/news/%d/media", newsID), body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := app.Test(req)
+	if err != nil {
+		return 0, nil
+	}
+	defer resp.Body.Close()
+
+	var result map[string]interface{}
+	data, _ := io.ReadAll(resp.Body)
+	json.Unmarshal(data, &result)
+	return resp.StatusCode, result
+}
+
+func TestNewsMedia(t *testing.T) {
+	// Файлы сохраняются относительно рабочей директории
+	wd, _ := os.Getwd()
+	os.Chdir(t.TempDir())
+	defer os.Chdir(wd)
+
+	db := setupCommunitiesTestDB()
+	app := setupCommunitiesTestApp(db)
+
+	editorID, editorToken := createCommunityMemberTestUser(db, "editor@example.com")
+	_, strangerToken := createCommunityMemberTestUser(db, "stranger@example.com")
+	community := createCommunitiesTestCommunity(db, editorID)
+	news := models.News{CommunityID: community.ID, AuthorID: editorID, Content: "Новость с фотографиями"}
+	db.Create(&news)
+
+	// Тип файла определяется по содержимому, а не по расширению
+	status, _ := uploadNewsMedia(app, news.ID, editorToken, newsMediaFile{name: "fake.png", content: []byte("not an image at all")})
+	assert.Equal(t, 400, status)
+
+	status, _ = uploadNewsMedia(app, news.ID, strangerToken, newsMediaFile{name: "photo.png", content: testPNG})
+	assert.Equal(t, 403, status)
+
+	status, result := uploadNewsMedia(app, news.ID, editorToken,
+		newsMediaFile{name: "first.png", content: testPNG, caption: "Первое"},
+		newsMediaFile{name: "second", content: testPNG, caption: "Второе"},
+	)
+	assert.Equal(t, 201, status)
+	uploaded := result["data"].([]interface{})
+	assert.Len(t, uploaded, 2)
+	first := uploaded[0].(map[string]interface{})
+	second := uploaded[1].(map[string]interface{})
+	assert.Equal(t, "image/png", first["mime_type"])
+	assert.Equal(t, float64(1), second["position"])
+	firstID, secondID := uint(first["id"].(float64)), uint(second["id"].(float64))
+
+	// Лимит изображений проверяется вместе с уже загруженными, лишние файлы не сохраняются
+	extra := make([]newsMediaFile, services.MaxNewsMediaPerPost-1)
+	for i := range extra {
+		extra[i] = newsMediaFile{name: fmt.Sprintf("extra%d.png", i), content: testPNG}
+	}
+	status, result = uploadNewsMedia(app, news.ID, editorToken, extra...)
+	assert.Equal(t, 400, status)
+	assert.Contains(t, result["message"], "не более")
+	var mediaCount int64
+	db.Model(&models.NewsMedia{}).Where("news_id = ?", news.ID).Count(&mediaCount)
+	assert.Equal(t, int64(2), mediaCount)
+
+	// Меняем порядок: список должен содержать все изображения
+	status, _ = communityRequest(app, "PUT", fmt.Sprintf("/news/%d/media/order", news.ID), editorToken, map[string]interface{}{"media_ids": []uint{secondID}})
+	assert.Equal(t, 400, status)
+	status, _ = communityRequest(app, "PUT", fmt.Sprintf("/news/%d/media/order", news.ID), editorToken, map[string]interface{}{"media_ids": []uint{secondID, firstID}})
+	assert.Equal(t, 200, status)
+	status, _ = communityRequest(app, "PUT", fmt.Sprintf("/news/%d/media/%d", news.ID, secondID), editorToken, map[string]interface{}{"caption": "Обложка"})
+	assert.Equal(t, 200, status)
+
+	// Изображения возвращаются структурированными объектами в порядке отображения
+	status, result = communityRequest(app, "GET", fmt.Sprintf("/news/%d", news.ID), "", nil)
+	assert.Equal(t, 200, status)
+	media := result["data"].(map[string]interface{})["media"].([]interface{})
+	if assert.Len(t, media, 2) {
+		cover := media[0].(map[string]interface{})
+		assert.Equal(t, float64(secondID), cover["id"])
+		assert.Equal(t, "Обложка", cover["caption"])
+		assert.Equal(t, fmt.Sprintf("/news/%d/media/%d/file", news.ID, secondID), cover["url"])
+		assert.Nil(t, cover["file_path"])
+	}
+
+	req := httptest.NewRequest("GET", fmt.Sprintf("/news/%d/media/%d/file", news.ID, firstID), nil)
+	resp, err := app.Test(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Equal(t, "image/png", resp.Header.Get("Content-Type"))
+
+	// При удалении новости удаляются и изображения вместе с файлами
+	var stored models.NewsMedia
+	db.First(&stored, firstID)
+	status, _ = communityRequest(app, "DELETE", fmt.Sprintf("/news/%d", news.ID), editorToken, nil)
+	assert.Equal(t, 200, status)
+
+	var count int64
+	db.Model(&models.NewsMedia{}).Where("news_id = ?", news.ID).Count(&count)
+	assert.Equal(t, int64(0), count)
+	_, err = os.Stat(stored.FilePath)
+	assert.True(t, os.IsNotExist(err))
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"toloko-backend/models"
+	"toloko-backend/routes"
+	"toloko-backend/services"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewsDraftsAndScheduling(t *testing.T) {
+	db := setupCommunitiesTestDB()
+	app := setupCommunitiesTestApp(db)
+
+	editorID, editorToken := createCommunityMemberTestUser(db, "editor@example.com")
+	memberID, memberToken := createCommunityMemberTestUser(db, "member@example.com")
+	community := createCommunitiesTestCommunity(db, editorID)
+	db.Create(&models.CommunityRole{CommunityID: community.ID, UserID: memberID, Role: models.CommunityRoleMember})
+
+	newsPath := fmt.Sprintf("/communities/%d/news", community.ID)
+
+	// Черновик видит только редактор
+	status, result := communityRequest(app, "POST", newsPath, editorToken, map[string]interface{}{
+		"content": "Черновик большой новости",
+		"status":  models.NewsStatusDraft,
+	})
+	assert.Equal(t, 201, status)
+	draftID := uint(result["data"].(map[string]interface{})["id"].(float64))
+
+	status, result = communityRequest(app, "GET", newsPath, memberToken, nil)
+	assert.Equal(t, 200, status)
+	assert.Nil(t, result["data"])
+	status, _ = communityRequest(app, "GET", fmt.Sprintf("/news/%d", draftID), memberToken, nil)
+	assert.Equal(t, 404, status)
+
+	status, result = communityRequest(app, "GET", newsPath+"?status=draft", editorToken, nil)
+	assert.Equal(t, 200, status)
+	assert.Len(t, result["data"], 1)
+
+	// Предпросмотр доступен только редактору
+	status, _ = communityRequest(app, "GET", fmt.Sprintf("/news/%d/preview", draftID), memberToken, nil)
+	assert.Equal(t, 403, status)
+	status, result = communityRequest(app, "GET", fmt.Sprintf("/news/%d/preview", draftID), editorToken, nil)
+	assert.Equal(t, 200, status)
+	preview := result["data"].(map[string]interface{})
+	assert.Equal(t, float64(1), preview["recipients"])
+	assert.Equal(t, "Черновик большой новости", preview["notification"].(map[string]interface{})["body"])
+
+	// Комментарии черновика скрыты так же, как сам черновик
+	comment := models.Comment{NewsID: draftID, AuthorID: editorID, Content: "Заметка редактора"}
+	db.Create(&comment)
+	for _, path := range []string{fmt.Sprintf("/news/%d/comments", draftID), fmt.Sprintf("/comments/%d", comment.ID), fmt.Sprintf("/comments/%d/replies", comment.ID)} {
+		status, _ = communityRequest(app, "GET", path, memberToken, nil)
+		assert.Equal(t, 404, status, path)
+		status, _ = communityRequest(app, "GET", path, "", nil)
+		assert.Equal(t, 404, status, path)
+		status, _ = communityRequest(app, "GET", path, editorToken, nil)
+		assert.Equal(t, 200, status, path)
+	}
+
+	// Отложенная публикация требует времени в будущем
+	status, _ = communityRequest(app, "POST", newsPath, editorToken, map[string]interface{}{
+		"content":    "Отложенная новость сообщества",
+		"status":     models.NewsStatusScheduled,
+		"publish_at": time.Now().Add(-time.Hour).Format(time.RFC3339),
+	})
+	assert.Equal(t, 400, status)
+
+	status, result = communityRequest(app, "POST", newsPath, editorToken, map[string]interface{}{
+		"content":    "Отложенная новость сообщества",
+		"status":     models.NewsStatusScheduled,
+		"publish_at": time.Now().Add(time.Hour).Format(time.RFC3339),
+	})
+	assert.Equal(t, 201, status)
+	scheduledID := uint(result["data"].(map[string]interface{})["id"].(float64))
+
+	// До наступления времени публикации ничего не происходит
+	published, err := services.NewNewsService(db).PublishDue(time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, published)
+
+	published, err = services.NewNewsService(db).PublishDue(time.Now().Add(2 * time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, published)
+
+	status, result = communityRequest(app, "GET", newsPath, memberToken, nil)
+	assert.Equal(t, 200, status)
+	assert.Equal(t, float64(scheduledID), result["data"].([]interface{})[0].(map[string]interface{})["id"])
+
+	// Уведомление получает участник, но не автор
+	var notifications []models.Notification
+	db.Where("entity_type = ? AND entity_id = ?", "news", scheduledID).Find(&notifications)
+	if assert.Len(t, notifications, 1) {
+		assert.Equal(t, memberID, notifications[0].UserID)
+		assert.Equal(t, models.NotificationTypeNewsPublished, notifications[0].Type)
+	}
+
+	// Опубликованную новость нельзя вернуть в черновики, а черновик публикуется сразу
+	status, _ = communityRequest(app, "PUT", fmt.Sprintf("/news/%d", scheduledID), editorToken, map[string]interface{}{"status": models.NewsStatusDraft})
+	assert.Equal(t, 400, status)
+	status, _ = communityRequest(app, "PUT", fmt.Sprintf("/news/%d", draftID), editorToken, map[string]interface{}{"status": models.NewsStatusPublished})
+	assert.Equal(t, 200, status)
+	status, _ = communityRequest(app, "GET", fmt.Sprintf("/news/%d", draftID), memberToken, nil)
+	assert.Equal(t, 200, status)
+}
+
+func TestNotificationsAPI(t *testing.T) {
+	db := setupCommunitiesTestDB()
+	app := setupCommunitiesTestApp(db)
+	routes.SetupNotificationRoutes(app, db)
+
+	userID, token := createCommunityMemberTestUser(db, "reader@example.com")
+	otherID, otherToken := createCommunityMemberTestUser(db, "other@example.com")
+	notifier := services.NewNotificationService(db)
+	assert.NoError(t, notifier.NotifyMany([]uint{userID, otherID}, models.Notification{Type: models.NotificationTypeNewsPublished, Title: "Первое"}))
+	assert.NoError(t, notifier.NotifyMany([]uint{userID}, models.Notification{Type: models.NotificationTypeNewsPublished, Title: "Второе"}))
+
+	status, result := communityRequest(app, "GET", "/api/notifications/unread-count", token, nil)
+	assert.Equal(t, 200, status)
+	assert.Equal(t, float64(2), result["unread_count"])
+
+	status, result = communityRequest(app, "GET", "/api/notifications", token, nil)
+	assert.Equal(t, 200, status)
+	list := result["notifications"].([]interface{})
+	assert.Len(t, list, 2)
+	firstID := uint(list[0].(map[string]interface{})["id"].(float64))
+
+	// Чужое уведомление отметить нельзя
+	status, _ = communityRequest(app, "POST", fmt.Sprintf("/api/notifications/%d/read", firstID), otherToken, nil)
+	assert.Equal(t, 404, status)
+
+	status, _ = communityRequest(app, "POST", fmt.Sprintf("/api/notifications/%d/read", firstID), token, nil)
+	assert.Equal(t, 200, status)
+	status, result = communityRequest(app, "GET", "/api/notifications?unread=true", token, nil)
+	assert.Equal(t, 200, status)
+	assert.Len(t, result["notifications"], 1)
+
+	status, result = communityRequest(app, "POST", "/api/notifications/read-all", token, nil)
+	assert.Equal(t, 200, status)
+	assert.Equal(t, float64(1), result["updated"])
+
+	_, result = communityRequest(app, "GET", "/api/notifications/unread-count", otherToken, nil)
+	assert.Equal(t, float64(1), result["unread_count"])
+}
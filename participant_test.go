@@ -36,7 +36,9 @@ func setupParticipantTestDB() *gorm.DB {
 		&models.EventPhotoPost{},
 		&models.Rating{},
 		&models.UserRatingSummary{},
+		&models.UserRatingBucket{},
 		&models.Complaint{},
+		&models.AuditLog{},
 	)
 
 	// Создаем тестовых пользователей
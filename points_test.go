@@ -0,0 +1,190 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"toloko-backend/controllers"
+	"toloko-backend/models"
+	"toloko-backend/routes"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// setupPointsTestApp создает тестовое приложение с маршрутами ивентов, участников и жалоб
+func setupPointsTestApp() (*fiber.App, *gorm.DB) {
+	db := setupCommunitiesTestDB()
+	db.AutoMigrate(&models.UserRole{}, &models.EventParticipant{}, &models.EventInventory{}, &models.EventPhoto{}, &models.ParticipantInventory{}, &models.Complaint{})
+
+	app := fiber.New()
+	routes.SetupParticipantRoutes(app, controllers.NewParticipantController(db))
+	routes.SetupEventRoutes(app, controllers.NewEventController(db))
+	routes.SetupComplaintRoutes(app, controllers.NewComplaintController(db))
+	return app, db
+}
+
+// createPointsTestEvent создает ивент с участниками, вступившими в него
+func createPointsTestEvent(db *gorm.DB, creatorID uint, participantIDs ...uint) (models.Event, []models.EventParticipant) {
+	event := models.Event{CreatorID: creatorID, Title: "Уборка парка", StartTime: time.Now(), EndTime: time.Now().Add(2 * time.Hour)}
+	db.Create(&event)
+
+	participants := make([]models.EventParticipant, 0, len(participantIDs))
+	for _, userID := range participantIDs {
+		participant := models.EventParticipant{EventID: event.ID, UserID: userID, Status: models.ParticipantStatusJoined}
+		db.Create(&participant)
+		participants = append(participants, participant)
+	}
+	return event, participants
+}
+
+// userPoints возвращает баланс очков пользователя
+func userPoints(db *gorm.DB, userID uint) int {
+	var level models.UserLevel
+	db.Where("user_id = ?", userID).First(&level)
+	return level.Points
+}
+
+func TestPointsLedgerCheckInAndCompletion(t *testing.T) {
+	app, db := setupPointsTestApp()
+	organizerID, _ := createCommunityMemberTestUser(db, "organizer@example.com")
+	organizerToken := generateTestJWT(organizerID)
+	volunteerID, _ := createCommunityMemberTestUser(db, "volunteer@example.com")
+	volunteerToken := generateTestJWT(volunteerID)
+	absentID, _ := createCommunityMemberTestUser(db, "absent@example.com")
+	event, participants := createPointsTestEvent(db, organizerID, volunteerID, absentID)
+	first := models.Achievement{Name: "Первый ивент", Description: "Посетите ивент", IconPath: "/icons/first.png",
+		CriterionEvent: models.AchievementEventParticipated, CriterionThreshold: 1}
+	db.Create(&first)
+
+	// Вступление в ивент еще не участие: достижение выдается только после отметки
+	upcoming := models.Event{CreatorID: organizerID, Title: "Посадка деревьев", StartTime: time.Now().Add(24 * time.Hour), EndTime: time.Now().Add(26 * time.Hour), IsActive: true, JoinMode: models.JoinModeFree}
+	db.Create(&upcoming)
+	status, _ := communityRequest(app, "POST", fmt.Sprintf("/events/%d/join", upcoming.ID), volunteerToken, map[string]interface{}{})
+	assert.Equal(t, 201, status)
+	assert.Equal(t, int64(0), countUserAchievements(db, volunteerID, first.ID))
+
+	// Отмечать участников может только организатор
+	path := fmt.Sprintf("/events/%d/participants/%d/check-in", event.ID, participants[0].ID)
+	status, _ = communityRequest(app, "POST", path, volunteerToken, nil)
+	assert.Equal(t, 403, status)
+
+	status, _ = communityRequest(app, "POST", path, organizerToken, nil)
+	assert.Equal(t, 200, status)
+	status, _ = communityRequest(app, "POST", path, organizerToken, nil)
+	assert.Equal(t, 409, status)
+
+	// Очки получает только отмеченный участник, и только один раз
+	assert.Equal(t, int64(1), countUserAchievements(db, volunteerID, first.ID))
+	assert.Equal(t, int64(0), countUserAchievements(db, absentID, first.ID))
+	assert.Equal(t, 20, userPoints(db, volunteerID))
+	assert.Equal(t, 0, userPoints(db, absentID))
+
+	// Завершение ивента начисляет очки организатору, повторно ивент не завершить
+	status, _ = communityRequest(app, "POST", fmt.Sprintf("/events/%d/complete", event.ID), organizerToken, nil)
+	assert.Equal(t, 200, status)
+	status, _ = communityRequest(app, "POST", fmt.Sprintf("/events/%d/complete", event.ID), organizerToken, nil)
+	assert.Equal(t, 400, status)
+	assert.Equal(t, 50, userPoints(db, organizerID))
+
+	var entries int64
+	db.Model(&models.PointsEntry{}).Count(&entries)
+	assert.Equal(t, int64(2), entries)
+
+	// Ивент без отмеченных участников очков организатору не приносит
+	emptyEvent, _ := createPointsTestEvent(db, organizerID, absentID)
+	status, _ = communityRequest(app, "POST", fmt.Sprintf("/events/%d/complete", emptyEvent.ID), organizerToken, nil)
+	assert.Equal(t, 200, status)
+	assert.Equal(t, 50, userPoints(db, organizerID))
+}
+
+func TestPointsLedgerSelfFarming(t *testing.T) {
+	app, db := setupPointsTestApp()
+	organizerID, organizerAuth := createCommunityMemberTestUser(db, "organizer@example.com")
+	organizerToken := generateTestJWT(organizerID)
+	volunteerID, _ := createCommunityMemberTestUser(db, "volunteer@example.com")
+
+	// Создатель не может вступить в свой ивент ни через один из обработчиков
+	upcoming := models.Event{CreatorID: organizerID, Title: "Посадка деревьев", StartTime: time.Now().Add(24 * time.Hour), EndTime: time.Now().Add(26 * time.Hour), IsActive: true, JoinMode: models.JoinModeFree}
+	db.Create(&upcoming)
+	joinPath := fmt.Sprintf("/events/%d/join", upcoming.ID)
+	status, result := communityRequest(app, "POST", joinPath, organizerToken, map[string]interface{}{})
+	assert.Equal(t, 400, status)
+	assert.Contains(t, result["message"], "Создатель")
+	eventsApp := fiber.New()
+	routes.SetupEventRoutes(eventsApp, controllers.NewEventController(db))
+	status, result = communityRequest(eventsApp, "POST", joinPath, organizerAuth, map[string]interface{}{})
+	assert.Equal(t, 400, status)
+	assert.Contains(t, result["message"], "Создатель")
+
+	// До начала ивента отмечать участников нельзя, мгновенное завершение очков не приносит
+	volunteer := models.EventParticipant{EventID: upcoming.ID, UserID: volunteerID, Status: models.ParticipantStatusJoined}
+	db.Create(&volunteer)
+	status, _ = communityRequest(app, "POST", fmt.Sprintf("/events/%d/participants/%d/check-in", upcoming.ID, volunteer.ID), organizerToken, nil)
+	assert.Equal(t, 400, status)
+	now := time.Now()
+	db.Model(&volunteer).Update("checked_in_at", &now)
+	status, _ = communityRequest(app, "POST", fmt.Sprintf("/events/%d/complete", upcoming.ID), organizerToken, nil)
+	assert.Equal(t, 200, status)
+
+	// Организатор не может отметить самого себя даже во время ивента
+	event, participants := createPointsTestEvent(db, organizerID, organizerID)
+	status, _ = communityRequest(app, "POST", fmt.Sprintf("/events/%d/participants/%d/check-in", event.ID, participants[0].ID), organizerToken, nil)
+	assert.Equal(t, 400, status)
+	db.Model(&participants[0]).Update("checked_in_at", &now)
+	status, _ = communityRequest(app, "POST", fmt.Sprintf("/events/%d/complete", event.ID), organizerToken, nil)
+	assert.Equal(t, 200, status)
+
+	assert.Equal(t, 0, userPoints(db, organizerID))
+	var entries int64
+	db.Model(&models.PointsEntry{}).Count(&entries)
+	assert.Equal(t, int64(0), entries)
+}
+
+func TestPointsLedgerReversals(t *testing.T) {
+	app, db := setupPointsTestApp()
+	organizerID, organizerUtilsToken := createCommunityMemberTestUser(db, "organizer@example.com")
+	organizerToken := generateTestJWT(organizerID)
+	cheaterID, _ := createCommunityMemberTestUser(db, "cheater@example.com")
+	honestID, _ := createCommunityMemberTestUser(db, "honest@example.com")
+	moderatorID, _ := createCommunityMemberTestUser(db, "moderator@example.com")
+	db.Create(&models.UserRole{UserID: moderatorID, Role: models.RoleAdmin})
+
+	event, participants := createPointsTestEvent(db, organizerID, cheaterID, honestID)
+	for _, participant := range participants {
+		status, _ := communityRequest(app, "POST", fmt.Sprintf("/events/%d/participants/%d/check-in", event.ID, participant.ID), organizerToken, nil)
+		assert.Equal(t, 200, status)
+	}
+
+	// Подтвержденная жалоба на участника отменяет его очки за ивент
+	complaint := models.Complaint{EventID: &event.ID, FromUserID: honestID, AboutUserID: cheaterID, ReasonCode: models.ComplaintReasonNoShow, Status: models.ComplaintStatusOpen}
+	db.Create(&complaint)
+	statusPath := fmt.Sprintf("/complaints/%d/status", complaint.ID)
+	status, _ := communityRequest(app, "PUT", statusPath, generateTestJWT(moderatorID), map[string]interface{}{"status": models.ComplaintStatusResolved})
+	assert.Equal(t, 200, status)
+	assert.Equal(t, 0, userPoints(db, cheaterID))
+	assert.Equal(t, 20, userPoints(db, honestID))
+
+	// Повторное подтверждение не отменяет очки дважды
+	db.Model(&complaint).Update("status", models.ComplaintStatusUnderReview)
+	status, _ = communityRequest(app, "PUT", statusPath, generateTestJWT(moderatorID), map[string]interface{}{"status": models.ComplaintStatusResolved})
+	assert.Equal(t, 200, status)
+	assert.Equal(t, 0, userPoints(db, cheaterID))
+
+	// Отмена ивента отменяет все оставшиеся начисления, журнал только дополняется
+	status, _ = communityRequest(app, "DELETE", fmt.Sprintf("/events/%d", event.ID), organizerUtilsToken, nil)
+	assert.Equal(t, 200, status)
+	assert.Equal(t, 0, userPoints(db, honestID))
+
+	var reversals []models.PointsEntry
+	db.Where("reason_code = ?", models.PointsReasonReversal).Order("id").Find(&reversals)
+	if assert.Len(t, reversals, 2) {
+		assert.Equal(t, models.PointsSourceComplaint, reversals[0].SourceType)
+		assert.Equal(t, -20, reversals[1].Amount)
+	}
+	var entries int64
+	db.Model(&models.PointsEntry{}).Count(&entries)
+	assert.Equal(t, int64(4), entries)
+}
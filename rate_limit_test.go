@@ -0,0 +1,185 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"toloko-backend/controllers"
+	"toloko-backend/models"
+	"toloko-backend/routes"
+	"toloko-backend/services"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+// setupRateLimitTestApp создает тестовое приложение с ограничениями частоты
+func setupRateLimitTestApp(policies map[string]services.RateLimitPolicy) *fiber.App {
+	app := fiber.New()
+	rateLimiter := services.NewRateLimiter(services.NewMemoryRateLimitStore(), policies)
+	routes.SetupRateLimits(app, rateLimiter)
+
+	ok := func(c *fiber.Ctx) error {
+		return c.JSON(fiber.Map{"success": true})
+	}
+	app.Post("/auth/login", ok)
+	app.Get("/auth/login", ok)
+	app.Post("/events/:id/complaints", ok)
+
+	return app
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	app := setupRateLimitTestApp(map[string]services.RateLimitPolicy{
+		services.PolicyAuthLogin: {Name: services.PolicyAuthLogin, Limit: 2, Window: time.Minute, KeyBy: services.RateLimitKeyByIP},
+	})
+
+	for i := 0; i < 2; i++ {
+		resp, err := app.Test(httptest.NewRequest("POST", "/auth/login", nil))
+		assert.NoError(t, err)
+		assert.Equal(t, 200, resp.StatusCode)
+	}
+
+	// Третий запрос превышает лимит
+	resp, err := app.Test(httptest.NewRequest("POST", "/auth/login", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, 429, resp.StatusCode)
+	assert.NotEmpty(t, resp.Header.Get("Retry-After"))
+	assert.Equal(t, "0", resp.Header.Get("X-RateLimit-Remaining"))
+
+	// Другие методы не ограничиваются
+	resp, err = app.Test(httptest.NewRequest("GET", "/auth/login", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+}
+
+func TestRateLimitByUser(t *testing.T) {
+	app := setupRateLimitTestApp(map[string]services.RateLimitPolicy{
+		services.PolicyComplaintsSubmit: {Name: services.PolicyComplaintsSubmit, Limit: 1, Window: time.Hour, KeyBy: services.RateLimitKeyByUser},
+	})
+
+	request := func(userID uint) int {
+		req := httptest.NewRequest("POST", "/events/1/complaints", nil)
+		req.Header.Set("Authorization", "Bearer "+roleTestToken(userID))
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		return resp.StatusCode
+	}
+
+	assert.Equal(t, 200, request(1))
+	assert.Equal(t, 429, request(1))
+
+	// Лимит другого пользователя не затронут
+	assert.Equal(t, 200, request(2))
+}
+
+func TestWSMessageRateLimit(t *testing.T) {
+	rateLimiter := services.NewRateLimiter(services.NewMemoryRateLimitStore(), map[string]services.RateLimitPolicy{
+		services.PolicyMessagesSend: {Name: services.PolicyMessagesSend, Limit: 1, Window: time.Minute, KeyBy: services.RateLimitKeyByUser},
+	})
+
+	assert.True(t, rateLimiter.AllowWSMessage(1, "message.send").Allowed)
+	result := rateLimiter.AllowWSMessage(1, "message.send")
+	assert.False(t, result.Allowed)
+	assert.True(t, result.RetryAfter > 0)
+
+	// Типы без политики не ограничиваются
+	assert.True(t, rateLimiter.AllowWSMessage(1, "ping").Allowed)
+}
+
+func TestLoginLockout(t *testing.T) {
+	app := setupTestApp()
+
+	registerReq := controllers.RegisterRequest{
+		Name:            "Тест Пользователь",
+		Email:           "lockout@example.com",
+		Password:        "password123",
+		ConfirmPassword: "password123",
+		AcceptTerms:     true,
+	}
+	jsonData, _ := json.Marshal(registerReq)
+	req := httptest.NewRequest("POST", "/auth/register", bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+	app.Test(req)
+
+	// Пятая неудачная попытка блокирует вход
+	statuses := []int{}
+	for i := 0; i < 5; i++ {
+		jsonData, _ := json.Marshal(controllers.LoginRequest{Email: "lockout@example.com", Password: "wrongpassword"})
+		req := httptest.NewRequest("POST", "/auth/login", bytes.NewBuffer(jsonData))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		statuses = append(statuses, resp.StatusCode)
+	}
+	assert.Equal(t, []int{401, 401, 401, 401, 429}, statuses)
+
+	// Верный пароль во время блокировки не принимается
+	jsonData, _ = json.Marshal(controllers.LoginRequest{Email: "lockout@example.com", Password: "password123"})
+	req = httptest.NewRequest("POST", "/auth/login", bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 429, resp.StatusCode)
+	assert.NotEmpty(t, resp.Header.Get("Retry-After"))
+}
+
+func TestLoginGuardProgressiveLockout(t *testing.T) {
+	guard := services.NewLoginGuard(services.NewMemoryRateLimitStore())
+
+	var lockout time.Duration
+	for i := 0; i < guard.MaxFailures; i++ {
+		lockout, _ = guard.RegisterFailure("user@example.com", "10.0.0.1")
+	}
+	assert.Equal(t, guard.BaseLockout, lockout)
+
+	// Каждая следующая ошибка удваивает блокировку
+	lockout, _ = guard.RegisterFailure("user@example.com", "10.0.0.1")
+	assert.Equal(t, 2*guard.BaseLockout, lockout)
+
+	lockedFor, err := guard.Check("USER@example.com", "10.0.0.1")
+	assert.NoError(t, err)
+	assert.True(t, lockedFor > guard.BaseLockout)
+
+	// Блокировка с чужого адреса не мешает владельцу войти со своего
+	lockedFor, _ = guard.Check("user@example.com", "10.0.0.2")
+	assert.Equal(t, time.Duration(0), lockedFor)
+
+	// Сброс снимает блокировку
+	assert.NoError(t, guard.Reset("user@example.com", "10.0.0.1"))
+	lockedFor, _ = guard.Check("user@example.com", "10.0.0.1")
+	assert.Equal(t, time.Duration(0), lockedFor)
+}
+
+func TestDBRateLimitStore(t *testing.T) {
+	db := setupTestDB()
+	db.AutoMigrate(&models.RateLimitCounter{})
+	store := services.NewDBRateLimitStore(db)
+
+	count, resetAt, err := store.Increment("test:key", time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+	assert.True(t, resetAt.After(time.Now()))
+
+	count, _, err = store.Increment("test:key", time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	count, _, err = store.Get("test:key")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	// Истекшее окно начинается заново
+	db.Model(&models.RateLimitCounter{}).Where("key = ?", "test:key").Update("reset_at", time.Now().UTC().Add(-time.Second))
+	count, _, err = store.Increment("test:key", time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	assert.NoError(t, store.Reset("test:key"))
+	count, _, err = store.Get("test:key")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, count)
+}
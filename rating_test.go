@@ -37,6 +37,7 @@ func setupRatingTestDB() *gorm.DB {
 		&models.EventPhotoPost{},
 		&models.Rating{},
 		&models.UserRatingSummary{},
+		&models.UserRatingBucket{},
 		&models.Complaint{},
 	)
 
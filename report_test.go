@@ -0,0 +1,175 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"toloko-backend/controllers"
+	"toloko-backend/models"
+	"toloko-backend/routes"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// setupReportTestApp создает тестовое приложение с маршрутами жалоб
+func setupReportTestApp() (*fiber.App, *gorm.DB) {
+	db := setupCommunitiesTestDB()
+	db.AutoMigrate(&models.Complaint{}, &models.UserRole{}, &models.Conversation{}, &models.Message{}, &models.Attachment{})
+
+	app := fiber.New()
+	routes.SetupComplaintRoutes(app, controllers.NewComplaintController(db))
+	return app, db
+}
+
+// reportBody формирует тело запроса жалобы
+func reportBody(targetType string, targetID uint) map[string]interface{} {
+	return map[string]interface{}{
+		"target_type": targetType,
+		"target_id":   targetID,
+		"reason_code": models.ComplaintReasonHarassment,
+		"reason_text": "Оскорбления в адрес участников",
+	}
+}
+
+func TestReportCommunityContent(t *testing.T) {
+	app, db := setupReportTestApp()
+	adminID, _ := createCommunityMemberTestUser(db, "admin@example.com")
+	adminToken := generateTestJWT(adminID)
+	authorID, _ := createCommunityMemberTestUser(db, "author@example.com")
+	authorToken := generateTestJWT(authorID)
+	reporterID, _ := createCommunityMemberTestUser(db, "reporter@example.com")
+	reporterToken := generateTestJWT(reporterID)
+	platformModeratorID, _ := createCommunityMemberTestUser(db, "platform@example.com")
+	platformModeratorToken := generateTestJWT(platformModeratorID)
+	db.Create(&models.UserRole{UserID: platformModeratorID, Role: models.RoleModerator})
+	community := createCommunitiesTestCommunity(db, adminID)
+	news := models.News{CommunityID: community.ID, AuthorID: authorID, Content: "Оскорбительная новость"}
+	db.Create(&news)
+
+	status, result := communityRequest(app, "POST", "/complaints", reporterToken, reportBody(models.ComplaintTargetNews, news.ID))
+	assert.Equal(t, 201, status)
+	complaint := result["complaint"].(map[string]interface{})
+	assert.Equal(t, float64(authorID), complaint["about_user_id"])
+	assert.Equal(t, float64(community.ID), complaint["community_id"])
+	complaintID := uint(complaint["id"].(float64))
+
+	// Копия контента сохраняется, даже если новость потом изменят
+	db.Model(&news).Update("content", "Исправленная новость")
+	var snapshot map[string]interface{}
+	assert.NoError(t, json.Unmarshal([]byte(complaint["snapshot"].(string)), &snapshot))
+	assert.Equal(t, "Оскорбительная новость", snapshot["content"])
+
+	// Повторная жалоба до рассмотрения и жалоба на свой контент запрещены
+	status, _ = communityRequest(app, "POST", "/complaints", reporterToken, reportBody(models.ComplaintTargetNews, news.ID))
+	assert.Equal(t, 409, status)
+	status, _ = communityRequest(app, "POST", "/complaints", authorToken, reportBody(models.ComplaintTargetNews, news.ID))
+	assert.Equal(t, 400, status)
+
+	// Жалоба попадает в очередь сообщества, а не платформы
+	status, result = communityRequest(app, "GET", fmt.Sprintf("/communities/%d/reports", community.ID), adminToken, nil)
+	assert.Equal(t, 200, status)
+	assert.Equal(t, float64(1), result["total"])
+	status, result = communityRequest(app, "GET", "/complaints", platformModeratorToken, nil)
+	assert.Equal(t, 200, status)
+	assert.Nil(t, result["complaints"])
+	status, _ = communityRequest(app, "GET", fmt.Sprintf("/communities/%d/reports", community.ID), reporterToken, nil)
+	assert.Equal(t, 403, status)
+
+	// Рассматривают модераторы сообщества
+	path := fmt.Sprintf("/complaints/%d/status", complaintID)
+	status, _ = communityRequest(app, "PUT", path, reporterToken, map[string]interface{}{"status": models.ComplaintStatusResolved})
+	assert.Equal(t, 403, status)
+	status, result = communityRequest(app, "PUT", path, adminToken, map[string]interface{}{"status": models.ComplaintStatusResolved})
+	assert.Equal(t, 200, status)
+	assert.Equal(t, models.ComplaintStatusResolved, result["complaint"].(map[string]interface{})["status"])
+
+	// Жалоба на само сообщество - в очередь платформы
+	status, result = communityRequest(app, "POST", "/complaints", reporterToken, reportBody(models.ComplaintTargetCommunity, community.ID))
+	assert.Equal(t, 201, status)
+	assert.Nil(t, result["complaint"].(map[string]interface{})["community_id"])
+	status, result = communityRequest(app, "GET", "/complaints", platformModeratorToken, nil)
+	assert.Equal(t, 200, status)
+	assert.Equal(t, float64(1), result["total"])
+}
+
+func TestReportReviewAndVisibility(t *testing.T) {
+	app, db := setupReportTestApp()
+	adminID, _ := createCommunityMemberTestUser(db, "admin@example.com")
+	moderatorID, _ := createCommunityMemberTestUser(db, "moderator@example.com")
+	moderatorToken := generateTestJWT(moderatorID)
+	reporterID, _ := createCommunityMemberTestUser(db, "reporter@example.com")
+	reporterToken := generateTestJWT(reporterID)
+	community := createCommunitiesTestCommunity(db, adminID)
+	db.Create(&models.CommunityRole{CommunityID: community.ID, UserID: moderatorID, Role: models.CommunityRoleModerator})
+
+	// Модератор не видит и не рассматривает жалобу на себя
+	news := models.News{CommunityID: community.ID, AuthorID: moderatorID, Content: "Новость модератора"}
+	db.Create(&news)
+	status, result := communityRequest(app, "POST", "/complaints", reporterToken, reportBody(models.ComplaintTargetNews, news.ID))
+	assert.Equal(t, 201, status)
+	complaintID := uint(result["complaint"].(map[string]interface{})["id"].(float64))
+	status, _ = communityRequest(app, "PUT", fmt.Sprintf("/complaints/%d/status", complaintID), moderatorToken, map[string]interface{}{"status": models.ComplaintStatusDismissed})
+	assert.Equal(t, 403, status)
+	status, result = communityRequest(app, "GET", fmt.Sprintf("/communities/%d/reports", community.ID), moderatorToken, nil)
+	assert.Equal(t, 200, status)
+	assert.Empty(t, result["complaints"])
+
+	// Черновики и новости закрытого сообщества, которые пользователь не видит, обжаловать нельзя
+	draft := models.News{CommunityID: community.ID, AuthorID: adminID, Content: "Черновик новости", Status: models.NewsStatusDraft}
+	db.Create(&draft)
+	status, _ = communityRequest(app, "POST", "/complaints", reporterToken, reportBody(models.ComplaintTargetNews, draft.ID))
+	assert.Equal(t, 404, status)
+
+	private := createCommunitiesTestCommunity(db, adminID)
+	db.Model(private).Update("visibility", models.CommunityVisibilityInvite)
+	hidden := models.News{CommunityID: private.ID, AuthorID: adminID, Content: "Новость для своих"}
+	db.Create(&hidden)
+	comment := models.Comment{NewsID: hidden.ID, AuthorID: adminID, Content: "Комментарий для своих"}
+	db.Create(&comment)
+	status, _ = communityRequest(app, "POST", "/complaints", reporterToken, reportBody(models.ComplaintTargetNews, hidden.ID))
+	assert.Equal(t, 404, status)
+	status, _ = communityRequest(app, "POST", "/complaints", reporterToken, reportBody(models.ComplaintTargetComment, comment.ID))
+	assert.Equal(t, 404, status)
+
+	db.Create(&models.CommunityRole{CommunityID: private.ID, UserID: reporterID, Role: models.CommunityRoleMember})
+	status, _ = communityRequest(app, "POST", "/complaints", reporterToken, reportBody(models.ComplaintTargetNews, hidden.ID))
+	assert.Equal(t, 201, status)
+}
+
+func TestReportMessage(t *testing.T) {
+	app, db := setupReportTestApp()
+	senderID, _ := createCommunityMemberTestUser(db, "sender@example.com")
+	recipientID, _ := createCommunityMemberTestUser(db, "recipient@example.com")
+	recipientToken := generateTestJWT(recipientID)
+	strangerID, _ := createCommunityMemberTestUser(db, "stranger@example.com")
+	strangerToken := generateTestJWT(strangerID)
+
+	conversation := models.Conversation{UserAID: senderID, UserBID: recipientID}
+	db.Create(&conversation)
+	message := models.Message{ConversationID: conversation.ID, FromUserID: senderID, ToUserID: recipientID, Text: "Угрозы"}
+	db.Create(&message)
+	attachment := models.Attachment{MessageID: message.ID, FilePath: "uploads/a.png", MimeType: "image/png", Size: 10, UploadedBy: senderID}
+	db.Create(&attachment)
+
+	// Пожаловаться на сообщение может только участник переписки
+	status, _ := communityRequest(app, "POST", "/complaints", strangerToken, reportBody(models.ComplaintTargetMessage, message.ID))
+	assert.Equal(t, 404, status)
+
+	status, result := communityRequest(app, "POST", "/complaints", recipientToken, reportBody(models.ComplaintTargetMessage, message.ID))
+	assert.Equal(t, 201, status)
+	complaint := result["complaint"].(map[string]interface{})
+	assert.Equal(t, float64(senderID), complaint["about_user_id"])
+	assert.Nil(t, complaint["community_id"])
+	assert.Contains(t, complaint["snapshot"], "Угрозы")
+
+	status, result = communityRequest(app, "POST", "/complaints", recipientToken, reportBody(models.ComplaintTargetAttachment, attachment.ID))
+	assert.Equal(t, 201, status)
+	assert.Contains(t, result["complaint"].(map[string]interface{})["snapshot"], "uploads/a.png")
+
+	// Неизвестный тип объекта
+	status, _ = communityRequest(app, "POST", "/complaints", recipientToken, reportBody("event", 1))
+	assert.Equal(t, 400, status)
+}
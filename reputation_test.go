@@ -0,0 +1,164 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"toloko-backend/controllers"
+	"toloko-backend/models"
+	"toloko-backend/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// applyTestRating сохраняет оценку на ивенте 1 и учитывает ее в репутации
+func applyTestRating(t *testing.T, db *gorm.DB, reputation *services.ReputationService, from, to uint, score int, createdAt time.Time) {
+	applyTestEventRating(t, db, reputation, 1, from, to, score, createdAt)
+}
+
+// applyTestEventRating сохраняет оценку на указанном ивенте и учитывает ее в репутации
+func applyTestEventRating(t *testing.T, db *gorm.DB, reputation *services.ReputationService, eventID, from, to uint, score int, createdAt time.Time) {
+	rating := models.Rating{EventID: eventID, FromUserID: from, ToUserID: to, Score: score}
+	require.NoError(t, db.Create(&rating).Error)
+	require.NoError(t, db.Model(&rating).UpdateColumn("created_at", createdAt).Error)
+	rating.CreatedAt = createdAt
+	require.NoError(t, db.Transaction(func(tx *gorm.DB) error {
+		return reputation.ApplyRating(tx, &rating)
+	}))
+}
+
+func TestReputationScoring(t *testing.T) {
+	db := setupRatingTestDB()
+	config := services.ReputationConfig{PriorMean: 7, PriorWeight: 5, HalfLife: 30 * 24 * time.Hour, ConcentrationWeight: 0.3}
+	reputation := services.NewReputationServiceWithConfig(db, config)
+	now := time.Now()
+
+	t.Run("Many good ratings outweigh a single perfect one", func(t *testing.T) {
+		applyTestRating(t, db, reputation, 1000, 100, 10, now)
+		for i := uint(0); i < 50; i++ {
+			applyTestRating(t, db, reputation, 2000+i, 101, 8, now)
+		}
+
+		single, _, err := reputation.Summary(100, now)
+		require.NoError(t, err)
+		many, breakdown, err := reputation.Summary(101, now)
+		require.NoError(t, err)
+
+		assert.Equal(t, 10.0, single.AverageScore)
+		assert.Equal(t, 7.5, single.Reputation)
+		assert.Greater(t, many.Reputation, single.Reputation)
+		assert.Equal(t, 50, many.VotesCount)
+
+		require.Len(t, breakdown, 10)
+		assert.Equal(t, 10, breakdown[0].Score)
+		assert.Equal(t, 8, breakdown[2].Score)
+		assert.Equal(t, 50, breakdown[2].Count)
+		assert.Equal(t, 100.0, breakdown[2].Percent)
+	})
+
+	t.Run("Reciprocal ratings after an event keep full weight", func(t *testing.T) {
+		applyTestRating(t, db, reputation, 201, 200, 10, now)
+		before, _, err := reputation.Summary(200, now)
+		require.NoError(t, err)
+
+		// Участники ивента обычно оценивают друг друга, это не сговор
+		applyTestRating(t, db, reputation, 200, 201, 10, now)
+		after, _, err := reputation.Summary(200, now)
+		require.NoError(t, err)
+		assert.Equal(t, before.Reputation, after.Reputation)
+
+		var ratings []models.Rating
+		require.NoError(t, db.Where("from_user_id IN ?", []uint{200, 201}).Find(&ratings).Error)
+		for _, rating := range ratings {
+			assert.Equal(t, 1.0, rating.Weight)
+		}
+	})
+
+	t.Run("Raters who keep rating the same people are down-weighted", func(t *testing.T) {
+		applyTestEventRating(t, db, reputation, 1, 401, 400, 10, now)
+		applyTestEventRating(t, db, reputation, 1, 401, 402, 10, now)
+		before, _, err := reputation.Summary(402, now)
+		require.NoError(t, err)
+
+		// 401 снова и снова оценивает 400 на других ивентах: 3 из 5 оценок повторные
+		for eventID := uint(2); eventID <= 4; eventID++ {
+			applyTestEventRating(t, db, reputation, eventID, 401, 400, 10, now)
+			applyTestEventRating(t, db, reputation, eventID, 400, 401, 10, now)
+		}
+		after, _, err := reputation.Summary(402, now)
+		require.NoError(t, err)
+		assert.Less(t, after.Reputation, before.Reputation)
+
+		var ratings []models.Rating
+		require.NoError(t, db.Where("from_user_id = ?", 401).Find(&ratings).Error)
+		for _, rating := range ratings {
+			assert.InDelta(t, 0.58, rating.Weight, 0.0001)
+		}
+		require.NoError(t, db.Where("from_user_id = ?", 400).Find(&ratings).Error)
+		for _, rating := range ratings {
+			assert.InDelta(t, 1-0.7*2.0/3.0, rating.Weight, 0.0001)
+		}
+
+		require.NoError(t, reputation.Rebuild(402))
+		rebuilt, _, err := reputation.Summary(402, now)
+		require.NoError(t, err)
+		assert.InDelta(t, after.Reputation, rebuilt.Reputation, 0.01)
+	})
+
+	t.Run("Old ratings decay toward the prior", func(t *testing.T) {
+		applyTestRating(t, db, reputation, 301, 300, 10, now.Add(-90*24*time.Hour))
+		applyTestRating(t, db, reputation, 302, 300, 10, now.Add(-90*24*time.Hour))
+		applyTestRating(t, db, reputation, 303, 301, 10, now)
+		applyTestRating(t, db, reputation, 304, 301, 10, now)
+
+		old, _, err := reputation.Summary(300, now)
+		require.NoError(t, err)
+		fresh, _, err := reputation.Summary(301, now)
+		require.NoError(t, err)
+
+		assert.Less(t, old.Reputation, fresh.Reputation)
+		assert.Greater(t, old.Reputation, config.PriorMean)
+	})
+
+	t.Run("Incremental summary matches full rebuild", func(t *testing.T) {
+		incremental, incrementalBreakdown, err := reputation.Summary(101, now)
+		require.NoError(t, err)
+
+		require.NoError(t, reputation.Rebuild(101))
+		rebuilt, rebuiltBreakdown, err := reputation.Summary(101, now)
+		require.NoError(t, err)
+
+		assert.InDelta(t, incremental.Reputation, rebuilt.Reputation, 0.01)
+		assert.Equal(t, incremental.VotesCount, rebuilt.VotesCount)
+		assert.Equal(t, incrementalBreakdown, rebuiltBreakdown)
+	})
+}
+
+func TestGetUserRatingBreakdown(t *testing.T) {
+	db := setupRatingTestDB()
+	app := createRatingTestApp(db)
+	reputation := services.NewReputationServiceWithConfig(db, services.DefaultReputationConfig())
+
+	applyTestRating(t, db, reputation, 1, 2, 9, time.Now())
+	applyTestRating(t, db, reputation, 3, 2, 6, time.Now())
+
+	req := httptest.NewRequest("GET", "/users/2/ratings", nil)
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	var response controllers.UserRatingResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&response))
+	require.NotNil(t, response.RatingSummary)
+	assert.Equal(t, 2, response.RatingSummary.VotesCount)
+	assert.Equal(t, 7.5, response.RatingSummary.AverageScore)
+	assert.Greater(t, response.RatingSummary.Reputation, 0.0)
+	require.Len(t, response.Breakdown, 10)
+	assert.Equal(t, 1, response.Breakdown[1].Count) // 9 баллов
+	assert.Equal(t, 1, response.Breakdown[4].Count) // 6 баллов
+	assert.Equal(t, 50.0, response.Breakdown[4].Percent)
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"toloko-backend/models"
+	"toloko-backend/routes"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventReviews(t *testing.T) {
+	app, db := setupPointsTestApp()
+	db.AutoMigrate(&models.EventReview{})
+	routes.SetupReviewRoutes(app, db)
+
+	organizerID, organizerAuth := createCommunityMemberTestUser(db, "organizer@example.com")
+	volunteerID, volunteerAuth := createCommunityMemberTestUser(db, "volunteer@example.com")
+	_, strangerAuth := createCommunityMemberTestUser(db, "stranger@example.com")
+
+	past := models.Event{CreatorID: organizerID, Title: "Уборка парка", StartTime: time.Now().Add(-5 * time.Hour), EndTime: time.Now().Add(-2 * time.Hour), IsActive: true}
+	upcoming := models.Event{CreatorID: organizerID, Title: "Посадка деревьев", StartTime: time.Now().Add(24 * time.Hour), EndTime: time.Now().Add(26 * time.Hour), IsActive: true}
+	db.Create(&past)
+	db.Create(&upcoming)
+	for _, event := range []models.Event{past, upcoming} {
+		db.Create(&models.EventParticipant{EventID: event.ID, UserID: volunteerID, Status: models.ParticipantStatusJoined})
+	}
+
+	review := map[string]interface{}{"score": 4, "organization": 2, "safety": 3, "matched_description": 1, "text": "Не хватило перчаток"}
+	reviewsPath := func(event models.Event) string {
+		return fmt.Sprintf("/events/%d/reviews", event.ID)
+	}
+
+	// Отзыв оставляют только посетившие участники и только после завершения ивента
+	status, _ := communityRequest(app, "POST", reviewsPath(upcoming), volunteerAuth, review)
+	assert.Equal(t, 400, status)
+	status, _ = communityRequest(app, "POST", reviewsPath(past), strangerAuth, review)
+	assert.Equal(t, 403, status)
+	status, _ = communityRequest(app, "POST", reviewsPath(past), organizerAuth, review)
+	assert.Equal(t, 403, status)
+	status, _ = communityRequest(app, "POST", reviewsPath(past), volunteerAuth, map[string]interface{}{"score": 4, "organization": 6, "safety": 3, "matched_description": 1})
+	assert.Equal(t, 400, status)
+
+	// Без отметки о присутствии участие не подтверждено
+	status, _ = communityRequest(app, "POST", reviewsPath(past), volunteerAuth, review)
+	assert.Equal(t, 403, status)
+	db.Model(&models.EventParticipant{}).Where("event_id = ? AND user_id = ?", past.ID, volunteerID).Update("checked_in_at", time.Now().Add(-4*time.Hour))
+
+	status, result := communityRequest(app, "POST", reviewsPath(past), volunteerAuth, review)
+	assert.Equal(t, 201, status)
+	reviewID := uint(result["review"].(map[string]interface{})["id"].(float64))
+	status, _ = communityRequest(app, "POST", reviewsPath(past), volunteerAuth, review)
+	assert.Equal(t, 409, status)
+
+	assert.NoError(t, db.Where("user_id = ? AND type = ?", organizerID, models.NotificationTypeEventReview).First(&models.Notification{}).Error)
+
+	// Ответить на отзыв может только организатор
+	replyPath := fmt.Sprintf("%s/%d/reply", reviewsPath(past), reviewID)
+	status, _ = communityRequest(app, "PUT", replyPath, volunteerAuth, map[string]interface{}{"reply": "Спасибо"})
+	assert.Equal(t, 403, status)
+	status, _ = communityRequest(app, "PUT", replyPath, organizerAuth, map[string]interface{}{"reply": " "})
+	assert.Equal(t, 400, status)
+	status, result = communityRequest(app, "PUT", replyPath, organizerAuth, map[string]interface{}{"reply": "Учтем, в следующий раз купим больше"})
+	assert.Equal(t, 200, status)
+	assert.Equal(t, "Учтем, в следующий раз купим больше", result["review"].(map[string]interface{})["reply"])
+	assert.NoError(t, db.Where("user_id = ? AND type = ?", volunteerID, models.NotificationTypeReviewReply).First(&models.Notification{}).Error)
+
+	// Сводка отзывов об ивенте
+	status, result = communityRequest(app, "GET", reviewsPath(past), strangerAuth, nil)
+	assert.Equal(t, 200, status)
+	assert.Len(t, result["reviews"], 1)
+	summary := result["summary"].(map[string]interface{})
+	assert.Equal(t, float64(1), summary["reviews_count"])
+	assert.Equal(t, float64(4), summary["average_score"])
+	assert.Equal(t, float64(2), summary["organization"])
+
+	// Репутация организатора в отзывах, в ивенте и в профиле
+	status, result = communityRequest(app, "GET", fmt.Sprintf("/api/users/%d/reviews", organizerID), strangerAuth, nil)
+	assert.Equal(t, 200, status)
+	assert.Len(t, result["reviews"], 1)
+	reputation := result["summary"].(map[string]interface{})["reputation"].(float64)
+	assert.Less(t, reputation, 7.0)
+	assert.Greater(t, reputation, 4.0)
+
+	status, result = communityRequest(app, "GET", fmt.Sprintf("/events/%d", upcoming.ID), "", nil)
+	assert.Equal(t, 200, status)
+	assert.Equal(t, reputation, result["organizer_reputation"].(map[string]interface{})["reputation"])
+	assert.Equal(t, float64(0), result["reviews"].(map[string]interface{})["reviews_count"])
+	assert.Equal(t, float64(0), result["reviews"].(map[string]interface{})["reputation"])
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"toloko-backend/models"
+	"toloko-backend/routes"
+	"toloko-backend/services"
+	"toloko-backend/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// setupRoleTestApp создает тестовое приложение с маршрутами ролей и администратором
+func setupRoleTestApp() (*fiber.App, *gorm.DB, uint, uint) {
+	db := setupTestDB()
+	db.AutoMigrate(&models.UserRole{})
+	adminID, userID := createTestUsers(db)
+	db.Create(&models.UserRole{UserID: adminID, Role: models.RoleAdmin})
+
+	app := fiber.New()
+	routes.SetupRoleRoutes(app, db)
+
+	return app, db, adminID, userID
+}
+
+// roleTestToken создает JWT токен для маршрутов с AuthMiddleware
+func roleTestToken(userID uint) string {
+	token, _ := utils.GenerateJWT(userID, "")
+	return token
+}
+
+func TestGrantRole(t *testing.T) {
+	app, db, adminID, userID := setupRoleTestApp()
+
+	body, _ := json.Marshal(map[string]string{"role": models.RoleModerator})
+	req := httptest.NewRequest("POST", "/api/admin/roles/users/2", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+roleTestToken(adminID))
+
+	resp, err := app.Test(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 201, resp.StatusCode)
+
+	// Проверяем, что роль выдана
+	var userRole models.UserRole
+	err = db.Where("user_id = ? AND role = ?", userID, models.RoleModerator).First(&userRole).Error
+	assert.NoError(t, err)
+	assert.Equal(t, adminID, userRole.GrantedBy)
+
+	// Повторная выдача роли
+	req = httptest.NewRequest("POST", "/api/admin/roles/users/2", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+roleTestToken(adminID))
+
+	resp, err = app.Test(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 409, resp.StatusCode)
+}
+
+func TestGrantUnknownRole(t *testing.T) {
+	app, _, adminID, _ := setupRoleTestApp()
+
+	body, _ := json.Marshal(map[string]string{"role": "superuser"})
+	req := httptest.NewRequest("POST", "/api/admin/roles/users/2", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+roleTestToken(adminID))
+
+	resp, err := app.Test(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 400, resp.StatusCode)
+}
+
+func TestGrantRoleWithoutPermission(t *testing.T) {
+	app, db, _, userID := setupRoleTestApp()
+
+	// Модератор не может управлять ролями
+	db.Create(&models.UserRole{UserID: userID, Role: models.RoleModerator})
+
+	body, _ := json.Marshal(map[string]string{"role": models.RoleAdmin})
+	req := httptest.NewRequest("POST", "/api/admin/roles/users/2", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+roleTestToken(userID))
+
+	resp, err := app.Test(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 403, resp.StatusCode)
+}
+
+func TestRevokeRole(t *testing.T) {
+	app, db, adminID, userID := setupRoleTestApp()
+	db.Create(&models.UserRole{UserID: userID, Role: models.RoleSupport})
+
+	req := httptest.NewRequest("DELETE", "/api/admin/roles/users/2/support", nil)
+	req.Header.Set("Authorization", "Bearer "+roleTestToken(adminID))
+
+	resp, err := app.Test(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	var count int64
+	db.Model(&models.UserRole{}).Where("user_id = ?", userID).Count(&count)
+	assert.Equal(t, int64(0), count)
+}
+
+func TestRevokeLastAdmin(t *testing.T) {
+	app, _, adminID, _ := setupRoleTestApp()
+
+	req := httptest.NewRequest("DELETE", "/api/admin/roles/users/1/admin", nil)
+	req.Header.Set("Authorization", "Bearer "+roleTestToken(adminID))
+
+	resp, err := app.Test(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 409, resp.StatusCode)
+}
+
+func TestGetMyRoles(t *testing.T) {
+	app, _, adminID, _ := setupRoleTestApp()
+
+	req := httptest.NewRequest("GET", "/api/roles/me", nil)
+	req.Header.Set("Authorization", "Bearer "+roleTestToken(adminID))
+
+	resp, err := app.Test(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	var result map[string]interface{}
+	json.NewDecoder(resp.Body).Decode(&result)
+	assert.Equal(t, []interface{}{models.RoleAdmin}, result["roles"])
+	assert.Contains(t, result["permissions"], models.PermissionRolesManage)
+}
+
+func TestHasCommunityPermission(t *testing.T) {
+	db := setupTestDB()
+	db.AutoMigrate(&models.UserRole{}, &models.Community{}, &models.CommunityRole{})
+	user1ID, user2ID := createTestUsers(db)
+
+	community := models.Community{Name: "Test Community", City: "Москва", CreatorID: user1ID}
+	db.Create(&community)
+	db.Create(&models.CommunityRole{CommunityID: community.ID, UserID: user1ID, Role: models.CommunityRoleAdmin})
+	db.Create(&models.CommunityRole{CommunityID: community.ID, UserID: user2ID, Role: models.CommunityRoleMember})
+
+	permissionService := services.NewPermissionService(db)
+
+	// Администратор сообщества управляет новостями
+	allowed, err := permissionService.HasCommunityPermission(user1ID, community.ID, models.PermissionCommunityNewsManage)
+	assert.NoError(t, err)
+	assert.True(t, allowed)
+
+	// Обычный участник не управляет новостями
+	allowed, err = permissionService.HasCommunityPermission(user2ID, community.ID, models.PermissionCommunityNewsManage)
+	assert.NoError(t, err)
+	assert.False(t, allowed)
+
+	// Глобальный модератор управляет любым сообществом
+	db.Create(&models.UserRole{UserID: user2ID, Role: models.RoleModerator})
+	allowed, err = permissionService.HasCommunityPermission(user2ID, community.ID, models.PermissionCommunityNewsManage)
+	assert.NoError(t, err)
+	assert.True(t, allowed)
+}
@@ -0,0 +1,29 @@
+package routes
+
+import (
+	"toloko-backend/controllers"
+	"toloko-backend/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// SetupAccountRoutes настраивает маршруты для экспорта данных и удаления аккаунта
+func SetupAccountRoutes(app *fiber.App, db *gorm.DB) {
+	accountController := controllers.NewAccountController(db)
+
+	// Группа маршрутов для аккаунта
+	account := app.Group("/api/account", utils.AuthMiddleware)
+
+	// GET /api/account/export - скачать ZIP архив с персональными данными
+	account.Get("/export", accountController.ExportData)
+
+	// GET /api/account/deletion - получить состояние запроса на удаление
+	account.Get("/deletion", accountController.GetDeletionStatus)
+
+	// POST /api/account/deletion - запросить удаление аккаунта
+	account.Post("/deletion", accountController.RequestDeletion)
+
+	// DELETE /api/account/deletion - отменить удаление аккаунта
+	account.Delete("/deletion", accountController.CancelDeletion)
+}
@@ -14,5 +14,6 @@ func SetupAchievementRoutes(app *fiber.App, achievementController *controllers.A
 	achievements := api.Group("/achievements")
 	achievements.Get("/", achievementController.GetAllAchievements)                                   // GET /api/achievements - получить все достижения
 	achievements.Get("/user/:id", achievementController.GetUserAchievements)                          // GET /api/achievements/user/:id - получить достижения пользователя
+	achievements.Get("/user/:id/closest", achievementController.GetClosestAchievements)               // GET /api/achievements/user/:id/closest - ближайшие к получению достижения (для дашборда)
 	achievements.Post("/user/:user_id/award/:achievement_id", achievementController.AwardAchievement) // POST /api/achievements/user/:user_id/award/:achievement_id - наградить достижением
 }
@@ -0,0 +1,24 @@
+package routes
+
+import (
+	"toloko-backend/controllers"
+	"toloko-backend/models"
+	"toloko-backend/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// SetupAuditRoutes настраивает маршруты для просмотра журнала аудита
+func SetupAuditRoutes(app *fiber.App, db *gorm.DB) {
+	auditController := controllers.NewAuditController(db)
+
+	// Группа маршрутов журнала аудита
+	audit := app.Group("/api/admin/audit", utils.AuthMiddleware, utils.RequirePermission(auditController.Permissions, models.PermissionAuditView))
+
+	// GET /api/admin/audit - получить записи журнала (?actor_id=&action=&target_type=&target_id=&from=&to=&page=&limit=)
+	audit.Get("/", auditController.GetAuditLogs)
+
+	// GET /api/admin/audit/:id - получить запись журнала
+	audit.Get("/:id", auditController.GetAuditLog)
+}
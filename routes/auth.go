@@ -17,6 +17,9 @@ func SetupAuthRoutes(app *fiber.App, authController *controllers.AuthController)
 	// POST /auth/login - вход пользователя
 	auth.Post("/login", authController.Login)
 
+	// POST /auth/2fa/verify - второй шаг входа с кодом 2FA
+	auth.Post("/2fa/verify", authController.VerifyTwoFactor)
+
 	// POST /auth/recover - запрос на восстановление пароля
 	auth.Post("/recover", authController.Recover)
 
@@ -0,0 +1,32 @@
+package routes
+
+import (
+	"toloko-backend/controllers"
+	"toloko-backend/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// SetupCertificateRoutes настраивает маршруты для волонтерских часов и сертификатов
+func SetupCertificateRoutes(app *fiber.App, db *gorm.DB) {
+	certificateController := controllers.NewCertificateController(db)
+
+	// GET /api/users/:id/hours - подтвержденные волонтерские часы по годам и типам ивентов (?year=)
+	app.Get("/api/users/:id/hours", utils.AuthMiddleware, certificateController.GetHoursSummary)
+
+	// Группа маршрутов для сертификатов текущего пользователя
+	certificates := app.Group("/api/certificates", utils.AuthMiddleware)
+
+	// GET /api/certificates - получить свои сертификаты
+	certificates.Get("/", certificateController.GetCertificates)
+
+	// POST /api/certificates - выдать сертификат на подтвержденные часы за год или за все время
+	certificates.Post("/", certificateController.IssueCertificate)
+
+	// GET /api/certificates/:code/download - скачать сертификат для печати
+	certificates.Get("/:code/download", certificateController.DownloadCertificate)
+
+	// GET /certificates/:code - проверить подлинность сертификата (публичный доступ)
+	app.Get("/certificates/:code", certificateController.VerifyCertificate)
+}
@@ -0,0 +1,47 @@
+package routes
+
+import (
+	"toloko-backend/controllers"
+	"toloko-backend/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// SetupChallengeRoutes настраивает маршруты для челленджей
+func SetupChallengeRoutes(app *fiber.App, db *gorm.DB) {
+	challengeController := controllers.NewChallengeController(db)
+
+	// Группа маршрутов для челленджей
+	challenges := app.Group("/api/challenges", utils.AuthMiddleware)
+
+	// GET /api/challenges - получить челленджи (?community_id=&status=upcoming|active|finished)
+	challenges.Get("/", challengeController.GetChallenges)
+
+	// POST /api/challenges - создать челлендж сообщества или платформы
+	challenges.Post("/", challengeController.CreateChallenge)
+
+	// GET /api/challenges/:id - получить челлендж с текущим прогрессом
+	challenges.Get("/:id", challengeController.GetChallenge)
+
+	// GET /api/challenges/:id/leaderboard - таблица участников или команд (?type=individual|team)
+	challenges.Get("/:id/leaderboard", challengeController.GetChallengeLeaderboard)
+
+	// POST /api/challenges/:id/join - участвовать лично или в команде
+	challenges.Post("/:id/join", challengeController.JoinChallenge)
+
+	// POST /api/challenges/:id/teams - создать команду и стать ее капитаном
+	challenges.Post("/:id/teams", challengeController.CreateChallengeTeam)
+
+	// POST /api/challenges/:id/contributions - записать результат на ивенте (метрика impact)
+	challenges.Post("/:id/contributions", challengeController.AddChallengeContribution)
+
+	// GET /api/challenges/:id/contributions/pending - неподтвержденные результаты на ивентах организатора
+	challenges.Get("/:id/contributions/pending", challengeController.GetPendingContributions)
+
+	// POST /api/challenges/:id/contributions/:contribution_id/confirm - подтвердить результат (организатор ивента)
+	challenges.Post("/:id/contributions/:contribution_id/confirm", challengeController.ConfirmChallengeContribution)
+
+	// POST /api/challenges/:id/contributions/:contribution_id/reject - отклонить результат (организатор ивента)
+	challenges.Post("/:id/contributions/:contribution_id/reject", challengeController.RejectChallengeContribution)
+}
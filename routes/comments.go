@@ -12,16 +12,19 @@ func SetupCommentRoutes(app *fiber.App, commentController *controllers.CommentCo
 	news := app.Group("/news")
 
 	// Маршруты для комментариев
-	news.Post("/:id/comments", commentController.CreateComment) // POST /news/:id/comments - создать комментарий
-	news.Get("/:id/comments", commentController.GetComments)    // GET /news/:id/comments - получить комментарии
+	news.Post("/:id/comments", commentController.CreateComment)      // POST /news/:id/comments - создать комментарий
+	news.Get("/:id/comments", commentController.GetComments)         // GET /news/:id/comments - получить комментарии
+	news.Get("/:id/comments/tree", commentController.GetCommentTree) // GET /news/:id/comments/tree - дерево комментариев с первыми ответами
 
 	// Группа маршрутов для отдельных комментариев
 	comments := app.Group("/comments")
 
 	// Публичные маршруты
-	comments.Get("/:id", commentController.GetComment) // GET /comments/:id - получить комментарий по ID
+	comments.Get("/:id", commentController.GetComment)         // GET /comments/:id - получить комментарий по ID
+	comments.Get("/:id/replies", commentController.GetReplies) // GET /comments/:id/replies - получить ответы (?cursor=&limit=)
 
 	// Защищенные маршруты
-	comments.Put("/:id", commentController.UpdateComment)    // PUT /comments/:id - обновить комментарий
-	comments.Delete("/:id", commentController.DeleteComment) // DELETE /comments/:id - удалить комментарий
+	comments.Put("/:id", commentController.UpdateComment)     // PUT /comments/:id - обновить комментарий
+	comments.Delete("/:id", commentController.DeleteComment)  // DELETE /comments/:id - удалить комментарий
+	comments.Post("/:id/like", commentController.LikeComment) // POST /comments/:id/like - лайк/анлайк комментария
 }
@@ -12,7 +12,11 @@ func SetupCommunityRoutes(app *fiber.App, communityController *controllers.Commu
 	communities := app.Group("/communities")
 
 	// Публичные маршруты (не требуют авторизации)
-	communities.Get("/", communityController.GetCommunities)  // GET /communities - список сообществ
+	communities.Get("/", communityController.GetCommunities) // GET /communities - поиск сообществ (?search=&city=&lat=&lng=&radius=&sort=)
+
+	// GET /communities/suggestions - сообщества из подписок (требует авторизации, должен быть перед параметрическим маршрутом)
+	communities.Get("/suggestions", communityController.GetSuggestedCommunities)
+
 	communities.Get("/:id", communityController.GetCommunity) // GET /communities/:id - получить сообщество
 
 	// Защищенные маршруты (требуют авторизации)
@@ -0,0 +1,42 @@
+package routes
+
+import (
+	"toloko-backend/controllers"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// SetupCommunityMemberRoutes настраивает маршруты для участия в сообществах
+func SetupCommunityMemberRoutes(app *fiber.App, memberController *controllers.CommunityMemberController) {
+	communities := app.Group("/communities")
+
+	// Участие в сообществе
+	communities.Post("/:id/join", memberController.JoinCommunity)                              // POST /communities/:id/join - вступить или подать заявку
+	communities.Post("/:id/leave", memberController.LeaveCommunity)                            // POST /communities/:id/leave - выйти или отозвать заявку
+	communities.Get("/:id/members", memberController.GetMembers)                               // GET /communities/:id/members - список участников (?role=&page=&limit=)
+	communities.Get("/:id/members/count", memberController.GetMembersCount)                    // GET /communities/:id/members/count - количество участников
+	communities.Get("/:id/requests", memberController.GetJoinRequests)                         // GET /communities/:id/requests - заявки на вступление (?status=pending)
+	communities.Post("/:id/requests/:request_id/approve", memberController.ApproveJoinRequest) // POST /communities/:id/requests/:request_id/approve - одобрить заявку
+	communities.Post("/:id/requests/:request_id/reject", memberController.RejectJoinRequest)   // POST /communities/:id/requests/:request_id/reject - отклонить заявку
+	communities.Post("/:id/invitations", memberController.InviteMember)                        // POST /communities/:id/invitations - пригласить пользователя
+
+	// Управление ролями
+	communities.Put("/:id/members/:user_id/role", memberController.ChangeMemberRole) // PUT /communities/:id/members/:user_id/role - изменить роль участника
+	communities.Delete("/:id/members/:user_id", memberController.RemoveMember)       // DELETE /communities/:id/members/:user_id - исключить участника
+	communities.Post("/:id/transfer", memberController.TransferOwnership)            // POST /communities/:id/transfer - передать владение сообществом
+	communities.Get("/:id/roles/history", memberController.GetRoleHistory)           // GET /communities/:id/roles/history - история ролей (?user_id=&page=&limit=)
+
+	// Модерация
+	communities.Post("/:id/bans", memberController.BanMember)                               // POST /communities/:id/bans - заблокировать пользователя
+	communities.Delete("/:id/bans/:user_id", memberController.UnbanMember)                  // DELETE /communities/:id/bans/:user_id - снять бан
+	communities.Post("/:id/mutes", memberController.MuteMember)                             // POST /communities/:id/mutes - временно запретить писать
+	communities.Delete("/:id/mutes/:user_id", memberController.UnmuteMember)                // DELETE /communities/:id/mutes/:user_id - снять мут
+	communities.Get("/:id/members/:user_id/sanctions", memberController.GetMemberSanctions) // GET /communities/:id/members/:user_id/sanctions - санкции пользователя
+	communities.Get("/:id/moderation/log", memberController.GetModerationLog)               // GET /communities/:id/moderation/log - журнал модерации (?action=&user_id=&page=&limit=)
+
+	// Приглашения текущего пользователя
+	invitations := app.Group("/community-invitations")
+	invitations.Get("/", memberController.GetMyInvitations)              // GET /community-invitations - мои приглашения
+	invitations.Post("/:id/accept", memberController.AcceptInvitation)   // POST /community-invitations/:id/accept - принять приглашение
+	invitations.Post("/:id/decline", memberController.DeclineInvitation) // POST /community-invitations/:id/decline - отклонить приглашение
+}
@@ -17,7 +17,10 @@ func SetupComplaintRoutes(app *fiber.App, complaintController *controllers.Compl
 	// Группа маршрутов для управления жалобами
 	complaintManagement := app.Group("/complaints")
 
-	// GET /complaints - получить список жалоб (только для модераторов, требует авторизации)
+	// POST /complaints - пожаловаться на пользователя, сообщение, вложение, новость, комментарий или сообщество (требует авторизации)
+	complaintManagement.Post("/", complaintController.SubmitReport)
+
+	// GET /complaints - получить очередь жалоб платформы (только для модераторов, требует авторизации; ?community_id= - жалобы сообщества)
 	complaintManagement.Get("/", complaintController.GetComplaints)
 
 	// PUT /complaints/:id/status - обновить статус жалобы (только для модераторов, требует авторизации)
@@ -28,4 +31,7 @@ func SetupComplaintRoutes(app *fiber.App, complaintController *controllers.Compl
 
 	// GET /complaints/my - получить жалобы пользователя (требует авторизации)
 	complaintManagement.Get("/my", complaintController.GetUserComplaints)
+
+	// GET /communities/:id/reports - очередь жалоб на контент сообщества (для модераторов сообщества)
+	app.Get("/communities/:id/reports", complaintController.GetCommunityReports)
 }
@@ -58,6 +58,9 @@ func SetupEventRoutes(app *fiber.App, eventController *controllers.EventControll
 	// POST /events/:id/leave - покинуть событие (требует авторизации)
 	events.Post("/:id/leave", eventController.LeaveEvent)
 
+	// GET /communities/:id/events - календарь ивентов сообщества (?view=month|week&date=YYYY-MM-DD)
+	app.Get("/communities/:id/events", eventController.GetCommunityEvents)
+
 	// Группа маршрутов для инвентаря
 	inventory := app.Group("/inventory")
 
@@ -20,6 +20,9 @@ func SetupFeedRoutes(app *fiber.App, feedController *controllers.FeedController)
 	// GET /feed/recommended - рекомендуемые события
 	feed.Get("/recommended", feedController.GetRecommendedEvents)
 
+	// GET /feed/activities - лента активности: новые уровни пользователя и его подписок
+	feed.Get("/activities", feedController.GetActivities)
+
 	// GET /feed/stats - статистика ленты
 	feed.Get("/stats", feedController.GetFeedStats)
 }
@@ -12,7 +12,10 @@ func SetupLevelRoutes(app *fiber.App, levelController *controllers.LevelControll
 
 	// Маршруты для уровней
 	levels := api.Group("/levels")
-	levels.Get("/user/:id", levelController.GetUserLevel)          // GET /api/levels/user/:id - получить уровень пользователя
-	levels.Get("/leaderboard", levelController.GetLeaderboard)     // GET /api/levels/leaderboard - получить таблицу лидеров
-	levels.Post("/user/:id/add-points", levelController.AddPoints) // POST /api/levels/user/:id/add-points - добавить очки пользователю
+	levels.Get("/user/:id", levelController.GetUserLevel)              // GET /api/levels/user/:id - получить уровень пользователя
+	levels.Get("/user/:id/points", levelController.GetPointsHistory)   // GET /api/levels/user/:id/points - журнал начисления очков
+	levels.Get("/leaderboard", levelController.GetLeaderboard)         // GET /api/levels/leaderboard - получить таблицу лидеров
+	levels.Get("/rankings", levelController.GetRankings)               // GET /api/levels/rankings - таблицы лидеров по периоду, городу, сообществу и типу ивента
+	levels.Get("/definitions", levelController.GetLevelDefinitions)    // GET /api/levels/definitions - кривая уровней
+	levels.Put("/definitions", levelController.UpdateLevelDefinitions) // PUT /api/levels/definitions - заменить кривую уровней (администраторы)
 }
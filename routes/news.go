@@ -23,6 +23,14 @@ func SetupNewsRoutes(app *fiber.App, newsController *controllers.NewsController)
 	news.Get("/:id/like", newsController.LikeNews) // GET /news/:id/like - лайк/анлайк новости
 
 	// Защищенные маршруты
-	news.Put("/:id", newsController.UpdateNews)    // PUT /news/:id - обновить новость
-	news.Delete("/:id", newsController.DeleteNews) // DELETE /news/:id - удалить новость
+	news.Get("/:id/preview", newsController.PreviewNews) // GET /news/:id/preview - предпросмотр новости для редакторов
+	news.Put("/:id", newsController.UpdateNews)          // PUT /news/:id - обновить новость
+	news.Delete("/:id", newsController.DeleteNews)       // DELETE /news/:id - удалить новость
+
+	// Изображения новостей
+	news.Get("/:id/media/:media_id/file", newsController.GetNewsMediaFile) // GET /news/:id/media/:media_id/file - получить файл изображения
+	news.Post("/:id/media", newsController.UploadNewsMedia)                // POST /news/:id/media - загрузить изображения (multipart: images, captions)
+	news.Put("/:id/media/order", newsController.ReorderNewsMedia)          // PUT /news/:id/media/order - изменить порядок изображений
+	news.Put("/:id/media/:media_id", newsController.UpdateNewsMedia)       // PUT /news/:id/media/:media_id - изменить подпись изображения
+	news.Delete("/:id/media/:media_id", newsController.DeleteNewsMedia)    // DELETE /news/:id/media/:media_id - удалить изображение
 }
@@ -0,0 +1,29 @@
+package routes
+
+import (
+	"toloko-backend/controllers"
+	"toloko-backend/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// SetupNotificationRoutes настраивает маршруты для уведомлений
+func SetupNotificationRoutes(app *fiber.App, db *gorm.DB) {
+	notificationController := controllers.NewNotificationController(db)
+
+	// Группа маршрутов для уведомлений
+	notifications := app.Group("/api/notifications", utils.AuthMiddleware)
+
+	// GET /api/notifications - получить уведомления (?unread=true&page=&limit=)
+	notifications.Get("/", notificationController.GetNotifications)
+
+	// GET /api/notifications/unread-count - получить количество непрочитанных уведомлений
+	notifications.Get("/unread-count", notificationController.GetUnreadCount)
+
+	// POST /api/notifications/read-all - отметить все уведомления прочитанными
+	notifications.Post("/read-all", notificationController.MarkAllRead)
+
+	// POST /api/notifications/:id/read - отметить уведомление прочитанным
+	notifications.Post("/:id/read", notificationController.MarkRead)
+}
@@ -29,6 +29,18 @@ func SetupParticipantRoutes(app *fiber.App, participantController *controllers.P
 	// POST /events/:id/applications/:application_id/reject - отклонить заявку (только для создателя, требует авторизации)
 	participants.Post("/:id/applications/:application_id/reject", participantController.RejectApplication)
 
+	// POST /events/:id/participants/:participant_id/check-in - отметить присутствие участника (только для создателя, требует авторизации)
+	participants.Post("/:id/participants/:participant_id/check-in", participantController.CheckInParticipant)
+
+	// POST /events/:id/participants/:participant_id/check-out - отметить уход участника (только для создателя, требует авторизации)
+	participants.Post("/:id/participants/:participant_id/check-out", participantController.CheckOutParticipant)
+
+	// PUT /events/:id/participants/:participant_id/hours - исправить волонтерские часы участника (только для создателя, требует авторизации)
+	participants.Put("/:id/participants/:participant_id/hours", participantController.UpdateParticipantHours)
+
+	// POST /events/:id/participants/:participant_id/hours/confirm - подтвердить часы участника (только для создателя, требует авторизации)
+	participants.Post("/:id/participants/:participant_id/hours/confirm", participantController.ConfirmParticipantHours)
+
 	// POST /events/:id/complete - завершить ивент (только для создателя, требует авторизации)
 	participants.Post("/:id/complete", participantController.CompleteEvent)
 
@@ -0,0 +1,44 @@
+package routes
+
+import (
+	"toloko-backend/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// SetupRateLimits настраивает ограничения частоты запросов.
+// Должна вызываться до настройки остальных маршрутов.
+func SetupRateLimits(app *fiber.App, rateLimiter *services.RateLimiter) {
+	// POST /auth/login - вход пользователя (по IP)
+	app.Use("/auth/login", rateLimiter.Middleware(services.PolicyAuthLogin, fiber.MethodPost))
+
+	// POST /auth/2fa/verify - второй шаг входа (по IP)
+	app.Use("/auth/2fa/verify", rateLimiter.Middleware(services.PolicyAuthTwoFactor, fiber.MethodPost))
+
+	// POST /auth/recover - восстановление пароля (по IP)
+	app.Use("/auth/recover", rateLimiter.Middleware(services.PolicyAuthRecover, fiber.MethodPost))
+
+	// POST /auth/register - регистрация пользователя (по IP)
+	app.Use("/auth/register", rateLimiter.Middleware(services.PolicyAuthRegister, fiber.MethodPost))
+
+	// POST /api/conversations/:conversation_id/messages - отправка сообщения (по пользователю)
+	app.Use("/api/conversations/:conversation_id/messages", rateLimiter.Middleware(services.PolicyMessagesSend, fiber.MethodPost))
+
+	// POST /events/:id/complaints - подача жалобы (по пользователю)
+	app.Use("/events/:id/complaints", rateLimiter.Middleware(services.PolicyComplaintsSubmit, fiber.MethodPost))
+
+	// POST /complaints - подача жалобы на любой объект (по пользователю)
+	app.Use("/complaints", rateLimiter.Middleware(services.PolicyComplaintsSubmit, fiber.MethodPost))
+
+	// POST /events/:id/photos - загрузка фотографий (по пользователю)
+	app.Use("/events/:id/photos", rateLimiter.Middleware(services.PolicyUploads, fiber.MethodPost))
+
+	// POST /news/:id/media - загрузка изображений новостей (по пользователю)
+	app.Use("/news/:id/media", rateLimiter.Middleware(services.PolicyUploads, fiber.MethodPost))
+
+	// POST /api/messages/:message_id/attachments - загрузка вложений (по пользователю)
+	app.Use("/api/messages/:message_id/attachments", rateLimiter.Middleware(services.PolicyUploads, fiber.MethodPost))
+
+	// GET /api/account/export - экспорт персональных данных (по пользователю)
+	app.Use("/api/account/export", rateLimiter.Middleware(services.PolicyAccountExport, fiber.MethodGet))
+}
@@ -0,0 +1,29 @@
+package routes
+
+import (
+	"toloko-backend/controllers"
+	"toloko-backend/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// SetupReviewRoutes настраивает маршруты для отзывов об ивентах и организаторах
+func SetupReviewRoutes(app *fiber.App, db *gorm.DB) {
+	reviewController := controllers.NewEventReviewController(db)
+
+	// Группа маршрутов для отзывов об ивентах
+	reviews := app.Group("/events/:id/reviews", utils.AuthMiddleware)
+
+	// GET /events/:id/reviews - получить отзывы об ивенте и их сводку
+	reviews.Get("/", reviewController.GetEventReviews)
+
+	// POST /events/:id/reviews - оставить отзыв после завершения ивента (только посетившие участники)
+	reviews.Post("/", reviewController.SubmitReview)
+
+	// PUT /events/:id/reviews/:reviewId/reply - ответить на отзыв (только организатор)
+	reviews.Put("/:reviewId/reply", reviewController.ReplyToReview)
+
+	// GET /api/users/:id/reviews - отзывы об ивентах организатора и его репутация
+	app.Get("/api/users/:id/reviews", utils.AuthMiddleware, reviewController.GetOrganizerReviews)
+}
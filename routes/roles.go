@@ -0,0 +1,36 @@
+package routes
+
+import (
+	"toloko-backend/controllers"
+	"toloko-backend/models"
+	"toloko-backend/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// SetupRoleRoutes настраивает маршруты для управления ролями платформы
+func SetupRoleRoutes(app *fiber.App, db *gorm.DB) {
+	roleController := controllers.NewRoleController(db)
+
+	// GET /api/roles/me - получить свои роли и разрешения
+	app.Get("/api/roles/me", utils.AuthMiddleware, roleController.GetMyRoles)
+
+	// Группа маршрутов администрирования ролей
+	admin := app.Group("/api/admin/roles", utils.AuthMiddleware, utils.RequirePermission(roleController.Permissions, models.PermissionRolesManage))
+
+	// GET /api/admin/roles - получить список ролей и их разрешений
+	admin.Get("/", roleController.GetRoles)
+
+	// GET /api/admin/roles/holders - получить список выданных ролей (?role=moderator)
+	admin.Get("/holders", roleController.GetRoleHolders)
+
+	// GET /api/admin/roles/users/:user_id - получить роли пользователя
+	admin.Get("/users/:user_id", roleController.GetUserRoles)
+
+	// POST /api/admin/roles/users/:user_id - выдать роль пользователю
+	admin.Post("/users/:user_id", roleController.GrantRole)
+
+	// DELETE /api/admin/roles/users/:user_id/:role - отозвать роль у пользователя
+	admin.Delete("/users/:user_id/:role", roleController.RevokeRole)
+}
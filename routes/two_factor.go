@@ -0,0 +1,32 @@
+package routes
+
+import (
+	"toloko-backend/controllers"
+	"toloko-backend/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// SetupTwoFactorRoutes настраивает маршруты для настройки двухфакторной аутентификации
+func SetupTwoFactorRoutes(app *fiber.App, db *gorm.DB) {
+	twoFactorController := controllers.NewTwoFactorController(db)
+
+	// Группа маршрутов для 2FA
+	twoFactor := app.Group("/api/2fa", utils.AuthMiddleware)
+
+	// GET /api/2fa - получить состояние 2FA
+	twoFactor.Get("/", twoFactorController.GetStatus)
+
+	// POST /api/2fa/enroll - начать настройку 2FA (секрет и otpauth URI)
+	twoFactor.Post("/enroll", twoFactorController.Enroll)
+
+	// POST /api/2fa/confirm - подтвердить настройку кодом и получить коды восстановления
+	twoFactor.Post("/confirm", twoFactorController.Confirm)
+
+	// POST /api/2fa/recovery-codes - перевыпустить коды восстановления
+	twoFactor.Post("/recovery-codes", twoFactorController.RegenerateRecoveryCodes)
+
+	// POST /api/2fa/disable - отключить 2FA
+	twoFactor.Post("/disable", twoFactorController.Disable)
+}
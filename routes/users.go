@@ -2,6 +2,7 @@ package routes
 
 import (
 	"toloko-backend/controllers"
+	"toloko-backend/utils"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -12,8 +13,9 @@ func SetupUserRoutes(app *fiber.App, userController *controllers.UserController)
 
 	// Маршруты для профилей пользователей
 	users := api.Group("/users")
-	users.Get("/:id", userController.GetProfile)                     // GET /api/users/:id - получить профиль пользователя
-	users.Put("/:id", userController.UpdateProfile)                  // PUT /api/users/:id - обновить профиль пользователя
-	users.Get("/:id/events", userController.GetUserEvents)           // GET /api/users/:id/events - получить события пользователя
-	users.Get("/:id/communities", userController.GetUserCommunities) // GET /api/users/:id/communities - получить сообщества пользователя
+	users.Get("/autocomplete", utils.AuthMiddleware, userController.AutocompleteUsers) // GET /api/users/autocomplete?q= - подсказки для упоминаний
+	users.Get("/:id", userController.GetProfile)                                       // GET /api/users/:id - получить профиль пользователя
+	users.Put("/:id", userController.UpdateProfile)                                    // PUT /api/users/:id - обновить профиль пользователя
+	users.Get("/:id/events", userController.GetUserEvents)                             // GET /api/users/:id/events - получить события пользователя
+	users.Get("/:id/communities", userController.GetUserCommunities)                   // GET /api/users/:id/communities - получить сообщества пользователя
 }
@@ -0,0 +1,422 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"toloko-backend/models"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// defaultAccountDeletionGracePeriod срок, в течение которого удаление можно отменить
+	defaultAccountDeletionGracePeriod = 14 * 24 * time.Hour
+
+	// Заглушки для обезличенного контента
+	deletedUserName       = "Удаленный пользователь"
+	deletedMessageText    = "Сообщение удалено"
+	deletedCommentContent = "Комментарий удален"
+	deletedNewsContent    = "Публикация удалена"
+)
+
+var (
+	// ErrAccountDeletionPending удаление аккаунта уже запрошено
+	ErrAccountDeletionPending = errors.New("account deletion already requested")
+	// ErrAccountDeletionNotFound нет активного запроса на удаление
+	ErrAccountDeletionNotFound = errors.New("account deletion request not found")
+	// ErrAccountOwnsCommunities владелец сообщества должен передать владение перед удалением аккаунта
+	ErrAccountOwnsCommunities = errors.New("account owns communities")
+)
+
+// AccountDeletionGracePeriod возвращает льготный период перед удалением аккаунта.
+// Настраивается переменной ACCOUNT_DELETION_GRACE_DAYS (в днях).
+func AccountDeletionGracePeriod() time.Duration {
+	days, err := strconv.Atoi(os.Getenv("ACCOUNT_DELETION_GRACE_DAYS"))
+	if err != nil || days < 0 {
+		return defaultAccountDeletionGracePeriod
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// DeletedUserEmail возвращает обезличенный email удаленного пользователя
+func DeletedUserEmail(userID uint) string {
+	return fmt.Sprintf("deleted-%d@deleted.toloka.local", userID)
+}
+
+// AccountService предоставляет методы для экспорта данных и удаления аккаунта
+type AccountService struct {
+	db *gorm.DB
+}
+
+// NewAccountService создает новый сервис аккаунта
+func NewAccountService(db *gorm.DB) *AccountService {
+	return &AccountService{db: db}
+}
+
+// exportFile описывает один JSON файл архива с данными пользователя
+type exportFile struct {
+	name  string
+	query func() *gorm.DB
+}
+
+// BuildExport собирает ZIP архив с персональными данными пользователя
+func (s *AccountService) BuildExport(userID uint) ([]byte, error) {
+	var user models.User
+	if err := s.db.First(&user, userID).Error; err != nil {
+		return nil, err
+	}
+
+	participations := s.db.Model(&models.EventParticipant{}).Select("id").Where("user_id = ?", userID)
+
+	files := []exportFile{
+		{"events_created.json", func() *gorm.DB {
+			return s.db.Model(&models.Event{}).Where("creator_id = ?", userID).Order("id")
+		}},
+		{"events_joined.json", func() *gorm.DB {
+			return s.db.Model(&models.EventParticipant{}).
+				Select("event_participants.id, event_participants.event_id, events.title AS event_title, events.start_time AS event_start_time, event_participants.status, event_participants.joined_at, event_participants.left_at, event_participants.created_at").
+				Joins("LEFT JOIN events ON events.id = event_participants.event_id").
+				Where("event_participants.user_id = ?", userID).
+				Order("event_participants.id")
+		}},
+		{"participant_inventory.json", func() *gorm.DB {
+			return s.db.Model(&models.ParticipantInventory{}).Where("participant_id IN (?)", participations).Order("id")
+		}},
+		{"ratings_given.json", func() *gorm.DB {
+			return s.db.Model(&models.Rating{}).Where("from_user_id = ?", userID).Order("id")
+		}},
+		{"ratings_received.json", func() *gorm.DB {
+			// Автор оценки не раскрывается
+			return s.db.Model(&models.Rating{}).Select("id, event_id, score, comment, created_at").Where("to_user_id = ?", userID).Order("id")
+		}},
+		{"complaints_filed.json", func() *gorm.DB {
+			return s.db.Model(&models.Complaint{}).Where("from_user_id = ?", userID).Order("id")
+		}},
+		{"messages.json", func() *gorm.DB {
+			return s.db.Model(&models.Message{}).
+				Select("id, conversation_id, from_user_id, to_user_id, text, status, created_at").
+				Where("from_user_id = ? OR to_user_id = ?", userID, userID).
+				Order("id")
+		}},
+		{"attachments.json", func() *gorm.DB {
+			return s.db.Model(&models.Attachment{}).Where("uploaded_by = ?", userID).Order("id")
+		}},
+		{"news.json", func() *gorm.DB {
+			return s.db.Model(&models.News{}).Where("author_id = ?", userID).Order("id")
+		}},
+		{"comments.json", func() *gorm.DB {
+			return s.db.Model(&models.Comment{}).Where("author_id = ?", userID).Order("id")
+		}},
+	}
+
+	buf := new(bytes.Buffer)
+	archive := zip.NewWriter(buf)
+
+	if err := writeExportJSON(archive, "profile.json", map[string]interface{}{
+		"exported_at": time.Now(),
+		"user":        user,
+	}); err != nil {
+		return nil, err
+	}
+
+	for _, file := range files {
+		rows := []map[string]interface{}{}
+		if err := file.query().Find(&rows).Error; err != nil {
+			return nil, err
+		}
+		if err := writeExportJSON(archive, file.name, rows); err != nil {
+			return nil, err
+		}
+	}
+
+	// Сами файлы вложений, если они доступны на диске
+	var attachments []models.Attachment
+	if err := s.db.Where("uploaded_by = ?", userID).Find(&attachments).Error; err != nil {
+		return nil, err
+	}
+	for _, attachment := range attachments {
+		data, err := os.ReadFile(attachment.FilePath)
+		if err != nil {
+			continue
+		}
+		w, err := archive.Create(fmt.Sprintf("attachments/%d_%s", attachment.ID, filepath.Base(attachment.FilePath)))
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write(data); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := archive.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writeExportJSON записывает значение в архив в виде JSON файла
+func writeExportJSON(archive *zip.Writer, name string, value interface{}) error {
+	w, err := archive.Create(name)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
+
+// GetDeletionRequest возвращает активный запрос на удаление (nil, если его нет)
+func (s *AccountService) GetDeletionRequest(userID uint) (*models.AccountDeletionRequest, error) {
+	var request models.AccountDeletionRequest
+	err := s.db.Where("user_id = ? AND status = ?", userID, models.AccountDeletionStatusPending).First(&request).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &request, nil
+}
+
+// RequestDeletion планирует удаление аккаунта по окончании льготного периода
+func (s *AccountService) RequestDeletion(userID uint) (*models.AccountDeletionRequest, error) {
+	existing, err := s.GetDeletionRequest(userID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, ErrAccountDeletionPending
+	}
+
+	if err := ensureAccountReplaceable(s.db, userID); err != nil {
+		return nil, err
+	}
+
+	request := models.AccountDeletionRequest{
+		UserID:      userID,
+		Status:      models.AccountDeletionStatusPending,
+		ScheduledAt: time.Now().Add(AccountDeletionGracePeriod()),
+	}
+	if err := s.db.Create(&request).Error; err != nil {
+		return nil, err
+	}
+	return &request, nil
+}
+
+// CancelDeletion отменяет запрос на удаление аккаунта
+func (s *AccountService) CancelDeletion(userID uint) error {
+	request, err := s.GetDeletionRequest(userID)
+	if err != nil {
+		return err
+	}
+	if request == nil {
+		return ErrAccountDeletionNotFound
+	}
+
+	now := time.Now()
+	return s.db.Model(request).Updates(map[string]interface{}{
+		"status":       models.AccountDeletionStatusCancelled,
+		"cancelled_at": &now,
+	}).Error
+}
+
+// ProcessDueDeletions обезличивает аккаунты, у которых истек льготный период.
+// Возвращает количество удаленных аккаунтов.
+func (s *AccountService) ProcessDueDeletions() (int, error) {
+	var requests []models.AccountDeletionRequest
+	if err := s.db.Where("status = ? AND scheduled_at <= ?", models.AccountDeletionStatusPending, time.Now()).
+		Find(&requests).Error; err != nil {
+		return 0, err
+	}
+
+	processed := 0
+	for _, request := range requests {
+		if err := s.AnonymizeUser(request.UserID); err != nil {
+			log.Printf("Ошибка удаления аккаунта %d: %v", request.UserID, err)
+			continue
+		}
+
+		now := time.Now()
+		if err := s.db.Model(&request).Updates(map[string]interface{}{
+			"status":       models.AccountDeletionStatusCompleted,
+			"completed_at": &now,
+		}).Error; err != nil {
+			return processed, err
+		}
+		processed++
+	}
+	return processed, nil
+}
+
+// AnonymizeUser обезличивает пользователя и созданный им контент.
+// Записи не удаляются, чтобы не нарушать внешние ключи и статистику мероприятий.
+func (s *AccountService) AnonymizeUser(userID uint) error {
+	var filePaths []string
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		// За льготный период пользователь мог снова стать владельцем или единственным администратором
+		if err := ensureAccountReplaceable(tx, userID); err != nil {
+			return err
+		}
+
+		if err := tx.Model(&models.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+			"name":            deletedUserName,
+			"email":           DeletedUserEmail(userID),
+			"username":        nil,
+			"password_hash":   "",
+			"o_auth_provider": "",
+			"o_auth_id":       "",
+			"is_active":       false,
+			"avatar":          "",
+			"bio":             "",
+			"location":        "",
+			"website":         "",
+			"is_public":       false,
+			"updated_at":      time.Now(),
+		}).Error; err != nil {
+			return err
+		}
+
+		// Авторский контент заменяется заглушками
+		if err := tx.Model(&models.Message{}).Where("from_user_id = ?", userID).Updates(map[string]interface{}{
+			"text":             deletedMessageText,
+			"attachments_json": "",
+		}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Comment{}).Where("author_id = ?", userID).
+			Update("content", deletedCommentContent).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.News{}).Where("author_id = ?", userID).Updates(map[string]interface{}{
+			"content":    deletedNewsContent,
+			"photo_path": "",
+		}).Error; err != nil {
+			return err
+		}
+		newsPaths, err := DeleteNewsMedia(tx, tx.Model(&models.News{}).Select("id").Where("author_id = ?", userID))
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Rating{}).Where("from_user_id = ?", userID).Update("comment", "").Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Complaint{}).Where("from_user_id = ?", userID).Update("reason_text", "").Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Complaint{}).Where("about_user_id = ?", userID).Update("snapshot", "").Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Event{}).Where("creator_id = ?", userID).Update("contact_info", "").Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.CommunityModerationLog{}).Where("target_user_id = ?", userID).Update("excerpt", "").Error; err != nil {
+			return err
+		}
+		// Сертификаты остаются проверяемыми по коду, но без имени владельца
+		if err := tx.Model(&models.Certificate{}).Where("user_id = ?", userID).Update("user_name", deletedUserName).Error; err != nil {
+			return err
+		}
+
+		// Вложения удаляются вместе с файлами
+		if err := tx.Model(&models.Attachment{}).Where("uploaded_by = ?", userID).Pluck("file_path", &filePaths).Error; err != nil {
+			return err
+		}
+		filePaths = append(filePaths, newsPaths...)
+		if err := tx.Where("uploaded_by = ?", userID).Delete(&models.Attachment{}).Error; err != nil {
+			return err
+		}
+
+		// Личные связи и настройки больше не нужны
+		if err := tx.Where("subscriber_id = ? OR subscribed_to_id = ?", userID, userID).Delete(&models.Subscription{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("blocker_id = ? OR blocked_id = ?", userID, userID).Delete(&models.Block{}).Error; err != nil {
+			return err
+		}
+		for _, model := range []interface{}{
+			&models.UserPresence{},
+			&models.PinnedPost{},
+			&models.UserRole{},
+			&models.CommunityRole{},
+			&models.Mention{},
+			&models.TwoFactorAuth{},
+			&models.TwoFactorRecoveryCode{},
+			&models.TwoFactorChallenge{},
+		} {
+			if err := tx.Where("user_id = ?", userID).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	RemoveFiles(filePaths)
+	return nil
+}
+
+// ensureAccountReplaceable проверяет, что после удаления аккаунта сообщества и платформа
+// не останутся без владельца и администраторов
+func ensureAccountReplaceable(tx *gorm.DB, userID uint) error {
+	var owned int64
+	if err := tx.Model(&models.Community{}).Where("creator_id = ?", userID).Count(&owned).Error; err != nil {
+		return err
+	}
+	if owned > 0 {
+		return ErrAccountOwnsCommunities
+	}
+
+	var memberships []models.CommunityRole
+	if err := tx.Where("user_id = ? AND role = ?", userID, models.CommunityRoleAdmin).Find(&memberships).Error; err != nil {
+		return err
+	}
+	for i := range memberships {
+		if err := ensureAdminRemains(tx, &memberships[i]); err != nil {
+			return err
+		}
+	}
+
+	var isAdmin int64
+	if err := tx.Model(&models.UserRole{}).Where("user_id = ? AND role = ?", userID, models.RoleAdmin).Count(&isAdmin).Error; err != nil {
+		return err
+	}
+	if isAdmin > 0 {
+		var admins int64
+		if err := tx.Model(&models.UserRole{}).Where("role = ?", models.RoleAdmin).Count(&admins).Error; err != nil {
+			return err
+		}
+		if admins <= 1 {
+			return ErrLastAdmin
+		}
+	}
+	return nil
+}
+
+// RunDeletionWorker периодически исполняет запросы на удаление аккаунтов
+func (s *AccountService) RunDeletionWorker(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		count, err := s.ProcessDueDeletions()
+		if err != nil {
+			log.Printf("Account deletion error: %v", err)
+		}
+		if count > 0 {
+			log.Printf("Удалено аккаунтов: %d", count)
+		}
+	}
+}
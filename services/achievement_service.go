@@ -0,0 +1,300 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"toloko-backend/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrAchievementAlreadyEarned достижение уже получено пользователем
+var ErrAchievementAlreadyEarned = errors.New("achievement already earned")
+
+// AchievementService выдает достижения по доменным событиям
+type AchievementService struct {
+	db            *gorm.DB
+	notifications *NotificationService
+}
+
+// NewAchievementService создает новый сервис достижений
+func NewAchievementService(db *gorm.DB) *AchievementService {
+	return &AchievementService{
+		db:            db,
+		notifications: NewNotificationService(db),
+	}
+}
+
+// Emit обрабатывает доменное событие пользователя: пересчитывает счетчики по истории
+// и выдает достижения, порог которых достигнут. Возвращает только что полученные достижения.
+func (s *AchievementService) Emit(userID uint, event string) ([]models.Achievement, error) {
+	var achievements []models.Achievement
+	if err := s.db.Where("is_active = ? AND criterion_event = ?", true, event).
+		Where("id NOT IN (?)", s.db.Model(&models.UserAchievement{}).Select("achievement_id").Where("user_id = ?", userID)).
+		Order("criterion_threshold").
+		Find(&achievements).Error; err != nil {
+		return nil, err
+	}
+
+	var earned []models.Achievement
+	for _, achievement := range achievements {
+		count, err := s.Count(userID, &achievement)
+		if err != nil {
+			return earned, err
+		}
+		if count < int64(achievement.CriterionThreshold) {
+			continue
+		}
+		if err := s.Award(userID, &achievement); err != nil {
+			if err == ErrAchievementAlreadyEarned {
+				continue
+			}
+			return earned, err
+		}
+		earned = append(earned, achievement)
+	}
+	return earned, nil
+}
+
+// TrackStreaks пересчитывает достижения за серии участия пользователя
+func (s *AchievementService) TrackStreaks(userID uint) {
+	s.Track(userID, models.AchievementEventWeeklyStreak)
+	s.Track(userID, models.AchievementEventMonthlyStreak)
+}
+
+// Track обрабатывает доменное событие и пишет ошибку в лог, не прерывая основной запрос
+func (s *AchievementService) Track(userID uint, event string) {
+	if _, err := s.Emit(userID, event); err != nil {
+		log.Printf("Ошибка начисления достижений пользователю %d по событию %s: %v", userID, event, err)
+	}
+}
+
+// Count считает доменные события пользователя, подходящие под критерий достижения
+func (s *AchievementService) Count(userID uint, achievement *models.Achievement) (int64, error) {
+	// Для серий порогом служит длина самой длинной серии
+	if achievement.IsStreakCriterion() {
+		period, err := streakPeriodForAchievement(achievement.CriterionEvent)
+		if err != nil {
+			return 0, err
+		}
+		longest, err := NewStreakService(s.db).Longest(userID, period)
+		return int64(longest), err
+	}
+
+	var query *gorm.DB
+	switch achievement.CriterionEvent {
+	case models.AchievementEventRegistered:
+		query = s.db.Model(&models.User{}).Where("id = ?", userID)
+	case models.AchievementEventParticipated:
+		// Засчитывается только подтвержденное организатором присутствие
+		query = s.db.Model(&models.EventParticipant{}).
+			Joins("JOIN events ON events.id = event_participants.event_id").
+			Where("event_participants.user_id = ? AND event_participants.checked_in_at IS NOT NULL", userID)
+	case models.AchievementEventEventCreated:
+		query = s.db.Model(&models.Event{}).Where("events.creator_id = ?", userID)
+	case models.AchievementEventCommunityCreated:
+		query = s.db.Model(&models.Community{}).Where("creator_id = ?", userID)
+	case models.AchievementEventNewsPublished:
+		// Автоматические анонсы ивентов не считаются
+		query = s.db.Model(&models.News{}).Where("author_id = ? AND status = ? AND event_id IS NULL", userID, models.NewsStatusPublished)
+	case models.AchievementEventCommentCreated:
+		query = s.db.Model(&models.Comment{}).Where("author_id = ? AND is_deleted = ?", userID, false)
+	case models.AchievementEventSubscribed:
+		query = s.db.Model(&models.Subscription{}).Where("subscriber_id = ?", userID)
+	case models.AchievementEventSubscriberGained:
+		query = s.db.Model(&models.Subscription{}).Where("subscribed_to_id = ?", userID)
+	default:
+		return 0, fmt.Errorf("unknown achievement event %q", achievement.CriterionEvent)
+	}
+
+	if achievement.IsEventCriterion() {
+		if achievement.CriterionEventType != "" {
+			query = query.Where("events.event_type = ?", achievement.CriterionEventType)
+		}
+		if achievement.CriterionKeyword != "" {
+			query = query.Where("LOWER(events.title) LIKE LOWER(?)", "%"+achievement.CriterionKeyword+"%")
+		}
+	}
+
+	var count int64
+	err := query.Count(&count).Error
+	return count, err
+}
+
+// Award выдает достижение один раз: повторная выдача возвращает ErrAchievementAlreadyEarned.
+// Очки за достижение записываются в журнал очков, пользователь получает уведомление.
+func (s *AchievementService) Award(userID uint, achievement *models.Achievement) error {
+	err := TransactionWithNotifications(s.db, func(tx *gorm.DB) error {
+		userAchievement := models.UserAchievement{UserID: userID, AchievementID: achievement.ID}
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&userAchievement)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrAchievementAlreadyEarned
+		}
+		_, err := GrantAchievementPoints(tx, userID, achievement)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := s.notifications.NotifyMany([]uint{userID}, models.Notification{
+		Type:       models.NotificationTypeAchievement,
+		Title:      "Новое достижение",
+		Body:       fmt.Sprintf("Вы получили достижение «%s»", achievement.Name),
+		EntityType: "achievement",
+		EntityID:   achievement.ID,
+	}); err != nil {
+		log.Printf("Ошибка уведомления о достижении %d: %v", achievement.ID, err)
+	}
+	return nil
+}
+
+// AchievementProgress прогресс пользователя по достижению
+type AchievementProgress struct {
+	Achievement models.Achievement `json:"achievement"`
+	Earned      bool               `json:"earned"`
+	EarnedAt    *time.Time         `json:"earned_at"`
+	Manual      bool               `json:"manual"`  // выдается только вручную, прогресс не считается
+	Current     int64              `json:"current"` // текущее значение счетчика, не больше цели
+	Target      int                `json:"target"`  // порог получения
+	Percent     int                `json:"percent"` // процент выполнения
+}
+
+// AchievementCategoryProgress достижения одной категории с прогрессом
+type AchievementCategoryProgress struct {
+	Category     string                `json:"category"`
+	Earned       int                   `json:"earned"`
+	Total        int                   `json:"total"`
+	Achievements []AchievementProgress `json:"achievements"`
+}
+
+// Progress считает прогресс пользователя по всем активным достижениям
+// по тем же счетчикам, по которым достижения выдаются
+func (s *AchievementService) Progress(userID uint) ([]AchievementProgress, error) {
+	var achievements []models.Achievement
+	if err := s.db.Where("is_active = ?", true).Order("category, criterion_threshold, name").Find(&achievements).Error; err != nil {
+		return nil, err
+	}
+
+	var earned []models.UserAchievement
+	if err := s.db.Where("user_id = ?", userID).Find(&earned).Error; err != nil {
+		return nil, err
+	}
+	earnedAt := make(map[uint]time.Time, len(earned))
+	for _, userAchievement := range earned {
+		earnedAt[userAchievement.AchievementID] = userAchievement.EarnedAt
+	}
+
+	progress := make([]AchievementProgress, 0, len(achievements))
+	for _, achievement := range achievements {
+		item := AchievementProgress{
+			Achievement: achievement,
+			Manual:      !achievement.HasCriterion(),
+			Target:      achievement.CriterionThreshold,
+		}
+		if item.Manual || item.Target < 1 {
+			item.Target = 1
+		}
+
+		if at, ok := earnedAt[achievement.ID]; ok {
+			item.Earned = true
+			item.EarnedAt = &at
+			item.Current = int64(item.Target)
+		} else if !item.Manual {
+			count, err := s.Count(userID, &achievement)
+			if err != nil {
+				return nil, err
+			}
+			item.Current = count
+			if item.Current > int64(item.Target) {
+				item.Current = int64(item.Target)
+			}
+		}
+		item.Percent = int(item.Current * 100 / int64(item.Target))
+		progress = append(progress, item)
+	}
+	return progress, nil
+}
+
+// GroupProgressByCategory группирует прогресс по категориям достижений
+func GroupProgressByCategory(progress []AchievementProgress) []AchievementCategoryProgress {
+	groups := make([]AchievementCategoryProgress, 0)
+	index := make(map[string]int)
+	for _, item := range progress {
+		i, ok := index[item.Achievement.Category]
+		if !ok {
+			i = len(groups)
+			index[item.Achievement.Category] = i
+			groups = append(groups, AchievementCategoryProgress{Category: item.Achievement.Category})
+		}
+		groups[i].Total++
+		if item.Earned {
+			groups[i].Earned++
+		}
+		groups[i].Achievements = append(groups[i].Achievements, item)
+	}
+	return groups
+}
+
+// Closest возвращает еще не полученные достижения, до которых пользователю осталось меньше всего:
+// по убыванию процента выполнения, затем по числу оставшихся шагов
+func (s *AchievementService) Closest(userID uint, limit int) ([]AchievementProgress, error) {
+	progress, err := s.Progress(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := make([]AchievementProgress, 0, len(progress))
+	for _, item := range progress {
+		if !item.Earned && !item.Manual {
+			candidates = append(candidates, item)
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].Percent != candidates[j].Percent {
+			return candidates[i].Percent > candidates[j].Percent
+		}
+		return int64(candidates[i].Target)-candidates[i].Current < int64(candidates[j].Target)-candidates[j].Current
+	})
+
+	if len(candidates) > limit {
+		candidates = candidates[:limit]
+	}
+	return candidates, nil
+}
+
+// Backfill пересчитывает достижения всех активных пользователей по существующей истории.
+// Возвращает число выданных достижений.
+func (s *AchievementService) Backfill() (int, error) {
+	var events []string
+	if err := s.db.Model(&models.Achievement{}).
+		Where("is_active = ? AND criterion_event <> ''", true).
+		Distinct().Pluck("criterion_event", &events).Error; err != nil {
+		return 0, err
+	}
+
+	var userIDs []uint
+	if err := s.db.Model(&models.User{}).Where("is_active = ?", true).Order("id").Pluck("id", &userIDs).Error; err != nil {
+		return 0, err
+	}
+
+	awarded := 0
+	for _, userID := range userIDs {
+		for _, event := range events {
+			earned, err := s.Emit(userID, event)
+			awarded += len(earned)
+			if err != nil {
+				return awarded, err
+			}
+		}
+	}
+	return awarded, nil
+}
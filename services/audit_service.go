@@ -0,0 +1,185 @@
+package services
+
+import (
+	"encoding/json"
+	"reflect"
+	"time"
+
+	"toloko-backend/models"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// auditIgnoredFields поля, изменения которых не записываются в журнал
+var auditIgnoredFields = map[string]bool{
+	"created_at": true,
+	"updated_at": true,
+}
+
+// AuditMetadata содержит сведения о запросе, вызвавшем изменение
+type AuditMetadata struct {
+	IPAddress string
+	UserAgent string
+	Method    string
+	Path      string
+}
+
+// AuditMetadataFromRequest извлекает метаданные аудита из HTTP запроса
+func AuditMetadataFromRequest(c *fiber.Ctx) AuditMetadata {
+	userAgent := c.Get(fiber.HeaderUserAgent)
+	if len(userAgent) > 255 {
+		userAgent = userAgent[:255]
+	}
+
+	return AuditMetadata{
+		IPAddress: c.IP(),
+		UserAgent: userAgent,
+		Method:    c.Method(),
+		Path:      c.Path(),
+	}
+}
+
+// RecordAudit записывает действие в журнал аудита.
+// Должна вызываться с транзакцией, в которой выполняется само изменение,
+// чтобы изменение и запись журнала сохранялись или откатывались вместе.
+// before и after - состояние объекта до и после изменения (nil для создания или удаления).
+func RecordAudit(tx *gorm.DB, actorID uint, action, targetType string, targetID uint, before, after interface{}, meta AuditMetadata) error {
+	changes, err := AuditDiff(before, after)
+	if err != nil {
+		return err
+	}
+
+	entry := models.AuditLog{
+		ActorID:    actorID,
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		Changes:    changes,
+		IPAddress:  meta.IPAddress,
+		UserAgent:  meta.UserAgent,
+		Method:     meta.Method,
+		Path:       meta.Path,
+	}
+	return tx.Create(&entry).Error
+}
+
+// AuditDiff сравнивает два состояния объекта и возвращает измененные поля.
+// Вложенные объекты и списки (связи) не сравниваются.
+func AuditDiff(before, after interface{}) (map[string]models.AuditChange, error) {
+	beforeFields, err := auditFields(before)
+	if err != nil {
+		return nil, err
+	}
+	afterFields, err := auditFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make(map[string]models.AuditChange)
+	for key, value := range beforeFields {
+		if !reflect.DeepEqual(value, afterFields[key]) {
+			changes[key] = models.AuditChange{From: value, To: afterFields[key]}
+		}
+	}
+	for key, value := range afterFields {
+		if _, ok := beforeFields[key]; !ok && value != nil {
+			changes[key] = models.AuditChange{From: nil, To: value}
+		}
+	}
+	return changes, nil
+}
+
+// auditFields преобразует объект в плоский набор полей через его JSON представление
+func auditFields(value interface{}) (map[string]interface{}, error) {
+	fields := make(map[string]interface{})
+	if value == nil || (reflect.ValueOf(value).Kind() == reflect.Ptr && reflect.ValueOf(value).IsNil()) {
+		return fields, nil
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+
+	for key, field := range raw {
+		if auditIgnoredFields[key] {
+			continue
+		}
+		switch field.(type) {
+		case map[string]interface{}, []interface{}:
+			continue
+		}
+		fields[key] = field
+	}
+	return fields, nil
+}
+
+// AuditFilter параметры выборки журнала аудита
+type AuditFilter struct {
+	ActorID    uint
+	Action     string
+	TargetType string
+	TargetID   uint
+	From       *time.Time
+	To         *time.Time
+}
+
+// AuditService предоставляет методы для чтения журнала аудита
+type AuditService struct {
+	db *gorm.DB
+}
+
+// NewAuditService создает новый сервис журнала аудита
+func NewAuditService(db *gorm.DB) *AuditService {
+	return &AuditService{db: db}
+}
+
+// List возвращает записи журнала по фильтру (новые первыми) и их общее количество
+func (s *AuditService) List(filter AuditFilter, page, limit int) ([]models.AuditLog, int64, error) {
+	query := s.db.Model(&models.AuditLog{})
+	if filter.ActorID != 0 {
+		query = query.Where("actor_id = ?", filter.ActorID)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.TargetType != "" {
+		query = query.Where("target_type = ?", filter.TargetType)
+	}
+	if filter.TargetID != 0 {
+		query = query.Where("target_id = ?", filter.TargetID)
+	}
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("created_at <= ?", *filter.To)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var logs []models.AuditLog
+	if err := query.Preload("Actor").Order("created_at DESC, id DESC").
+		Limit(limit).Offset((page - 1) * limit).Find(&logs).Error; err != nil {
+		return nil, 0, err
+	}
+	return logs, total, nil
+}
+
+// Get возвращает запись журнала по ID
+func (s *AuditService) Get(id uint) (*models.AuditLog, error) {
+	var entry models.AuditLog
+	if err := s.db.Preload("Actor").First(&entry, id).Error; err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
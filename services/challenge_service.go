@@ -0,0 +1,532 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"time"
+
+	"toloko-backend/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Ошибки челленджей
+var (
+	ErrChallengeFinished       = errors.New("challenge is finished")
+	ErrChallengeNotStarted     = errors.New("challenge has not started")
+	ErrAlreadyInChallenge      = errors.New("already participating in challenge")
+	ErrNotChallengeParticipant = errors.New("not a challenge participant")
+	ErrChallengeTeamNotFound   = errors.New("challenge team not found")
+	ErrChallengeTeamExists     = errors.New("challenge team name is taken")
+	ErrContributionNotAllowed  = errors.New("contribution is not allowed")
+	ErrContributionExists      = errors.New("contribution for this event already exists")
+	ErrContributionNotFound    = errors.New("contribution not found")
+	ErrContributionConfirmed   = errors.New("contribution is already confirmed")
+	ErrNotContributionReviewer = errors.New("only the event organizer can review the contribution")
+)
+
+// ChallengeStanding место участника или команды в таблице челленджа
+type ChallengeStanding struct {
+	Rank      int     `json:"rank"`
+	UserID    uint    `json:"user_id,omitempty"`
+	TeamID    uint    `json:"team_id,omitempty"`
+	Name      string  `json:"name"`
+	Members   int     `json:"members,omitempty"`
+	Progress  float64 `json:"progress"`
+	Percent   int     `json:"percent"`
+	Completed bool    `json:"completed"`
+}
+
+// ChallengeProgress текущий прогресс челленджа
+type ChallengeProgress struct {
+	ChallengeID   uint                `json:"challenge_id"`
+	Status        string              `json:"status"`
+	Metric        string              `json:"metric"`
+	Goal          float64             `json:"goal"`
+	GoalScope     string              `json:"goal_scope"`
+	TotalProgress float64             `json:"total_progress"` // сумма прогресса всех участников
+	GoalReached   bool                `json:"goal_reached"`   // общая цель достигнута
+	Participants  int                 `json:"participants"`
+	Completed     int                 `json:"completed"` // участников, достигших цели
+	Individuals   []ChallengeStanding `json:"individuals"`
+	Teams         []ChallengeStanding `json:"teams"`
+}
+
+// ChallengeService предоставляет методы для работы с челленджами
+type ChallengeService struct {
+	db *gorm.DB
+}
+
+// NewChallengeService создает новый сервис челленджей
+func NewChallengeService(db *gorm.DB) *ChallengeService {
+	return &ChallengeService{db: db}
+}
+
+// Join добавляет пользователя в челлендж лично или в состав команды
+func (s *ChallengeService) Join(challenge *models.Challenge, userID uint, teamID *uint) (*models.ChallengeParticipant, error) {
+	if challenge.Status(time.Now()) == models.ChallengeStatusFinished {
+		return nil, ErrChallengeFinished
+	}
+
+	if teamID != nil {
+		var team models.ChallengeTeam
+		if err := s.db.Where("id = ? AND challenge_id = ?", *teamID, challenge.ID).First(&team).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return nil, ErrChallengeTeamNotFound
+			}
+			return nil, err
+		}
+	}
+
+	participant := models.ChallengeParticipant{ChallengeID: challenge.ID, UserID: userID, TeamID: teamID}
+	result := s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&participant)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrAlreadyInChallenge
+	}
+	return &participant, nil
+}
+
+// CreateTeam создает команду челленджа; создатель становится ее капитаном и участником
+func (s *ChallengeService) CreateTeam(challenge *models.Challenge, userID uint, name string) (*models.ChallengeTeam, error) {
+	if challenge.Status(time.Now()) == models.ChallengeStatusFinished {
+		return nil, ErrChallengeFinished
+	}
+
+	team := models.ChallengeTeam{ChallengeID: challenge.ID, Name: name, CaptainID: userID}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var existing int64
+		if err := tx.Model(&models.ChallengeParticipant{}).
+			Where("challenge_id = ? AND user_id = ?", challenge.ID, userID).Count(&existing).Error; err != nil {
+			return err
+		}
+		if existing > 0 {
+			return ErrAlreadyInChallenge
+		}
+
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&team)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrChallengeTeamExists
+		}
+		return tx.Create(&models.ChallengeParticipant{ChallengeID: challenge.ID, UserID: userID, TeamID: &team.ID}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &team, nil
+}
+
+// Contribute записывает результат участника на ивенте для челленджа с метрикой impact.
+// Засчитываются только ивенты в период челленджа, на которых участник отмечен организатором;
+// в прогресс результат попадает после подтверждения организатором ивента.
+func (s *ChallengeService) Contribute(challenge *models.Challenge, userID, eventID uint, quantity float64) (*models.ChallengeContribution, error) {
+	switch challenge.Status(time.Now()) {
+	case models.ChallengeStatusUpcoming:
+		return nil, ErrChallengeNotStarted
+	case models.ChallengeStatusFinished:
+		return nil, ErrChallengeFinished
+	}
+	if challenge.Metric != models.ChallengeMetricImpact {
+		return nil, ErrContributionNotAllowed
+	}
+
+	var participants int64
+	if err := s.db.Model(&models.ChallengeParticipant{}).
+		Where("challenge_id = ? AND user_id = ?", challenge.ID, userID).Count(&participants).Error; err != nil {
+		return nil, err
+	}
+	if participants == 0 {
+		return nil, ErrNotChallengeParticipant
+	}
+
+	var attended int64
+	if err := s.attendedEvents(challenge).
+		Where("event_participants.user_id = ? AND event_participants.event_id = ?", userID, eventID).
+		Count(&attended).Error; err != nil {
+		return nil, err
+	}
+	if attended == 0 {
+		return nil, ErrContributionNotAllowed
+	}
+
+	contribution := models.ChallengeContribution{ChallengeID: challenge.ID, UserID: userID, EventID: eventID, Quantity: quantity}
+	result := s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&contribution)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrContributionExists
+	}
+	return &contribution, nil
+}
+
+// PendingContributions возвращает неподтвержденные результаты челленджа на ивентах организатора
+func (s *ChallengeService) PendingContributions(challenge *models.Challenge, organizerID uint) ([]models.ChallengeContribution, error) {
+	var contributions []models.ChallengeContribution
+	err := s.db.Preload("User").Preload("Event").
+		Where("challenge_id = ? AND confirmed_at IS NULL", challenge.ID).
+		Where("event_id IN (?)", s.db.Model(&models.Event{}).Select("id").Where("creator_id = ?", organizerID)).
+		Order("id ASC").Find(&contributions).Error
+	return contributions, err
+}
+
+// ConfirmContribution подтверждает результат участника; организатор может исправить количество
+func (s *ChallengeService) ConfirmContribution(challenge *models.Challenge, contributionID, organizerID uint, quantity *float64) (*models.ChallengeContribution, error) {
+	contribution, err := s.reviewableContribution(challenge, contributionID, organizerID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	updates := map[string]interface{}{
+		"confirmed_at": &now,
+		"confirmed_by": organizerID,
+	}
+	if quantity != nil {
+		updates["quantity"] = *quantity
+	}
+	result := s.db.Model(contribution).Where("confirmed_at IS NULL").Updates(updates)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrContributionConfirmed
+	}
+
+	if err := s.db.First(contribution, contribution.ID).Error; err != nil {
+		return nil, err
+	}
+	return contribution, nil
+}
+
+// RejectContribution отклоняет неподтвержденный результат; участник может отправить его заново
+func (s *ChallengeService) RejectContribution(challenge *models.Challenge, contributionID, organizerID uint) error {
+	contribution, err := s.reviewableContribution(challenge, contributionID, organizerID)
+	if err != nil {
+		return err
+	}
+	result := s.db.Where("confirmed_at IS NULL").Delete(contribution)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrContributionConfirmed
+	}
+	return nil
+}
+
+// reviewableContribution загружает неподтвержденный результат, проверяя, что его рассматривает организатор ивента
+func (s *ChallengeService) reviewableContribution(challenge *models.Challenge, contributionID, organizerID uint) (*models.ChallengeContribution, error) {
+	var contribution models.ChallengeContribution
+	if err := s.db.Preload("Event").Where("id = ? AND challenge_id = ?", contributionID, challenge.ID).
+		First(&contribution).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, ErrContributionNotFound
+		}
+		return nil, err
+	}
+	if contribution.Event.CreatorID != organizerID {
+		return nil, ErrNotContributionReviewer
+	}
+	if contribution.ConfirmedAt != nil {
+		return nil, ErrContributionConfirmed
+	}
+	return &contribution, nil
+}
+
+// Progress считает текущий прогресс участников и команд челленджа
+func (s *ChallengeService) Progress(challenge *models.Challenge) (*ChallengeProgress, error) {
+	var participants []models.ChallengeParticipant
+	if err := s.db.Preload("User").Where("challenge_id = ?", challenge.ID).Find(&participants).Error; err != nil {
+		return nil, err
+	}
+	var teams []models.ChallengeTeam
+	if err := s.db.Where("challenge_id = ?", challenge.ID).Find(&teams).Error; err != nil {
+		return nil, err
+	}
+
+	userIDs := make([]uint, 0, len(participants))
+	for _, participant := range participants {
+		userIDs = append(userIDs, participant.UserID)
+	}
+	values, err := s.userProgress(challenge, userIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	progress := &ChallengeProgress{
+		ChallengeID:  challenge.ID,
+		Status:       challenge.Status(time.Now()),
+		Metric:       challenge.Metric,
+		Goal:         challenge.Goal,
+		GoalScope:    challenge.GoalScope,
+		Participants: len(participants),
+		Individuals:  make([]ChallengeStanding, 0, len(participants)),
+		Teams:        make([]ChallengeStanding, 0, len(teams)),
+	}
+
+	teamStandings := make(map[uint]*ChallengeStanding, len(teams))
+	for _, team := range teams {
+		teamStandings[team.ID] = &ChallengeStanding{TeamID: team.ID, Name: team.Name, Completed: team.CompletedAt != nil}
+	}
+	for _, participant := range participants {
+		value := values[participant.UserID]
+		progress.TotalProgress += value
+		if participant.CompletedAt != nil {
+			progress.Completed++
+		}
+		progress.Individuals = append(progress.Individuals, ChallengeStanding{
+			UserID:    participant.UserID,
+			Name:      participant.User.Name,
+			Progress:  value,
+			Completed: participant.CompletedAt != nil,
+		})
+		if participant.TeamID != nil && teamStandings[*participant.TeamID] != nil {
+			teamStandings[*participant.TeamID].Progress += value
+			teamStandings[*participant.TeamID].Members++
+		}
+	}
+	for _, standing := range teamStandings {
+		progress.Teams = append(progress.Teams, *standing)
+	}
+
+	progress.TotalProgress = roundHours(progress.TotalProgress)
+	progress.GoalReached = challenge.IsCollective() && progress.TotalProgress >= challenge.Goal
+	rankChallengeStandings(progress.Individuals, challenge.Goal)
+	rankChallengeStandings(progress.Teams, challenge.Goal)
+	return progress, nil
+}
+
+// Evaluate пересчитывает прогресс челленджа, выдает награды достигшим цели
+// и рассылает обновление подписчикам темы сообщества или платформы.
+// При общей цели награду получают все участники, внесшие вклад в ее достижение.
+func (s *ChallengeService) Evaluate(challenge *models.Challenge) (*ChallengeProgress, error) {
+	if challenge.Status(time.Now()) == models.ChallengeStatusUpcoming {
+		return s.Progress(challenge)
+	}
+
+	progress, err := s.Progress(challenge)
+	if err != nil {
+		return nil, err
+	}
+
+	collective := challenge.IsCollective()
+	completedTeams := make(map[uint]bool)
+	for _, team := range progress.Teams {
+		if !collective && team.Progress >= challenge.Goal {
+			completedTeams[team.TeamID] = true
+		}
+	}
+	reached := make(map[uint]bool)
+	for _, individual := range progress.Individuals {
+		if collective {
+			reached[individual.UserID] = progress.GoalReached && individual.Progress > 0
+		} else {
+			reached[individual.UserID] = individual.Progress >= challenge.Goal
+		}
+	}
+
+	if progress.GoalReached && challenge.GoalReachedAt == nil {
+		now := time.Now()
+		if err := s.db.Model(challenge).Where("goal_reached_at IS NULL").Update("goal_reached_at", &now).Error; err != nil {
+			return nil, err
+		}
+		challenge.GoalReachedAt = &now
+	}
+
+	var participants []models.ChallengeParticipant
+	if err := s.db.Where("challenge_id = ? AND completed_at IS NULL", challenge.ID).Find(&participants).Error; err != nil {
+		return nil, err
+	}
+
+	changed := false
+	for _, participant := range participants {
+		// Участник команды выполняет челлендж вместе с командой
+		done := reached[participant.UserID]
+		if !collective && participant.TeamID != nil {
+			done = completedTeams[*participant.TeamID]
+		}
+		if !done {
+			continue
+		}
+		if err := s.complete(challenge, &participant); err != nil {
+			return nil, err
+		}
+		changed = true
+	}
+	for teamID := range completedTeams {
+		if err := s.db.Model(&models.ChallengeTeam{}).Where("id = ? AND completed_at IS NULL", teamID).
+			Update("completed_at", time.Now()).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	if changed {
+		if progress, err = s.Progress(challenge); err != nil {
+			return nil, err
+		}
+	}
+
+	publishToTopic(ChallengeTopic(challenge), WSMessage{Type: "challenge.progress", Payload: progress})
+	return progress, nil
+}
+
+// TrackUser пересчитывает начавшиеся челленджи, в которых участвует пользователь,
+// и пишет ошибку в лог, не прерывая основной запрос
+func (s *ChallengeService) TrackUser(userID uint) {
+	var challenges []models.Challenge
+	if err := s.db.Where("starts_at <= ? AND id IN (?)", time.Now(),
+		s.db.Model(&models.ChallengeParticipant{}).Select("challenge_id").Where("user_id = ?", userID)).
+		Find(&challenges).Error; err != nil {
+		log.Printf("Ошибка получения челленджей пользователя %d: %v", userID, err)
+		return
+	}
+
+	for i := range challenges {
+		if _, err := s.Evaluate(&challenges[i]); err != nil {
+			log.Printf("Ошибка пересчета челленджа %d: %v", challenges[i].ID, err)
+		}
+	}
+}
+
+// ChallengeTopic возвращает тему WebSocket, в которую публикуется прогресс челленджа
+func ChallengeTopic(challenge *models.Challenge) string {
+	if challenge.CommunityID != nil {
+		return CommunityTopic(*challenge.CommunityID)
+	}
+	return PlatformTopic
+}
+
+// complete отмечает выполнение челленджа участником и выдает награду.
+// Создатель челленджа может его выполнить, но награду не получает.
+func (s *ChallengeService) complete(challenge *models.Challenge, participant *models.ChallengeParticipant) error {
+	rewarded := participant.UserID != challenge.CreatorID
+	err := TransactionWithNotifications(s.db, func(tx *gorm.DB) error {
+		result := tx.Model(participant).Where("completed_at IS NULL").Update("completed_at", time.Now())
+		if result.Error != nil || result.RowsAffected == 0 || !rewarded {
+			return result.Error
+		}
+		_, err := GrantPoints(tx, models.PointsEntry{
+			UserID:         participant.UserID,
+			Amount:         challenge.RewardPoints,
+			ReasonCode:     models.PointsReasonChallenge,
+			SourceType:     models.PointsSourceChallenge,
+			SourceID:       challenge.ID,
+			IdempotencyKey: fmt.Sprintf("challenge:%d:user:%d", challenge.ID, participant.UserID),
+		})
+		return err
+	})
+	if err != nil || !rewarded || challenge.RewardAchievementID == nil {
+		return err
+	}
+
+	var achievement models.Achievement
+	if err := s.db.First(&achievement, *challenge.RewardAchievementID).Error; err != nil {
+		return err
+	}
+	if err := NewAchievementService(s.db).Award(participant.UserID, &achievement); err != nil && err != ErrAchievementAlreadyEarned {
+		return err
+	}
+	return nil
+}
+
+// userProgress считает значение метрики челленджа для пользователей за период челленджа
+func (s *ChallengeService) userProgress(challenge *models.Challenge, userIDs []uint) (map[uint]float64, error) {
+	values := make(map[uint]float64, len(userIDs))
+	if len(userIDs) == 0 {
+		return values, nil
+	}
+
+	type userTotal struct {
+		UserID uint
+		Total  float64
+	}
+	var totals []userTotal
+
+	switch challenge.Metric {
+	case models.ChallengeMetricEvents:
+		if err := s.attendedEvents(challenge).
+			Where("event_participants.user_id IN ?", userIDs).
+			Select("event_participants.user_id AS user_id, COUNT(*) AS total").
+			Group("event_participants.user_id").
+			Scan(&totals).Error; err != nil {
+			return nil, err
+		}
+	case models.ChallengeMetricHours:
+		var participations []models.EventParticipant
+		if err := s.challengeEvents(challenge, s.db.Preload("Event")).
+			Where("event_participants.user_id IN ? AND event_participants.status IN ? AND event_participants.hours_confirmed_at IS NOT NULL", userIDs,
+				[]string{models.ParticipantStatusJoined, models.ParticipantStatusAccepted}).
+			Find(&participations).Error; err != nil {
+			return nil, err
+		}
+		for _, participation := range participations {
+			values[participation.UserID] += participation.VolunteerHours(&participation.Event)
+		}
+	case models.ChallengeMetricImpact:
+		if err := s.db.Model(&models.ChallengeContribution{}).
+			Where("challenge_id = ? AND user_id IN ? AND confirmed_at IS NOT NULL", challenge.ID, userIDs).
+			Select("user_id, SUM(quantity) AS total").
+			Group("user_id").
+			Scan(&totals).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	for _, total := range totals {
+		values[total.UserID] += total.Total
+	}
+	for userID, value := range values {
+		values[userID] = roundHours(value)
+	}
+	return values, nil
+}
+
+// attendedEvents возвращает запрос посещений ивентов в период челленджа с отметкой организатора
+func (s *ChallengeService) attendedEvents(challenge *models.Challenge) *gorm.DB {
+	return s.challengeEvents(challenge, s.db.Model(&models.EventParticipant{})).
+		Where("event_participants.status IN ? AND event_participants.checked_in_at IS NOT NULL",
+			[]string{models.ParticipantStatusJoined, models.ParticipantStatusAccepted})
+}
+
+// challengeEvents ограничивает запрос участий ивентами в период челленджа;
+// в челлендж сообщества засчитываются только ивенты этого сообщества
+func (s *ChallengeService) challengeEvents(challenge *models.Challenge, query *gorm.DB) *gorm.DB {
+	query = query.Joins("JOIN events ON events.id = event_participants.event_id").
+		Where("events.start_time BETWEEN ? AND ?", challenge.StartsAt, challenge.EndsAt)
+	if challenge.CommunityID != nil {
+		query = query.Where("events.community_id = ?", *challenge.CommunityID)
+	}
+	return query
+}
+
+// rankChallengeStandings сортирует таблицу по прогрессу и расставляет места;
+// одинаковый прогресс - одинаковое место
+func rankChallengeStandings(standings []ChallengeStanding, goal float64) {
+	sort.SliceStable(standings, func(i, j int) bool {
+		if standings[i].Progress != standings[j].Progress {
+			return standings[i].Progress > standings[j].Progress
+		}
+		return standings[i].Name < standings[j].Name
+	})
+	for i := range standings {
+		standings[i].Progress = roundHours(standings[i].Progress)
+		if goal > 0 {
+			standings[i].Percent = int(math.Min(100, math.Floor(standings[i].Progress*100/goal)))
+		}
+		if i > 0 && standings[i].Progress == standings[i-1].Progress {
+			standings[i].Rank = standings[i-1].Rank
+		} else {
+			standings[i].Rank = i + 1
+		}
+	}
+}
@@ -0,0 +1,308 @@
+package services
+
+import (
+	"errors"
+
+	"toloko-backend/models"
+
+	"gorm.io/gorm"
+)
+
+// MaxCommentDepth максимальная глубина ответа (0 - комментарий верхнего уровня)
+const MaxCommentDepth = 3
+
+var (
+	// ErrCommentNotFound комментарий не найден
+	ErrCommentNotFound = errors.New("comment not found")
+	// ErrCommentTooDeep превышена максимальная глубина ответов
+	ErrCommentTooDeep = errors.New("comment depth limit reached")
+	// ErrCommentDeleted нельзя ответить на удаленный комментарий или лайкнуть его
+	ErrCommentDeleted = errors.New("comment is deleted")
+)
+
+// CommentThread комментарий с первыми ответами и курсором для их дозагрузки
+type CommentThread struct {
+	models.Comment
+	Replies    []models.Comment `json:"replies"`
+	NextCursor *uint            `json:"next_cursor,omitempty"` // передается в GET /comments/:id/replies?cursor=
+}
+
+// CommentService предоставляет методы для работы с деревом комментариев
+type CommentService struct {
+	db *gorm.DB
+}
+
+// NewCommentService создает новый сервис комментариев
+func NewCommentService(db *gorm.DB) *CommentService {
+	return &CommentService{db: db}
+}
+
+// Create создает комментарий или ответ, проверяя глубину и обновляя счетчик ответов родителя
+func (s *CommentService) Create(comment *models.Comment) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if comment.ParentID != nil {
+			var parent models.Comment
+			if err := tx.Where("id = ? AND news_id = ?", *comment.ParentID, comment.NewsID).First(&parent).Error; err != nil {
+				if err == gorm.ErrRecordNotFound {
+					return ErrCommentNotFound
+				}
+				return err
+			}
+			if parent.IsDeleted {
+				return ErrCommentDeleted
+			}
+			if parent.Depth+1 > MaxCommentDepth {
+				return ErrCommentTooDeep
+			}
+			comment.Depth = parent.Depth + 1
+
+			if err := tx.Model(&parent).UpdateColumn("replies_count", gorm.Expr("replies_count + 1")).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Create(comment).Error
+	})
+}
+
+// Tree возвращает страницу комментариев верхнего уровня, у каждого - первые replies ответов
+func (s *CommentService) Tree(newsID, viewerID uint, page, limit, replies int) ([]CommentThread, int64, error) {
+	query := s.db.Model(&models.Comment{}).Where("news_id = ? AND parent_id IS NULL", newsID)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var roots []models.Comment
+	if err := query.Session(&gorm.Session{}).Preload("Author").Preload("Mentions").Order("id ASC").
+		Limit(limit).Offset((page - 1) * limit).Find(&roots).Error; err != nil {
+		return nil, 0, err
+	}
+
+	rootIDs := make([]uint, len(roots))
+	for i, root := range roots {
+		rootIDs[i] = root.ID
+	}
+
+	// Первые ответы каждого комментария выбираются одним запросом с оконной функцией
+	byParent := make(map[uint][]models.Comment)
+	if len(rootIDs) > 0 && replies > 0 {
+		ranked := s.db.Model(&models.Comment{}).
+			Select("id, ROW_NUMBER() OVER (PARTITION BY parent_id ORDER BY id) AS position").
+			Where("parent_id IN ?", rootIDs)
+		var replyIDs []uint
+		if err := s.db.Table("(?) AS ranked", ranked).Where("position <= ?", replies).Pluck("id", &replyIDs).Error; err != nil {
+			return nil, 0, err
+		}
+
+		var loaded []models.Comment
+		if len(replyIDs) > 0 {
+			if err := s.db.Preload("Author").Preload("Mentions").Where("id IN ?", replyIDs).Order("id ASC").Find(&loaded).Error; err != nil {
+				return nil, 0, err
+			}
+		}
+		for _, reply := range loaded {
+			byParent[*reply.ParentID] = append(byParent[*reply.ParentID], reply)
+		}
+	}
+
+	threads := make([]CommentThread, 0, len(roots))
+	for _, root := range roots {
+		thread := CommentThread{Comment: root, Replies: byParent[root.ID]}
+		if thread.Replies == nil {
+			thread.Replies = []models.Comment{}
+		}
+		if len(thread.Replies) > 0 && len(thread.Replies) < root.RepliesCount {
+			cursor := thread.Replies[len(thread.Replies)-1].ID
+			thread.NextCursor = &cursor
+		}
+		threads = append(threads, thread)
+	}
+
+	if err := s.decorateThreads(threads, viewerID); err != nil {
+		return nil, 0, err
+	}
+	return threads, total, nil
+}
+
+// Replies возвращает прямые ответы на комментарий после курсора (ID последнего полученного ответа)
+func (s *CommentService) Replies(parentID, viewerID, cursor uint, limit int) ([]models.Comment, *uint, error) {
+	var count int64
+	if err := s.db.Model(&models.Comment{}).Where("id = ?", parentID).Count(&count).Error; err != nil {
+		return nil, nil, err
+	}
+	if count == 0 {
+		return nil, nil, ErrCommentNotFound
+	}
+
+	var replies []models.Comment
+	if err := s.db.Preload("Author").Preload("Mentions").Where("parent_id = ? AND id > ?", parentID, cursor).
+		Order("id ASC").Limit(limit + 1).Find(&replies).Error; err != nil {
+		return nil, nil, err
+	}
+
+	var next *uint
+	if len(replies) > limit {
+		replies = replies[:limit]
+		last := replies[limit-1].ID
+		next = &last
+	}
+
+	if err := s.decorate(replies, viewerID); err != nil {
+		return nil, nil, err
+	}
+	return replies, next, nil
+}
+
+// Delete удаляет комментарий. Комментарий с ответами заменяется заглушкой «[deleted]»,
+// чтобы ответы не потерялись; заглушка без ответов удаляется вслед за последним ответом.
+func (s *CommentService) Delete(comment *models.Comment) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		// Счетчик ответов мог остаться устаревшим у старых комментариев, поэтому ответы пересчитываются
+		replies, err := countReplies(tx, comment.ID)
+		if err != nil {
+			return err
+		}
+		if replies > 0 {
+			if err := tx.Where("comment_id = ?", comment.ID).Delete(&models.CommentLike{}).Error; err != nil {
+				return err
+			}
+			if err := DeleteMentions(tx, models.MentionSourceComment, []uint{comment.ID}); err != nil {
+				return err
+			}
+			return tx.Model(comment).Updates(map[string]interface{}{
+				"content":     models.DeletedCommentContent,
+				"is_deleted":  true,
+				"likes_count": 0,
+			}).Error
+		}
+
+		current := *comment
+		for {
+			if err := tx.Where("comment_id = ?", current.ID).Delete(&models.CommentLike{}).Error; err != nil {
+				return err
+			}
+			if err := DeleteMentions(tx, models.MentionSourceComment, []uint{current.ID}); err != nil {
+				return err
+			}
+			if err := tx.Delete(&models.Comment{}, current.ID).Error; err != nil {
+				return err
+			}
+			if current.ParentID == nil {
+				return nil
+			}
+
+			var parent models.Comment
+			if err := tx.First(&parent, *current.ParentID).Error; err != nil {
+				if err == gorm.ErrRecordNotFound {
+					return nil
+				}
+				return err
+			}
+			remaining, err := countReplies(tx, parent.ID)
+			if err != nil {
+				return err
+			}
+			if parent.IsDeleted && remaining == 0 {
+				current = parent
+				continue
+			}
+			return tx.Model(&parent).UpdateColumn("replies_count", remaining).Error
+		}
+	})
+}
+
+// countReplies возвращает количество прямых ответов на комментарий
+func countReplies(tx *gorm.DB, commentID uint) (int64, error) {
+	var count int64
+	err := tx.Model(&models.Comment{}).Where("parent_id = ?", commentID).Count(&count).Error
+	return count, err
+}
+
+// ToggleLike ставит или убирает лайк комментария. Возвращает новое состояние и количество лайков.
+func (s *CommentService) ToggleLike(commentID, userID uint) (bool, int, error) {
+	liked := false
+	var comment models.Comment
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Preload("News").First(&comment, commentID).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return ErrCommentNotFound
+			}
+			return err
+		}
+		if comment.IsDeleted {
+			return ErrCommentDeleted
+		}
+		if err := checkCanParticipate(tx, comment.News.CommunityID, userID); err != nil {
+			return err
+		}
+
+		result := tx.Where("comment_id = ? AND user_id = ?", commentID, userID).Delete(&models.CommentLike{})
+		if result.Error != nil {
+			return result.Error
+		}
+		delta := -1
+		if result.RowsAffected == 0 {
+			if err := tx.Create(&models.CommentLike{CommentID: commentID, UserID: userID}).Error; err != nil {
+				return err
+			}
+			liked, delta = true, 1
+		}
+
+		if err := tx.Model(&comment).UpdateColumn("likes_count", gorm.Expr("likes_count + ?", delta)).Error; err != nil {
+			return err
+		}
+		return tx.Select("likes_count").First(&comment, commentID).Error
+	})
+	if err != nil {
+		return false, 0, err
+	}
+	return liked, comment.LikesCount, nil
+}
+
+// decorateThreads дополняет комментарии и ответы веток данными для текущего пользователя
+func (s *CommentService) decorateThreads(threads []CommentThread, viewerID uint) error {
+	comments := make([]*models.Comment, 0, len(threads))
+	for i := range threads {
+		comments = append(comments, &threads[i].Comment)
+		for j := range threads[i].Replies {
+			comments = append(comments, &threads[i].Replies[j])
+		}
+	}
+	return s.decoratePointers(comments, viewerID)
+}
+
+// decorate дополняет список комментариев данными для текущего пользователя
+func (s *CommentService) decorate(list []models.Comment, viewerID uint) error {
+	comments := make([]*models.Comment, len(list))
+	for i := range list {
+		comments[i] = &list[i]
+	}
+	return s.decoratePointers(comments, viewerID)
+}
+
+// decoratePointers скрывает автора удаленных комментариев и отмечает лайки текущего пользователя
+func (s *CommentService) decoratePointers(comments []*models.Comment, viewerID uint) error {
+	ids := make([]uint, 0, len(comments))
+	for _, comment := range comments {
+		comment.HideDeletedAuthors()
+		ids = append(ids, comment.ID)
+	}
+	if viewerID == 0 || len(ids) == 0 {
+		return nil
+	}
+
+	var liked []uint
+	if err := s.db.Model(&models.CommentLike{}).Where("user_id = ? AND comment_id IN ?", viewerID, ids).
+		Pluck("comment_id", &liked).Error; err != nil {
+		return err
+	}
+	likedSet := make(map[uint]bool, len(liked))
+	for _, id := range liked {
+		likedSet[id] = true
+	}
+	for _, comment := range comments {
+		comment.LikedByMe = likedSet[comment.ID]
+	}
+	return nil
+}
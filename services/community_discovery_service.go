@@ -0,0 +1,245 @@
+package services
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"time"
+
+	"toloko-backend/models"
+
+	"gorm.io/gorm"
+)
+
+// Сортировки при поиске сообществ
+const (
+	CommunitySortNewest   = "newest"
+	CommunitySortMembers  = "members"
+	CommunitySortActivity = "activity"
+	CommunitySortDistance = "distance"
+)
+
+const (
+	// communityActivityWindow период, за который учитывается активность сообщества
+	communityActivityWindow = 30 * 24 * time.Hour
+	// kmPerDegree длина одного градуса широты в километрах
+	kmPerDegree = 111.32
+	// DefaultDiscoveryRadiusKm радиус поиска поблизости по умолчанию
+	DefaultDiscoveryRadiusKm = 25.0
+)
+
+var (
+	// ErrInvalidCommunitySort неизвестная сортировка
+	ErrInvalidCommunitySort = errors.New("invalid community sort")
+	// ErrDistanceSortWithoutPoint сортировка по расстоянию требует координат
+	ErrDistanceSortWithoutPoint = errors.New("distance sort requires a point")
+)
+
+// CommunityDiscoveryFilter параметры поиска сообществ
+type CommunityDiscoveryFilter struct {
+	Search    string
+	City      string
+	Latitude  *float64
+	Longitude *float64
+	RadiusKm  float64
+	Sort      string
+}
+
+// CommunitySummary сообщество со счетчиками для выдачи поиска
+type CommunitySummary struct {
+	models.Community
+	MembersCount   int64    `json:"members_count"`
+	RecentActivity int64    `json:"recent_activity"` // новости и ивенты за последние 30 дней
+	DistanceKm     *float64 `json:"distance_km,omitempty"`
+}
+
+// CommunitySuggestion сообщество, в котором состоят пользователи из подписок
+type CommunitySuggestion struct {
+	models.Community
+	SubscriptionsCount int64 `json:"subscriptions_count"`
+}
+
+// communityDiscoveryRow строка выборки со счетчиками
+type communityDiscoveryRow struct {
+	ID             uint
+	MembersCount   int64
+	RecentActivity int64
+	DistanceSq     *float64
+}
+
+// CommunityDiscoveryService предоставляет поиск и рекомендации сообществ
+type CommunityDiscoveryService struct {
+	db *gorm.DB
+}
+
+// NewCommunityDiscoveryService создает новый сервис поиска сообществ
+func NewCommunityDiscoveryService(db *gorm.DB) *CommunityDiscoveryService {
+	return &CommunityDiscoveryService{db: db}
+}
+
+// Discover ищет сообщества по фильтру. Счетчики участников и активности
+// считаются агрегирующими подзапросами, без загрузки участников.
+// Сообщества по приглашению в выдачу не попадают.
+func (s *CommunityDiscoveryService) Discover(filter CommunityDiscoveryFilter, page, limit int) ([]CommunitySummary, int64, error) {
+	if filter.Sort == "" {
+		filter.Sort = CommunitySortNewest
+	}
+	switch filter.Sort {
+	case CommunitySortNewest, CommunitySortMembers, CommunitySortActivity:
+	case CommunitySortDistance:
+		if filter.Latitude == nil || filter.Longitude == nil {
+			return nil, 0, ErrDistanceSortWithoutPoint
+		}
+	default:
+		return nil, 0, ErrInvalidCommunitySort
+	}
+
+	query := s.db.Table("communities").Where("communities.visibility <> ?", models.CommunityVisibilityInvite)
+	if search := strings.TrimSpace(filter.Search); search != "" {
+		query = query.Where("LOWER(communities.name) LIKE LOWER(?) OR LOWER(communities.description) LIKE LOWER(?)", "%"+search+"%", "%"+search+"%")
+	}
+	if city := strings.TrimSpace(filter.City); city != "" {
+		query = query.Where("LOWER(communities.city) = LOWER(?)", city)
+	}
+
+	// Расстояние считается по равнопромежуточной проекции: на масштабе города
+	// погрешность пренебрежимо мала, а выражение работает в любой СУБД
+	distanceExpr, distanceArgs := "", []interface{}{}
+	if filter.Latitude != nil && filter.Longitude != nil {
+		radius := filter.RadiusKm
+		if radius <= 0 {
+			radius = DefaultDiscoveryRadiusKm
+		}
+		scale := math.Cos(*filter.Latitude * math.Pi / 180)
+		distanceExpr = "(communities.latitude - ?) * (communities.latitude - ?) + (communities.longitude - ?) * (communities.longitude - ?) * ?"
+		distanceArgs = []interface{}{*filter.Latitude, *filter.Latitude, *filter.Longitude, *filter.Longitude, scale * scale}
+
+		maxDegrees := radius / kmPerDegree
+		query = query.Where("communities.latitude IS NOT NULL AND communities.longitude IS NOT NULL").
+			Where(distanceExpr+" <= ?", append(distanceArgs, maxDegrees*maxDegrees)...)
+	}
+
+	query = query.Session(&gorm.Session{})
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	since := time.Now().Add(-communityActivityWindow)
+	members := s.db.Model(&models.CommunityRole{}).Select("community_id, COUNT(*) AS members_count").Group("community_id")
+	news := s.db.Model(&models.News{}).Select("community_id, COUNT(*) AS news_count").
+		Where("status = ? AND COALESCE(published_at, created_at) >= ?", models.NewsStatusPublished, since).Group("community_id")
+	events := s.db.Model(&models.Event{}).Select("community_id, COUNT(*) AS events_count").
+		Where("community_id IS NOT NULL AND created_at >= ?", since).Group("community_id")
+
+	columns := "communities.id, COALESCE(m.members_count, 0) AS members_count, " +
+		"COALESCE(n.news_count, 0) + COALESCE(e.events_count, 0) AS recent_activity"
+	args := []interface{}{}
+	if distanceExpr != "" {
+		columns += ", " + distanceExpr + " AS distance_sq"
+		args = distanceArgs
+	}
+
+	query = query.Select(columns, args...).
+		Joins("LEFT JOIN (?) AS m ON m.community_id = communities.id", members).
+		Joins("LEFT JOIN (?) AS n ON n.community_id = communities.id", news).
+		Joins("LEFT JOIN (?) AS e ON e.community_id = communities.id", events)
+
+	switch filter.Sort {
+	case CommunitySortMembers:
+		query = query.Order("members_count DESC")
+	case CommunitySortActivity:
+		query = query.Order("recent_activity DESC")
+	case CommunitySortDistance:
+		query = query.Order("distance_sq ASC")
+	}
+	query = query.Order("communities.created_at DESC").Order("communities.id DESC")
+
+	var rows []communityDiscoveryRow
+	if err := query.Limit(limit).Offset((page - 1) * limit).Scan(&rows).Error; err != nil {
+		return nil, 0, err
+	}
+
+	ids := make([]uint, len(rows))
+	for i, row := range rows {
+		ids[i] = row.ID
+	}
+	communities, err := s.loadCommunities(ids)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	summaries := make([]CommunitySummary, 0, len(rows))
+	for _, row := range rows {
+		summary := CommunitySummary{
+			Community:      communities[row.ID],
+			MembersCount:   row.MembersCount,
+			RecentActivity: row.RecentActivity,
+		}
+		if row.DistanceSq != nil {
+			distance := math.Round(math.Sqrt(*row.DistanceSq)*kmPerDegree*10) / 10
+			summary.DistanceKm = &distance
+		}
+		summaries = append(summaries, summary)
+	}
+	return summaries, total, nil
+}
+
+// Suggest возвращает сообщества, в которых состоят пользователи из подписок,
+// за исключением тех, где пользователь уже состоит. Чем больше подписок в сообществе, тем выше оно в списке.
+func (s *CommunityDiscoveryService) Suggest(userID uint, limit int) ([]CommunitySuggestion, error) {
+	subscriptions := s.db.Model(&models.Subscription{}).Select("subscribed_to_id").Where("subscriber_id = ?", userID)
+	joined := s.db.Model(&models.CommunityRole{}).Select("community_id").Where("user_id = ?", userID)
+
+	var rows []struct {
+		ID                 uint
+		SubscriptionsCount int64
+	}
+	err := s.db.Table("communities").
+		Select("communities.id, COUNT(community_roles.user_id) AS subscriptions_count").
+		Joins("JOIN community_roles ON community_roles.community_id = communities.id").
+		Where("community_roles.user_id IN (?)", subscriptions).
+		Where("communities.id NOT IN (?)", joined).
+		Where("communities.visibility <> ?", models.CommunityVisibilityInvite).
+		Group("communities.id").
+		Order("subscriptions_count DESC").Order("communities.id DESC").
+		Limit(limit).Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]uint, len(rows))
+	for i, row := range rows {
+		ids[i] = row.ID
+	}
+	communities, err := s.loadCommunities(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	suggestions := make([]CommunitySuggestion, 0, len(rows))
+	for _, row := range rows {
+		suggestions = append(suggestions, CommunitySuggestion{
+			Community:          communities[row.ID],
+			SubscriptionsCount: row.SubscriptionsCount,
+		})
+	}
+	return suggestions, nil
+}
+
+// loadCommunities загружает сообщества с создателями по списку ID
+func (s *CommunityDiscoveryService) loadCommunities(ids []uint) (map[uint]models.Community, error) {
+	result := make(map[uint]models.Community, len(ids))
+	if len(ids) == 0 {
+		return result, nil
+	}
+
+	var communities []models.Community
+	if err := s.db.Preload("Creator").Where("id IN ?", ids).Find(&communities).Error; err != nil {
+		return nil, err
+	}
+	for _, community := range communities {
+		result[community.ID] = community
+	}
+	return result, nil
+}
@@ -0,0 +1,126 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"toloko-backend/models"
+
+	"gorm.io/gorm"
+)
+
+// Режимы календаря сообщества
+const (
+	CalendarViewMonth = "month"
+	CalendarViewWeek  = "week"
+)
+
+// ErrInvalidCalendarView неизвестный режим календаря
+var ErrInvalidCalendarView = errors.New("invalid calendar view")
+
+// CommunityEventService предоставляет методы для ивентов сообществ
+type CommunityEventService struct {
+	db *gorm.DB
+}
+
+// NewCommunityEventService создает новый сервис ивентов сообществ
+func NewCommunityEventService(db *gorm.DB) *CommunityEventService {
+	return &CommunityEventService{db: db}
+}
+
+// memberCommunities возвращает подзапрос ID сообществ, в которых состоит пользователь
+func (s *CommunityEventService) memberCommunities(userID uint) *gorm.DB {
+	return s.db.Model(&models.CommunityRole{}).Select("community_id").Where("user_id = ?", userID)
+}
+
+// VisibleTo возвращает scope, оставляющий только ивенты, доступные пользователю.
+// Ивенты «только для участников» видят участники сообщества и создатель ивента;
+// анонимному пользователю (viewerID = 0) они не показываются.
+func (s *CommunityEventService) VisibleTo(viewerID uint) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if viewerID == 0 {
+			return db.Where("events.members_only = ?", false)
+		}
+		return db.Where("events.members_only = ? OR events.creator_id = ? OR events.community_id IN (?)",
+			false, viewerID, s.memberCommunities(viewerID))
+	}
+}
+
+// FromFollowed возвращает scope ивентов от указанных авторов и от сообществ, в которых состоит пользователь
+func (s *CommunityEventService) FromFollowed(userID uint, creatorIDs []uint) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("events.creator_id IN ? OR events.community_id IN (?)", creatorIDs, s.memberCommunities(userID))
+	}
+}
+
+// ExcludeMembersOnlyAnnouncements скрывает анонсы ивентов «только для участников»
+func ExcludeMembersOnlyAnnouncements(db *gorm.DB) *gorm.DB {
+	restricted := db.Session(&gorm.Session{NewDB: true}).Model(&models.Event{}).Select("id").Where("members_only = ?", true)
+	return db.Where("news.event_id IS NULL OR news.event_id NOT IN (?)", restricted)
+}
+
+// CanView проверяет, может ли пользователь видеть ивент
+func (s *CommunityEventService) CanView(event *models.Event, viewerID uint) (bool, error) {
+	if !event.MembersOnly || event.CommunityID == nil {
+		return true, nil
+	}
+	if viewerID == 0 {
+		return false, nil
+	}
+	if event.CreatorID == viewerID {
+		return true, nil
+	}
+
+	var count int64
+	err := s.db.Model(&models.CommunityRole{}).
+		Where("community_id = ? AND user_id = ?", *event.CommunityID, viewerID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// CalendarRange вычисляет границы периода календаря [from, to) для даты.
+// Неделя начинается с понедельника.
+func CalendarRange(view string, date time.Time) (time.Time, time.Time, error) {
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
+
+	switch view {
+	case CalendarViewMonth:
+		from := day.AddDate(0, 0, 1-day.Day())
+		return from, from.AddDate(0, 1, 0), nil
+	case CalendarViewWeek:
+		offset := (int(day.Weekday()) + 6) % 7
+		from := day.AddDate(0, 0, -offset)
+		return from, from.AddDate(0, 0, 7), nil
+	}
+	return time.Time{}, time.Time{}, ErrInvalidCalendarView
+}
+
+// ListCalendar возвращает активные ивенты сообщества, пересекающиеся с периодом [from, to).
+// Посторонним (member = false) показываются только публичные ивенты не для участников.
+func (s *CommunityEventService) ListCalendar(communityID uint, from, to time.Time, member bool) ([]models.Event, error) {
+	query := s.db.Preload("Creator").Preload("Photos").
+		Where("community_id = ? AND is_active = ?", communityID, true).
+		Where("start_time < ? AND end_time > ?", to, from)
+	if !member {
+		query = query.Where("is_public = ? AND members_only = ?", true, false)
+	}
+
+	var events []models.Event
+	err := query.Order("start_time ASC, id ASC").Find(&events).Error
+	return events, err
+}
+
+// Announce публикует в новостях сообщества анонс нового ивента
+func (s *CommunityEventService) Announce(tx *gorm.DB, event *models.Event) error {
+	if event.CommunityID == nil {
+		return nil
+	}
+
+	return tx.Create(&models.News{
+		CommunityID: *event.CommunityID,
+		AuthorID:    event.CreatorID,
+		EventID:     &event.ID,
+		Content:     fmt.Sprintf("Новый ивент: %s — %s", event.Title, event.StartTime.Format("02.01.2006 15:04")),
+	}).Error
+}
@@ -0,0 +1,548 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"toloko-backend/models"
+
+	"gorm.io/gorm"
+)
+
+// Результаты вступления в сообщество
+const (
+	JoinResultJoined    = "joined"
+	JoinResultRequested = "requested"
+)
+
+var (
+	// ErrAlreadyMember пользователь уже состоит в сообществе
+	ErrAlreadyMember = errors.New("user is already a community member")
+	// ErrNotMember пользователь не состоит в сообществе
+	ErrNotMember = errors.New("user is not a community member")
+	// ErrJoinRequestPending заявка на вступление уже подана
+	ErrJoinRequestPending = errors.New("join request already pending")
+	// ErrJoinRequestNotFound заявка не найдена или уже рассмотрена
+	ErrJoinRequestNotFound = errors.New("join request not found")
+	// ErrCommunityInviteOnly в сообщество можно вступить только по приглашению
+	ErrCommunityInviteOnly = errors.New("community is invite-only")
+	// ErrInvitationPending приглашение уже отправлено
+	ErrInvitationPending = errors.New("invitation already pending")
+	// ErrInvitationNotFound приглашение не найдено или уже принято
+	ErrInvitationNotFound = errors.New("invitation not found")
+	// ErrLastCommunityAdmin нельзя оставить сообщество без администратора
+	ErrLastCommunityAdmin = errors.New("cannot remove the last community admin")
+	// ErrCommunityOwner владельца нельзя понизить или исключить, сначала нужно передать владение
+	ErrCommunityOwner = errors.New("community owner must transfer ownership first")
+	// ErrInsufficientCommunityRole роли инициатора недостаточно для изменения
+	ErrInsufficientCommunityRole = errors.New("insufficient community role")
+	// ErrInvalidCommunityRole неизвестная роль в сообществе
+	ErrInvalidCommunityRole = errors.New("invalid community role")
+)
+
+// CommunityMembershipService предоставляет методы для участия в сообществах
+type CommunityMembershipService struct {
+	db *gorm.DB
+}
+
+// NewCommunityMembershipService создает новый сервис участия в сообществах
+func NewCommunityMembershipService(db *gorm.DB) *CommunityMembershipService {
+	return &CommunityMembershipService{db: db}
+}
+
+// GetMembership возвращает запись участия пользователя (nil, если не состоит)
+func (s *CommunityMembershipService) GetMembership(communityID, userID uint) (*models.CommunityRole, error) {
+	var role models.CommunityRole
+	err := s.db.Where("community_id = ? AND user_id = ?", communityID, userID).First(&role).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+// IsMember проверяет, состоит ли пользователь в сообществе
+func (s *CommunityMembershipService) IsMember(communityID, userID uint) (bool, error) {
+	membership, err := s.GetMembership(communityID, userID)
+	return membership != nil, err
+}
+
+// Join вступает в сообщество или подает заявку в зависимости от его видимости.
+// Если у пользователя есть приглашение, он вступает сразу.
+func (s *CommunityMembershipService) Join(communityID, userID uint, message string) (string, *models.CommunityJoinRequest, error) {
+	var community models.Community
+	if err := s.db.First(&community, communityID).Error; err != nil {
+		return "", nil, err
+	}
+
+	if member, err := s.IsMember(communityID, userID); err != nil {
+		return "", nil, err
+	} else if member {
+		return "", nil, ErrAlreadyMember
+	}
+
+	if ban, err := activeSanction(s.db, communityID, userID, models.SanctionTypeBan); err != nil {
+		return "", nil, err
+	} else if ban != nil {
+		return "", nil, ErrCommunityBanned
+	}
+
+	var invitation models.CommunityInvitation
+	err := s.db.Where("community_id = ? AND user_id = ? AND status = ?", communityID, userID, models.MembershipStatusPending).
+		First(&invitation).Error
+	if err == nil {
+		return JoinResultJoined, nil, s.AcceptInvitation(invitation.ID, userID)
+	}
+	if err != gorm.ErrRecordNotFound {
+		return "", nil, err
+	}
+
+	switch community.Visibility {
+	case models.CommunityVisibilityInvite:
+		return "", nil, ErrCommunityInviteOnly
+	case models.CommunityVisibilityRequest:
+		request, err := s.createJoinRequest(communityID, userID, message)
+		return JoinResultRequested, request, err
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		return addMember(tx, communityID, userID, userID)
+	})
+	return JoinResultJoined, nil, err
+}
+
+// createJoinRequest создает заявку на вступление
+func (s *CommunityMembershipService) createJoinRequest(communityID, userID uint, message string) (*models.CommunityJoinRequest, error) {
+	var count int64
+	if err := s.db.Model(&models.CommunityJoinRequest{}).
+		Where("community_id = ? AND user_id = ? AND status = ?", communityID, userID, models.MembershipStatusPending).
+		Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, ErrJoinRequestPending
+	}
+
+	request := models.CommunityJoinRequest{
+		CommunityID: communityID,
+		UserID:      userID,
+		Message:     message,
+		Status:      models.MembershipStatusPending,
+	}
+	if err := s.db.Create(&request).Error; err != nil {
+		return nil, err
+	}
+	return &request, nil
+}
+
+// Leave выходит из сообщества. Если пользователь еще не участник, отменяет его заявку.
+func (s *CommunityMembershipService) Leave(communityID, userID uint) error {
+	membership, err := s.GetMembership(communityID, userID)
+	if err != nil {
+		return err
+	}
+
+	if membership == nil {
+		result := s.db.Model(&models.CommunityJoinRequest{}).
+			Where("community_id = ? AND user_id = ? AND status = ?", communityID, userID, models.MembershipStatusPending).
+			Update("status", models.MembershipStatusCancelled)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrNotMember
+		}
+		return nil
+	}
+
+	var community models.Community
+	if err := s.db.First(&community, communityID).Error; err != nil {
+		return err
+	}
+	if community.CreatorID == userID {
+		return ErrCommunityOwner
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := ensureAdminRemains(tx, membership); err != nil {
+			return err
+		}
+		if err := tx.Delete(membership).Error; err != nil {
+			return err
+		}
+		return recordRoleChange(tx, communityID, userID, userID, models.RoleChangeLeft, membership.Role, "")
+	})
+}
+
+// ListRequests возвращает заявки сообщества с указанным статусом
+func (s *CommunityMembershipService) ListRequests(communityID uint, status string) ([]models.CommunityJoinRequest, error) {
+	var requests []models.CommunityJoinRequest
+	err := s.db.Preload("User").Where("community_id = ? AND status = ?", communityID, status).
+		Order("created_at ASC").Find(&requests).Error
+	return requests, err
+}
+
+// ApproveRequest одобряет заявку и добавляет пользователя в сообщество
+func (s *CommunityMembershipService) ApproveRequest(communityID, requestID, reviewerID uint) (*models.CommunityJoinRequest, error) {
+	return s.reviewRequest(communityID, requestID, reviewerID, models.MembershipStatusApproved)
+}
+
+// RejectRequest отклоняет заявку
+func (s *CommunityMembershipService) RejectRequest(communityID, requestID, reviewerID uint) (*models.CommunityJoinRequest, error) {
+	return s.reviewRequest(communityID, requestID, reviewerID, models.MembershipStatusRejected)
+}
+
+// reviewRequest переводит заявку в итоговый статус
+func (s *CommunityMembershipService) reviewRequest(communityID, requestID, reviewerID uint, status string) (*models.CommunityJoinRequest, error) {
+	var request models.CommunityJoinRequest
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id = ? AND community_id = ? AND status = ?", requestID, communityID, models.MembershipStatusPending).
+			First(&request).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return ErrJoinRequestNotFound
+			}
+			return err
+		}
+
+		now := time.Now()
+		request.Status = status
+		request.ReviewedBy = &reviewerID
+		request.ReviewedAt = &now
+		if err := tx.Save(&request).Error; err != nil {
+			return err
+		}
+
+		if status != models.MembershipStatusApproved {
+			return nil
+		}
+		return addMember(tx, communityID, request.UserID, reviewerID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &request, nil
+}
+
+// Invite приглашает пользователя в сообщество
+func (s *CommunityMembershipService) Invite(communityID, userID, invitedBy uint) (*models.CommunityInvitation, error) {
+	if member, err := s.IsMember(communityID, userID); err != nil {
+		return nil, err
+	} else if member {
+		return nil, ErrAlreadyMember
+	}
+
+	if ban, err := activeSanction(s.db, communityID, userID, models.SanctionTypeBan); err != nil {
+		return nil, err
+	} else if ban != nil {
+		return nil, ErrCommunityBanned
+	}
+
+	var count int64
+	if err := s.db.Model(&models.CommunityInvitation{}).
+		Where("community_id = ? AND user_id = ? AND status = ?", communityID, userID, models.MembershipStatusPending).
+		Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, ErrInvitationPending
+	}
+
+	invitation := models.CommunityInvitation{
+		CommunityID: communityID,
+		UserID:      userID,
+		InvitedBy:   invitedBy,
+		Status:      models.MembershipStatusPending,
+	}
+	if err := s.db.Create(&invitation).Error; err != nil {
+		return nil, err
+	}
+	return &invitation, nil
+}
+
+// ListInvitations возвращает ожидающие приглашения пользователя
+func (s *CommunityMembershipService) ListInvitations(userID uint) ([]models.CommunityInvitation, error) {
+	var invitations []models.CommunityInvitation
+	err := s.db.Preload("Community").Preload("Inviter").
+		Where("user_id = ? AND status = ?", userID, models.MembershipStatusPending).
+		Order("created_at DESC").Find(&invitations).Error
+	return invitations, err
+}
+
+// AcceptInvitation принимает приглашение и добавляет пользователя в сообщество
+func (s *CommunityMembershipService) AcceptInvitation(invitationID, userID uint) error {
+	return s.respondInvitation(invitationID, userID, models.MembershipStatusApproved)
+}
+
+// DeclineInvitation отклоняет приглашение
+func (s *CommunityMembershipService) DeclineInvitation(invitationID, userID uint) error {
+	return s.respondInvitation(invitationID, userID, models.MembershipStatusRejected)
+}
+
+// respondInvitation переводит приглашение в итоговый статус
+func (s *CommunityMembershipService) respondInvitation(invitationID, userID uint, status string) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var invitation models.CommunityInvitation
+		if err := tx.Where("id = ? AND user_id = ? AND status = ?", invitationID, userID, models.MembershipStatusPending).
+			First(&invitation).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return ErrInvitationNotFound
+			}
+			return err
+		}
+
+		now := time.Now()
+		if err := tx.Model(&invitation).Updates(map[string]interface{}{
+			"status":       status,
+			"responded_at": &now,
+		}).Error; err != nil {
+			return err
+		}
+
+		if status != models.MembershipStatusApproved {
+			return nil
+		}
+
+		// Заявка, поданная до приглашения, больше не нужна
+		if err := tx.Model(&models.CommunityJoinRequest{}).
+			Where("community_id = ? AND user_id = ? AND status = ?", invitation.CommunityID, userID, models.MembershipStatusPending).
+			Update("status", models.MembershipStatusCancelled).Error; err != nil {
+			return err
+		}
+		return addMember(tx, invitation.CommunityID, userID, invitation.InvitedBy)
+	})
+}
+
+// ListMembers возвращает участников сообщества (фильтр по роли необязателен) и их общее количество
+func (s *CommunityMembershipService) ListMembers(communityID uint, role string, page, limit int) ([]models.CommunityRole, int64, error) {
+	query := s.db.Model(&models.CommunityRole{}).Where("community_id = ?", communityID)
+	if role != "" {
+		query = query.Where("role = ?", role)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var members []models.CommunityRole
+	err := query.Preload("User").Order("created_at ASC, id ASC").
+		Limit(limit).Offset((page - 1) * limit).Find(&members).Error
+	return members, total, err
+}
+
+// CountMembers возвращает количество участников сообщества по ролям
+func (s *CommunityMembershipService) CountMembers(communityID uint) (int64, map[string]int64, error) {
+	var rows []struct {
+		Role  string
+		Count int64
+	}
+	if err := s.db.Model(&models.CommunityRole{}).Select("role, COUNT(*) AS count").
+		Where("community_id = ?", communityID).Group("role").Scan(&rows).Error; err != nil {
+		return 0, nil, err
+	}
+
+	var total int64
+	byRole := map[string]int64{
+		models.CommunityRoleAdmin:     0,
+		models.CommunityRoleModerator: 0,
+		models.CommunityRoleMember:    0,
+	}
+	for _, row := range rows {
+		byRole[row.Role] = row.Count
+		total += row.Count
+	}
+	return total, byRole, nil
+}
+
+// ChangeRole меняет роль участника сообщества. Модератор (actorIsAdmin = false)
+// не может назначать администраторов и менять роль администраторов.
+func (s *CommunityMembershipService) ChangeRole(communityID, targetID, actorID uint, role string, actorIsAdmin bool) (*models.CommunityRole, error) {
+	if !models.IsValidCommunityRole(role) {
+		return nil, ErrInvalidCommunityRole
+	}
+
+	var membership models.CommunityRole
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		community, err := loadMembership(tx, communityID, targetID, &membership)
+		if err != nil {
+			return err
+		}
+		if membership.Role == role {
+			return nil
+		}
+		if !actorIsAdmin && (membership.IsAdmin() || role == models.CommunityRoleAdmin) {
+			return ErrInsufficientCommunityRole
+		}
+		if community.CreatorID == targetID && role != models.CommunityRoleAdmin {
+			return ErrCommunityOwner
+		}
+		if role != models.CommunityRoleAdmin {
+			if err := ensureAdminRemains(tx, &membership); err != nil {
+				return err
+			}
+		}
+
+		action := models.RoleChangePromoted
+		if models.CommunityRoleRank(role) < models.CommunityRoleRank(membership.Role) {
+			action = models.RoleChangeDemoted
+		}
+		fromRole := membership.Role
+		if err := tx.Model(&membership).Update("role", role).Error; err != nil {
+			return err
+		}
+		return recordRoleChange(tx, communityID, targetID, actorID, action, fromRole, role)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &membership, nil
+}
+
+// RemoveMember исключает участника из сообщества. Модератор может исключать только рядовых участников.
+func (s *CommunityMembershipService) RemoveMember(communityID, targetID, actorID uint, actorIsAdmin bool) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var membership models.CommunityRole
+		community, err := loadMembership(tx, communityID, targetID, &membership)
+		if err != nil {
+			return err
+		}
+		if !actorIsAdmin && membership.Role != models.CommunityRoleMember {
+			return ErrInsufficientCommunityRole
+		}
+		if community.CreatorID == targetID {
+			return ErrCommunityOwner
+		}
+		if err := ensureAdminRemains(tx, &membership); err != nil {
+			return err
+		}
+
+		if err := tx.Delete(&membership).Error; err != nil {
+			return err
+		}
+		return recordRoleChange(tx, communityID, targetID, actorID, models.RoleChangeRemoved, membership.Role, "")
+	})
+}
+
+// TransferOwnership передает владение сообществом другому участнику.
+// Новый владелец становится администратором, прежний сохраняет роль администратора.
+func (s *CommunityMembershipService) TransferOwnership(communityID, newOwnerID, actorID uint) (*models.Community, error) {
+	var community *models.Community
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var membership models.CommunityRole
+		var err error
+		community, err = loadMembership(tx, communityID, newOwnerID, &membership)
+		if err != nil {
+			return err
+		}
+		if community.CreatorID == newOwnerID {
+			return nil
+		}
+
+		fromRole := membership.Role
+		if !membership.IsAdmin() {
+			if err := tx.Model(&membership).Update("role", models.CommunityRoleAdmin).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Model(community).Update("creator_id", newOwnerID).Error; err != nil {
+			return err
+		}
+		return recordRoleChange(tx, communityID, newOwnerID, actorID, models.RoleChangeTransferred, fromRole, models.CommunityRoleAdmin)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return community, nil
+}
+
+// GetRoleHistory возвращает историю ролей сообщества (фильтр по пользователю необязателен)
+func (s *CommunityMembershipService) GetRoleHistory(communityID, userID uint, page, limit int) ([]models.CommunityRoleChange, int64, error) {
+	query := s.db.Model(&models.CommunityRoleChange{}).Where("community_id = ?", communityID)
+	if userID != 0 {
+		query = query.Where("user_id = ?", userID)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var changes []models.CommunityRoleChange
+	err := query.Preload("User").Preload("Actor").Order("created_at DESC, id DESC").
+		Limit(limit).Offset((page - 1) * limit).Find(&changes).Error
+	return changes, total, err
+}
+
+// loadMembership находит сообщество и запись участия пользователя в нем
+func loadMembership(tx *gorm.DB, communityID, userID uint, membership *models.CommunityRole) (*models.Community, error) {
+	var community models.Community
+	if err := tx.First(&community, communityID).Error; err != nil {
+		return nil, err
+	}
+	err := tx.Where("community_id = ? AND user_id = ?", communityID, userID).First(membership).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, ErrNotMember
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &community, nil
+}
+
+// ensureAdminRemains проверяет, что после снятия роли у участника в сообществе останется администратор
+func ensureAdminRemains(tx *gorm.DB, membership *models.CommunityRole) error {
+	if !membership.IsAdmin() {
+		return nil
+	}
+	var admins int64
+	if err := tx.Model(&models.CommunityRole{}).
+		Where("community_id = ? AND role = ?", membership.CommunityID, models.CommunityRoleAdmin).
+		Count(&admins).Error; err != nil {
+		return err
+	}
+	if admins <= 1 {
+		return ErrLastCommunityAdmin
+	}
+	return nil
+}
+
+// addMember добавляет пользователя в сообщество с ролью участника, если он еще не состоит в нем.
+// Заблокированного в сообществе пользователя добавить нельзя.
+func addMember(tx *gorm.DB, communityID, userID, actorID uint) error {
+	if ban, err := activeSanction(tx, communityID, userID, models.SanctionTypeBan); err != nil {
+		return err
+	} else if ban != nil {
+		return ErrCommunityBanned
+	}
+
+	var count int64
+	if err := tx.Model(&models.CommunityRole{}).Where("community_id = ? AND user_id = ?", communityID, userID).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+	if err := tx.Create(&models.CommunityRole{
+		CommunityID: communityID,
+		UserID:      userID,
+		Role:        models.CommunityRoleMember,
+	}).Error; err != nil {
+		return err
+	}
+	return recordRoleChange(tx, communityID, userID, actorID, models.RoleChangeJoined, "", models.CommunityRoleMember)
+}
+
+// recordRoleChange записывает изменение роли в историю сообщества
+func recordRoleChange(tx *gorm.DB, communityID, userID, actorID uint, action, fromRole, toRole string) error {
+	return tx.Create(&models.CommunityRoleChange{
+		CommunityID: communityID,
+		UserID:      userID,
+		ActorID:     actorID,
+		Action:      action,
+		FromRole:    fromRole,
+		ToRole:      toRole,
+	}).Error
+}
@@ -0,0 +1,350 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"toloko-backend/models"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrCommunityBanned пользователь заблокирован в сообществе
+	ErrCommunityBanned = errors.New("user is banned in the community")
+	// ErrCommunityMuted пользователю временно запрещено публиковать в сообществе
+	ErrCommunityMuted = errors.New("user is muted in the community")
+	// ErrSanctionNotFound активная санкция не найдена
+	ErrSanctionNotFound = errors.New("sanction not found")
+	// ErrInvalidSanctionDuration срок санкции не указан или некорректен
+	ErrInvalidSanctionDuration = errors.New("invalid sanction duration")
+)
+
+// CommunityModerationService предоставляет методы модерации сообществ: баны, муты и журнал модерации
+type CommunityModerationService struct {
+	db            *gorm.DB
+	notifications *NotificationService
+}
+
+// NewCommunityModerationService создает новый сервис модерации сообществ
+func NewCommunityModerationService(db *gorm.DB) *CommunityModerationService {
+	return &CommunityModerationService{
+		db:            db,
+		notifications: NewNotificationService(db),
+	}
+}
+
+// ActiveSanction возвращает действующую санкцию указанного типа (nil, если ее нет)
+func (s *CommunityModerationService) ActiveSanction(communityID, userID uint, sanctionType string) (*models.CommunitySanction, error) {
+	return activeSanction(s.db, communityID, userID, sanctionType)
+}
+
+// CheckCanParticipate проверяет, что пользователь может публиковать новости и комментарии
+// и ставить лайки в сообществе. Возвращает ErrCommunityBanned или ErrCommunityMuted.
+func (s *CommunityModerationService) CheckCanParticipate(communityID, userID uint) error {
+	return checkCanParticipate(s.db, communityID, userID)
+}
+
+// Ban блокирует пользователя в сообществе: исключает его, отменяет заявки и приглашения.
+// until = nil - бессрочный бан. Модератор (actorIsAdmin = false) может банить только рядовых участников.
+func (s *CommunityModerationService) Ban(communityID, targetID, actorID uint, reason string, until *time.Time, actorIsAdmin bool) (*models.CommunitySanction, error) {
+	if until != nil && !until.After(time.Now()) {
+		return nil, ErrInvalidSanctionDuration
+	}
+
+	var sanction *models.CommunitySanction
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		membership, err := checkSanctionTarget(tx, communityID, targetID, actorIsAdmin)
+		if err != nil {
+			return err
+		}
+
+		if membership != nil {
+			if err := ensureAdminRemains(tx, membership); err != nil {
+				return err
+			}
+			if err := tx.Delete(membership).Error; err != nil {
+				return err
+			}
+			if err := recordRoleChange(tx, communityID, targetID, actorID, models.RoleChangeRemoved, membership.Role, ""); err != nil {
+				return err
+			}
+		}
+
+		pending := []interface{}{&models.CommunityJoinRequest{}, &models.CommunityInvitation{}}
+		for _, model := range pending {
+			if err := tx.Model(model).
+				Where("community_id = ? AND user_id = ? AND status = ?", communityID, targetID, models.MembershipStatusPending).
+				Update("status", models.MembershipStatusCancelled).Error; err != nil {
+				return err
+			}
+		}
+
+		sanction, err = createSanction(tx, communityID, targetID, actorID, models.SanctionTypeBan, reason, until)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.notifySanction(sanction)
+	return sanction, nil
+}
+
+// Mute временно запрещает пользователю публиковать новости и комментарии и ставить лайки в сообществе
+func (s *CommunityModerationService) Mute(communityID, targetID, actorID uint, reason string, until time.Time, actorIsAdmin bool) (*models.CommunitySanction, error) {
+	if !until.After(time.Now()) {
+		return nil, ErrInvalidSanctionDuration
+	}
+
+	var sanction *models.CommunitySanction
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if _, err := checkSanctionTarget(tx, communityID, targetID, actorIsAdmin); err != nil {
+			return err
+		}
+		var err error
+		sanction, err = createSanction(tx, communityID, targetID, actorID, models.SanctionTypeMute, reason, &until)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.notifySanction(sanction)
+	return sanction, nil
+}
+
+// Revoke досрочно снимает действующую санкцию указанного типа
+func (s *CommunityModerationService) Revoke(communityID, targetID, actorID uint, sanctionType string) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		sanction, err := activeSanction(tx, communityID, targetID, sanctionType)
+		if err != nil {
+			return err
+		}
+		if sanction == nil {
+			return ErrSanctionNotFound
+		}
+
+		now := time.Now()
+		if err := tx.Model(sanction).Updates(map[string]interface{}{
+			"revoked_at": &now,
+			"revoked_by": actorID,
+		}).Error; err != nil {
+			return err
+		}
+
+		action := models.ModerationActionUnban
+		if sanctionType == models.SanctionTypeMute {
+			action = models.ModerationActionUnmute
+		}
+		return tx.Create(&models.CommunityModerationLog{
+			CommunityID:  communityID,
+			ActorID:      actorID,
+			Action:       action,
+			TargetUserID: targetID,
+		}).Error
+	})
+}
+
+// RecordRemoval записывает удаление новости или комментария модератором в журнал
+// и сообщает автору причину удаления. Удаление собственного контента не записывается.
+func (s *CommunityModerationService) RecordRemoval(communityID, actorID, authorID uint, targetType string, targetID uint, content, reason string) error {
+	if actorID == authorID {
+		return nil
+	}
+
+	action := models.ModerationActionCommentRemoved
+	title := "Модератор удалил ваш комментарий"
+	if targetType == "news" {
+		action = models.ModerationActionNewsRemoved
+		title = "Модератор удалил вашу новость"
+	}
+
+	entry := models.CommunityModerationLog{
+		CommunityID:  communityID,
+		ActorID:      actorID,
+		Action:       action,
+		TargetUserID: authorID,
+		TargetType:   targetType,
+		TargetID:     targetID,
+		Reason:       reason,
+		Excerpt:      excerpt(content, newsExcerptLength),
+	}
+	if err := s.db.Create(&entry).Error; err != nil {
+		return err
+	}
+
+	body := entry.Excerpt
+	if reason != "" {
+		body = fmt.Sprintf("Причина: %s\n\n%s", reason, entry.Excerpt)
+	}
+	return s.notifications.NotifyMany([]uint{authorID}, models.Notification{
+		Type:       models.NotificationTypeRemoved,
+		Title:      title,
+		Body:       body,
+		EntityType: "community",
+		EntityID:   communityID,
+	})
+}
+
+// Log возвращает журнал модерации сообщества, новые записи сначала (фильтры необязательны)
+func (s *CommunityModerationService) Log(communityID uint, action string, userID uint, page, limit int) ([]models.CommunityModerationLog, int64, error) {
+	query := s.db.Model(&models.CommunityModerationLog{}).Where("community_id = ?", communityID)
+	if action != "" {
+		query = query.Where("action = ?", action)
+	}
+	if userID != 0 {
+		query = query.Where("target_user_id = ?", userID)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var entries []models.CommunityModerationLog
+	err := query.Session(&gorm.Session{}).Preload("Actor").Preload("TargetUser").
+		Order("created_at DESC, id DESC").Limit(limit).Offset((page - 1) * limit).Find(&entries).Error
+	return entries, total, err
+}
+
+// UserSanctions возвращает все санкции пользователя в сообществе, включая истекшие и снятые
+func (s *CommunityModerationService) UserSanctions(communityID, userID uint) ([]models.CommunitySanction, error) {
+	var sanctions []models.CommunitySanction
+	err := s.db.Preload("Creator").Where("community_id = ? AND user_id = ?", communityID, userID).
+		Order("created_at DESC, id DESC").Find(&sanctions).Error
+	return sanctions, err
+}
+
+// UserRemovals возвращает удаленный модераторами контент пользователя в сообществе с причинами
+func (s *CommunityModerationService) UserRemovals(communityID, userID uint) ([]models.CommunityModerationLog, error) {
+	var entries []models.CommunityModerationLog
+	err := s.db.Where("community_id = ? AND target_user_id = ? AND action IN ?", communityID, userID,
+		[]string{models.ModerationActionNewsRemoved, models.ModerationActionCommentRemoved}).
+		Order("created_at DESC, id DESC").Find(&entries).Error
+	return entries, err
+}
+
+// notifySanction сообщает пользователю о бане или муте и его причине
+func (s *CommunityModerationService) notifySanction(sanction *models.CommunitySanction) {
+	var community models.Community
+	s.db.Select("id", "name").First(&community, sanction.CommunityID)
+
+	title := fmt.Sprintf("Вы заблокированы в сообществе «%s»", community.Name)
+	if sanction.Type == models.SanctionTypeMute {
+		title = fmt.Sprintf("Вам временно запрещено писать в сообществе «%s»", community.Name)
+	}
+	body := sanction.Reason
+	if sanction.ExpiresAt != nil {
+		body = fmt.Sprintf("До %s. %s", sanction.ExpiresAt.Format("02.01.2006 15:04"), sanction.Reason)
+	}
+
+	if err := s.notifications.NotifyMany([]uint{sanction.UserID}, models.Notification{
+		Type:       models.NotificationTypeSanction,
+		Title:      title,
+		Body:       body,
+		EntityType: "community",
+		EntityID:   sanction.CommunityID,
+	}); err != nil {
+		log.Printf("Ошибка уведомления о санкции %d: %v", sanction.ID, err)
+	}
+}
+
+// checkSanctionTarget проверяет, что к пользователю можно применить санкцию:
+// владельца сообщества наказать нельзя, модератор может наказывать только рядовых участников.
+// Возвращает запись участия (nil, если пользователь не состоит в сообществе).
+func checkSanctionTarget(tx *gorm.DB, communityID, targetID uint, actorIsAdmin bool) (*models.CommunityRole, error) {
+	var community models.Community
+	if err := tx.First(&community, communityID).Error; err != nil {
+		return nil, err
+	}
+	if community.CreatorID == targetID {
+		return nil, ErrCommunityOwner
+	}
+
+	var membership models.CommunityRole
+	err := tx.Where("community_id = ? AND user_id = ?", communityID, targetID).First(&membership).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !actorIsAdmin && membership.Role != models.CommunityRoleMember {
+		return nil, ErrInsufficientCommunityRole
+	}
+	return &membership, nil
+}
+
+// createSanction заменяет действующую санкцию того же типа новой и записывает ее в журнал модерации
+func createSanction(tx *gorm.DB, communityID, targetID, actorID uint, sanctionType, reason string, until *time.Time) (*models.CommunitySanction, error) {
+	now := time.Now()
+	if err := tx.Model(&models.CommunitySanction{}).
+		Where("community_id = ? AND user_id = ? AND type = ? AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > ?)",
+			communityID, targetID, sanctionType, now).
+		Updates(map[string]interface{}{"revoked_at": &now, "revoked_by": actorID}).Error; err != nil {
+		return nil, err
+	}
+
+	sanction := models.CommunitySanction{
+		CommunityID: communityID,
+		UserID:      targetID,
+		Type:        sanctionType,
+		Reason:      reason,
+		ExpiresAt:   until,
+		CreatedBy:   actorID,
+	}
+	if err := tx.Create(&sanction).Error; err != nil {
+		return nil, err
+	}
+
+	action := models.ModerationActionBan
+	if sanctionType == models.SanctionTypeMute {
+		action = models.ModerationActionMute
+	}
+	if err := tx.Create(&models.CommunityModerationLog{
+		CommunityID:  communityID,
+		ActorID:      actorID,
+		Action:       action,
+		TargetUserID: targetID,
+		Reason:       reason,
+	}).Error; err != nil {
+		return nil, err
+	}
+	return &sanction, nil
+}
+
+// activeSanction находит действующую санкцию указанного типа (nil, если ее нет)
+func activeSanction(tx *gorm.DB, communityID, userID uint, sanctionType string) (*models.CommunitySanction, error) {
+	var sanction models.CommunitySanction
+	err := tx.Where("community_id = ? AND user_id = ? AND type = ? AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > ?)",
+		communityID, userID, sanctionType, time.Now()).
+		Order("id DESC").First(&sanction).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &sanction, nil
+}
+
+// checkCanParticipate проверяет отсутствие действующих бана и мута
+func checkCanParticipate(tx *gorm.DB, communityID, userID uint) error {
+	var sanctions []models.CommunitySanction
+	if err := tx.Where("community_id = ? AND user_id = ? AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > ?)",
+		communityID, userID, time.Now()).Find(&sanctions).Error; err != nil {
+		return err
+	}
+
+	var err error
+	for _, sanction := range sanctions {
+		if sanction.Type == models.SanctionTypeBan {
+			return ErrCommunityBanned
+		}
+		err = ErrCommunityMuted
+	}
+	return err
+}
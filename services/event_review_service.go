@@ -0,0 +1,227 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"toloko-backend/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrEventNotCompleted отзыв можно оставить только после завершения ивента
+	ErrEventNotCompleted = errors.New("event is not completed yet")
+	// ErrReviewNotAllowed отзыв оставляют только участники, посетившие ивент
+	ErrReviewNotAllowed = errors.New("only attended participants can review the event")
+	// ErrReviewExists пользователь уже оставил отзыв об ивенте
+	ErrReviewExists = errors.New("event already reviewed")
+	// ErrReviewNotFound отзыв не найден
+	ErrReviewNotFound = errors.New("review not found")
+	// ErrNotEventOrganizer отвечать на отзывы может только организатор ивента
+	ErrNotEventOrganizer = errors.New("only the event organizer can reply to reviews")
+)
+
+// ReviewInput оценки и текст отзыва об ивенте
+type ReviewInput struct {
+	Score              int    `json:"score"`
+	Organization       int    `json:"organization"`
+	Safety             int    `json:"safety"`
+	MatchedDescription int    `json:"matched_description"`
+	Text               string `json:"text"`
+}
+
+// ReviewSummary сводка отзывов об ивенте или организаторе
+type ReviewSummary struct {
+	ReviewsCount       int64   `json:"reviews_count"`
+	AverageScore       float64 `json:"average_score"`
+	Reputation         float64 `json:"reputation"` // байесовская оценка с тем же априорным средним, что и у репутации участников; 0 - отзывов нет
+	Organization       float64 `json:"organization"`
+	Safety             float64 `json:"safety"`
+	MatchedDescription float64 `json:"matched_description"`
+}
+
+// EventReviewService предоставляет методы для отзывов участников об ивентах и организаторах
+type EventReviewService struct {
+	db            *gorm.DB
+	config        ReputationConfig
+	notifications *NotificationService
+}
+
+// NewEventReviewService создает новый сервис отзывов
+func NewEventReviewService(db *gorm.DB) *EventReviewService {
+	return &EventReviewService{
+		db:            db,
+		config:        LoadReputationConfig(),
+		notifications: NewNotificationService(db),
+	}
+}
+
+// Submit сохраняет отзыв посетившего ивент участника после завершения ивента
+func (s *EventReviewService) Submit(event *models.Event, userID uint, input ReviewInput) (*models.EventReview, error) {
+	if event.IsActive && event.EndTime.After(time.Now()) {
+		return nil, ErrEventNotCompleted
+	}
+	if event.CreatorID == userID {
+		return nil, ErrReviewNotAllowed
+	}
+
+	attended, err := s.attended(event.ID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !attended {
+		return nil, ErrReviewNotAllowed
+	}
+
+	review := models.EventReview{
+		EventID:            event.ID,
+		UserID:             userID,
+		OrganizerID:        event.CreatorID,
+		Score:              input.Score,
+		Organization:       input.Organization,
+		Safety:             input.Safety,
+		MatchedDescription: input.MatchedDescription,
+		Text:               input.Text,
+	}
+	result := s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&review)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrReviewExists
+	}
+
+	if err := s.notifications.NotifyMany([]uint{event.CreatorID}, models.Notification{
+		Type:       models.NotificationTypeEventReview,
+		Title:      fmt.Sprintf("Новый отзыв об ивенте «%s»", event.Title),
+		Body:       review.Text,
+		EntityType: "event",
+		EntityID:   event.ID,
+	}); err != nil {
+		return nil, err
+	}
+
+	return &review, nil
+}
+
+// Reply сохраняет или заменяет ответ организатора на отзыв
+func (s *EventReviewService) Reply(event *models.Event, organizerID, reviewID uint, reply string) (*models.EventReview, error) {
+	if event.CreatorID != organizerID {
+		return nil, ErrNotEventOrganizer
+	}
+
+	var review models.EventReview
+	if err := s.db.Where("id = ? AND event_id = ?", reviewID, event.ID).First(&review).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, ErrReviewNotFound
+		}
+		return nil, err
+	}
+
+	now := time.Now()
+	review.Reply = reply
+	review.RepliedAt = &now
+	if err := s.db.Save(&review).Error; err != nil {
+		return nil, err
+	}
+
+	if err := s.notifications.NotifyMany([]uint{review.UserID}, models.Notification{
+		Type:       models.NotificationTypeReviewReply,
+		Title:      fmt.Sprintf("Организатор ответил на ваш отзыв об ивенте «%s»", event.Title),
+		Body:       reply,
+		EntityType: "event",
+		EntityID:   event.ID,
+	}); err != nil {
+		return nil, err
+	}
+
+	return &review, nil
+}
+
+// ListByEvent возвращает отзывы об ивенте, новые сначала
+func (s *EventReviewService) ListByEvent(eventID uint, page, limit int) ([]models.EventReview, int64, error) {
+	return s.list(s.db.Model(&models.EventReview{}).Where("event_id = ?", eventID), page, limit)
+}
+
+// ListByOrganizer возвращает отзывы об ивентах организатора, новые сначала.
+// Отзывы об ивентах «только для участников» видны только участникам сообщества.
+func (s *EventReviewService) ListByOrganizer(organizerID, viewerID uint, page, limit int) ([]models.EventReview, int64, error) {
+	visible := s.db.Model(&models.Event{}).Select("id").
+		Where("members_only = ? OR community_id IS NULL OR creator_id = ? OR community_id IN (?)",
+			false, viewerID, s.db.Model(&models.CommunityRole{}).Select("community_id").Where("user_id = ?", viewerID))
+	return s.list(s.db.Model(&models.EventReview{}).Where("organizer_id = ? AND event_id IN (?)", organizerID, visible), page, limit)
+}
+
+// EventSummary возвращает сводку отзывов об ивенте
+func (s *EventReviewService) EventSummary(eventID uint) (*ReviewSummary, error) {
+	return s.summary(s.db.Model(&models.EventReview{}).Where("event_id = ?", eventID))
+}
+
+// OrganizerSummary возвращает репутацию организатора по отзывам обо всех его ивентах
+func (s *EventReviewService) OrganizerSummary(organizerID uint) (*ReviewSummary, error) {
+	return s.summary(s.db.Model(&models.EventReview{}).Where("organizer_id = ?", organizerID))
+}
+
+// attended проверяет, что пользователь участвовал в ивенте и организатор отметил его присутствие
+func (s *EventReviewService) attended(eventID, userID uint) (bool, error) {
+	var count int64
+	err := s.db.Model(&models.EventParticipant{}).
+		Where("event_id = ? AND user_id = ? AND status IN ? AND checked_in_at IS NOT NULL",
+			eventID, userID, []string{models.ParticipantStatusJoined, models.ParticipantStatusAccepted}).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// list применяет сортировку и пагинацию к запросу отзывов
+func (s *EventReviewService) list(query *gorm.DB, page, limit int) ([]models.EventReview, int64, error) {
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var reviews []models.EventReview
+	err := query.Session(&gorm.Session{}).Preload("User").
+		Order("created_at DESC, id DESC").Limit(limit).Offset((page - 1) * limit).
+		Find(&reviews).Error
+	return reviews, total, err
+}
+
+// summary агрегирует отзывы запроса
+func (s *EventReviewService) summary(query *gorm.DB) (*ReviewSummary, error) {
+	var row struct {
+		Count              int64
+		Score              float64
+		Organization       float64
+		Safety             float64
+		MatchedDescription float64
+	}
+	err := query.Select("COUNT(*) AS count, COALESCE(SUM(score), 0) AS score, " +
+		"COALESCE(AVG(organization), 0) AS organization, COALESCE(AVG(safety), 0) AS safety, " +
+		"COALESCE(AVG(matched_description), 0) AS matched_description").
+		Scan(&row).Error
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &ReviewSummary{
+		ReviewsCount:       row.Count,
+		Organization:       roundReviewValue(row.Organization),
+		Safety:             roundReviewValue(row.Safety),
+		MatchedDescription: roundReviewValue(row.MatchedDescription),
+	}
+	// Без отзывов репутации нет: априорное среднее не выдается за оценку организатора
+	if row.Count > 0 {
+		summary.AverageScore = roundReviewValue(row.Score / float64(row.Count))
+		summary.Reputation = roundReviewValue((s.config.PriorWeight*s.config.PriorMean + row.Score) / (s.config.PriorWeight + float64(row.Count)))
+	}
+	return summary, nil
+}
+
+// roundReviewValue округляет среднее до сотых
+func roundReviewValue(value float64) float64 {
+	return math.Round(value*100) / 100
+}
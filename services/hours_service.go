@@ -0,0 +1,212 @@
+package services
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"html/template"
+	"math"
+	"sort"
+	"strings"
+
+	"toloko-backend/models"
+
+	"gorm.io/gorm"
+)
+
+// ErrNoConfirmedHours у пользователя нет подтвержденных часов за выбранный период
+var ErrNoConfirmedHours = errors.New("no confirmed volunteer hours")
+
+// HoursBucket часы за год или по типу ивента
+type HoursBucket struct {
+	Year      int     `json:"year,omitempty"`
+	EventType string  `json:"event_type,omitempty"`
+	Hours     float64 `json:"hours"`
+	Events    int     `json:"events"`
+}
+
+// HoursSummary сводка подтвержденных волонтерских часов пользователя
+type HoursSummary struct {
+	UserID      uint          `json:"user_id"`
+	Year        *int          `json:"year"`
+	TotalHours  float64       `json:"total_hours"`
+	EventsCount int           `json:"events_count"`
+	ByYear      []HoursBucket `json:"by_year"`
+	ByEventType []HoursBucket `json:"by_event_type"`
+}
+
+// HoursService предоставляет методы для учета волонтерских часов и выдачи сертификатов
+type HoursService struct {
+	db *gorm.DB
+}
+
+// NewHoursService создает новый сервис волонтерских часов
+func NewHoursService(db *gorm.DB) *HoursService {
+	return &HoursService{db: db}
+}
+
+// Summary считает подтвержденные организаторами часы пользователя по годам и типам ивентов.
+// Если указан год, учитываются только ивенты, начавшиеся в этом году.
+func (s *HoursService) Summary(userID uint, year *int) (*HoursSummary, error) {
+	var participants []models.EventParticipant
+	if err := s.db.Preload("Event").
+		Where("user_id = ? AND status IN ? AND hours_confirmed_at IS NOT NULL", userID,
+			[]string{models.ParticipantStatusJoined, models.ParticipantStatusAccepted}).
+		Find(&participants).Error; err != nil {
+		return nil, err
+	}
+
+	summary := &HoursSummary{UserID: userID, Year: year, ByYear: []HoursBucket{}, ByEventType: []HoursBucket{}}
+	byYear := make(map[int]*HoursBucket)
+	byType := make(map[string]*HoursBucket)
+	for _, participant := range participants {
+		eventYear := participant.Event.StartTime.Year()
+		if year != nil && eventYear != *year {
+			continue
+		}
+		hours := participant.VolunteerHours(&participant.Event)
+
+		summary.TotalHours += hours
+		summary.EventsCount++
+		if byYear[eventYear] == nil {
+			byYear[eventYear] = &HoursBucket{Year: eventYear}
+		}
+		byYear[eventYear].Hours += hours
+		byYear[eventYear].Events++
+		if byType[participant.Event.EventType] == nil {
+			byType[participant.Event.EventType] = &HoursBucket{EventType: participant.Event.EventType}
+		}
+		byType[participant.Event.EventType].Hours += hours
+		byType[participant.Event.EventType].Events++
+	}
+
+	summary.TotalHours = roundHours(summary.TotalHours)
+	for _, bucket := range byYear {
+		bucket.Hours = roundHours(bucket.Hours)
+		summary.ByYear = append(summary.ByYear, *bucket)
+	}
+	for _, bucket := range byType {
+		bucket.Hours = roundHours(bucket.Hours)
+		summary.ByEventType = append(summary.ByEventType, *bucket)
+	}
+	sort.Slice(summary.ByYear, func(i, j int) bool { return summary.ByYear[i].Year > summary.ByYear[j].Year })
+	sort.Slice(summary.ByEventType, func(i, j int) bool {
+		if summary.ByEventType[i].Hours != summary.ByEventType[j].Hours {
+			return summary.ByEventType[i].Hours > summary.ByEventType[j].Hours
+		}
+		return summary.ByEventType[i].EventType < summary.ByEventType[j].EventType
+	})
+	return summary, nil
+}
+
+// IssueCertificate выдает сертификат на подтвержденные часы пользователя за год или за все время
+func (s *HoursService) IssueCertificate(userID uint, year *int) (*models.Certificate, error) {
+	var user models.User
+	if err := s.db.First(&user, userID).Error; err != nil {
+		return nil, err
+	}
+
+	summary, err := s.Summary(userID, year)
+	if err != nil {
+		return nil, err
+	}
+	if summary.EventsCount == 0 {
+		return nil, ErrNoConfirmedHours
+	}
+
+	code, err := generateCertificateCode()
+	if err != nil {
+		return nil, err
+	}
+
+	certificate := models.Certificate{
+		Code:        code,
+		UserID:      userID,
+		UserName:    user.Name,
+		Year:        year,
+		TotalHours:  summary.TotalHours,
+		EventsCount: summary.EventsCount,
+	}
+	if err := s.db.Create(&certificate).Error; err != nil {
+		return nil, err
+	}
+	return &certificate, nil
+}
+
+// Certificates возвращает сертификаты пользователя, начиная с последних
+func (s *HoursService) Certificates(userID uint) ([]models.Certificate, error) {
+	var certificates []models.Certificate
+	err := s.db.Where("user_id = ?", userID).Order("issued_at DESC, id DESC").Find(&certificates).Error
+	return certificates, err
+}
+
+// Verify находит сертификат по коду проверки
+func (s *HoursService) Verify(code string) (*models.Certificate, error) {
+	var certificate models.Certificate
+	if err := s.db.Where("code = ?", NormalizeCertificateCode(code)).First(&certificate).Error; err != nil {
+		return nil, err
+	}
+	return &certificate, nil
+}
+
+// RenderCertificate формирует готовый к печати HTML сертификата
+func (s *HoursService) RenderCertificate(certificate *models.Certificate, verifyURL string) ([]byte, error) {
+	var buf bytes.Buffer
+	err := certificateTemplate.Execute(&buf, map[string]interface{}{
+		"Certificate": certificate,
+		"VerifyURL":   verifyURL,
+	})
+	return buf.Bytes(), err
+}
+
+// NormalizeCertificateCode приводит код сертификата к виду, в котором он хранится
+func NormalizeCertificateCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// generateCertificateCode генерирует код проверки вида "ABCD-EFGH-IJKL"
+func generateCertificateCode() (string, error) {
+	raw := make([]byte, 8)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	encoded := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(raw)[:12]
+	return encoded[:4] + "-" + encoded[4:8] + "-" + encoded[8:], nil
+}
+
+// roundHours округляет часы до сотых
+func roundHours(hours float64) float64 {
+	return math.Round(hours*100) / 100
+}
+
+// certificateTemplate шаблон сертификата для печати
+var certificateTemplate = template.Must(template.New("certificate").Parse(`<!DOCTYPE html>
+<html lang="ru">
+<head>
+<meta charset="utf-8">
+<title>Сертификат волонтера {{.Certificate.Code}}</title>
+<style>
+@page { size: A4 landscape; margin: 20mm; }
+body { font-family: "PT Serif", Georgia, serif; text-align: center; color: #1f2d1f; }
+.frame { border: 6px double #2e7d32; padding: 40px; }
+h1 { font-size: 36px; margin-bottom: 8px; }
+.name { font-size: 30px; font-weight: bold; margin: 24px 0; }
+.hours { font-size: 22px; }
+.code { margin-top: 40px; font-family: monospace; font-size: 14px; }
+</style>
+</head>
+<body>
+<div class="frame">
+<h1>Сертификат волонтера</h1>
+<p>Настоящим подтверждается, что</p>
+<p class="name">{{.Certificate.UserName}}</p>
+<p class="hours">отработал(а) {{printf "%.2f" .Certificate.TotalHours}} волонтерских часов
+на {{.Certificate.EventsCount}} мероприятиях{{if .Certificate.Year}} в {{.Certificate.Year}} году{{end}}.</p>
+<p>Часы подтверждены организаторами мероприятий.</p>
+<p>Дата выдачи: {{.Certificate.IssuedAt.Format "02.01.2006"}}</p>
+<p class="code">Код проверки: {{.Certificate.Code}}<br>Проверить подлинность: {{.VerifyURL}}</p>
+</div>
+</body>
+</html>
+`))
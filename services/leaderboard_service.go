@@ -0,0 +1,224 @@
+package services
+
+import (
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"toloko-backend/models"
+
+	"gorm.io/gorm"
+)
+
+// leaderboardBatchSize размер пачки при записи таблиц лидеров
+const leaderboardBatchSize = 500
+
+// leaderboardPeriods и leaderboardScopes - все пересчитываемые таблицы лидеров
+var (
+	leaderboardPeriods = []string{models.LeaderboardPeriodWeek, models.LeaderboardPeriodMonth, models.LeaderboardPeriodYear, models.LeaderboardPeriodAll}
+	leaderboardScopes  = []string{models.LeaderboardScopeGlobal, models.LeaderboardScopeCity, models.LeaderboardScopeEventCity, models.LeaderboardScopeCommunity, models.LeaderboardScopeEventType}
+)
+
+// leaderboardRow сумма очков пользователя в срезе
+type leaderboardRow struct {
+	ScopeKey string
+	UserID   uint
+	Points   int
+}
+
+// LeaderboardService предоставляет методы для работы с таблицами лидеров
+type LeaderboardService struct {
+	db *gorm.DB
+}
+
+// NewLeaderboardService создает новый сервис таблиц лидеров
+func NewLeaderboardService(db *gorm.DB) *LeaderboardService {
+	return &LeaderboardService{db: db}
+}
+
+// Page возвращает страницу таблицы лидеров
+func (s *LeaderboardService) Page(period, scope, key string, page, limit int) ([]models.LeaderboardEntry, int64, error) {
+	query := s.db.Model(&models.LeaderboardEntry{}).
+		Where("period = ? AND scope_type = ? AND scope_key = ?", period, scope, NormalizeLeaderboardKey(scope, key))
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var entries []models.LeaderboardEntry
+	err := query.Session(&gorm.Session{}).Preload("User").
+		Order("rank ASC, user_id ASC").
+		Limit(limit).Offset((page - 1) * limit).
+		Find(&entries).Error
+	return entries, total, err
+}
+
+// UserRank возвращает место пользователя в таблице лидеров или nil, если он в нее не попал
+func (s *LeaderboardService) UserRank(period, scope, key string, userID uint) (*models.LeaderboardEntry, error) {
+	var entry models.LeaderboardEntry
+	err := s.db.Preload("User").
+		Where("period = ? AND scope_type = ? AND scope_key = ? AND user_id = ?", period, scope, NormalizeLeaderboardKey(scope, key), userID).
+		First(&entry).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// Refresh пересчитывает все таблицы лидеров из журнала очков
+func (s *LeaderboardService) Refresh(now time.Time) error {
+	for _, period := range leaderboardPeriods {
+		start := LeaderboardPeriodStart(period, now)
+		for _, scope := range leaderboardScopes {
+			if err := s.refreshBoard(period, scope, start, now); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// RunRefresher периодически пересчитывает таблицы лидеров
+func (s *LeaderboardService) RunRefresher(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.Refresh(time.Now()); err != nil {
+			log.Printf("Ошибка пересчета таблиц лидеров: %v", err)
+		}
+		<-ticker.C
+	}
+}
+
+// refreshBoard заменяет строки таблиц лидеров одного периода и среза
+func (s *LeaderboardService) refreshBoard(period, scope string, start, now time.Time) error {
+	rows, err := s.aggregate(scope, start)
+	if err != nil {
+		return err
+	}
+
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].ScopeKey != rows[j].ScopeKey {
+			return rows[i].ScopeKey < rows[j].ScopeKey
+		}
+		if rows[i].Points != rows[j].Points {
+			return rows[i].Points > rows[j].Points
+		}
+		return rows[i].UserID < rows[j].UserID
+	})
+
+	// Одинаковое число очков - одинаковое место, следующее место пропускается
+	entries := make([]models.LeaderboardEntry, 0, len(rows))
+	rank, first := 0, 0
+	for i, row := range rows {
+		if i == 0 || row.ScopeKey != rows[i-1].ScopeKey {
+			rank, first = 1, i
+		} else if row.Points != rows[i-1].Points {
+			rank = i - first + 1
+		}
+		entries = append(entries, models.LeaderboardEntry{
+			Period:      period,
+			ScopeType:   scope,
+			ScopeKey:    row.ScopeKey,
+			UserID:      row.UserID,
+			Points:      row.Points,
+			Rank:        rank,
+			PeriodStart: start,
+			RefreshedAt: now,
+		})
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("period = ? AND scope_type = ?", period, scope).Delete(&models.LeaderboardEntry{}).Error; err != nil {
+			return err
+		}
+		if len(entries) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(&entries, leaderboardBatchSize).Error
+	})
+}
+
+// aggregate суммирует очки активных пользователей за период в разрезе среза
+func (s *LeaderboardService) aggregate(scope string, start time.Time) ([]leaderboardRow, error) {
+	query := s.db.Table("points_entries").
+		Joins("JOIN users ON users.id = points_entries.user_id AND users.is_active = ?", true)
+	if !start.IsZero() {
+		query = query.Where("points_entries.created_at >= ?", start)
+	}
+
+	keyColumn := ""
+	switch scope {
+	case models.LeaderboardScopeCity:
+		keyColumn = "TRIM(users.location)"
+		query = query.Where("TRIM(users.location) <> ''")
+	case models.LeaderboardScopeEventCity:
+		keyColumn = "TRIM(events.city)"
+		query = query.Joins("JOIN events ON events.id = points_entries.event_id").Where("TRIM(events.city) <> ''")
+	case models.LeaderboardScopeCommunity:
+		keyColumn = "community_roles.community_id"
+		query = query.Joins("JOIN community_roles ON community_roles.user_id = points_entries.user_id")
+	case models.LeaderboardScopeEventType:
+		keyColumn = "events.event_type"
+		query = query.Joins("JOIN events ON events.id = points_entries.event_id")
+	}
+
+	columns := "points_entries.user_id AS user_id, SUM(points_entries.amount) AS points"
+	if keyColumn != "" {
+		columns = keyColumn + " AS scope_key, " + columns
+		query = query.Group(keyColumn)
+	}
+
+	var rows []leaderboardRow
+	if err := query.Select(columns).Group("points_entries.user_id").Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	// Города сравниваются без учета регистра, поэтому строки с одним ключом объединяются
+	merged := make(map[leaderboardRow]int)
+	for _, row := range rows {
+		key := leaderboardRow{ScopeKey: NormalizeLeaderboardKey(scope, row.ScopeKey), UserID: row.UserID}
+		merged[key] += row.Points
+	}
+
+	result := make([]leaderboardRow, 0, len(merged))
+	for key, points := range merged {
+		if points > 0 {
+			result = append(result, leaderboardRow{ScopeKey: key.ScopeKey, UserID: key.UserID, Points: points})
+		}
+	}
+	return result, nil
+}
+
+// NormalizeLeaderboardKey приводит ключ среза к виду, в котором он хранится в таблице лидеров
+func NormalizeLeaderboardKey(scope, key string) string {
+	key = strings.TrimSpace(key)
+	switch scope {
+	case models.LeaderboardScopeGlobal:
+		return ""
+	case models.LeaderboardScopeCity, models.LeaderboardScopeEventCity:
+		return strings.ToLower(key)
+	}
+	return key
+}
+
+// LeaderboardPeriodStart возвращает начало текущего периода: понедельник, первое число месяца или года.
+// Для периода "all" возвращается нулевое время.
+func LeaderboardPeriodStart(period string, now time.Time) time.Time {
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	switch period {
+	case models.LeaderboardPeriodWeek:
+		return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	case models.LeaderboardPeriodMonth:
+		return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	case models.LeaderboardPeriodYear:
+		return time.Date(now.Year(), 1, 1, 0, 0, 0, 0, now.Location())
+	}
+	return time.Time{}
+}
@@ -0,0 +1,189 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"toloko-backend/models"
+
+	"gorm.io/gorm"
+)
+
+// ErrInvalidLevelCurve кривая уровней задана неверно
+var ErrInvalidLevelCurve = errors.New("invalid level curve")
+
+// DefaultLevelCurve базовая кривая уровней: каждый следующий уровень требует больше очков
+var DefaultLevelCurve = []models.LevelDefinition{
+	{Level: 1, Threshold: 0, Title: "Новичок", IconPath: "/icons/levels/novice.png"},
+	{Level: 2, Threshold: 100, Title: "Помощник", IconPath: "/icons/levels/helper.png"},
+	{Level: 3, Threshold: 300, Title: "Активист", IconPath: "/icons/levels/activist.png"},
+	{Level: 4, Threshold: 600, Title: "Волонтер", IconPath: "/icons/levels/volunteer.png"},
+	{Level: 5, Threshold: 1000, Title: "Опытный волонтер", IconPath: "/icons/levels/experienced.png"},
+	{Level: 6, Threshold: 1600, Title: "Наставник", IconPath: "/icons/levels/mentor.png"},
+	{Level: 7, Threshold: 2500, Title: "Эко-страж", IconPath: "/icons/levels/eco-guardian.png"},
+	{Level: 8, Threshold: 4000, Title: "Хранитель природы", IconPath: "/icons/levels/nature-keeper.png"},
+	{Level: 9, Threshold: 6000, Title: "Легенда", IconPath: "/icons/levels/legend.png"},
+}
+
+// LevelService предоставляет методы для работы с кривой уровней
+type LevelService struct {
+	db *gorm.DB
+}
+
+// NewLevelService создает новый сервис уровней
+func NewLevelService(db *gorm.DB) *LevelService {
+	return &LevelService{db: db}
+}
+
+// Curve возвращает действующую кривую уровней
+func (s *LevelService) Curve() ([]models.LevelDefinition, error) {
+	return loadLevelCurve(s.db)
+}
+
+// SeedDefaultCurve сохраняет базовую кривую, если уровни еще не настроены, и пересчитывает уровни пользователей
+func (s *LevelService) SeedDefaultCurve() error {
+	var count int64
+	if err := s.db.Model(&models.LevelDefinition{}).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+	_, err := s.ReplaceCurve(DefaultLevelCurve)
+	return err
+}
+
+// ReplaceCurve заменяет кривую уровней и пересчитывает уровни всех пользователей.
+// Возвращает число пользователей, у которых изменился уровень.
+func (s *LevelService) ReplaceCurve(levels []models.LevelDefinition) (int, error) {
+	if err := ValidateLevelCurve(levels); err != nil {
+		return 0, err
+	}
+
+	changed := 0
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("1 = 1").Delete(&models.LevelDefinition{}).Error; err != nil {
+			return err
+		}
+		for _, level := range levels {
+			level.ID = 0
+			level.Title = strings.TrimSpace(level.Title)
+			if err := tx.Create(&level).Error; err != nil {
+				return err
+			}
+		}
+
+		var err error
+		changed, err = RecalculateLevels(tx)
+		return err
+	})
+	return changed, err
+}
+
+// ValidateLevelCurve проверяет кривую: уровни идут подряд с первого, первый начинается с нуля очков,
+// пороги строго возрастают, у каждого уровня есть звание
+func ValidateLevelCurve(levels []models.LevelDefinition) error {
+	if len(levels) == 0 {
+		return fmt.Errorf("%w: no levels", ErrInvalidLevelCurve)
+	}
+	for i, level := range levels {
+		if level.Level != i+1 {
+			return fmt.Errorf("%w: levels must go in order starting from 1", ErrInvalidLevelCurve)
+		}
+		if strings.TrimSpace(level.Title) == "" {
+			return fmt.Errorf("%w: level %d has no title", ErrInvalidLevelCurve, level.Level)
+		}
+		if i == 0 && level.Threshold != 0 {
+			return fmt.Errorf("%w: first level must start at 0 points", ErrInvalidLevelCurve)
+		}
+		if i > 0 && level.Threshold <= levels[i-1].Threshold {
+			return fmt.Errorf("%w: thresholds must increase", ErrInvalidLevelCurve)
+		}
+	}
+	return nil
+}
+
+// LevelFor возвращает уровень, соответствующий количеству очков
+func LevelFor(curve []models.LevelDefinition, points int) models.LevelDefinition {
+	current := curve[0]
+	for _, level := range curve {
+		if points < level.Threshold {
+			break
+		}
+		current = level
+	}
+	return current
+}
+
+// NextLevel возвращает следующий уровень кривой или nil для последнего уровня
+func NextLevel(curve []models.LevelDefinition, level int) *models.LevelDefinition {
+	if level < len(curve) {
+		return &curve[level]
+	}
+	return nil
+}
+
+// RecalculateLevels пересчитывает уровни всех пользователей по действующей кривой.
+// Повышение уровня при смене кривой не считается событием и не рассылается.
+func RecalculateLevels(tx *gorm.DB) (int, error) {
+	curve, err := loadLevelCurve(tx)
+	if err != nil {
+		return 0, err
+	}
+
+	var userLevels []models.UserLevel
+	if err := tx.Find(&userLevels).Error; err != nil {
+		return 0, err
+	}
+
+	changed := 0
+	for _, userLevel := range userLevels {
+		level := LevelFor(curve, userLevel.Points).Level
+		if level == userLevel.Level {
+			continue
+		}
+		if err := tx.Model(&userLevel).Update("level", level).Error; err != nil {
+			return changed, err
+		}
+		changed++
+	}
+	return changed, nil
+}
+
+// loadLevelCurve загружает кривую уровней; пока уровни не настроены, действует базовая кривая
+func loadLevelCurve(tx *gorm.DB) ([]models.LevelDefinition, error) {
+	var curve []models.LevelDefinition
+	if err := tx.Order("level").Find(&curve).Error; err != nil {
+		return nil, err
+	}
+	if len(curve) == 0 {
+		return DefaultLevelCurve, nil
+	}
+	return curve, nil
+}
+
+// emitLevelUp обрабатывает событие повышения уровня: уведомляет пользователя и добавляет запись в ленту подписчиков
+func emitLevelUp(tx *gorm.DB, userID uint, level models.LevelDefinition) error {
+	title := fmt.Sprintf("Новый уровень: %d", level.Level)
+	body := fmt.Sprintf("Достигнут уровень %d — «%s»", level.Level, level.Title)
+
+	if err := NewNotificationService(tx).NotifyMany([]uint{userID}, models.Notification{
+		Type:       models.NotificationTypeLevelUp,
+		Title:      title,
+		Body:       body,
+		EntityType: "level",
+		EntityID:   uint(level.Level),
+	}); err != nil {
+		return err
+	}
+
+	return tx.Create(&models.FeedActivity{
+		UserID:     userID,
+		Type:       models.FeedActivityLevelUp,
+		Title:      title,
+		Body:       body,
+		EntityType: "level",
+		EntityID:   uint(level.Level),
+	}).Error
+}
@@ -0,0 +1,92 @@
+package services
+
+import (
+	"strings"
+	"time"
+)
+
+// LoginGuard защищает вход от перебора паролей прогрессивной блокировкой.
+// Счетчики ведутся по паре email и IP клиента, чтобы посторонний не мог заблокировать
+// владельцу вход в аккаунт; перебор с разных адресов сдерживает лимит запросов по IP.
+type LoginGuard struct {
+	store         RateLimitStore
+	MaxFailures   int           // число неудачных попыток до первой блокировки
+	BaseLockout   time.Duration // длительность первой блокировки
+	MaxLockout    time.Duration // максимальная длительность блокировки
+	FailureWindow time.Duration // окно, в течение которого учитываются неудачные попытки
+}
+
+// NewLoginGuard создает защиту входа с параметрами по умолчанию
+func NewLoginGuard(store RateLimitStore) *LoginGuard {
+	return &LoginGuard{
+		store:         store,
+		MaxFailures:   5,
+		BaseLockout:   time.Minute,
+		MaxLockout:    time.Hour,
+		FailureWindow: 24 * time.Hour,
+	}
+}
+
+// Check возвращает оставшееся время блокировки входа в аккаунт с адреса клиента (0, если вход разрешен)
+func (g *LoginGuard) Check(login, ip string) (time.Duration, error) {
+	count, resetAt, err := g.store.Get(g.lockKey(login, ip))
+	if err != nil || count == 0 {
+		return 0, err
+	}
+
+	return time.Until(resetAt), nil
+}
+
+// RegisterFailure учитывает неудачную попытку входа и возвращает длительность
+// назначенной блокировки (0, если блокировка не назначена)
+func (g *LoginGuard) RegisterFailure(login, ip string) (time.Duration, error) {
+	failures, _, err := g.store.Increment(g.failureKey(login, ip), g.FailureWindow)
+	if err != nil {
+		return 0, err
+	}
+
+	if failures < g.MaxFailures {
+		return 0, nil
+	}
+
+	// Каждая следующая неудачная попытка удваивает блокировку
+	lockout := g.BaseLockout
+	for i := g.MaxFailures; i < failures && lockout < g.MaxLockout; i++ {
+		lockout *= 2
+	}
+	if lockout > g.MaxLockout {
+		lockout = g.MaxLockout
+	}
+
+	if err := g.store.Reset(g.lockKey(login, ip)); err != nil {
+		return 0, err
+	}
+	if _, _, err := g.store.Increment(g.lockKey(login, ip), lockout); err != nil {
+		return 0, err
+	}
+
+	return lockout, nil
+}
+
+// Reset сбрасывает счетчик неудачных попыток после успешного входа
+func (g *LoginGuard) Reset(login, ip string) error {
+	if err := g.store.Reset(g.failureKey(login, ip)); err != nil {
+		return err
+	}
+	return g.store.Reset(g.lockKey(login, ip))
+}
+
+// failureKey ключ счетчика неудачных попыток
+func (g *LoginGuard) failureKey(login, ip string) string {
+	return "login.failures:" + loginGuardSubject(login, ip)
+}
+
+// lockKey ключ блокировки входа
+func (g *LoginGuard) lockKey(login, ip string) string {
+	return "login.lock:" + loginGuardSubject(login, ip)
+}
+
+// loginGuardSubject объединяет нормализованный email и IP клиента в часть ключа
+func loginGuardSubject(login, ip string) string {
+	return strings.ToLower(strings.TrimSpace(login)) + "|" + ip
+}
@@ -0,0 +1,338 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"toloko-backend/models"
+
+	"gorm.io/gorm"
+)
+
+// mentionExcerptLength длина отрывка текста в уведомлении об упоминании
+const mentionExcerptLength = 140
+
+// mentionToken упоминание, найденное в тексте
+type mentionToken struct {
+	Username string
+	Offset   int // позиция «@» в символах
+	Length   int // длина вместе с «@»
+}
+
+// mentionScope аудитория текста: упомянуть можно только тех, кто его увидит
+type mentionScope struct {
+	CommunityID  uint   // новость или комментарий в сообществе
+	MembersOnly  bool   // текст виден только участникам сообщества (анонс ивента «только для участников»)
+	Participants []uint // участники диалога
+}
+
+// MentionCandidate пользователь в подсказке для упоминания
+type MentionCandidate struct {
+	ID       uint   `json:"id"`
+	Name     string `json:"name"`
+	Username string `json:"username"`
+	Avatar   string `json:"avatar"`
+}
+
+// MentionService разбирает упоминания пользователей и уведомляет упомянутых
+type MentionService struct {
+	db            *gorm.DB
+	notifications *NotificationService
+}
+
+// NewMentionService создает новый сервис упоминаний
+func NewMentionService(db *gorm.DB) *MentionService {
+	return &MentionService{
+		db:            db,
+		notifications: NewNotificationService(db),
+	}
+}
+
+// parseMentions находит в тексте упоминания вида @username.
+// Упоминание должно начинаться с начала текста или после символа, не входящего в слово (так e-mail не считается упоминанием).
+func parseMentions(text string) []mentionToken {
+	runes := []rune(text)
+	var tokens []mentionToken
+	for i := 0; i < len(runes); i++ {
+		if runes[i] != '@' || (i > 0 && isMentionRune(runes[i-1])) {
+			continue
+		}
+		end := i + 1
+		for end < len(runes) && isMentionRune(runes[end]) {
+			end++
+		}
+		length := end - i - 1
+		if length >= models.UsernameMinLength && length <= models.UsernameMaxLength {
+			tokens = append(tokens, mentionToken{
+				Username: strings.ToLower(string(runes[i+1 : end])),
+				Offset:   i,
+				Length:   length + 1,
+			})
+		}
+		i = end - 1
+	}
+	return tokens
+}
+
+// isMentionRune проверяет, может ли символ входить в имя пользователя (или примыкать к «@» слева)
+func isMentionRune(r rune) bool {
+	return r == '_' || r == '@' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// SyncNews обновляет упоминания в новости. Уведомления отправляются только для опубликованной новости.
+func (s *MentionService) SyncNews(news *models.News) error {
+	scope, err := s.newsScope(news)
+	if err != nil {
+		return err
+	}
+	added, mentions, err := s.sync(models.MentionSourceNews, news.ID, news.AuthorID, news.Content, scope)
+	if err != nil {
+		return err
+	}
+	news.Mentions = mentions
+	if !news.IsPublished() {
+		return nil
+	}
+	return s.notify(added, news.AuthorID, models.MentionSourceNews, news.ID, "новости", news.Content)
+}
+
+// NotifyNews уведомляет всех упомянутых в новости, используется при публикации черновика
+func (s *MentionService) NotifyNews(news *models.News) error {
+	var userIDs []uint
+	if err := s.db.Model(&models.Mention{}).
+		Where("source_type = ? AND source_id = ?", models.MentionSourceNews, news.ID).
+		Distinct().Pluck("user_id", &userIDs).Error; err != nil {
+		return err
+	}
+	return s.notify(userIDs, news.AuthorID, models.MentionSourceNews, news.ID, "новости", news.Content)
+}
+
+// SyncComment обновляет упоминания в комментарии к новости
+func (s *MentionService) SyncComment(comment *models.Comment, news *models.News) error {
+	scope, err := s.newsScope(news)
+	if err != nil {
+		return err
+	}
+	added, mentions, err := s.sync(models.MentionSourceComment, comment.ID, comment.AuthorID, comment.Content, scope)
+	if err != nil {
+		return err
+	}
+	comment.Mentions = mentions
+	return s.notify(added, comment.AuthorID, models.MentionSourceComment, comment.ID, "комментарии", comment.Content)
+}
+
+// SyncMessage сохраняет упоминания в личном сообщении. Упомянуть можно только участников диалога.
+func (s *MentionService) SyncMessage(message *models.Message) error {
+	scope := mentionScope{Participants: []uint{message.FromUserID, message.ToUserID}}
+	added, mentions, err := s.sync(models.MentionSourceMessage, message.ID, message.FromUserID, message.Text, scope)
+	if err != nil {
+		return err
+	}
+	message.Mentions = mentions
+	return s.notify(added, message.FromUserID, models.MentionSourceMessage, message.ID, "сообщении", message.Text)
+}
+
+// newsScope возвращает область упоминаний для новости и комментариев к ней.
+// Анонс ивента «только для участников» скрыт от посторонних, поэтому упомянуть в нем можно только участников сообщества.
+func (s *MentionService) newsScope(news *models.News) (mentionScope, error) {
+	scope := mentionScope{CommunityID: news.CommunityID}
+	if news.EventID == nil {
+		return scope, nil
+	}
+	if news.Event != nil {
+		scope.MembersOnly = news.Event.MembersOnly
+		return scope, nil
+	}
+
+	var event models.Event
+	err := s.db.Select("id", "members_only").First(&event, *news.EventID).Error
+	if err == gorm.ErrRecordNotFound {
+		return scope, nil
+	}
+	scope.MembersOnly = event.MembersOnly
+	return scope, err
+}
+
+// DeleteMentions удаляет упоминания удаленных объектов
+func DeleteMentions(tx *gorm.DB, sourceType string, sourceIDs interface{}) error {
+	return tx.Where("source_type = ? AND source_id IN (?)", sourceType, sourceIDs).Delete(&models.Mention{}).Error
+}
+
+// Autocomplete подсказывает пользователей для упоминания по началу имени или username.
+// Скрытые профили и пользователи, связанные с viewer блокировкой, не предлагаются.
+func (s *MentionService) Autocomplete(viewerID uint, prefix string, limit int) ([]MentionCandidate, error) {
+	prefix = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(prefix), "@"))
+	candidates := []MentionCandidate{}
+	if prefix == "" {
+		return candidates, nil
+	}
+	pattern := escapeLike(prefix) + "%"
+
+	var users []models.User
+	err := s.db.Where("is_active = ? AND is_public = ? AND username IS NOT NULL AND id <> ?", true, true, viewerID).
+		Where("LOWER(username) LIKE ? ESCAPE '\\' OR LOWER(name) LIKE ? ESCAPE '\\'", pattern, pattern).
+		Where("id NOT IN (?)", s.blockedWith(viewerID)).
+		Order("username ASC").Limit(limit).Find(&users).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, user := range users {
+		candidates = append(candidates, MentionCandidate{
+			ID:       user.ID,
+			Name:     user.Name,
+			Username: *user.Username,
+			Avatar:   user.Avatar,
+		})
+	}
+	return candidates, nil
+}
+
+// sync заменяет упоминания объекта найденными в тексте и возвращает пользователей,
+// которые упомянуты впервые (им отправляется уведомление)
+func (s *MentionService) sync(sourceType string, sourceID, authorID uint, text string, scope mentionScope) ([]uint, []models.Mention, error) {
+	tokens := parseMentions(text)
+	users, err := s.resolve(authorID, tokens, scope)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var previous []uint
+	if err := s.db.Model(&models.Mention{}).Where("source_type = ? AND source_id = ?", sourceType, sourceID).
+		Pluck("user_id", &previous).Error; err != nil {
+		return nil, nil, err
+	}
+	known := make(map[uint]bool, len(previous))
+	for _, userID := range previous {
+		known[userID] = true
+	}
+
+	mentions := []models.Mention{}
+	var added []uint
+	for _, token := range tokens {
+		user, ok := users[token.Username]
+		if !ok {
+			continue
+		}
+		mentions = append(mentions, models.Mention{
+			SourceType: sourceType,
+			SourceID:   sourceID,
+			UserID:     user.ID,
+			Username:   token.Username,
+			Offset:     token.Offset,
+			Length:     token.Length,
+		})
+		if !known[user.ID] {
+			known[user.ID] = true
+			added = append(added, user.ID)
+		}
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := DeleteMentions(tx, sourceType, []uint{sourceID}); err != nil {
+			return err
+		}
+		if len(mentions) == 0 {
+			return nil
+		}
+		return tx.Create(&mentions).Error
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return added, mentions, nil
+}
+
+// resolve находит упомянутых пользователей, которым доступен текст:
+// нет блокировки с автором, текст виден пользователю (текст закрытого сообщества или ивента «только для участников» -
+// только участникам), скрытый профиль упоминается только внутри своего сообщества
+func (s *MentionService) resolve(authorID uint, tokens []mentionToken, scope mentionScope) (map[string]models.User, error) {
+	result := make(map[string]models.User)
+	if len(tokens) == 0 {
+		return result, nil
+	}
+
+	usernames := make([]string, 0, len(tokens))
+	for _, token := range tokens {
+		usernames = append(usernames, token.Username)
+	}
+
+	query := s.db.Where("username IN ? AND is_active = ?", usernames, true).
+		Where("id NOT IN (?)", s.blockedWith(authorID))
+	if scope.Participants != nil {
+		query = query.Where("id IN ?", scope.Participants)
+	}
+	var users []models.User
+	if err := query.Find(&users).Error; err != nil {
+		return nil, err
+	}
+
+	var members map[uint]bool
+	privateCommunity := false
+	if scope.CommunityID != 0 && len(users) > 0 {
+		var community models.Community
+		if err := s.db.Select("id", "visibility").First(&community, scope.CommunityID).Error; err != nil {
+			return nil, err
+		}
+		privateCommunity = community.IsPrivate()
+
+		ids := make([]uint, len(users))
+		for i, user := range users {
+			ids[i] = user.ID
+		}
+		var memberIDs []uint
+		if err := s.db.Model(&models.CommunityRole{}).Where("community_id = ? AND user_id IN ?", scope.CommunityID, ids).
+			Pluck("user_id", &memberIDs).Error; err != nil {
+			return nil, err
+		}
+		members = make(map[uint]bool, len(memberIDs))
+		for _, id := range memberIDs {
+			members[id] = true
+		}
+	}
+
+	for _, user := range users {
+		if scope.CommunityID != 0 && (privateCommunity || scope.MembersOnly || !user.IsPublic) && !members[user.ID] {
+			continue
+		}
+		result[*user.Username] = user
+	}
+	return result, nil
+}
+
+// blockedWith возвращает подзапрос пользователей, которые заблокировали userID или заблокированы им
+func (s *MentionService) blockedWith(userID uint) *gorm.DB {
+	return s.db.Model(&models.Block{}).
+		Select("CASE WHEN blocker_id = ? THEN blocked_id ELSE blocker_id END", userID).
+		Where("blocker_id = ? OR blocked_id = ?", userID, userID)
+}
+
+// notify отправляет упомянутым пользователям уведомления (автор себе не уведомляется)
+func (s *MentionService) notify(userIDs []uint, authorID uint, sourceType string, sourceID uint, where, text string) error {
+	recipients := make([]uint, 0, len(userIDs))
+	for _, userID := range userIDs {
+		if userID != authorID {
+			recipients = append(recipients, userID)
+		}
+	}
+	if len(recipients) == 0 {
+		return nil
+	}
+
+	var author models.User
+	s.db.Select("id", "name").First(&author, authorID)
+
+	return s.notifications.NotifyMany(recipients, models.Notification{
+		Type:       models.NotificationTypeMention,
+		Title:      fmt.Sprintf("%s упомянул(а) вас в %s", author.Name, where),
+		Body:       excerpt(text, mentionExcerptLength),
+		EntityType: sourceType,
+		EntityID:   sourceID,
+	})
+}
+
+// escapeLike экранирует спецсимволы шаблона LIKE
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+}
@@ -2,6 +2,7 @@ package services
 
 import (
 	"errors"
+	"log"
 	"time"
 
 	"toloko-backend/models"
@@ -31,7 +32,7 @@ func (s *MessageService) GetMessages(conversationID, userID uint, beforeID *uint
 	}
 
 	var messages []models.Message
-	query := s.db.Preload("FromUser").Preload("ToUser").Preload("Attachments").
+	query := s.db.Preload("FromUser").Preload("ToUser").Preload("Attachments").Preload("Mentions").
 		Where("conversation_id = ?", conversationID).
 		Order("created_at DESC")
 
@@ -51,7 +52,7 @@ func (s *MessageService) GetMessages(conversationID, userID uint, beforeID *uint
 func (s *MessageService) GetMessage(messageID, userID uint) (*models.Message, error) {
 	var message models.Message
 
-	err := s.db.Preload("FromUser").Preload("ToUser").Preload("Attachments").
+	err := s.db.Preload("FromUser").Preload("ToUser").Preload("Attachments").Preload("Mentions").
 		Where("id = ? AND (from_user_id = ? OR to_user_id = ?)", messageID, userID, userID).
 		First(&message).Error
 
@@ -100,8 +101,13 @@ func (s *MessageService) CreateMessage(conversationID, fromUserID, toUserID uint
 	conversation.LastMessageID = &message.ID
 	s.db.Save(&conversation)
 
+	// Разбираем упоминания участников диалога
+	if err := NewMentionService(s.db).SyncMessage(&message); err != nil {
+		log.Printf("Ошибка обработки упоминаний в сообщении %d: %v", message.ID, err)
+	}
+
 	// Загружаем связанные данные
-	s.db.Preload("FromUser").Preload("ToUser").Preload("Attachments").First(&message, message.ID)
+	s.db.Preload("FromUser").Preload("ToUser").Preload("Attachments").Preload("Mentions").First(&message, message.ID)
 
 	return &message, nil
 }
@@ -152,8 +158,9 @@ func (s *MessageService) DeleteMessage(messageID, userID uint) error {
 		return err
 	}
 
-	// Удаляем вложения
+	// Удаляем вложения и упоминания
 	s.db.Where("message_id = ?", messageID).Delete(&models.Attachment{})
+	DeleteMentions(s.db, models.MentionSourceMessage, []uint{message.ID})
 
 	// Удаляем сообщение
 	return s.db.Delete(&message).Error
@@ -163,7 +170,7 @@ func (s *MessageService) DeleteMessage(messageID, userID uint) error {
 func (s *MessageService) SearchMessages(userID uint, query string, limit int) ([]models.Message, error) {
 	var messages []models.Message
 
-	dbQuery := s.db.Preload("FromUser").Preload("ToUser").Preload("Attachments").
+	dbQuery := s.db.Preload("FromUser").Preload("ToUser").Preload("Attachments").Preload("Mentions").
 		Where("(from_user_id = ? OR to_user_id = ?) AND text LIKE ?",
 			userID, userID, "%"+query+"%").
 		Order("created_at DESC")
@@ -180,7 +187,7 @@ func (s *MessageService) SearchMessages(userID uint, query string, limit int) ([
 func (s *MessageService) GetUnreadMessages(userID uint) ([]models.Message, error) {
 	var messages []models.Message
 
-	err := s.db.Preload("FromUser").Preload("ToUser").Preload("Attachments").
+	err := s.db.Preload("FromUser").Preload("ToUser").Preload("Attachments").Preload("Mentions").
 		Where("to_user_id = ? AND status != ?", userID, models.MessageStatusRead).
 		Order("created_at ASC").
 		Find(&messages).Error
@@ -237,7 +244,7 @@ func (s *MessageService) GetMessageStats(userID uint) (map[string]interface{}, e
 func (s *MessageService) GetMessagesByDateRange(userID uint, startDate, endDate time.Time) ([]models.Message, error) {
 	var messages []models.Message
 
-	err := s.db.Preload("FromUser").Preload("ToUser").Preload("Attachments").
+	err := s.db.Preload("FromUser").Preload("ToUser").Preload("Attachments").Preload("Mentions").
 		Where("(from_user_id = ? OR to_user_id = ?) AND created_at BETWEEN ? AND ?",
 			userID, userID, startDate, endDate).
 		Order("created_at ASC").
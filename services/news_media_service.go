@@ -0,0 +1,285 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"toloko-backend/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// MaxNewsMediaPerPost максимальное количество изображений в одной новости
+	MaxNewsMediaPerPost = 10
+	// MaxNewsMediaSize максимальный размер одного изображения (10 MB)
+	MaxNewsMediaSize = 10 * 1024 * 1024
+	// newsMediaDir папка для изображений новостей
+	newsMediaDir = "uploads/news"
+)
+
+var (
+	// ErrNewsMediaNotFound изображение не найдено
+	ErrNewsMediaNotFound = errors.New("news media not found")
+	// ErrNewsMediaLimit превышено количество изображений в новости
+	ErrNewsMediaLimit = errors.New("too many images in news")
+	// ErrNewsMediaTooLarge изображение слишком большое
+	ErrNewsMediaTooLarge = errors.New("image is too large")
+	// ErrNewsMediaType содержимое файла не является поддерживаемым изображением
+	ErrNewsMediaType = errors.New("unsupported image type")
+	// ErrNewsMediaOrder новый порядок должен содержать все изображения новости
+	ErrNewsMediaOrder = errors.New("media order must list every image of the news")
+)
+
+// newsMediaExtensions допустимые типы изображений и расширения сохраняемых файлов
+var newsMediaExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+// NewsMediaUpload загружаемое изображение с подписью
+type NewsMediaUpload struct {
+	File    *multipart.FileHeader
+	Caption string
+}
+
+// NewsMediaService предоставляет методы для работы с изображениями новостей
+type NewsMediaService struct {
+	db  *gorm.DB
+	dir string
+}
+
+// NewNewsMediaService создает новый сервис изображений новостей
+func NewNewsMediaService(db *gorm.DB) *NewsMediaService {
+	return &NewsMediaService{db: db, dir: newsMediaDir}
+}
+
+// DetectImageType определяет тип изображения по содержимому файла, а не по заголовкам клиента
+func DetectImageType(file *multipart.FileHeader) (string, error) {
+	if file.Size > MaxNewsMediaSize {
+		return "", ErrNewsMediaTooLarge
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+
+	head := make([]byte, 512)
+	n, err := io.ReadFull(src, head)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return "", ErrNewsMediaType
+	}
+
+	mimeType := http.DetectContentType(head[:n])
+	if _, ok := newsMediaExtensions[mimeType]; !ok {
+		return "", ErrNewsMediaType
+	}
+	return mimeType, nil
+}
+
+// Upload сохраняет изображения в конец списка изображений новости
+func (s *NewsMediaService) Upload(newsID, uploaderID uint, uploads []NewsMediaUpload) ([]models.NewsMedia, error) {
+	// Сначала проверяем все файлы, чтобы не сохранять часть загрузки
+	mimeTypes := make([]string, len(uploads))
+	for i, upload := range uploads {
+		mimeType, err := DetectImageType(upload.File)
+		if err != nil {
+			return nil, err
+		}
+		mimeTypes[i] = mimeType
+	}
+
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return nil, err
+	}
+
+	media := make([]models.NewsMedia, 0, len(uploads))
+	var saved []string
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		// Блокируем новость, чтобы параллельные загрузки не превысили лимит изображений
+		var news models.News
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&news, newsID).Error; err != nil {
+			return err
+		}
+
+		var count int64
+		if err := tx.Model(&models.NewsMedia{}).Where("news_id = ?", newsID).Count(&count).Error; err != nil {
+			return err
+		}
+		if int(count)+len(uploads) > MaxNewsMediaPerPost {
+			return ErrNewsMediaLimit
+		}
+
+		var lastPosition int
+		if err := tx.Model(&models.NewsMedia{}).Where("news_id = ?", newsID).
+			Select("COALESCE(MAX(position), -1)").Scan(&lastPosition).Error; err != nil {
+			return err
+		}
+
+		for i, upload := range uploads {
+			fileName := fmt.Sprintf("%d_%d%s", newsID, time.Now().UnixNano(), newsMediaExtensions[mimeTypes[i]])
+			filePath := filepath.Join(s.dir, fileName)
+			if err := saveUploadedFile(upload.File, filePath); err != nil {
+				return err
+			}
+			saved = append(saved, filePath)
+
+			item := models.NewsMedia{
+				NewsID:     newsID,
+				UploaderID: uploaderID,
+				FilePath:   filePath,
+				MimeType:   mimeTypes[i],
+				Size:       upload.File.Size,
+				Caption:    upload.Caption,
+				Position:   lastPosition + 1 + i,
+			}
+			if err := tx.Create(&item).Error; err != nil {
+				return err
+			}
+			item.SetURL()
+			media = append(media, item)
+		}
+		return nil
+	})
+	if err != nil {
+		RemoveFiles(saved)
+		return nil, err
+	}
+	return media, nil
+}
+
+// List возвращает изображения новости в порядке отображения
+func (s *NewsMediaService) List(newsID uint) ([]models.NewsMedia, error) {
+	var media []models.NewsMedia
+	err := s.db.Scopes(OrderedNewsMedia).Where("news_id = ?", newsID).Find(&media).Error
+	return media, err
+}
+
+// Get возвращает изображение новости
+func (s *NewsMediaService) Get(newsID, mediaID uint) (*models.NewsMedia, error) {
+	var media models.NewsMedia
+	if err := s.db.Where("id = ? AND news_id = ?", mediaID, newsID).First(&media).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, ErrNewsMediaNotFound
+		}
+		return nil, err
+	}
+	return &media, nil
+}
+
+// UpdateCaption изменяет подпись изображения
+func (s *NewsMediaService) UpdateCaption(newsID, mediaID uint, caption string) (*models.NewsMedia, error) {
+	media, err := s.Get(newsID, mediaID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.db.Model(media).Update("caption", caption).Error; err != nil {
+		return nil, err
+	}
+	return media, nil
+}
+
+// Reorder задает новый порядок изображений. Список должен содержать все изображения новости.
+func (s *NewsMediaService) Reorder(newsID uint, mediaIDs []uint) ([]models.NewsMedia, error) {
+	var existing []uint
+	if err := s.db.Model(&models.NewsMedia{}).Where("news_id = ?", newsID).Pluck("id", &existing).Error; err != nil {
+		return nil, err
+	}
+	if len(existing) != len(mediaIDs) {
+		return nil, ErrNewsMediaOrder
+	}
+	known := make(map[uint]bool, len(existing))
+	for _, id := range existing {
+		known[id] = true
+	}
+	for _, id := range mediaIDs {
+		if !known[id] {
+			return nil, ErrNewsMediaOrder
+		}
+		delete(known, id)
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		for position, id := range mediaIDs {
+			if err := tx.Model(&models.NewsMedia{}).Where("id = ?", id).Update("position", position).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.List(newsID)
+}
+
+// Delete удаляет изображение вместе с файлом
+func (s *NewsMediaService) Delete(newsID, mediaID uint) error {
+	media, err := s.Get(newsID, mediaID)
+	if err != nil {
+		return err
+	}
+	if err := s.db.Delete(media).Error; err != nil {
+		return err
+	}
+	RemoveFiles([]string{media.FilePath})
+	return nil
+}
+
+// DeleteNewsMedia удаляет записи об изображениях новостей в транзакции и возвращает пути файлов,
+// которые нужно удалить после ее подтверждения
+func DeleteNewsMedia(tx *gorm.DB, newsIDs interface{}) ([]string, error) {
+	var filePaths []string
+	if err := tx.Model(&models.NewsMedia{}).Where("news_id IN (?)", newsIDs).Pluck("file_path", &filePaths).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Where("news_id IN (?)", newsIDs).Delete(&models.NewsMedia{}).Error; err != nil {
+		return nil, err
+	}
+	return filePaths, nil
+}
+
+// OrderedNewsMedia сортирует изображения в порядке отображения
+func OrderedNewsMedia(db *gorm.DB) *gorm.DB {
+	return db.Order("position ASC, id ASC")
+}
+
+// RemoveFiles удаляет файлы с диска, ошибки только логируются
+func RemoveFiles(paths []string) {
+	for _, path := range paths {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			log.Printf("Ошибка удаления файла %s: %v", path, err)
+		}
+	}
+}
+
+// saveUploadedFile сохраняет загруженный файл на диск
+func saveUploadedFile(file *multipart.FileHeader, path string) error {
+	src, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		return err
+	}
+	return dst.Close()
+}
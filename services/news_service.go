@@ -0,0 +1,139 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"time"
+	"unicode/utf8"
+
+	"toloko-backend/models"
+
+	"gorm.io/gorm"
+)
+
+// newsExcerptLength длина отрывка новости в уведомлении
+const newsExcerptLength = 140
+
+// NewsService предоставляет методы публикации новостей сообществ
+type NewsService struct {
+	db            *gorm.DB
+	notifications *NotificationService
+	mentions      *MentionService
+	achievements  *AchievementService
+}
+
+// NewNewsService создает новый сервис новостей
+func NewNewsService(db *gorm.DB) *NewsService {
+	return &NewsService{
+		db:            db,
+		notifications: NewNotificationService(db),
+		mentions:      NewMentionService(db),
+		achievements:  NewAchievementService(db),
+	}
+}
+
+// Publish публикует черновик или отложенную новость и уведомляет участников сообщества и упомянутых пользователей.
+// Возвращает false, если новость уже была опубликована.
+func (s *NewsService) Publish(newsID uint) (bool, error) {
+	now := time.Now()
+	result := s.db.Model(&models.News{}).
+		Where("id = ? AND status <> ?", newsID, models.NewsStatusPublished).
+		Updates(map[string]interface{}{
+			"status":       models.NewsStatusPublished,
+			"published_at": &now,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return false, nil
+	}
+
+	var news models.News
+	if err := s.db.First(&news, newsID).Error; err != nil {
+		return true, err
+	}
+	s.achievements.Track(news.AuthorID, models.AchievementEventNewsPublished)
+	if err := s.NotifyPublished(&news); err != nil {
+		return true, err
+	}
+	return true, s.mentions.NotifyNews(&news)
+}
+
+// PublishDue публикует отложенные новости, время публикации которых наступило
+func (s *NewsService) PublishDue(now time.Time) (int, error) {
+	var ids []uint
+	if err := s.db.Model(&models.News{}).
+		Where("status = ? AND publish_at <= ?", models.NewsStatusScheduled, now).
+		Order("publish_at ASC").
+		Pluck("id", &ids).Error; err != nil {
+		return 0, err
+	}
+
+	published := 0
+	for _, id := range ids {
+		ok, err := s.Publish(id)
+		if err != nil {
+			return published, err
+		}
+		if ok {
+			published++
+		}
+	}
+	return published, nil
+}
+
+// RunPublisher периодически публикует отложенные новости
+func (s *NewsService) RunPublisher(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if count, err := s.PublishDue(time.Now()); err != nil {
+			log.Printf("Ошибка публикации отложенных новостей: %v", err)
+		} else if count > 0 {
+			log.Printf("Опубликовано отложенных новостей: %d", count)
+		}
+	}
+}
+
+// NotifyPublished рассылает участникам сообщества (кроме автора) уведомление о новости
+func (s *NewsService) NotifyPublished(news *models.News) error {
+	recipients, err := s.Recipients(news)
+	if err != nil {
+		return err
+	}
+	return s.notifications.NotifyMany(recipients, s.BuildNotification(news))
+}
+
+// Recipients возвращает ID участников сообщества, которые получат уведомление о новости
+func (s *NewsService) Recipients(news *models.News) ([]uint, error) {
+	var ids []uint
+	err := s.db.Model(&models.CommunityRole{}).
+		Where("community_id = ? AND user_id <> ?", news.CommunityID, news.AuthorID).
+		Order("user_id ASC").
+		Pluck("user_id", &ids).Error
+	return ids, err
+}
+
+// BuildNotification формирует уведомление о публикации новости
+func (s *NewsService) BuildNotification(news *models.News) models.Notification {
+	var community models.Community
+	s.db.Select("id", "name").First(&community, news.CommunityID)
+
+	return models.Notification{
+		Type:       models.NotificationTypeNewsPublished,
+		Title:      fmt.Sprintf("Новая новость в сообществе «%s»", community.Name),
+		Body:       excerpt(news.Content, newsExcerptLength),
+		EntityType: "news",
+		EntityID:   news.ID,
+	}
+}
+
+// excerpt обрезает текст до указанного количества символов
+func excerpt(text string, length int) string {
+	if utf8.RuneCountInString(text) <= length {
+		return text
+	}
+	return string([]rune(text)[:length]) + "…"
+}
@@ -0,0 +1,149 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"toloko-backend/models"
+
+	"gorm.io/gorm"
+)
+
+// ErrNotificationNotFound уведомление не найдено
+var ErrNotificationNotFound = errors.New("notification not found")
+
+// notificationBatchSize размер пачки при массовой рассылке уведомлений
+const notificationBatchSize = 500
+
+// NotificationPusher доставляет уведомления подключенным клиентам (реализуется Hub)
+type NotificationPusher interface {
+	SendToUser(userID uint, message WSMessage)
+}
+
+// notificationPusher доставка уведомлений в реальном времени, устанавливается при запуске
+var notificationPusher NotificationPusher
+
+// SetNotificationPusher устанавливает доставку уведомлений в реальном времени
+func SetNotificationPusher(pusher NotificationPusher) {
+	notificationPusher = pusher
+}
+
+// deferredPushKey ключ контекста транзакции, в котором копятся уведомления до ее фиксации
+type deferredPushKey struct{}
+
+// TransactionWithNotifications выполняет транзакцию, а созданные в ней уведомления доставляет
+// в реальном времени только после фиксации; при откате уведомления не отправляются
+func TransactionWithNotifications(db *gorm.DB, fn func(tx *gorm.DB) error) error {
+	parent := db.Statement.Context
+	if parent == nil {
+		parent = context.Background()
+	}
+	var pending []models.Notification
+	ctx := context.WithValue(parent, deferredPushKey{}, &pending)
+	if err := db.WithContext(ctx).Transaction(fn); err != nil {
+		return err
+	}
+	pushNotifications(pending)
+	return nil
+}
+
+// pushNotifications доставляет уведомления подключенным клиентам
+func pushNotifications(notifications []models.Notification) {
+	if notificationPusher == nil {
+		return
+	}
+	for _, notification := range notifications {
+		notificationPusher.SendToUser(notification.UserID, WSMessage{
+			Type:    "notification",
+			Payload: notification,
+		})
+	}
+}
+
+// NotificationService предоставляет методы для работы с уведомлениями
+type NotificationService struct {
+	db *gorm.DB
+}
+
+// NewNotificationService создает новый сервис уведомлений
+func NewNotificationService(db *gorm.DB) *NotificationService {
+	return &NotificationService{db: db}
+}
+
+// NotifyMany создает одинаковое уведомление для каждого пользователя из списка
+// и отправляет его подключенным клиентам
+func (s *NotificationService) NotifyMany(userIDs []uint, template models.Notification) error {
+	if len(userIDs) == 0 {
+		return nil
+	}
+
+	notifications := make([]models.Notification, 0, len(userIDs))
+	for _, userID := range userIDs {
+		notification := template
+		notification.ID = 0
+		notification.UserID = userID
+		notifications = append(notifications, notification)
+	}
+
+	if err := s.db.CreateInBatches(&notifications, notificationBatchSize).Error; err != nil {
+		return err
+	}
+
+	if ctx := s.db.Statement.Context; ctx != nil {
+		if pending, ok := ctx.Value(deferredPushKey{}).(*[]models.Notification); ok {
+			*pending = append(*pending, notifications...)
+			return nil
+		}
+	}
+	pushNotifications(notifications)
+	return nil
+}
+
+// List возвращает уведомления пользователя, новые сначала
+func (s *NotificationService) List(userID uint, unreadOnly bool, page, limit int) ([]models.Notification, int64, error) {
+	query := s.db.Model(&models.Notification{}).Where("user_id = ?", userID)
+	if unreadOnly {
+		query = query.Where("is_read = ?", false)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var notifications []models.Notification
+	err := query.Order("created_at DESC, id DESC").Limit(limit).Offset((page - 1) * limit).Find(&notifications).Error
+	return notifications, total, err
+}
+
+// UnreadCount возвращает количество непрочитанных уведомлений
+func (s *NotificationService) UnreadCount(userID uint) (int64, error) {
+	var count int64
+	err := s.db.Model(&models.Notification{}).Where("user_id = ? AND is_read = ?", userID, false).Count(&count).Error
+	return count, err
+}
+
+// MarkRead отмечает уведомление прочитанным
+func (s *NotificationService) MarkRead(userID, notificationID uint) error {
+	now := time.Now()
+	result := s.db.Model(&models.Notification{}).
+		Where("id = ? AND user_id = ?", notificationID, userID).
+		Updates(map[string]interface{}{"is_read": true, "read_at": &now})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotificationNotFound
+	}
+	return nil
+}
+
+// MarkAllRead отмечает все уведомления пользователя прочитанными
+func (s *NotificationService) MarkAllRead(userID uint) (int64, error) {
+	now := time.Now()
+	result := s.db.Model(&models.Notification{}).
+		Where("user_id = ? AND is_read = ?", userID, false).
+		Updates(map[string]interface{}{"is_read": true, "read_at": &now})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,210 @@
+package services
+
+import (
+	"errors"
+
+	"toloko-backend/models"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrUnknownRole роль не зарегистрирована в реестре
+	ErrUnknownRole = errors.New("unknown role")
+	// ErrRoleAlreadyGranted роль уже выдана пользователю
+	ErrRoleAlreadyGranted = errors.New("role already granted")
+	// ErrRoleNotGranted роль не выдана пользователю
+	ErrRoleNotGranted = errors.New("role not granted")
+	// ErrLastAdmin нельзя отозвать роль у последнего администратора
+	ErrLastAdmin = errors.New("cannot revoke the last admin")
+)
+
+// PermissionService предоставляет методы для проверки ролей и разрешений
+type PermissionService struct {
+	db *gorm.DB
+}
+
+// NewPermissionService создает новый сервис разрешений
+func NewPermissionService(db *gorm.DB) *PermissionService {
+	return &PermissionService{db: db}
+}
+
+// GetUserRoles возвращает глобальные роли пользователя
+func (s *PermissionService) GetUserRoles(userID uint) ([]string, error) {
+	var roles []string
+	err := s.db.Model(&models.UserRole{}).
+		Where("user_id = ?", userID).
+		Order("role").
+		Pluck("role", &roles).Error
+	return roles, err
+}
+
+// GetEffectiveRoles возвращает действующие роли пользователя.
+// Роли, для которых обязательна 2FA, не действуют, пока пользователь ее не включил.
+func (s *PermissionService) GetEffectiveRoles(userID uint) ([]string, error) {
+	roles, err := s.GetUserRoles(userID)
+	if err != nil || len(roles) == 0 {
+		return roles, err
+	}
+
+	required := TwoFactorRequiredRoles()
+	if len(required) == 0 {
+		return roles, nil
+	}
+
+	var twoFactorEnabled *bool
+	effective := make([]string, 0, len(roles))
+	for _, role := range roles {
+		if required[role] {
+			if twoFactorEnabled == nil {
+				enabled, err := isTwoFactorEnabled(s.db, userID)
+				if err != nil {
+					return nil, err
+				}
+				twoFactorEnabled = &enabled
+			}
+			if !*twoFactorEnabled {
+				continue
+			}
+		}
+		effective = append(effective, role)
+	}
+
+	return effective, nil
+}
+
+// GetUserPermissions возвращает все разрешения пользователя, полученные через глобальные роли
+func (s *PermissionService) GetUserPermissions(userID uint) ([]string, error) {
+	roles, err := s.GetEffectiveRoles(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	permissions := []string{}
+	for _, role := range roles {
+		for _, permission := range models.GetRolePermissions(role) {
+			if !seen[permission] {
+				seen[permission] = true
+				permissions = append(permissions, permission)
+			}
+		}
+	}
+
+	return permissions, nil
+}
+
+// HasRole проверяет, есть ли у пользователя глобальная роль
+func (s *PermissionService) HasRole(userID uint, role string) (bool, error) {
+	var count int64
+	err := s.db.Model(&models.UserRole{}).
+		Where("user_id = ? AND role = ?", userID, role).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// HasPermission проверяет, есть ли у пользователя разрешение платформы
+func (s *PermissionService) HasPermission(userID uint, permission string) (bool, error) {
+	roles, err := s.GetEffectiveRoles(userID)
+	if err != nil {
+		return false, err
+	}
+
+	for _, role := range roles {
+		if models.RoleHasPermission(role, permission) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// HasCommunityPermission проверяет, есть ли у пользователя разрешение в сообществе.
+// Сначала проверяется роль в сообществе, затем глобальные роли с правом управления любым сообществом.
+func (s *PermissionService) HasCommunityPermission(userID, communityID uint, permission string) (bool, error) {
+	var role models.CommunityRole
+	err := s.db.Where("user_id = ? AND community_id = ?", userID, communityID).First(&role).Error
+	if err == nil && models.CommunityRoleHasPermission(role.Role, permission) {
+		// Роль в сообществе не действует без обязательной 2FA
+		if !TwoFactorRequiredCommunityRoles()[role.Role] {
+			return true, nil
+		}
+		enabled, err := isTwoFactorEnabled(s.db, userID)
+		if err != nil {
+			return false, err
+		}
+		if enabled {
+			return true, nil
+		}
+	}
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return false, err
+	}
+
+	return s.HasPermission(userID, models.PermissionCommunitiesManage)
+}
+
+// GrantRole выдает пользователю глобальную роль
+func (s *PermissionService) GrantRole(userID uint, role string, grantedBy uint) (*models.UserRole, error) {
+	if !models.IsValidRole(role) {
+		return nil, ErrUnknownRole
+	}
+
+	hasRole, err := s.HasRole(userID, role)
+	if err != nil {
+		return nil, err
+	}
+	if hasRole {
+		return nil, ErrRoleAlreadyGranted
+	}
+
+	userRole := &models.UserRole{
+		UserID:    userID,
+		Role:      role,
+		GrantedBy: grantedBy,
+	}
+	if err := s.db.Create(userRole).Error; err != nil {
+		return nil, err
+	}
+
+	return userRole, nil
+}
+
+// RevokeRole отзывает у пользователя глобальную роль
+func (s *PermissionService) RevokeRole(userID uint, role string) error {
+	if !models.IsValidRole(role) {
+		return ErrUnknownRole
+	}
+
+	hasRole, err := s.HasRole(userID, role)
+	if err != nil {
+		return err
+	}
+	if !hasRole {
+		return ErrRoleNotGranted
+	}
+
+	// Не даем оставить платформу без администраторов
+	if role == models.RoleAdmin {
+		var adminCount int64
+		if err := s.db.Model(&models.UserRole{}).Where("role = ?", models.RoleAdmin).Count(&adminCount).Error; err != nil {
+			return err
+		}
+		if adminCount <= 1 {
+			return ErrLastAdmin
+		}
+	}
+
+	return s.db.Where("user_id = ? AND role = ?", userID, role).Delete(&models.UserRole{}).Error
+}
+
+// GetRoleHolders возвращает список выданных ролей, опционально отфильтрованный по роли
+func (s *PermissionService) GetRoleHolders(role string) ([]models.UserRole, error) {
+	var userRoles []models.UserRole
+	query := s.db.Preload("User").Order("created_at DESC")
+	if role != "" {
+		query = query.Where("role = ?", role)
+	}
+	err := query.Find(&userRoles).Error
+	return userRoles, err
+}
@@ -0,0 +1,204 @@
+package services
+
+import (
+	"fmt"
+
+	"toloko-backend/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Очки, начисляемые правилами сервера
+const (
+	PointsForParticipation = 20 // участие с отметкой о присутствии
+	PointsForOrganization  = 50 // проведение завершенного ивента
+)
+
+// PointsService предоставляет методы для работы с журналом очков
+type PointsService struct {
+	db *gorm.DB
+}
+
+// NewPointsService создает новый сервис очков
+func NewPointsService(db *gorm.DB) *PointsService {
+	return &PointsService{db: db}
+}
+
+// History возвращает записи журнала очков пользователя, начиная с последних
+func (s *PointsService) History(userID uint, page, limit int) ([]models.PointsEntry, int64, error) {
+	query := s.db.Model(&models.PointsEntry{}).Where("user_id = ?", userID)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var entries []models.PointsEntry
+	err := query.Session(&gorm.Session{}).Order("created_at DESC, id DESC").
+		Limit(limit).Offset((page - 1) * limit).Find(&entries).Error
+	return entries, total, err
+}
+
+// Backfill переносит в журнал очки за уже полученные достижения и пересчитывает баланс всех пользователей.
+// Очки, добавленные пользователями вручную, в журнал не попадают.
+func (s *PointsService) Backfill() error {
+	var earned []models.UserAchievement
+	if err := s.db.Preload("Achievement").Find(&earned).Error; err != nil {
+		return err
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		for _, userAchievement := range earned {
+			if _, err := GrantAchievementPoints(tx, userAchievement.UserID, &userAchievement.Achievement); err != nil {
+				return err
+			}
+		}
+
+		var userIDs []uint
+		if err := tx.Model(&models.UserLevel{}).Pluck("user_id", &userIDs).Error; err != nil {
+			return err
+		}
+		for _, userID := range userIDs {
+			if err := RecalculateBalance(tx, userID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// GrantPoints добавляет запись в журнал и пересчитывает баланс пользователя.
+// Повторное начисление с тем же ключом игнорируется, возвращается false.
+func GrantPoints(tx *gorm.DB, entry models.PointsEntry) (bool, error) {
+	if entry.Amount == 0 {
+		return false, nil
+	}
+
+	result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&entry)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return false, nil
+	}
+	return true, RecalculateBalance(tx, entry.UserID)
+}
+
+// GrantParticipationPoints начисляет очки за участие в ивенте с отметкой о присутствии
+func GrantParticipationPoints(tx *gorm.DB, userID, eventID uint) (bool, error) {
+	return GrantPoints(tx, models.PointsEntry{
+		UserID:         userID,
+		Amount:         PointsForParticipation,
+		ReasonCode:     models.PointsReasonParticipation,
+		SourceType:     models.PointsSourceEvent,
+		SourceID:       eventID,
+		EventID:        &eventID,
+		IdempotencyKey: fmt.Sprintf("participation:event:%d:user:%d", eventID, userID),
+	})
+}
+
+// GrantOrganizationPoints начисляет организатору очки за проведенный ивент
+func GrantOrganizationPoints(tx *gorm.DB, event *models.Event) (bool, error) {
+	return GrantPoints(tx, models.PointsEntry{
+		UserID:         event.CreatorID,
+		Amount:         PointsForOrganization,
+		ReasonCode:     models.PointsReasonOrganization,
+		SourceType:     models.PointsSourceEvent,
+		SourceID:       event.ID,
+		EventID:        &event.ID,
+		IdempotencyKey: fmt.Sprintf("organization:event:%d", event.ID),
+	})
+}
+
+// GrantAchievementPoints начисляет очки за полученное достижение
+func GrantAchievementPoints(tx *gorm.DB, userID uint, achievement *models.Achievement) (bool, error) {
+	return GrantPoints(tx, models.PointsEntry{
+		UserID:         userID,
+		Amount:         achievement.Points,
+		ReasonCode:     models.PointsReasonAchievement,
+		SourceType:     models.PointsSourceAchievement,
+		SourceID:       achievement.ID,
+		IdempotencyKey: fmt.Sprintf("achievement:%d:user:%d", achievement.ID, userID),
+	})
+}
+
+// ReverseEventPoints отменяет все очки, начисленные за отмененный ивент
+func ReverseEventPoints(tx *gorm.DB, eventID uint) (int, error) {
+	query := tx.Where("source_type = ? AND source_id = ?", models.PointsSourceEvent, eventID)
+	return reverseEntries(tx, query, models.PointsSourceEvent, eventID)
+}
+
+// ReverseComplaintPoints отменяет очки пользователя за ивент, если жалоба на его участие подтверждена
+func ReverseComplaintPoints(tx *gorm.DB, complaint *models.Complaint) (int, error) {
+	if complaint.EventID == nil {
+		return 0, nil
+	}
+	query := tx.Where("source_type = ? AND source_id = ? AND user_id = ?", models.PointsSourceEvent, *complaint.EventID, complaint.AboutUserID)
+	return reverseEntries(tx, query, models.PointsSourceComplaint, complaint.ID)
+}
+
+// reverseEntries создает отменяющие записи для еще не отмененных начислений
+func reverseEntries(tx *gorm.DB, query *gorm.DB, sourceType string, sourceID uint) (int, error) {
+	var entries []models.PointsEntry
+	if err := query.Where("reason_code <> ?", models.PointsReasonReversal).
+		Where("id NOT IN (?)", tx.Model(&models.PointsEntry{}).Select("reversal_of").Where("reversal_of IS NOT NULL")).
+		Find(&entries).Error; err != nil {
+		return 0, err
+	}
+
+	for _, entry := range entries {
+		reversed := entry.ID
+		if _, err := GrantPoints(tx, models.PointsEntry{
+			UserID:         entry.UserID,
+			Amount:         -entry.Amount,
+			ReasonCode:     models.PointsReasonReversal,
+			SourceType:     sourceType,
+			SourceID:       sourceID,
+			EventID:        entry.EventID,
+			IdempotencyKey: fmt.Sprintf("reversal:%d", entry.ID),
+			ReversalOf:     &reversed,
+		}); err != nil {
+			return 0, err
+		}
+	}
+	return len(entries), nil
+}
+
+// RecalculateBalance пересчитывает очки и уровень пользователя по журналу.
+// При первом достижении уровня создается событие повышения уровня.
+func RecalculateBalance(tx *gorm.DB, userID uint) error {
+	var points int
+	if err := tx.Model(&models.PointsEntry{}).Where("user_id = ?", userID).
+		Select("COALESCE(SUM(amount), 0)").Scan(&points).Error; err != nil {
+		return err
+	}
+
+	curve, err := loadLevelCurve(tx)
+	if err != nil {
+		return err
+	}
+	level := LevelFor(curve, points)
+
+	// Уровень, потерянный после отмены очков и полученный снова, повторно не объявляется
+	highest := 1
+	var existing models.UserLevel
+	if err := tx.Where("user_id = ?", userID).First(&existing).Error; err == nil {
+		highest = max(existing.Level, existing.HighestLevel)
+	} else if err != gorm.ErrRecordNotFound {
+		return err
+	}
+
+	userLevel := models.UserLevel{UserID: userID, Level: level.Level, HighestLevel: max(highest, level.Level), Points: points}
+	if err := tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"level", "highest_level", "points", "updated_at"}),
+	}).Create(&userLevel).Error; err != nil {
+		return err
+	}
+
+	if level.Level > highest {
+		return emitLevelUp(tx, userID, level)
+	}
+	return nil
+}
@@ -0,0 +1,424 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"toloko-backend/models"
+	"toloko-backend/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Названия политик ограничения частоты
+const (
+	PolicyAuthLogin        = "auth.login"
+	PolicyAuthTwoFactor    = "auth.2fa"
+	PolicyAuthRecover      = "auth.recover"
+	PolicyAuthRegister     = "auth.register"
+	PolicyMessagesSend     = "messages.send"
+	PolicyTyping           = "typing"
+	PolicyComplaintsSubmit = "complaints.submit"
+	PolicyUploads          = "uploads"
+	PolicyAccountExport    = "account.export"
+
+	// Способы определения ключа ограничения
+	RateLimitKeyByIP   = "ip"
+	RateLimitKeyByUser = "user"
+)
+
+// RateLimitPolicy описывает политику ограничения частоты запросов
+type RateLimitPolicy struct {
+	Name   string
+	Limit  int
+	Window time.Duration
+	KeyBy  string // "ip" или "user" (для неавторизованных запросов используется IP)
+}
+
+// RateLimitResult результат проверки ограничения
+type RateLimitResult struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	ResetAt    time.Time
+	RetryAfter time.Duration
+}
+
+// RateLimitStore хранилище счетчиков ограничения частоты
+type RateLimitStore interface {
+	// Increment увеличивает счетчик ключа и возвращает новое значение и время сброса окна
+	Increment(key string, window time.Duration) (int, time.Time, error)
+	// Get возвращает текущее значение счетчика и время сброса окна
+	Get(key string) (int, time.Time, error)
+	// Reset сбрасывает счетчик
+	Reset(key string) error
+	// Cleanup удаляет истекшие счетчики
+	Cleanup() error
+}
+
+// DefaultRateLimitPolicies возвращает политики ограничения по умолчанию
+func DefaultRateLimitPolicies() map[string]RateLimitPolicy {
+	policies := []RateLimitPolicy{
+		{Name: PolicyAuthLogin, Limit: 10, Window: time.Minute, KeyBy: RateLimitKeyByIP},
+		{Name: PolicyAuthTwoFactor, Limit: 10, Window: time.Minute, KeyBy: RateLimitKeyByIP},
+		{Name: PolicyAuthRecover, Limit: 5, Window: time.Hour, KeyBy: RateLimitKeyByIP},
+		{Name: PolicyAuthRegister, Limit: 10, Window: time.Hour, KeyBy: RateLimitKeyByIP},
+		{Name: PolicyMessagesSend, Limit: 30, Window: time.Minute, KeyBy: RateLimitKeyByUser},
+		{Name: PolicyTyping, Limit: 120, Window: time.Minute, KeyBy: RateLimitKeyByUser},
+		{Name: PolicyComplaintsSubmit, Limit: 10, Window: time.Hour, KeyBy: RateLimitKeyByUser},
+		{Name: PolicyUploads, Limit: 30, Window: 10 * time.Minute, KeyBy: RateLimitKeyByUser},
+		{Name: PolicyAccountExport, Limit: 3, Window: time.Hour, KeyBy: RateLimitKeyByUser},
+	}
+
+	result := make(map[string]RateLimitPolicy, len(policies))
+	for _, policy := range policies {
+		result[policy.Name] = policy
+	}
+	return result
+}
+
+// LoadRateLimitPolicies возвращает политики по умолчанию с переопределениями из окружения.
+// Формат переменной: RATE_LIMIT_AUTH_LOGIN=20/1m
+func LoadRateLimitPolicies() map[string]RateLimitPolicy {
+	policies := DefaultRateLimitPolicies()
+
+	for name, policy := range policies {
+		envName := "RATE_LIMIT_" + strings.ToUpper(strings.ReplaceAll(name, ".", "_"))
+		value := os.Getenv(envName)
+		if value == "" {
+			continue
+		}
+
+		limit, window, err := parseRateLimitValue(value)
+		if err != nil {
+			log.Printf("Неверное значение %s: %v", envName, err)
+			continue
+		}
+
+		policy.Limit = limit
+		policy.Window = window
+		policies[name] = policy
+	}
+
+	return policies
+}
+
+// parseRateLimitValue разбирает значение вида "20/1m"
+func parseRateLimitValue(value string) (int, time.Duration, error) {
+	parts := strings.Split(value, "/")
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("expected format limit/window")
+	}
+
+	limit, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+	if err != nil || limit <= 0 {
+		return 0, 0, fmt.Errorf("invalid limit %q", parts[0])
+	}
+
+	window, err := time.ParseDuration(strings.TrimSpace(parts[1]))
+	if err != nil || window <= 0 {
+		return 0, 0, fmt.Errorf("invalid window %q", parts[1])
+	}
+
+	return limit, window, nil
+}
+
+// NewRateLimitStore создает хранилище согласно переменной окружения RATE_LIMIT_STORE.
+// "db" - общее хранилище в базе данных для нескольких инстансов, иначе - в памяти.
+func NewRateLimitStore(db *gorm.DB) RateLimitStore {
+	if os.Getenv("RATE_LIMIT_STORE") == "db" {
+		return NewDBRateLimitStore(db)
+	}
+	return NewMemoryRateLimitStore()
+}
+
+// RateLimiter проверяет запросы по политикам ограничения частоты
+type RateLimiter struct {
+	store      RateLimitStore
+	policies   map[string]RateLimitPolicy
+	wsPolicies map[string]string
+}
+
+// NewRateLimiter создает новый ограничитель частоты
+func NewRateLimiter(store RateLimitStore, policies map[string]RateLimitPolicy) *RateLimiter {
+	return &RateLimiter{
+		store:    store,
+		policies: policies,
+		wsPolicies: map[string]string{
+			"message.send": PolicyMessagesSend,
+			"typing.start": PolicyTyping,
+			"typing.stop":  PolicyTyping,
+		},
+	}
+}
+
+// Allow учитывает запрос субъекта по политике и возвращает результат проверки
+func (rl *RateLimiter) Allow(policyName, subject string) (RateLimitResult, error) {
+	policy, exists := rl.policies[policyName]
+	if !exists {
+		return RateLimitResult{Allowed: true}, nil
+	}
+
+	count, resetAt, err := rl.store.Increment(policy.Name+":"+subject, policy.Window)
+	if err != nil {
+		return RateLimitResult{Allowed: true}, err
+	}
+
+	result := RateLimitResult{
+		Allowed:   count <= policy.Limit,
+		Limit:     policy.Limit,
+		Remaining: policy.Limit - count,
+		ResetAt:   resetAt,
+	}
+	if result.Remaining < 0 {
+		result.Remaining = 0
+	}
+	if !result.Allowed {
+		result.RetryAfter = time.Until(resetAt)
+	}
+
+	return result, nil
+}
+
+// Middleware возвращает Fiber middleware для политики.
+// Если указаны методы, ограничение применяется только к ним.
+func (rl *RateLimiter) Middleware(policyName string, methods ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if len(methods) > 0 && !containsMethod(methods, c.Method()) {
+			return c.Next()
+		}
+
+		policy, exists := rl.policies[policyName]
+		if !exists {
+			return c.Next()
+		}
+
+		result, err := rl.Allow(policyName, rateLimitSubject(c, policy))
+		if err != nil {
+			// Ошибка хранилища не должна блокировать запросы
+			log.Printf("Rate limit store error: %v", err)
+			return c.Next()
+		}
+
+		c.Set("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+		c.Set("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		c.Set("X-RateLimit-Reset", strconv.FormatInt(result.ResetAt.Unix(), 10))
+
+		if !result.Allowed {
+			return RespondTooManyRequests(c, result.RetryAfter)
+		}
+
+		return c.Next()
+	}
+}
+
+// AllowWSMessage проверяет сообщение WebSocket по политике его типа
+func (rl *RateLimiter) AllowWSMessage(userID uint, messageType string) RateLimitResult {
+	policyName, exists := rl.wsPolicies[messageType]
+	if !exists {
+		return RateLimitResult{Allowed: true}
+	}
+
+	result, err := rl.Allow(policyName, fmt.Sprintf("user:%d", userID))
+	if err != nil {
+		log.Printf("Rate limit store error: %v", err)
+		return RateLimitResult{Allowed: true}
+	}
+	return result
+}
+
+// RunCleanup периодически удаляет истекшие счетчики
+func (rl *RateLimiter) RunCleanup(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if err := rl.store.Cleanup(); err != nil {
+			log.Printf("Rate limit cleanup error: %v", err)
+		}
+	}
+}
+
+// RespondTooManyRequests отправляет ответ 429 с заголовком Retry-After
+func RespondTooManyRequests(c *fiber.Ctx, retryAfter time.Duration) error {
+	seconds := retryAfterSeconds(retryAfter)
+	c.Set(fiber.HeaderRetryAfter, strconv.Itoa(seconds))
+	return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+		"error":       "Too many requests",
+		"retry_after": seconds,
+	})
+}
+
+// retryAfterSeconds округляет время ожидания вверх до секунд
+func retryAfterSeconds(retryAfter time.Duration) int {
+	seconds := int((retryAfter + time.Second - 1) / time.Second)
+	if seconds < 1 {
+		seconds = 1
+	}
+	return seconds
+}
+
+// rateLimitSubject определяет ключ субъекта запроса по политике
+func rateLimitSubject(c *fiber.Ctx, policy RateLimitPolicy) string {
+	if policy.KeyBy == RateLimitKeyByUser {
+		if userID, ok := c.Locals("user_id").(uint); ok {
+			return fmt.Sprintf("user:%d", userID)
+		}
+
+		// Маршруты со встроенной проверкой токена выполняются до контроллера,
+		// поэтому пытаемся извлечь пользователя из заголовка
+		tokenString := strings.TrimPrefix(c.Get("Authorization"), "Bearer ")
+		if tokenString != "" {
+			if claims, err := utils.ValidateJWT(tokenString); err == nil {
+				return fmt.Sprintf("user:%d", claims.UserID)
+			}
+		}
+	}
+
+	return "ip:" + c.IP()
+}
+
+// containsMethod проверяет, входит ли метод в список
+func containsMethod(methods []string, method string) bool {
+	for _, m := range methods {
+		if strings.EqualFold(m, method) {
+			return true
+		}
+	}
+	return false
+}
+
+// MemoryRateLimitStore хранилище счетчиков в памяти одного инстанса
+type MemoryRateLimitStore struct {
+	counters map[string]*memoryRateLimitCounter
+	mutex    sync.Mutex
+}
+
+type memoryRateLimitCounter struct {
+	count   int
+	resetAt time.Time
+}
+
+// NewMemoryRateLimitStore создает хранилище счетчиков в памяти
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{
+		counters: make(map[string]*memoryRateLimitCounter),
+	}
+}
+
+// Increment увеличивает счетчик ключа
+func (s *MemoryRateLimitStore) Increment(key string, window time.Duration) (int, time.Time, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := time.Now()
+	counter, exists := s.counters[key]
+	if !exists || !counter.resetAt.After(now) {
+		counter = &memoryRateLimitCounter{resetAt: now.Add(window)}
+		s.counters[key] = counter
+	}
+	counter.count++
+
+	return counter.count, counter.resetAt, nil
+}
+
+// Get возвращает текущее значение счетчика
+func (s *MemoryRateLimitStore) Get(key string) (int, time.Time, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	counter, exists := s.counters[key]
+	if !exists || !counter.resetAt.After(time.Now()) {
+		return 0, time.Time{}, nil
+	}
+
+	return counter.count, counter.resetAt, nil
+}
+
+// Reset сбрасывает счетчик
+func (s *MemoryRateLimitStore) Reset(key string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.counters, key)
+	return nil
+}
+
+// Cleanup удаляет истекшие счетчики
+func (s *MemoryRateLimitStore) Cleanup() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := time.Now()
+	for key, counter := range s.counters {
+		if !counter.resetAt.After(now) {
+			delete(s.counters, key)
+		}
+	}
+	return nil
+}
+
+// DBRateLimitStore хранилище счетчиков в базе данных, общее для всех инстансов
+type DBRateLimitStore struct {
+	db *gorm.DB
+}
+
+// NewDBRateLimitStore создает хранилище счетчиков в базе данных
+func NewDBRateLimitStore(db *gorm.DB) *DBRateLimitStore {
+	return &DBRateLimitStore{db: db}
+}
+
+// Increment атомарно увеличивает счетчик ключа, начиная новое окно для истекших счетчиков
+func (s *DBRateLimitStore) Increment(key string, window time.Duration) (int, time.Time, error) {
+	now := time.Now().UTC()
+	resetAt := now.Add(window)
+
+	counter := models.RateLimitCounter{Key: key, Count: 1, ResetAt: resetAt}
+	err := s.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "key"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"count":    gorm.Expr("CASE WHEN rate_limit_counters.reset_at <= ? THEN 1 ELSE rate_limit_counters.count + 1 END", now),
+			"reset_at": gorm.Expr("CASE WHEN rate_limit_counters.reset_at <= ? THEN ? ELSE rate_limit_counters.reset_at END", now, resetAt),
+		}),
+	}).Create(&counter).Error
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+
+	if err := s.db.Where("key = ?", key).First(&counter).Error; err != nil {
+		return 0, time.Time{}, err
+	}
+
+	return counter.Count, counter.ResetAt, nil
+}
+
+// Get возвращает текущее значение счетчика
+func (s *DBRateLimitStore) Get(key string) (int, time.Time, error) {
+	var counter models.RateLimitCounter
+	err := s.db.Where("key = ? AND reset_at > ?", key, time.Now().UTC()).First(&counter).Error
+	if err == gorm.ErrRecordNotFound {
+		return 0, time.Time{}, nil
+	}
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+
+	return counter.Count, counter.ResetAt, nil
+}
+
+// Reset сбрасывает счетчик
+func (s *DBRateLimitStore) Reset(key string) error {
+	return s.db.Where("key = ?", key).Delete(&models.RateLimitCounter{}).Error
+}
+
+// Cleanup удаляет истекшие счетчики
+func (s *DBRateLimitStore) Cleanup() error {
+	return s.db.Where("reset_at <= ?", time.Now().UTC()).Delete(&models.RateLimitCounter{}).Error
+}
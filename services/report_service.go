@@ -0,0 +1,320 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+
+	"toloko-backend/models"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrInvalidReportTarget неизвестный тип объекта жалобы
+	ErrInvalidReportTarget = errors.New("invalid report target type")
+	// ErrReportTargetNotFound объект жалобы не найден или недоступен пользователю
+	ErrReportTargetNotFound = errors.New("report target not found")
+	// ErrReportSelf нельзя пожаловаться на себя или свой контент
+	ErrReportSelf = errors.New("cannot report yourself")
+	// ErrReportDuplicate жалоба на этот объект уже подана
+	ErrReportDuplicate = errors.New("report already submitted")
+	// ErrReportTargetNotParticipant пользователь, на которого жалуются, не участвовал в ивенте
+	ErrReportTargetNotParticipant = errors.New("reported user did not participate in the event")
+	// ErrReporterNotParticipant жалующийся не участвовал в ивенте
+	ErrReporterNotParticipant = errors.New("reporter did not participate in the event")
+)
+
+// ReportTarget описывает объект жалобы и очередь, в которую она попадет
+type ReportTarget struct {
+	Type        string
+	ID          uint
+	AboutUserID uint                   // автор контента или сам пользователь
+	EventID     *uint                  // ивент, в котором произошел инцидент
+	CommunityID *uint                  // nil - жалобу рассматривают модераторы платформы
+	Snapshot    map[string]interface{} // копия контента на момент жалобы
+}
+
+// ReportService предоставляет методы для подачи и рассмотрения жалоб
+type ReportService struct {
+	db          *gorm.DB
+	permissions *PermissionService
+}
+
+// NewReportService создает новый сервис жалоб
+func NewReportService(db *gorm.DB) *ReportService {
+	return &ReportService{
+		db:          db,
+		permissions: NewPermissionService(db),
+	}
+}
+
+// ResolveTarget находит объект жалобы, проверяет, что он доступен пользователю, и снимает копию контента.
+// Жалобы на новости и комментарии попадают в очередь сообщества, остальные - в очередь платформы.
+func (s *ReportService) ResolveTarget(reporterID uint, targetType string, targetID uint) (*ReportTarget, error) {
+	if !models.IsValidComplaintTarget(targetType) {
+		return nil, ErrInvalidReportTarget
+	}
+
+	target := &ReportTarget{Type: targetType, ID: targetID}
+	var err error
+	switch targetType {
+	case models.ComplaintTargetUser:
+		err = s.resolveUser(target)
+	case models.ComplaintTargetMessage:
+		err = s.resolveMessage(target, reporterID)
+	case models.ComplaintTargetAttachment:
+		err = s.resolveAttachment(target, reporterID)
+	case models.ComplaintTargetNews:
+		err = s.resolveNews(target, reporterID)
+	case models.ComplaintTargetComment:
+		err = s.resolveComment(target, reporterID)
+	case models.ComplaintTargetCommunity:
+		err = s.resolveCommunity(target)
+	}
+	if err == gorm.ErrRecordNotFound {
+		return nil, ErrReportTargetNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if target.AboutUserID == reporterID {
+		return nil, ErrReportSelf
+	}
+	return target, nil
+}
+
+// EventParticipantTarget формирует жалобу на участника ивента. Оба пользователя должны были участвовать в ивенте.
+func (s *ReportService) EventParticipantTarget(eventID, reporterID, aboutUserID uint) (*ReportTarget, error) {
+	if aboutUserID == reporterID {
+		return nil, ErrReportSelf
+	}
+
+	var event models.Event
+	if err := s.db.First(&event, eventID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, ErrReportTargetNotFound
+		}
+		return nil, err
+	}
+
+	participated := func(userID uint) (bool, error) {
+		var count int64
+		err := s.db.Model(&models.EventParticipant{}).
+			Where("event_id = ? AND user_id = ? AND status IN ?", eventID, userID,
+				[]string{models.ParticipantStatusJoined, models.ParticipantStatusAccepted}).
+			Count(&count).Error
+		return count > 0, err
+	}
+	if ok, err := participated(aboutUserID); err != nil {
+		return nil, err
+	} else if !ok {
+		return nil, ErrReportTargetNotParticipant
+	}
+	if ok, err := participated(reporterID); err != nil {
+		return nil, err
+	} else if !ok {
+		return nil, ErrReporterNotParticipant
+	}
+
+	target := &ReportTarget{Type: models.ComplaintTargetUser, ID: aboutUserID, EventID: &eventID}
+	if err := s.resolveUser(target); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, ErrReportTargetNotFound
+		}
+		return nil, err
+	}
+	target.Snapshot["event_title"] = event.Title
+	return target, nil
+}
+
+// Submit подает жалобу. Повторная жалоба на тот же объект возможна только после рассмотрения предыдущей,
+// жалоба на участника ивента подается один раз.
+func (s *ReportService) Submit(reporterID uint, target *ReportTarget, reasonCode, reasonText string) (*models.Complaint, error) {
+	query := s.db.Model(&models.Complaint{}).
+		Where("from_user_id = ? AND target_type = ? AND target_id = ?", reporterID, target.Type, target.ID)
+	if target.EventID != nil {
+		query = query.Where("event_id = ?", *target.EventID)
+	} else {
+		query = query.Where("status IN ?", []string{models.ComplaintStatusOpen, models.ComplaintStatusUnderReview})
+	}
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, ErrReportDuplicate
+	}
+
+	snapshot, err := json.Marshal(target.Snapshot)
+	if err != nil {
+		return nil, err
+	}
+
+	complaint := models.Complaint{
+		TargetType:  target.Type,
+		TargetID:    target.ID,
+		EventID:     target.EventID,
+		CommunityID: target.CommunityID,
+		FromUserID:  reporterID,
+		AboutUserID: target.AboutUserID,
+		ReasonCode:  reasonCode,
+		ReasonText:  reasonText,
+		Snapshot:    string(snapshot),
+		Status:      models.ComplaintStatusOpen,
+	}
+	if err := s.db.Create(&complaint).Error; err != nil {
+		return nil, err
+	}
+	return &complaint, nil
+}
+
+// CanReview проверяет, может ли пользователь работать с жалобой: жалобы сообщества доступны
+// его модераторам, остальные - модераторам платформы с указанным разрешением.
+// Жалобу на себя рассматривает кто-то другой.
+func (s *ReportService) CanReview(userID uint, complaint *models.Complaint, permission string) bool {
+	if complaint.AboutUserID == userID {
+		return false
+	}
+	if complaint.CommunityID != nil {
+		allowed, err := s.permissions.HasCommunityPermission(userID, *complaint.CommunityID, models.PermissionCommunityReportsManage)
+		if err == nil && allowed {
+			return true
+		}
+	}
+	allowed, err := s.permissions.HasPermission(userID, permission)
+	return err == nil && allowed
+}
+
+// resolveUser находит активного пользователя
+func (s *ReportService) resolveUser(target *ReportTarget) error {
+	var user models.User
+	if err := s.db.Where("id = ? AND is_active = ?", target.ID, true).First(&user).Error; err != nil {
+		return err
+	}
+	target.AboutUserID = user.ID
+	target.Snapshot = map[string]interface{}{
+		"name":     user.Name,
+		"username": user.Username,
+		"bio":      user.Bio,
+		"avatar":   user.Avatar,
+	}
+	return nil
+}
+
+// resolveMessage находит сообщение, на которое может пожаловаться только участник переписки
+func (s *ReportService) resolveMessage(target *ReportTarget, reporterID uint) error {
+	var message models.Message
+	if err := s.db.Preload("Attachments").
+		Where("id = ? AND (from_user_id = ? OR to_user_id = ?)", target.ID, reporterID, reporterID).
+		First(&message).Error; err != nil {
+		return err
+	}
+	files := make([]string, 0, len(message.Attachments))
+	for _, attachment := range message.Attachments {
+		files = append(files, attachment.FilePath)
+	}
+	target.AboutUserID = message.FromUserID
+	target.Snapshot = map[string]interface{}{
+		"text":            message.Text,
+		"attachments":     files,
+		"conversation_id": message.ConversationID,
+		"sent_at":         message.CreatedAt,
+	}
+	return nil
+}
+
+// resolveAttachment находит вложение сообщения, доступное участнику переписки
+func (s *ReportService) resolveAttachment(target *ReportTarget, reporterID uint) error {
+	var attachment models.Attachment
+	if err := s.db.Joins("JOIN messages ON messages.id = attachments.message_id").
+		Where("attachments.id = ? AND (messages.from_user_id = ? OR messages.to_user_id = ?)", target.ID, reporterID, reporterID).
+		First(&attachment).Error; err != nil {
+		return err
+	}
+	target.AboutUserID = attachment.UploadedBy
+	target.Snapshot = map[string]interface{}{
+		"file_path":  attachment.FilePath,
+		"mime_type":  attachment.MimeType,
+		"size":       attachment.Size,
+		"message_id": attachment.MessageID,
+	}
+	return nil
+}
+
+// resolveNews находит опубликованную новость, которую видит пользователь
+func (s *ReportService) resolveNews(target *ReportTarget, reporterID uint) error {
+	var news models.News
+	if err := s.db.Preload("Media", OrderedNewsMedia).Preload("Community").Preload("Event").
+		Where("id = ? AND status = ?", target.ID, models.NewsStatusPublished).
+		First(&news).Error; err != nil {
+		return err
+	}
+	if visible, err := s.canViewNews(reporterID, &news); err != nil || !visible {
+		if err == nil {
+			err = gorm.ErrRecordNotFound
+		}
+		return err
+	}
+	media := make([]string, 0, len(news.Media))
+	for _, item := range news.Media {
+		media = append(media, item.FilePath)
+	}
+	target.AboutUserID = news.AuthorID
+	target.CommunityID = &news.CommunityID
+	target.Snapshot = map[string]interface{}{
+		"content": news.Content,
+		"media":   media,
+	}
+	return nil
+}
+
+// resolveComment находит неудаленный комментарий к новости, которую видит пользователь
+func (s *ReportService) resolveComment(target *ReportTarget, reporterID uint) error {
+	var comment models.Comment
+	if err := s.db.Preload("News.Community").Preload("News.Event").
+		Where("id = ? AND is_deleted = ?", target.ID, false).First(&comment).Error; err != nil {
+		return err
+	}
+	if visible, err := s.canViewNews(reporterID, &comment.News); err != nil || !visible {
+		if err == nil {
+			err = gorm.ErrRecordNotFound
+		}
+		return err
+	}
+	target.AboutUserID = comment.AuthorID
+	target.CommunityID = &comment.News.CommunityID
+	target.Snapshot = map[string]interface{}{
+		"content": comment.Content,
+		"news_id": comment.NewsID,
+	}
+	return nil
+}
+
+// canViewNews проверяет, что пользователь видит новость: она опубликована, а новости закрытого
+// сообщества и анонсы ивентов «только для участников» доступны только участникам сообщества.
+// Новость должна быть загружена вместе с Community и Event.
+func (s *ReportService) canViewNews(userID uint, news *models.News) (bool, error) {
+	if !news.IsPublished() {
+		return false, nil
+	}
+	if !news.Community.IsPrivate() && (news.Event == nil || !news.Event.MembersOnly) {
+		return true, nil
+	}
+	return s.permissions.HasCommunityPermission(userID, news.CommunityID, models.PermissionCommunityView)
+}
+
+// resolveCommunity находит сообщество. Жалобы на само сообщество рассматривают модераторы платформы.
+func (s *ReportService) resolveCommunity(target *ReportTarget) error {
+	var community models.Community
+	if err := s.db.First(&community, target.ID).Error; err != nil {
+		return err
+	}
+	target.AboutUserID = community.CreatorID
+	target.Snapshot = map[string]interface{}{
+		"name":        community.Name,
+		"description": community.Description,
+		"city":        community.City,
+	}
+	return nil
+}
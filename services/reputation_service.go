@@ -0,0 +1,345 @@
+package services
+
+import (
+	"math"
+	"os"
+	"strconv"
+	"time"
+
+	"toloko-backend/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// defaultReputationPriorMean средняя оценка, к которой стягивается репутация при малом числе голосов
+	defaultReputationPriorMean = 7.0
+	// defaultReputationPriorWeight сколько "виртуальных" голосов весит априорная оценка
+	defaultReputationPriorWeight = 5.0
+	// defaultReputationHalfLife за это время вес оценки уменьшается вдвое
+	defaultReputationHalfLife = 365 * 24 * time.Hour
+	// defaultReputationConcentrationWeight вес оценок пользователя, который раз за разом оценивает одних и тех же людей
+	defaultReputationConcentrationWeight = 0.3
+)
+
+// ReputationConfig параметры расчета репутации
+type ReputationConfig struct {
+	PriorMean           float64       // априорная средняя оценка
+	PriorWeight         float64       // вес априорной оценки в голосах
+	HalfLife            time.Duration // период полураспада веса оценки; 0 - без затухания
+	ConcentrationWeight float64       // минимальный вес оценок для пользователей, оценивающих на ивентах одних и тех же людей
+}
+
+// DefaultReputationConfig возвращает параметры репутации по умолчанию
+func DefaultReputationConfig() ReputationConfig {
+	return ReputationConfig{
+		PriorMean:           defaultReputationPriorMean,
+		PriorWeight:         defaultReputationPriorWeight,
+		HalfLife:            defaultReputationHalfLife,
+		ConcentrationWeight: defaultReputationConcentrationWeight,
+	}
+}
+
+// LoadReputationConfig возвращает параметры репутации с переопределениями из окружения:
+// REPUTATION_PRIOR_MEAN, REPUTATION_PRIOR_WEIGHT, REPUTATION_HALF_LIFE_DAYS, REPUTATION_CONCENTRATION_WEIGHT
+func LoadReputationConfig() ReputationConfig {
+	config := DefaultReputationConfig()
+
+	if value, err := strconv.ParseFloat(os.Getenv("REPUTATION_PRIOR_MEAN"), 64); err == nil && value >= 1 && value <= 10 {
+		config.PriorMean = value
+	}
+	if value, err := strconv.ParseFloat(os.Getenv("REPUTATION_PRIOR_WEIGHT"), 64); err == nil && value >= 0 {
+		config.PriorWeight = value
+	}
+	if days, err := strconv.Atoi(os.Getenv("REPUTATION_HALF_LIFE_DAYS")); err == nil && days >= 0 {
+		config.HalfLife = time.Duration(days) * 24 * time.Hour
+	}
+	if value, err := strconv.ParseFloat(os.Getenv("REPUTATION_CONCENTRATION_WEIGHT"), 64); err == nil && value >= 0 && value <= 1 {
+		config.ConcentrationWeight = value
+	}
+
+	return config
+}
+
+// RatingBreakdownBucket количество и доля оценок с определенным баллом
+type RatingBreakdownBucket struct {
+	Score   int     `json:"score"`
+	Count   int     `json:"count"`
+	Percent float64 `json:"percent"`
+}
+
+// ReputationService поддерживает репутацию пользователей по оценкам участников.
+// Репутация - байесовское среднее взвешенных оценок: вес оценки затухает со временем
+// и снижается для пользователей, которые от ивента к ивенту оценивают одних и тех же людей.
+type ReputationService struct {
+	db     *gorm.DB
+	config ReputationConfig
+}
+
+// NewReputationService создает сервис репутации с параметрами из окружения
+func NewReputationService(db *gorm.DB) *ReputationService {
+	return NewReputationServiceWithConfig(db, LoadReputationConfig())
+}
+
+// NewReputationServiceWithConfig создает сервис репутации с указанными параметрами
+func NewReputationServiceWithConfig(db *gorm.DB, config ReputationConfig) *ReputationService {
+	return &ReputationService{db: db, config: config}
+}
+
+// ApplyRating учитывает новую оценку в сводке получателя без пересчета всех его оценок.
+// Оценка должна быть уже сохранена; вызывается в транзакции сохранения.
+func (s *ReputationService) ApplyRating(tx *gorm.DB, rating *models.Rating) error {
+	now := rating.CreatedAt
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	weight, err := s.raterWeight(tx, rating.FromUserID)
+	if err != nil {
+		return err
+	}
+	if err := tx.Model(rating).UpdateColumn("weight", weight).Error; err != nil {
+		return err
+	}
+	rating.Weight = weight
+
+	summary, err := s.loadSummary(tx, rating.ToUserID)
+	if err != nil {
+		return err
+	}
+	s.decaySummary(summary, now)
+	summary.WeightedScore += weight * float64(rating.Score)
+	summary.WeightTotal += weight
+	summary.TotalScore += rating.Score
+	summary.VotesCount++
+	summary.AverageScore = float64(summary.TotalScore) / float64(summary.VotesCount)
+	summary.Reputation = s.reputation(summary)
+	if err := tx.Save(summary).Error; err != nil {
+		return err
+	}
+
+	bucket := models.UserRatingBucket{UserID: rating.ToUserID, Score: rating.Score, Count: 1}
+	if err := tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "score"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{"count": gorm.Expr("user_rating_buckets.count + 1")}),
+	}).Create(&bucket).Error; err != nil {
+		return err
+	}
+
+	// Оценка меняет долю повторных оценок автора: пересчитываем веса всех его оценок
+	return s.reweightRater(tx, rating.FromUserID, now)
+}
+
+// Summary возвращает сводку пользователя с репутацией на момент now и гистограмму оценок
+func (s *ReputationService) Summary(userID uint, now time.Time) (*models.UserRatingSummary, []RatingBreakdownBucket, error) {
+	summary, err := s.loadSummary(s.db, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	s.decaySummary(summary, now)
+	summary.Reputation = s.reputation(summary)
+
+	var buckets []models.UserRatingBucket
+	if err := s.db.Where("user_id = ?", userID).Find(&buckets).Error; err != nil {
+		return nil, nil, err
+	}
+
+	counts := make(map[int]int, len(buckets))
+	total := 0
+	for _, bucket := range buckets {
+		counts[bucket.Score] = bucket.Count
+		total += bucket.Count
+	}
+
+	breakdown := make([]RatingBreakdownBucket, 0, 10)
+	for score := 10; score >= 1; score-- {
+		item := RatingBreakdownBucket{Score: score, Count: counts[score]}
+		if total > 0 {
+			item.Percent = math.Round(float64(item.Count)/float64(total)*1000) / 10
+		}
+		breakdown = append(breakdown, item)
+	}
+
+	return summary, breakdown, nil
+}
+
+// Rebuild полностью пересчитывает сводку, веса и гистограмму пользователя по всем его оценкам.
+// Используется для миграции существующих данных, при обычной работе сводка обновляется инкрементально.
+func (s *ReputationService) Rebuild(userID uint) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var ratings []models.Rating
+		if err := tx.Where("to_user_id = ?", userID).Order("created_at ASC").Find(&ratings).Error; err != nil {
+			return err
+		}
+
+		now := time.Now()
+		summary := &models.UserRatingSummary{UserID: userID, DecayedAt: &now}
+		counts := make(map[int]int)
+		weights := make(map[uint]float64)
+		for _, rating := range ratings {
+			weight, ok := weights[rating.FromUserID]
+			if !ok {
+				var err error
+				if weight, err = s.raterWeight(tx, rating.FromUserID); err != nil {
+					return err
+				}
+				weights[rating.FromUserID] = weight
+			}
+			if rating.Weight != weight {
+				if err := tx.Model(&rating).UpdateColumn("weight", weight).Error; err != nil {
+					return err
+				}
+			}
+
+			decayed := weight * s.decayFactor(rating.CreatedAt, now)
+			summary.WeightedScore += decayed * float64(rating.Score)
+			summary.WeightTotal += decayed
+			summary.TotalScore += rating.Score
+			summary.VotesCount++
+			counts[rating.Score]++
+		}
+		if summary.VotesCount > 0 {
+			summary.AverageScore = float64(summary.TotalScore) / float64(summary.VotesCount)
+		}
+		summary.Reputation = s.reputation(summary)
+
+		if err := tx.Save(summary).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&models.UserRatingBucket{}).Error; err != nil {
+			return err
+		}
+		for score, count := range counts {
+			if err := tx.Create(&models.UserRatingBucket{UserID: userID, Score: score, Count: count}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// BackfillReputation пересчитывает сводки пользователей, оценки которых еще не учтены в репутации
+func (s *ReputationService) BackfillReputation() error {
+	var userIDs []uint
+	err := s.db.Model(&models.Rating{}).
+		Where("to_user_id NOT IN (?)", s.db.Model(&models.UserRatingSummary{}).Select("user_id").Where("decayed_at IS NOT NULL")).
+		Distinct().
+		Pluck("to_user_id", &userIDs).Error
+	if err != nil {
+		return err
+	}
+
+	for _, userID := range userIDs {
+		if err := s.Rebuild(userID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// raterWeight возвращает вес оценок пользователя по их концентрации. На одном ивенте пару
+// оценивают один раз, поэтому повторная оценка того же человека - это оценка на другом ивенте.
+// Чем большая доля оценок приходится на уже оцененных людей, тем ближе вес к ConcentrationWeight:
+// взаимные оценки после обычного ивента вес не снижают, а группа, оценивающая только друг друга, теряет его.
+func (s *ReputationService) raterWeight(tx *gorm.DB, raterID uint) (float64, error) {
+	var total int64
+	if err := tx.Model(&models.Rating{}).Where("from_user_id = ?", raterID).Count(&total).Error; err != nil {
+		return 0, err
+	}
+	if total == 0 {
+		return 1, nil
+	}
+
+	var counterparts int64
+	if err := tx.Model(&models.Rating{}).Where("from_user_id = ?", raterID).
+		Distinct("to_user_id").Count(&counterparts).Error; err != nil {
+		return 0, err
+	}
+
+	share := float64(total-counterparts) / float64(total)
+	return 1 - (1-s.config.ConcentrationWeight)*share, nil
+}
+
+// reweightRater пересчитывает вес всех оценок raterID и их вклад в сводки оцененных пользователей.
+// Вес зависит от доли повторных оценок, поэтому при ее изменении устаревают и ранее поставленные оценки.
+func (s *ReputationService) reweightRater(tx *gorm.DB, raterID uint, now time.Time) error {
+	weight, err := s.raterWeight(tx, raterID)
+	if err != nil {
+		return err
+	}
+
+	var stale []models.Rating
+	if err := tx.Where("from_user_id = ? AND weight <> ?", raterID, weight).Order("to_user_id").Find(&stale).Error; err != nil {
+		return err
+	}
+
+	var summary *models.UserRatingSummary
+	for _, rating := range stale {
+		if summary == nil || summary.UserID != rating.ToUserID {
+			if summary != nil {
+				summary.Reputation = s.reputation(summary)
+				if err := tx.Save(summary).Error; err != nil {
+					return err
+				}
+			}
+			if summary, err = s.loadSummary(tx, rating.ToUserID); err != nil {
+				return err
+			}
+			s.decaySummary(summary, now)
+		}
+
+		delta := (weight - rating.Weight) * s.decayFactor(rating.CreatedAt, now)
+		summary.WeightedScore = math.Max(0, summary.WeightedScore+delta*float64(rating.Score))
+		summary.WeightTotal = math.Max(0, summary.WeightTotal+delta)
+		if err := tx.Model(&rating).UpdateColumn("weight", weight).Error; err != nil {
+			return err
+		}
+	}
+	if summary == nil {
+		return nil
+	}
+	summary.Reputation = s.reputation(summary)
+	return tx.Save(summary).Error
+}
+
+// loadSummary загружает сводку пользователя или возвращает новую пустую
+func (s *ReputationService) loadSummary(tx *gorm.DB, userID uint) (*models.UserRatingSummary, error) {
+	var summary models.UserRatingSummary
+	err := tx.Where("user_id = ?", userID).First(&summary).Error
+	if err == gorm.ErrRecordNotFound {
+		return &models.UserRatingSummary{UserID: userID}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &summary, nil
+}
+
+// decaySummary переносит взвешенные суммы сводки на момент now с учетом затухания
+func (s *ReputationService) decaySummary(summary *models.UserRatingSummary, now time.Time) {
+	if summary.DecayedAt != nil {
+		factor := s.decayFactor(*summary.DecayedAt, now)
+		summary.WeightedScore *= factor
+		summary.WeightTotal *= factor
+	}
+	summary.DecayedAt = &now
+}
+
+// decayFactor возвращает множитель веса оценки, поставленной в from, на момент to
+func (s *ReputationService) decayFactor(from, to time.Time) float64 {
+	if s.config.HalfLife <= 0 || !to.After(from) {
+		return 1
+	}
+	return math.Pow(0.5, float64(to.Sub(from))/float64(s.config.HalfLife))
+}
+
+// reputation вычисляет байесовское среднее: взвешенные оценки плюс PriorWeight голосов за PriorMean
+func (s *ReputationService) reputation(summary *models.UserRatingSummary) float64 {
+	weight := s.config.PriorWeight + summary.WeightTotal
+	if weight <= 0 {
+		return 0
+	}
+	value := (s.config.PriorWeight*s.config.PriorMean + summary.WeightedScore) / weight
+	return math.Round(value*100) / 100
+}
@@ -0,0 +1,238 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"toloko-backend/models"
+
+	"gorm.io/gorm"
+)
+
+// Периоды серий участия
+const (
+	StreakPeriodWeek  = "week"
+	StreakPeriodMonth = "month"
+)
+
+const (
+	// defaultWeeklyStreakFreezes сколько пропущенных недель не прерывает недельную серию
+	defaultWeeklyStreakFreezes = 1
+	// defaultMonthlyStreakFreezes сколько пропущенных месяцев не прерывает месячную серию
+	defaultMonthlyStreakFreezes = 0
+	// consistencyWindowWeeks за сколько последних недель считается регулярность
+	consistencyWindowWeeks = 12
+)
+
+// streakEpoch понедельник, от которого отсчитываются номера недель
+var streakEpoch = time.Date(1970, time.January, 5, 0, 0, 0, 0, time.UTC)
+
+// StreakConfig параметры серий участия
+type StreakConfig struct {
+	WeeklyFreezes  int // допустимые пропуски в недельной серии
+	MonthlyFreezes int // допустимые пропуски в месячной серии
+}
+
+// LoadStreakConfig возвращает параметры серий с переопределениями из окружения:
+// STREAK_WEEKLY_FREEZES, STREAK_MONTHLY_FREEZES
+func LoadStreakConfig() StreakConfig {
+	config := StreakConfig{
+		WeeklyFreezes:  defaultWeeklyStreakFreezes,
+		MonthlyFreezes: defaultMonthlyStreakFreezes,
+	}
+	if value, err := strconv.Atoi(os.Getenv("STREAK_WEEKLY_FREEZES")); err == nil && value >= 0 {
+		config.WeeklyFreezes = value
+	}
+	if value, err := strconv.Atoi(os.Getenv("STREAK_MONTHLY_FREEZES")); err == nil && value >= 0 {
+		config.MonthlyFreezes = value
+	}
+	return config
+}
+
+// StreakStats текущая и самая длинная серия периодов с участием в ивентах
+type StreakStats struct {
+	Period         string     `json:"period"`
+	Current        int        `json:"current"`
+	Longest        int        `json:"longest"`
+	FreezesAllowed int        `json:"freezes_allowed"`
+	FreezesUsed    int        `json:"freezes_used"`   // пропуски, уже использованные в текущей серии
+	LastActiveAt   *time.Time `json:"last_active_at"` // начало последнего периода с участием
+}
+
+// ConsistencyStats серии и регулярность участия пользователя
+type ConsistencyStats struct {
+	Weekly       StreakStats `json:"weekly"`
+	Monthly      StreakStats `json:"monthly"`
+	ActiveWeeks  int         `json:"active_weeks"`
+	ActiveMonths int         `json:"active_months"`
+	Consistency  int         `json:"consistency"` // процент недель с участием за последние 12 недель
+}
+
+// StreakService считает серии участия по завершенным ивентам, где пользователь вступил или отмечен на месте
+type StreakService struct {
+	db     *gorm.DB
+	config StreakConfig
+}
+
+// NewStreakService создает сервис серий с параметрами из окружения
+func NewStreakService(db *gorm.DB) *StreakService {
+	return NewStreakServiceWithConfig(db, LoadStreakConfig())
+}
+
+// NewStreakServiceWithConfig создает сервис серий с указанными параметрами
+func NewStreakServiceWithConfig(db *gorm.DB, config StreakConfig) *StreakService {
+	return &StreakService{db: db, config: config}
+}
+
+// Stats возвращает недельную и месячную серии пользователя на момент now
+func (s *StreakService) Stats(userID uint, now time.Time) (*ConsistencyStats, error) {
+	times, err := s.activeTimes(userID, now)
+	if err != nil {
+		return nil, err
+	}
+
+	weeks := streakPeriods(times, StreakPeriodWeek, now.Location())
+	months := streakPeriods(times, StreakPeriodMonth, now.Location())
+	currentWeek := StreakPeriodIndex(StreakPeriodWeek, now)
+
+	stats := &ConsistencyStats{
+		Weekly:       s.streak(weeks, StreakPeriodWeek, now),
+		Monthly:      s.streak(months, StreakPeriodMonth, now),
+		ActiveWeeks:  len(weeks),
+		ActiveMonths: len(months),
+	}
+	recent := 0
+	for _, week := range weeks {
+		if week > currentWeek-consistencyWindowWeeks && week <= currentWeek {
+			recent++
+		}
+	}
+	stats.Consistency = recent * 100 / consistencyWindowWeeks
+	return stats, nil
+}
+
+// Longest возвращает самую длинную серию пользователя за период week или month
+func (s *StreakService) Longest(userID uint, period string) (int, error) {
+	now := time.Now()
+	times, err := s.activeTimes(userID, now)
+	if err != nil {
+		return 0, err
+	}
+	return s.streak(streakPeriods(times, period, now.Location()), period, now).Longest, nil
+}
+
+// streak считает серии по отсортированным номерам периодов с участием
+func (s *StreakService) streak(periods []int, period string, now time.Time) StreakStats {
+	freezes := s.config.WeeklyFreezes
+	if period == StreakPeriodMonth {
+		freezes = s.config.MonthlyFreezes
+	}
+
+	stats := StreakStats{Period: period, FreezesAllowed: freezes}
+	if len(periods) == 0 {
+		return stats
+	}
+
+	current, longest, used := ComputeStreak(periods, StreakPeriodIndex(period, now), freezes)
+	stats.Current = current
+	stats.Longest = longest
+	if current > 0 {
+		stats.FreezesUsed = used
+	}
+	last := streakPeriodStart(period, periods[len(periods)-1], now.Location())
+	stats.LastActiveAt = &last
+	return stats
+}
+
+// activeTimes возвращает время начала завершенных ивентов, в которых пользователь участвовал
+func (s *StreakService) activeTimes(userID uint, now time.Time) ([]time.Time, error) {
+	var times []time.Time
+	err := s.db.Model(&models.EventParticipant{}).
+		Joins("JOIN events ON events.id = event_participants.event_id").
+		Where("event_participants.user_id = ?", userID).
+		Where("event_participants.checked_in_at IS NOT NULL OR event_participants.status IN ?",
+			[]string{models.ParticipantStatusJoined, models.ParticipantStatusAccepted}).
+		Where("events.is_active = ? OR events.end_time < ?", false, now).
+		Pluck("events.start_time", &times).Error
+	return times, err
+}
+
+// ComputeStreak возвращает текущую и самую длинную серию по отсортированным номерам периодов,
+// а также число пропусков, использованных в последней серии. Серия не прерывается, пока суммарное
+// число пропущенных периодов не превышает freezes. Текущий период еще не закончился и пропуском не считается.
+func ComputeStreak(periods []int, currentPeriod, freezes int) (int, int, int) {
+	if len(periods) == 0 {
+		return 0, 0, 0
+	}
+
+	length, used, longest := 1, 0, 1
+	for i := 1; i < len(periods); i++ {
+		gap := periods[i] - periods[i-1] - 1
+		if gap <= freezes-used {
+			length++
+			used += gap
+		} else {
+			length, used = 1, 0
+		}
+		if length > longest {
+			longest = length
+		}
+	}
+
+	missed := currentPeriod - periods[len(periods)-1] - 1
+	if missed < 0 {
+		missed = 0
+	}
+	if missed > freezes-used {
+		return 0, longest, used
+	}
+	return length, longest, used + missed
+}
+
+// StreakPeriodIndex возвращает порядковый номер недели (с понедельника) или месяца для момента t
+func StreakPeriodIndex(period string, t time.Time) int {
+	if period == StreakPeriodMonth {
+		return t.Year()*12 + int(t.Month()) - 1
+	}
+	start := LeaderboardPeriodStart(models.LeaderboardPeriodWeek, t)
+	day := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, time.UTC)
+	return int(day.Sub(streakEpoch).Hours()/24) / 7
+}
+
+// streakPeriods возвращает отсортированные номера периодов без повторов
+func streakPeriods(times []time.Time, period string, location *time.Location) []int {
+	seen := make(map[int]bool, len(times))
+	periods := make([]int, 0, len(times))
+	for _, t := range times {
+		index := StreakPeriodIndex(period, t.In(location))
+		if !seen[index] {
+			seen[index] = true
+			periods = append(periods, index)
+		}
+	}
+	sort.Ints(periods)
+	return periods
+}
+
+// streakPeriodStart возвращает начало периода по его номеру
+func streakPeriodStart(period string, index int, location *time.Location) time.Time {
+	if period == StreakPeriodMonth {
+		return time.Date(index/12, time.Month(index%12+1), 1, 0, 0, 0, 0, location)
+	}
+	day := streakEpoch.AddDate(0, 0, index*7)
+	return time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, location)
+}
+
+// streakPeriodForAchievement возвращает период серии для события достижения
+func streakPeriodForAchievement(event string) (string, error) {
+	switch event {
+	case models.AchievementEventWeeklyStreak:
+		return StreakPeriodWeek, nil
+	case models.AchievementEventMonthlyStreak:
+		return StreakPeriodMonth, nil
+	}
+	return "", fmt.Errorf("achievement event %q is not a streak", event)
+}
@@ -0,0 +1,382 @@
+package services
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"time"
+
+	"toloko-backend/models"
+	"toloko-backend/utils"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// TwoFactorIssuer название сервиса в приложении-аутентификаторе
+	TwoFactorIssuer = "Toloka"
+	// twoFactorRecoveryCodesCount количество кодов восстановления
+	twoFactorRecoveryCodesCount = 10
+	// twoFactorChallengeTTL время на ввод второго фактора
+	twoFactorChallengeTTL = 5 * time.Minute
+	// twoFactorChallengeMaxAttempts количество попыток ввода кода
+	twoFactorChallengeMaxAttempts = 5
+)
+
+var (
+	// ErrTwoFactorAlreadyEnabled 2FA уже включена
+	ErrTwoFactorAlreadyEnabled = errors.New("two-factor authentication already enabled")
+	// ErrTwoFactorNotEnrolled 2FA не настроена
+	ErrTwoFactorNotEnrolled = errors.New("two-factor authentication not enrolled")
+	// ErrTwoFactorNotEnabled 2FA не включена
+	ErrTwoFactorNotEnabled = errors.New("two-factor authentication not enabled")
+	// ErrTwoFactorRequired 2FA обязательна для ролей пользователя
+	ErrTwoFactorRequired = errors.New("two-factor authentication is required for user roles")
+	// ErrInvalidTwoFactorCode неверный код
+	ErrInvalidTwoFactorCode = errors.New("invalid two-factor code")
+	// ErrInvalidTwoFactorChallenge вход не найден, истек или исчерпал попытки
+	ErrInvalidTwoFactorChallenge = errors.New("invalid or expired two-factor challenge")
+)
+
+// TwoFactorRequiredRoles возвращает глобальные роли, для которых 2FA обязательна.
+// Настраивается переменной TWO_FACTOR_REQUIRED_ROLES, например "admin,moderator,support".
+func TwoFactorRequiredRoles() map[string]bool {
+	return parseRoleList(os.Getenv("TWO_FACTOR_REQUIRED_ROLES"))
+}
+
+// TwoFactorRequiredCommunityRoles возвращает роли в сообществе, для которых 2FA обязательна.
+// Настраивается переменной TWO_FACTOR_REQUIRED_COMMUNITY_ROLES, например "admin,moderator".
+func TwoFactorRequiredCommunityRoles() map[string]bool {
+	return parseRoleList(os.Getenv("TWO_FACTOR_REQUIRED_COMMUNITY_ROLES"))
+}
+
+// parseRoleList разбирает список ролей через запятую
+func parseRoleList(value string) map[string]bool {
+	roles := make(map[string]bool)
+	for _, role := range strings.Split(value, ",") {
+		role = strings.TrimSpace(role)
+		if role != "" {
+			roles[role] = true
+		}
+	}
+	return roles
+}
+
+// TwoFactorService предоставляет методы для двухфакторной аутентификации
+type TwoFactorService struct {
+	db *gorm.DB
+}
+
+// NewTwoFactorService создает новый сервис двухфакторной аутентификации
+func NewTwoFactorService(db *gorm.DB) *TwoFactorService {
+	return &TwoFactorService{db: db}
+}
+
+// GetSettings возвращает настройки 2FA пользователя (nil, если не настраивалась)
+func (s *TwoFactorService) GetSettings(userID uint) (*models.TwoFactorAuth, error) {
+	var settings models.TwoFactorAuth
+	err := s.db.Where("user_id = ?", userID).First(&settings).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &settings, nil
+}
+
+// IsEnabled проверяет, включена ли 2FA у пользователя
+func (s *TwoFactorService) IsEnabled(userID uint) (bool, error) {
+	return isTwoFactorEnabled(s.db, userID)
+}
+
+// IsRequired проверяет, обязательна ли 2FA для пользователя из-за его ролей
+func (s *TwoFactorService) IsRequired(userID uint) (bool, error) {
+	if roles := TwoFactorRequiredRoles(); len(roles) > 0 {
+		var count int64
+		err := s.db.Model(&models.UserRole{}).
+			Where("user_id = ? AND role IN ?", userID, roleNames(roles)).
+			Count(&count).Error
+		if err != nil || count > 0 {
+			return count > 0, err
+		}
+	}
+
+	if roles := TwoFactorRequiredCommunityRoles(); len(roles) > 0 {
+		var count int64
+		err := s.db.Model(&models.CommunityRole{}).
+			Where("user_id = ? AND role IN ?", userID, roleNames(roles)).
+			Count(&count).Error
+		return count > 0, err
+	}
+
+	return false, nil
+}
+
+// Enroll создает новый секрет TOTP, ожидающий подтверждения
+func (s *TwoFactorService) Enroll(userID uint) (*models.TwoFactorAuth, error) {
+	settings, err := s.GetSettings(userID)
+	if err != nil {
+		return nil, err
+	}
+	if settings != nil && settings.IsEnabled {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	if settings == nil {
+		settings = &models.TwoFactorAuth{UserID: userID, Secret: secret}
+		err = s.db.Create(settings).Error
+	} else {
+		settings.Secret = secret
+		settings.LastUsedStep = 0
+		err = s.db.Save(settings).Error
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return settings, nil
+}
+
+// Confirm включает 2FA после проверки кода и возвращает коды восстановления
+func (s *TwoFactorService) Confirm(userID uint, code string) ([]string, error) {
+	settings, err := s.GetSettings(userID)
+	if err != nil {
+		return nil, err
+	}
+	if settings == nil {
+		return nil, ErrTwoFactorNotEnrolled
+	}
+	if settings.IsEnabled {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+
+	step, valid := utils.ValidateTOTPCode(settings.Secret, code, time.Now())
+	if !valid {
+		return nil, ErrInvalidTwoFactorCode
+	}
+
+	var codes []string
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if err := tx.Model(settings).Updates(map[string]interface{}{
+			"is_enabled":     true,
+			"enabled_at":     &now,
+			"last_used_step": step,
+		}).Error; err != nil {
+			return err
+		}
+
+		codes, err = replaceRecoveryCodes(tx, userID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// Disable отключает 2FA после проверки кода (TOTP или кода восстановления)
+func (s *TwoFactorService) Disable(userID uint, code string) error {
+	required, err := s.IsRequired(userID)
+	if err != nil {
+		return err
+	}
+	if required {
+		return ErrTwoFactorRequired
+	}
+
+	valid, err := s.VerifyCode(userID, code)
+	if err != nil {
+		return err
+	}
+	if !valid {
+		return ErrInvalidTwoFactorCode
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&models.TwoFactorRecoveryCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", userID).Delete(&models.TwoFactorAuth{}).Error
+	})
+}
+
+// RegenerateRecoveryCodes заменяет коды восстановления после проверки кода TOTP
+func (s *TwoFactorService) RegenerateRecoveryCodes(userID uint, code string) ([]string, error) {
+	valid, err := s.verifyTOTP(userID, code)
+	if err != nil {
+		return nil, err
+	}
+	if !valid {
+		return nil, ErrInvalidTwoFactorCode
+	}
+
+	var codes []string
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		codes, err = replaceRecoveryCodes(tx, userID)
+		return err
+	})
+	return codes, err
+}
+
+// CountRecoveryCodes возвращает количество неиспользованных кодов восстановления
+func (s *TwoFactorService) CountRecoveryCodes(userID uint) (int64, error) {
+	var count int64
+	err := s.db.Model(&models.TwoFactorRecoveryCode{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Count(&count).Error
+	return count, err
+}
+
+// VerifyCode проверяет код TOTP или одноразовый код восстановления
+func (s *TwoFactorService) VerifyCode(userID uint, code string) (bool, error) {
+	if len(strings.TrimSpace(code)) == utils.TOTPDigits {
+		return s.verifyTOTP(userID, code)
+	}
+	return s.useRecoveryCode(userID, code)
+}
+
+// CreateChallenge создает незавершенный вход и возвращает его токен
+func (s *TwoFactorService) CreateChallenge(userID uint) (string, error) {
+	token, tokenHash, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+
+	// Удаляем истекшие незавершенные входы
+	s.db.Where("expires_at < ?", time.Now()).Delete(&models.TwoFactorChallenge{})
+
+	challenge := models.TwoFactorChallenge{
+		UserID:    userID,
+		TokenHash: tokenHash,
+		ExpiresAt: time.Now().Add(twoFactorChallengeTTL),
+	}
+	if err := s.db.Create(&challenge).Error; err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// CompleteChallenge проверяет второй фактор и возвращает ID пользователя
+func (s *TwoFactorService) CompleteChallenge(token, code string) (uint, error) {
+	var challenge models.TwoFactorChallenge
+	if err := s.db.Where("token_hash = ?", utils.HashOpaqueToken(token)).First(&challenge).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return 0, ErrInvalidTwoFactorChallenge
+		}
+		return 0, err
+	}
+
+	if challenge.IsExpired() || challenge.Attempts >= twoFactorChallengeMaxAttempts {
+		s.db.Delete(&challenge)
+		return 0, ErrInvalidTwoFactorChallenge
+	}
+
+	valid, err := s.VerifyCode(challenge.UserID, code)
+	if err != nil {
+		return 0, err
+	}
+	if !valid {
+		s.db.Model(&challenge).Update("attempts", gorm.Expr("attempts + 1"))
+		return 0, ErrInvalidTwoFactorCode
+	}
+
+	s.db.Delete(&challenge)
+	return challenge.UserID, nil
+}
+
+// verifyTOTP проверяет код TOTP и запоминает шаг, чтобы код нельзя было использовать повторно
+func (s *TwoFactorService) verifyTOTP(userID uint, code string) (bool, error) {
+	settings, err := s.GetSettings(userID)
+	if err != nil {
+		return false, err
+	}
+	if settings == nil || !settings.IsEnabled {
+		return false, ErrTwoFactorNotEnabled
+	}
+
+	step, valid := utils.ValidateTOTPCode(settings.Secret, code, time.Now())
+	if !valid {
+		return false, nil
+	}
+
+	// Обновляем шаг только если он больше последнего принятого
+	result := s.db.Model(&models.TwoFactorAuth{}).
+		Where("id = ? AND last_used_step < ?", settings.ID, step).
+		Update("last_used_step", step)
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+// useRecoveryCode проверяет и погашает код восстановления
+func (s *TwoFactorService) useRecoveryCode(userID uint, code string) (bool, error) {
+	enabled, err := s.IsEnabled(userID)
+	if err != nil {
+		return false, err
+	}
+	if !enabled {
+		return false, ErrTwoFactorNotEnabled
+	}
+
+	result := s.db.Model(&models.TwoFactorRecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, utils.HashRecoveryCode(code)).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+// replaceRecoveryCodes удаляет старые и создает новые коды восстановления
+func replaceRecoveryCodes(tx *gorm.DB, userID uint) ([]string, error) {
+	codes, err := utils.GenerateRecoveryCodes(twoFactorRecoveryCodesCount)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Where("user_id = ?", userID).Delete(&models.TwoFactorRecoveryCode{}).Error; err != nil {
+		return nil, err
+	}
+
+	for _, code := range codes {
+		recoveryCode := models.TwoFactorRecoveryCode{
+			UserID:   userID,
+			CodeHash: utils.HashRecoveryCode(code),
+		}
+		if err := tx.Create(&recoveryCode).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	return codes, nil
+}
+
+// isTwoFactorEnabled проверяет, включена ли 2FA у пользователя
+func isTwoFactorEnabled(db *gorm.DB, userID uint) (bool, error) {
+	var count int64
+	err := db.Model(&models.TwoFactorAuth{}).
+		Where("user_id = ? AND is_enabled = ?", userID, true).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// roleNames возвращает список ролей из множества
+func roleNames(roles map[string]bool) []string {
+	names := make([]string, 0, len(roles))
+	for role := range roles {
+		names = append(names, role)
+	}
+	return names
+}
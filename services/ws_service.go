@@ -1,6 +1,7 @@
 package services
 
 import (
+	"fmt"
 	"log"
 	"sync"
 	"time"
@@ -47,16 +48,51 @@ type Client struct {
 	Send     chan WSMessage
 	Hub      *Hub
 	LastPing time.Time
+	topics   map[string]bool // темы, на которые подписан клиент (защищены мьютексом хаба)
+}
+
+// PlatformTopic тема событий платформы, например челленджей без сообщества
+const PlatformTopic = "platform"
+
+// CommunityTopic возвращает тему событий сообщества
+func CommunityTopic(communityID uint) string {
+	return fmt.Sprintf("community:%d", communityID)
+}
+
+// TopicPublisher рассылает сообщения подписчикам темы (реализуется Hub)
+type TopicPublisher interface {
+	Publish(topic string, message WSMessage)
+}
+
+// topicPublisher рассылка по темам, устанавливается при запуске
+var topicPublisher TopicPublisher
+
+// SetTopicPublisher устанавливает рассылку сообщений по темам
+func SetTopicPublisher(publisher TopicPublisher) {
+	topicPublisher = publisher
+}
+
+// publishToTopic рассылает сообщение подписчикам темы, если рассылка настроена
+func publishToTopic(topic string, message WSMessage) {
+	if topicPublisher != nil {
+		topicPublisher.Publish(topic, message)
+	}
+}
+
+// TopicPayload представляет payload подписки на тему
+type TopicPayload struct {
+	Topic string `json:"topic"`
 }
 
 // Hub управляет всеми подключениями
 type Hub struct {
-	clients    map[*Client]bool
-	register   chan *Client
-	unregister chan *Client
-	broadcast  chan WSMessage
-	mutex      sync.RWMutex
-	db         *gorm.DB
+	clients     map[*Client]bool
+	register    chan *Client
+	unregister  chan *Client
+	broadcast   chan WSMessage
+	mutex       sync.RWMutex
+	db          *gorm.DB
+	rateLimiter *RateLimiter
 }
 
 // NewHub создает новый хаб
@@ -70,6 +106,11 @@ func NewHub(db *gorm.DB) *Hub {
 	}
 }
 
+// SetRateLimiter устанавливает ограничитель частоты входящих сообщений
+func (h *Hub) SetRateLimiter(rateLimiter *RateLimiter) {
+	h.rateLimiter = rateLimiter
+}
+
 // Run запускает хаб
 func (h *Hub) Run() {
 	for {
@@ -190,6 +231,58 @@ func (h *Hub) SendToConversation(conversationID uint, message WSMessage, exclude
 	h.mutex.RUnlock()
 }
 
+// Publish отправляет сообщение всем клиентам, подписанным на тему
+func (h *Hub) Publish(topic string, message WSMessage) {
+	h.mutex.RLock()
+	for client := range h.clients {
+		if client.topics[topic] {
+			select {
+			case client.Send <- message:
+			default:
+			}
+		}
+	}
+	h.mutex.RUnlock()
+}
+
+// canSubscribe проверяет, может ли пользователь подписаться на тему:
+// на тему платформы - любой, на тему закрытого сообщества - только его участники
+func (h *Hub) canSubscribe(userID uint, topic string) bool {
+	if topic == PlatformTopic {
+		return true
+	}
+
+	var communityID uint
+	if _, err := fmt.Sscanf(topic, "community:%d", &communityID); err != nil || CommunityTopic(communityID) != topic {
+		return false
+	}
+
+	var community models.Community
+	if err := h.db.First(&community, communityID).Error; err != nil {
+		return false
+	}
+	if !community.IsPrivate() {
+		return true
+	}
+	allowed, err := NewPermissionService(h.db).HasCommunityPermission(userID, communityID, models.PermissionCommunityView)
+	return err == nil && allowed
+}
+
+// setSubscription подписывает клиента на тему или отписывает от нее
+func (h *Hub) setSubscription(client *Client, topic string, subscribed bool) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if client.topics == nil {
+		client.topics = make(map[string]bool)
+	}
+	if subscribed {
+		client.topics[topic] = true
+	} else {
+		delete(client.topics, topic)
+	}
+}
+
 // HandleWebSocket обрабатывает WebSocket соединение
 func (h *Hub) HandleWebSocket(c *websocket.Conn) {
 	// Получаем JWT токен из query параметров
@@ -302,6 +395,14 @@ func (c *Client) writePump() {
 
 // handleMessage обрабатывает входящие сообщения
 func (c *Client) handleMessage(message WSMessage) {
+	if c.Hub.rateLimiter != nil {
+		result := c.Hub.rateLimiter.AllowWSMessage(c.UserID, message.Type)
+		if !result.Allowed {
+			c.sendRateLimited(message, result.RetryAfter)
+			return
+		}
+	}
+
 	switch message.Type {
 	case "message.send":
 		c.handleSendMessage(message)
@@ -311,11 +412,62 @@ func (c *Client) handleMessage(message WSMessage) {
 		c.handleTypingStart(message)
 	case "typing.stop":
 		c.handleTypingStop(message)
+	case "topic.subscribe":
+		c.handleTopicSubscription(message, true)
+	case "topic.unsubscribe":
+		c.handleTopicSubscription(message, false)
 	case "ping":
 		c.handlePing(message)
 	}
 }
 
+// handleTopicSubscription обрабатывает подписку на тему и отписку от нее
+func (c *Client) handleTopicSubscription(message WSMessage, subscribed bool) {
+	payload, ok := message.Payload.(map[string]interface{})
+	if !ok {
+		return
+	}
+	topic, _ := payload["topic"].(string)
+
+	if subscribed && !c.Hub.canSubscribe(c.UserID, topic) {
+		c.sendTopicResult(message, "topic.denied", topic)
+		return
+	}
+	c.Hub.setSubscription(c, topic, subscribed)
+
+	resultType := "topic.subscribed"
+	if !subscribed {
+		resultType = "topic.unsubscribed"
+	}
+	c.sendTopicResult(message, resultType, topic)
+}
+
+// sendTopicResult сообщает клиенту результат подписки на тему
+func (c *Client) sendTopicResult(message WSMessage, resultType, topic string) {
+	select {
+	case c.Send <- WSMessage{Type: resultType, Payload: TopicPayload{Topic: topic}, TempID: message.TempID}:
+	default:
+	}
+}
+
+// sendRateLimited уведомляет клиента о превышении лимита сообщений
+func (c *Client) sendRateLimited(message WSMessage, retryAfter time.Duration) {
+	errorMessage := WSMessage{
+		Type: "error",
+		Payload: map[string]interface{}{
+			"code":         "rate_limited",
+			"message_type": message.Type,
+			"retry_after":  retryAfterSeconds(retryAfter),
+		},
+		TempID: message.TempID,
+	}
+
+	select {
+	case c.Send <- errorMessage:
+	default:
+	}
+}
+
 // handleSendMessage обрабатывает отправку сообщения
 func (c *Client) handleSendMessage(message WSMessage) {
 	payload, ok := message.Payload.(map[string]interface{})
@@ -374,6 +526,11 @@ func (c *Client) handleSendMessage(message WSMessage) {
 	conversation.LastMessageID = &msg.ID
 	c.Hub.db.Save(&conversation)
 
+	// Разбираем упоминания участников диалога
+	if err := NewMentionService(c.Hub.db).SyncMessage(&msg); err != nil {
+		log.Printf("Error syncing message mentions: %v", err)
+	}
+
 	// Отправляем подтверждение отправителю
 	deliveryMessage := WSMessage{
 		Type: "message.deliver",
@@ -390,6 +547,7 @@ func (c *Client) handleSendMessage(message WSMessage) {
 		"conversation_id": conversation.ID,
 		"from_user_id":    msg.FromUserID,
 		"text":            msg.Text,
+		"mentions":        msg.Mentions,
 		"status":          msg.Status,
 		"created_at":      msg.CreatedAt,
 	}
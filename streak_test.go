@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"toloko-backend/controllers"
+	"toloko-backend/models"
+	"toloko-backend/routes"
+	"toloko-backend/services"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestComputeStreak(t *testing.T) {
+	cases := []struct {
+		name                               string
+		periods                            []int
+		current, freezes                   int
+		wantCurrent, wantLongest, wantUsed int
+	}{
+		{"no activity", nil, 10, 1, 0, 0, 0},
+		{"active this period", []int{1, 2, 3}, 3, 0, 3, 3, 0},
+		{"current period in progress", []int{1, 2, 3}, 4, 0, 3, 3, 0},
+		{"missed period breaks streak", []int{1, 2, 3}, 5, 0, 0, 3, 0},
+		{"freeze covers missed period", []int{1, 2, 3}, 5, 1, 3, 3, 1},
+		{"freezes are shared within a streak", []int{1, 3, 4, 7, 8}, 8, 1, 2, 3, 0},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			current, longest, used := services.ComputeStreak(tc.periods, tc.current, tc.freezes)
+			assert.Equal(t, tc.wantCurrent, current)
+			assert.Equal(t, tc.wantLongest, longest)
+			assert.Equal(t, tc.wantUsed, used)
+		})
+	}
+}
+
+func TestParticipationStreaks(t *testing.T) {
+	app, db := setupPointsTestApp()
+	db.AutoMigrate(&models.EventReview{})
+	routes.SetupUserRoutes(app, controllers.NewUserController(db))
+
+	organizerID, _ := createCommunityMemberTestUser(db, "organizer@example.com")
+	volunteerID, volunteerToken := createCommunityMemberTestUser(db, "volunteer@example.com")
+
+	weekly := models.Achievement{Name: "Четыре недели подряд", Description: "Участвуйте в ивентах 4 недели подряд", IconPath: "/icons/weekly-streak.png",
+		Category: "consistency", IsActive: true, CriterionEvent: models.AchievementEventWeeklyStreak, CriterionThreshold: 4}
+	db.Create(&weekly)
+
+	// Завершенные ивенты в три предыдущие недели и текущий ивент на этой неделе
+	now := time.Now()
+	weekStart := services.LeaderboardPeriodStart(models.LeaderboardPeriodWeek, now)
+	for k := 1; k <= 3; k++ {
+		start := weekStart.AddDate(0, 0, -7*k+2).Add(12 * time.Hour)
+		event := models.Event{CreatorID: organizerID, Title: fmt.Sprintf("Уборка %d", k), StartTime: start, EndTime: start.Add(2 * time.Hour)}
+		db.Create(&event)
+		db.Model(&event).Update("is_active", false)
+		db.Create(&models.EventParticipant{EventID: event.ID, UserID: volunteerID, Status: models.ParticipantStatusJoined})
+	}
+	current := models.Event{CreatorID: organizerID, Title: "Уборка сегодня", StartTime: now, EndTime: now.Add(2 * time.Hour), IsActive: true}
+	db.Create(&current)
+	db.Create(&models.EventParticipant{EventID: current.ID, UserID: volunteerID, Status: models.ParticipantStatusJoined})
+
+	streaks := services.NewStreakServiceWithConfig(db, services.StreakConfig{WeeklyFreezes: 0, MonthlyFreezes: 0})
+	stats, err := streaks.Stats(volunteerID, time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, 3, stats.Weekly.Current)
+	assert.Equal(t, 3, stats.Weekly.Longest)
+	assert.Equal(t, 3, stats.ActiveWeeks)
+	assert.Equal(t, 25, stats.Consistency)
+
+	// Завершение текущего ивента продлевает серию и выдает достижение
+	status, _ := communityRequest(app, "POST", fmt.Sprintf("/events/%d/complete", current.ID), generateTestJWT(organizerID), nil)
+	assert.Equal(t, 200, status)
+
+	stats, err = streaks.Stats(volunteerID, time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, 4, stats.Weekly.Current)
+	assert.Equal(t, 4, stats.Weekly.Longest)
+
+	var earned models.UserAchievement
+	assert.NoError(t, db.Where("user_id = ? AND achievement_id = ?", volunteerID, weekly.ID).First(&earned).Error)
+
+	// Серии видны в профиле
+	status, result := levelRequest(app, "GET", fmt.Sprintf("/api/users/%d", volunteerID), volunteerToken, nil)
+	assert.Equal(t, 200, status)
+	weeklyStreak := result["streaks"].(map[string]interface{})["weekly"].(map[string]interface{})
+	assert.Equal(t, float64(4), weeklyStreak["current"])
+	assert.Equal(t, float64(4), weeklyStreak["longest"])
+}
@@ -12,7 +12,7 @@ import (
 // setupTestDB создает тестовую базу данных в памяти
 func setupTestDB() *gorm.DB {
 	db, _ := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
-	db.AutoMigrate(&models.User{}, &models.Conversation{}, &models.Message{}, &models.Attachment{}, &models.Block{}, &models.UserPresence{})
+	db.AutoMigrate(&models.User{}, &models.Conversation{}, &models.Message{}, &models.Attachment{}, &models.Block{}, &models.UserPresence{}, &models.TwoFactorAuth{}, &models.TwoFactorRecoveryCode{}, &models.TwoFactorChallenge{}, &models.Mention{}, &models.Notification{})
 	return db
 }
 
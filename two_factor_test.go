@@ -0,0 +1,209 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"toloko-backend/controllers"
+	"toloko-backend/models"
+	"toloko-backend/routes"
+	"toloko-backend/services"
+	"toloko-backend/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// setupTwoFactorTestApp создает тестовое приложение с маршрутами входа и 2FA
+func setupTwoFactorTestApp() (*fiber.App, *gorm.DB, uint) {
+	db := setupTestDB()
+	db.AutoMigrate(&models.UserRole{}, &models.CommunityRole{})
+
+	hash, _ := utils.HashPassword("password123")
+	user := models.User{Name: "Organizer", Email: "organizer@example.com", PasswordHash: hash, IsActive: true}
+	db.Create(&user)
+
+	app := fiber.New()
+	authController := controllers.NewAuthController(db)
+	app.Post("/auth/login", authController.Login)
+	app.Post("/auth/2fa/verify", authController.VerifyTwoFactor)
+	routes.SetupTwoFactorRoutes(app, db)
+
+	return app, db, user.ID
+}
+
+// postJSON отправляет POST запрос с JSON телом
+func postJSON(app *fiber.App, path string, body interface{}, token string) (int, map[string]interface{}) {
+	jsonData, _ := json.Marshal(body)
+	req := httptest.NewRequest("POST", path, bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := app.Test(req)
+	if err != nil {
+		return 0, nil
+	}
+
+	var result map[string]interface{}
+	json.NewDecoder(resp.Body).Decode(&result)
+	return resp.StatusCode, result
+}
+
+// enableTwoFactor включает 2FA пользователю и возвращает секрет и коды восстановления
+func enableTwoFactor(t *testing.T, app *fiber.App, userID uint) (string, []interface{}) {
+	token := roleTestToken(userID)
+
+	status, result := postJSON(app, "/api/2fa/enroll", nil, token)
+	assert.Equal(t, 200, status)
+	secret := result["secret"].(string)
+	assert.Contains(t, result["otpauth_uri"], "otpauth://totp/Toloka:organizer@example.com?")
+
+	code, _ := utils.GenerateTOTPCode(secret, utils.TOTPStep(time.Now())-1)
+	status, result = postJSON(app, "/api/2fa/confirm", map[string]string{"code": code}, token)
+	assert.Equal(t, 200, status)
+
+	return secret, result["recovery_codes"].([]interface{})
+}
+
+func TestTOTPCode(t *testing.T) {
+	// Тестовый вектор RFC 6238 (секрет "12345678901234567890", T = 59)
+	secret := "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+	code, err := utils.GenerateTOTPCode(secret, utils.TOTPStep(time.Unix(59, 0)))
+	assert.NoError(t, err)
+	assert.Equal(t, "287082", code)
+
+	step, valid := utils.ValidateTOTPCode(secret, "287082", time.Unix(59+30, 0))
+	assert.True(t, valid)
+	assert.Equal(t, int64(1), step)
+
+	_, valid = utils.ValidateTOTPCode(secret, "287082", time.Unix(59+120, 0))
+	assert.False(t, valid)
+}
+
+func TestTwoFactorEnrollment(t *testing.T) {
+	app, db, userID := setupTwoFactorTestApp()
+	token := roleTestToken(userID)
+
+	// Подтверждение неверным кодом
+	postJSON(app, "/api/2fa/enroll", nil, token)
+	status, _ := postJSON(app, "/api/2fa/confirm", map[string]string{"code": "000000"}, token)
+	assert.Equal(t, 400, status)
+
+	_, recoveryCodes := enableTwoFactor(t, app, userID)
+	assert.Len(t, recoveryCodes, 10)
+
+	// Коды восстановления хранятся только в виде хэшей
+	var stored models.TwoFactorRecoveryCode
+	db.Where("user_id = ?", userID).First(&stored)
+	assert.NotEqual(t, recoveryCodes[0], stored.CodeHash)
+	assert.Equal(t, utils.HashRecoveryCode(recoveryCodes[0].(string)), stored.CodeHash)
+
+	// Повторная настройка невозможна
+	status, _ = postJSON(app, "/api/2fa/enroll", nil, token)
+	assert.Equal(t, 409, status)
+}
+
+func TestTwoFactorLogin(t *testing.T) {
+	app, _, userID := setupTwoFactorTestApp()
+	secret, _ := enableTwoFactor(t, app, userID)
+
+	// Первый шаг не выдает JWT
+	status, result := postJSON(app, "/auth/login", controllers.LoginRequest{Email: "organizer@example.com", Password: "password123"}, "")
+	assert.Equal(t, 200, status)
+	assert.Equal(t, true, result["requires_two_factor"])
+	assert.Nil(t, result["token"])
+	challengeToken := result["challenge_token"].(string)
+
+	// Неверный код
+	status, _ = postJSON(app, "/auth/2fa/verify", controllers.TwoFactorLoginRequest{ChallengeToken: challengeToken, Code: "000000"}, "")
+	assert.Equal(t, 401, status)
+
+	// Верный код выдает JWT
+	code, _ := utils.GenerateTOTPCode(secret, utils.TOTPStep(time.Now()))
+	status, result = postJSON(app, "/auth/2fa/verify", controllers.TwoFactorLoginRequest{ChallengeToken: challengeToken, Code: code}, "")
+	assert.Equal(t, 200, status)
+	assert.NotEmpty(t, result["token"])
+
+	// Токен входа одноразовый
+	status, _ = postJSON(app, "/auth/2fa/verify", controllers.TwoFactorLoginRequest{ChallengeToken: challengeToken, Code: code}, "")
+	assert.Equal(t, 401, status)
+}
+
+func TestTwoFactorOAuthLogin(t *testing.T) {
+	app, db, userID := setupTwoFactorTestApp()
+	authController := controllers.NewAuthController(db)
+	app.Post("/auth/oauth/google", authController.OAuth)
+	db.Model(&models.User{}).Where("id = ?", userID).Updates(models.User{OAuthProvider: "google", OAuthID: "google-organizer"})
+	secret, _ := enableTwoFactor(t, app, userID)
+
+	// Вход через провайдера тоже требует код 2FA
+	status, result := postJSON(app, "/auth/oauth/google", controllers.OAuthRequest{
+		Token:    "google_token",
+		Provider: "google",
+		Name:     "Organizer",
+		Email:    "organizer@example.com",
+		OAuthID:  "google-organizer",
+	}, "")
+	assert.Equal(t, 200, status)
+	assert.Equal(t, true, result["requires_two_factor"])
+	assert.Nil(t, result["token"])
+	challengeToken := result["challenge_token"].(string)
+
+	code, _ := utils.GenerateTOTPCode(secret, utils.TOTPStep(time.Now()))
+	status, result = postJSON(app, "/auth/2fa/verify", controllers.TwoFactorLoginRequest{ChallengeToken: challengeToken, Code: code}, "")
+	assert.Equal(t, 200, status)
+	assert.NotEmpty(t, result["token"])
+}
+
+func TestTwoFactorRecoveryCodeLogin(t *testing.T) {
+	app, _, userID := setupTwoFactorTestApp()
+	_, recoveryCodes := enableTwoFactor(t, app, userID)
+	recoveryCode := recoveryCodes[0].(string)
+
+	login := func() string {
+		_, result := postJSON(app, "/auth/login", controllers.LoginRequest{Email: "organizer@example.com", Password: "password123"}, "")
+		return result["challenge_token"].(string)
+	}
+
+	status, result := postJSON(app, "/auth/2fa/verify", controllers.TwoFactorLoginRequest{ChallengeToken: login(), Code: recoveryCode}, "")
+	assert.Equal(t, 200, status)
+	assert.NotEmpty(t, result["token"])
+
+	// Код восстановления одноразовый
+	status, _ = postJSON(app, "/auth/2fa/verify", controllers.TwoFactorLoginRequest{ChallengeToken: login(), Code: recoveryCode}, "")
+	assert.Equal(t, 401, status)
+}
+
+func TestTwoFactorEnforcedForRoles(t *testing.T) {
+	t.Setenv("TWO_FACTOR_REQUIRED_ROLES", "admin,moderator")
+
+	app, db, userID := setupTwoFactorTestApp()
+	db.Create(&models.UserRole{UserID: userID, Role: models.RoleModerator})
+	permissionService := services.NewPermissionService(db)
+
+	// Без 2FA роль модератора не действует
+	allowed, err := permissionService.HasPermission(userID, models.PermissionComplaintsView)
+	assert.NoError(t, err)
+	assert.False(t, allowed)
+
+	status, result := postJSON(app, "/auth/login", controllers.LoginRequest{Email: "organizer@example.com", Password: "password123"}, "")
+	assert.Equal(t, 200, status)
+	assert.Equal(t, true, result["two_factor_setup_required"])
+
+	secret, _ := enableTwoFactor(t, app, userID)
+
+	allowed, err = permissionService.HasPermission(userID, models.PermissionComplaintsView)
+	assert.NoError(t, err)
+	assert.True(t, allowed)
+
+	// Отключить обязательную 2FA нельзя
+	code, _ := utils.GenerateTOTPCode(secret, utils.TOTPStep(time.Now()))
+	status, _ = postJSON(app, "/api/2fa/disable", map[string]string{"code": code}, roleTestToken(userID))
+	assert.Equal(t, 403, status)
+}
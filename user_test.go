@@ -8,6 +8,7 @@ import (
 
 	"toloko-backend/controllers"
 	"toloko-backend/models"
+	"toloko-backend/services"
 	"toloko-backend/utils"
 
 	"github.com/gofiber/fiber/v2"
@@ -18,7 +19,7 @@ import (
 
 func setupUserTestDB() *gorm.DB {
 	db, _ := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
-	db.AutoMigrate(&models.User{}, &models.Achievement{}, &models.UserAchievement{}, &models.UserLevel{}, &models.PinnedPost{})
+	db.AutoMigrate(&models.User{}, &models.Achievement{}, &models.UserAchievement{}, &models.UserLevel{}, &models.PinnedPost{}, &models.UserRole{}, &models.Community{}, &models.CommunityRole{}, &models.News{}, &models.PointsEntry{}, &models.Notification{}, &models.LevelDefinition{}, &models.FeedActivity{}, &models.LeaderboardEntry{})
 	return db
 }
 
@@ -196,11 +197,21 @@ func TestAwardAchievement(t *testing.T) {
 	token, err := utils.GenerateJWT(user.ID, user.Email)
 	assert.NoError(t, err)
 
-	// Тест награждения достижением
+	// Тест награждения без прав администратора
 	req := httptest.NewRequest("POST", "/achievements/user/1/award/1", nil)
 	req.Header.Set("Authorization", token)
 	resp, err := app.Test(req)
 	assert.NoError(t, err)
+	assert.Equal(t, 403, resp.StatusCode)
+
+	// Назначаем пользователя администратором
+	db.Create(&models.UserRole{UserID: user.ID, Role: models.RoleAdmin})
+
+	// Тест награждения достижением
+	req = httptest.NewRequest("POST", "/achievements/user/1/award/1", nil)
+	req.Header.Set("Authorization", token)
+	resp, err = app.Test(req)
+	assert.NoError(t, err)
 	assert.Equal(t, 200, resp.StatusCode)
 
 	var result map[string]interface{}
@@ -235,30 +246,28 @@ func TestGetUserLevel(t *testing.T) {
 	assert.NotNil(t, result["user_level"])
 }
 
-func TestAddPoints(t *testing.T) {
+func TestGetPointsHistory(t *testing.T) {
 	db := setupUserTestDB()
 	levelController := controllers.NewLevelController(db)
 	app := fiber.New()
-	app.Post("/levels/user/:id/add-points", levelController.AddPoints)
+	app.Get("/levels/user/:id/points", levelController.GetPointsHistory)
 
-	// Создаем тестового пользователя
+	// Создаем тестовых пользователей и начисляем очки за достижение
 	user := createUserTestUser(db, "Test User", "test@example.com")
+	other := createUserTestUser(db, "Other User", "other@example.com")
+	achievement := createTestAchievement(db)
+	_, err := services.GrantAchievementPoints(db, user.ID, achievement)
+	assert.NoError(t, err)
 
-	// Создаем JWT токен
+	// Создаем JWT токены
 	token, err := utils.GenerateJWT(user.ID, user.Email)
 	assert.NoError(t, err)
+	otherToken, err := utils.GenerateJWT(other.ID, other.Email)
+	assert.NoError(t, err)
 
-	// Тест добавления очков
-	addPointsData := map[string]interface{}{
-		"points": 50,
-		"reason": "Test points",
-	}
-
-	jsonData, _ := json.Marshal(addPointsData)
-	req := httptest.NewRequest("POST", "/levels/user/1/add-points", bytes.NewBuffer(jsonData))
+	// Тест получения своего журнала очков
+	req := httptest.NewRequest("GET", "/levels/user/1/points", nil)
 	req.Header.Set("Authorization", token)
-	req.Header.Set("Content-Type", "application/json")
-
 	resp, err := app.Test(req)
 	assert.NoError(t, err)
 	assert.Equal(t, 200, resp.StatusCode)
@@ -266,7 +275,14 @@ func TestAddPoints(t *testing.T) {
 	var result map[string]interface{}
 	json.NewDecoder(resp.Body).Decode(&result)
 	assert.False(t, result["error"].(bool))
-	assert.NotNil(t, result["user_level"])
+	assert.Len(t, result["entries"], 1)
+
+	// Чужой журнал недоступен без прав
+	req = httptest.NewRequest("GET", "/levels/user/1/points", nil)
+	req.Header.Set("Authorization", otherToken)
+	resp, err = app.Test(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 403, resp.StatusCode)
 }
 
 func TestGetLeaderboard(t *testing.T) {
@@ -110,3 +110,35 @@ func AuthMiddleware(c *fiber.Ctx) error {
 
 	return c.Next()
 }
+
+// PermissionChecker проверяет наличие разрешения у пользователя
+type PermissionChecker interface {
+	HasPermission(userID uint, permission string) (bool, error)
+}
+
+// RequirePermission middleware для проверки разрешения платформы.
+// Должен использоваться после AuthMiddleware.
+func RequirePermission(checker PermissionChecker, permission string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, ok := c.Locals("user_id").(uint)
+		if !ok {
+			return c.Status(401).JSON(fiber.Map{
+				"error": "Unauthorized",
+			})
+		}
+
+		allowed, err := checker.HasPermission(userID, permission)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{
+				"error": "Failed to check permissions",
+			})
+		}
+		if !allowed {
+			return c.Status(403).JSON(fiber.Map{
+				"error": "Insufficient permissions",
+			})
+		}
+
+		return c.Next()
+	}
+}